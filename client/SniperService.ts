@@ -3,6 +3,15 @@ import type { IRecognitionMode } from "./SniperCore";
 export class SniperService {
   private readonly baseUrl = "http://localhost:9090";
 
+  /**
+   * The API token the server injected into the page (local loads only),
+   * falling back to one the user stored in localStorage.
+   */
+  private readonly token =
+    document.querySelector<HTMLMetaElement>('meta[name="sniper-token"]')?.content ||
+    localStorage.getItem("sniper-token") ||
+    "";
+
   /**
    * Sends the processed command string to the backend API.
    * @returns A promise that resolves to the HTTP status code of the response.
@@ -14,11 +23,15 @@ export class SniperService {
         command: command,
         mode: mode.name(),
       })
+      const headers: Record<string, string> = {
+        "Content-Type": "application/json",
+      };
+      if (this.token) {
+        headers["Authorization"] = `Bearer ${this.token}`;
+      }
       const response = await fetch(`${this.baseUrl}/api/data`, {
         method: "POST",
-        headers: {
-          "Content-Type": "application/json",
-        },
+        headers,
         body: reqBody,
       });
 
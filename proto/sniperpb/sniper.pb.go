@@ -0,0 +1,706 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: sniper.proto
+
+package sniperpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ExecuteCommandRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Phrase        string                 `protobuf:"bytes,1,opt,name=phrase,proto3" json:"phrase,omitempty"`
+	Mode          string                 `protobuf:"bytes,2,opt,name=mode,proto3" json:"mode,omitempty"`                    // "phrase", "rapid" or "" for the default mode
+	DryRun        bool                   `protobuf:"varint,3,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"` // parse only, don't touch the keyboard or mouse
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecuteCommandRequest) Reset() {
+	*x = ExecuteCommandRequest{}
+	mi := &file_sniper_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecuteCommandRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecuteCommandRequest) ProtoMessage() {}
+
+func (x *ExecuteCommandRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sniper_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecuteCommandRequest.ProtoReflect.Descriptor instead.
+func (*ExecuteCommandRequest) Descriptor() ([]byte, []int) {
+	return file_sniper_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ExecuteCommandRequest) GetPhrase() string {
+	if x != nil {
+		return x.Phrase
+	}
+	return ""
+}
+
+func (x *ExecuteCommandRequest) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *ExecuteCommandRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+type HistoryToken struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Literal       string                 `protobuf:"bytes,1,opt,name=literal,proto3" json:"literal,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Outcome       string                 `protobuf:"bytes,3,opt,name=outcome,proto3" json:"outcome,omitempty"`
+	Confidence    float64                `protobuf:"fixed64,4,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	Command       string                 `protobuf:"bytes,5,opt,name=command,proto3" json:"command,omitempty"`
+	DurationMs    float64                `protobuf:"fixed64,6,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	FuzzyMatch    string                 `protobuf:"bytes,7,opt,name=fuzzy_match,json=fuzzyMatch,proto3" json:"fuzzy_match,omitempty"`
+	Effects       []string               `protobuf:"bytes,8,rep,name=effects,proto3" json:"effects,omitempty"`
+	Source        string                 `protobuf:"bytes,9,opt,name=source,proto3" json:"source,omitempty"`
+	Word          int32                  `protobuf:"varint,10,opt,name=word,proto3" json:"word,omitempty"`
+	Original      string                 `protobuf:"bytes,11,opt,name=original,proto3" json:"original,omitempty"`
+	Segment       int32                  `protobuf:"varint,12,opt,name=segment,proto3" json:"segment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryToken) Reset() {
+	*x = HistoryToken{}
+	mi := &file_sniper_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryToken) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryToken) ProtoMessage() {}
+
+func (x *HistoryToken) ProtoReflect() protoreflect.Message {
+	mi := &file_sniper_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryToken.ProtoReflect.Descriptor instead.
+func (*HistoryToken) Descriptor() ([]byte, []int) {
+	return file_sniper_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *HistoryToken) GetLiteral() string {
+	if x != nil {
+		return x.Literal
+	}
+	return ""
+}
+
+func (x *HistoryToken) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *HistoryToken) GetOutcome() string {
+	if x != nil {
+		return x.Outcome
+	}
+	return ""
+}
+
+func (x *HistoryToken) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+func (x *HistoryToken) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *HistoryToken) GetDurationMs() float64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *HistoryToken) GetFuzzyMatch() string {
+	if x != nil {
+		return x.FuzzyMatch
+	}
+	return ""
+}
+
+func (x *HistoryToken) GetEffects() []string {
+	if x != nil {
+		return x.Effects
+	}
+	return nil
+}
+
+func (x *HistoryToken) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *HistoryToken) GetWord() int32 {
+	if x != nil {
+		return x.Word
+	}
+	return 0
+}
+
+func (x *HistoryToken) GetOriginal() string {
+	if x != nil {
+		return x.Original
+	}
+	return ""
+}
+
+func (x *HistoryToken) GetSegment() int32 {
+	if x != nil {
+		return x.Segment
+	}
+	return 0
+}
+
+type ExecutionResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	HistoryId     uint64                 `protobuf:"varint,1,opt,name=history_id,json=historyId,proto3" json:"history_id,omitempty"`
+	Input         string                 `protobuf:"bytes,2,opt,name=input,proto3" json:"input,omitempty"`
+	Normalized    string                 `protobuf:"bytes,3,opt,name=normalized,proto3" json:"normalized,omitempty"`
+	Mode          string                 `protobuf:"bytes,4,opt,name=mode,proto3" json:"mode,omitempty"` // "PHRASE" or "RAPID"
+	Tokens        []*HistoryToken        `protobuf:"bytes,5,rep,name=tokens,proto3" json:"tokens,omitempty"`
+	DurationMs    float64                `protobuf:"fixed64,6,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	Error         string                 `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	Deduplicated  bool                   `protobuf:"varint,8,opt,name=deduplicated,proto3" json:"deduplicated,omitempty"`
+	ModeDetected  bool                   `protobuf:"varint,9,opt,name=mode_detected,json=modeDetected,proto3" json:"mode_detected,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecutionResult) Reset() {
+	*x = ExecutionResult{}
+	mi := &file_sniper_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecutionResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecutionResult) ProtoMessage() {}
+
+func (x *ExecutionResult) ProtoReflect() protoreflect.Message {
+	mi := &file_sniper_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecutionResult.ProtoReflect.Descriptor instead.
+func (*ExecutionResult) Descriptor() ([]byte, []int) {
+	return file_sniper_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ExecutionResult) GetHistoryId() uint64 {
+	if x != nil {
+		return x.HistoryId
+	}
+	return 0
+}
+
+func (x *ExecutionResult) GetInput() string {
+	if x != nil {
+		return x.Input
+	}
+	return ""
+}
+
+func (x *ExecutionResult) GetNormalized() string {
+	if x != nil {
+		return x.Normalized
+	}
+	return ""
+}
+
+func (x *ExecutionResult) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *ExecutionResult) GetTokens() []*HistoryToken {
+	if x != nil {
+		return x.Tokens
+	}
+	return nil
+}
+
+func (x *ExecutionResult) GetDurationMs() float64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *ExecutionResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *ExecutionResult) GetDeduplicated() bool {
+	if x != nil {
+		return x.Deduplicated
+	}
+	return false
+}
+
+func (x *ExecutionResult) GetModeDetected() bool {
+	if x != nil {
+		return x.ModeDetected
+	}
+	return false
+}
+
+type ListCommandsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCommandsRequest) Reset() {
+	*x = ListCommandsRequest{}
+	mi := &file_sniper_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCommandsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCommandsRequest) ProtoMessage() {}
+
+func (x *ListCommandsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sniper_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCommandsRequest.ProtoReflect.Descriptor instead.
+func (*ListCommandsRequest) Descriptor() ([]byte, []int) {
+	return file_sniper_proto_rawDescGZIP(), []int{3}
+}
+
+type Command struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	CalledBy      []string               `protobuf:"bytes,2,rep,name=called_by,json=calledBy,proto3" json:"called_by,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Category      string                 `protobuf:"bytes,4,opt,name=category,proto3" json:"category,omitempty"`
+	Effects       []string               `protobuf:"bytes,5,rep,name=effects,proto3" json:"effects,omitempty"`
+	ConsumesArgs  bool                   `protobuf:"varint,6,opt,name=consumes_args,json=consumesArgs,proto3" json:"consumes_args,omitempty"`
+	Disabled      bool                   `protobuf:"varint,7,opt,name=disabled,proto3" json:"disabled,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Command) Reset() {
+	*x = Command{}
+	mi := &file_sniper_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Command) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Command) ProtoMessage() {}
+
+func (x *Command) ProtoReflect() protoreflect.Message {
+	mi := &file_sniper_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Command.ProtoReflect.Descriptor instead.
+func (*Command) Descriptor() ([]byte, []int) {
+	return file_sniper_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Command) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Command) GetCalledBy() []string {
+	if x != nil {
+		return x.CalledBy
+	}
+	return nil
+}
+
+func (x *Command) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Command) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *Command) GetEffects() []string {
+	if x != nil {
+		return x.Effects
+	}
+	return nil
+}
+
+func (x *Command) GetConsumesArgs() bool {
+	if x != nil {
+		return x.ConsumesArgs
+	}
+	return false
+}
+
+func (x *Command) GetDisabled() bool {
+	if x != nil {
+		return x.Disabled
+	}
+	return false
+}
+
+type ListCommandsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Commands      []*Command             `protobuf:"bytes,1,rep,name=commands,proto3" json:"commands,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCommandsResponse) Reset() {
+	*x = ListCommandsResponse{}
+	mi := &file_sniper_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCommandsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCommandsResponse) ProtoMessage() {}
+
+func (x *ListCommandsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sniper_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCommandsResponse.ProtoReflect.Descriptor instead.
+func (*ListCommandsResponse) Descriptor() ([]byte, []int) {
+	return file_sniper_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListCommandsResponse) GetCommands() []*Command {
+	if x != nil {
+		return x.Commands
+	}
+	return nil
+}
+
+type EventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventsRequest) Reset() {
+	*x = EventsRequest{}
+	mi := &file_sniper_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventsRequest) ProtoMessage() {}
+
+func (x *EventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sniper_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventsRequest.ProtoReflect.Descriptor instead.
+func (*EventsRequest) Descriptor() ([]byte, []int) {
+	return file_sniper_proto_rawDescGZIP(), []int{6}
+}
+
+type Event struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"` // "phrase" or "mode"
+	Data          string                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"` // JSON payload, as sent by GET /api/events
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_sniper_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_sniper_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_sniper_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Event) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+var File_sniper_proto protoreflect.FileDescriptor
+
+const file_sniper_proto_rawDesc = "" +
+	"\n" +
+	"\fsniper.proto\x12\tsniper.v1\"\\\n" +
+	"\x15ExecuteCommandRequest\x12\x16\n" +
+	"\x06phrase\x18\x01 \x01(\tR\x06phrase\x12\x12\n" +
+	"\x04mode\x18\x02 \x01(\tR\x04mode\x12\x17\n" +
+	"\adry_run\x18\x03 \x01(\bR\x06dryRun\"\xce\x02\n" +
+	"\fHistoryToken\x12\x18\n" +
+	"\aliteral\x18\x01 \x01(\tR\aliteral\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x18\n" +
+	"\aoutcome\x18\x03 \x01(\tR\aoutcome\x12\x1e\n" +
+	"\n" +
+	"confidence\x18\x04 \x01(\x01R\n" +
+	"confidence\x12\x18\n" +
+	"\acommand\x18\x05 \x01(\tR\acommand\x12\x1f\n" +
+	"\vduration_ms\x18\x06 \x01(\x01R\n" +
+	"durationMs\x12\x1f\n" +
+	"\vfuzzy_match\x18\a \x01(\tR\n" +
+	"fuzzyMatch\x12\x18\n" +
+	"\aeffects\x18\b \x03(\tR\aeffects\x12\x16\n" +
+	"\x06source\x18\t \x01(\tR\x06source\x12\x12\n" +
+	"\x04word\x18\n" +
+	" \x01(\x05R\x04word\x12\x1a\n" +
+	"\boriginal\x18\v \x01(\tR\boriginal\x12\x18\n" +
+	"\asegment\x18\f \x01(\x05R\asegment\"\xab\x02\n" +
+	"\x0fExecutionResult\x12\x1d\n" +
+	"\n" +
+	"history_id\x18\x01 \x01(\x04R\thistoryId\x12\x14\n" +
+	"\x05input\x18\x02 \x01(\tR\x05input\x12\x1e\n" +
+	"\n" +
+	"normalized\x18\x03 \x01(\tR\n" +
+	"normalized\x12\x12\n" +
+	"\x04mode\x18\x04 \x01(\tR\x04mode\x12/\n" +
+	"\x06tokens\x18\x05 \x03(\v2\x17.sniper.v1.HistoryTokenR\x06tokens\x12\x1f\n" +
+	"\vduration_ms\x18\x06 \x01(\x01R\n" +
+	"durationMs\x12\x14\n" +
+	"\x05error\x18\a \x01(\tR\x05error\x12\"\n" +
+	"\fdeduplicated\x18\b \x01(\bR\fdeduplicated\x12#\n" +
+	"\rmode_detected\x18\t \x01(\bR\fmodeDetected\"\x15\n" +
+	"\x13ListCommandsRequest\"\xd3\x01\n" +
+	"\aCommand\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1b\n" +
+	"\tcalled_by\x18\x02 \x03(\tR\bcalledBy\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x1a\n" +
+	"\bcategory\x18\x04 \x01(\tR\bcategory\x12\x18\n" +
+	"\aeffects\x18\x05 \x03(\tR\aeffects\x12#\n" +
+	"\rconsumes_args\x18\x06 \x01(\bR\fconsumesArgs\x12\x1a\n" +
+	"\bdisabled\x18\a \x01(\bR\bdisabled\"F\n" +
+	"\x14ListCommandsResponse\x12.\n" +
+	"\bcommands\x18\x01 \x03(\v2\x12.sniper.v1.CommandR\bcommands\"\x0f\n" +
+	"\rEventsRequest\"/\n" +
+	"\x05Event\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\tR\x04data2\xe1\x01\n" +
+	"\x06Sniper\x12N\n" +
+	"\x0eExecuteCommand\x12 .sniper.v1.ExecuteCommandRequest\x1a\x1a.sniper.v1.ExecutionResult\x12O\n" +
+	"\fListCommands\x12\x1e.sniper.v1.ListCommandsRequest\x1a\x1f.sniper.v1.ListCommandsResponse\x126\n" +
+	"\x06Events\x12\x18.sniper.v1.EventsRequest\x1a\x10.sniper.v1.Event0\x01B2Z0github.com/phillip-england/sniper/proto/sniperpbb\x06proto3"
+
+var (
+	file_sniper_proto_rawDescOnce sync.Once
+	file_sniper_proto_rawDescData []byte
+)
+
+func file_sniper_proto_rawDescGZIP() []byte {
+	file_sniper_proto_rawDescOnce.Do(func() {
+		file_sniper_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_sniper_proto_rawDesc), len(file_sniper_proto_rawDesc)))
+	})
+	return file_sniper_proto_rawDescData
+}
+
+var file_sniper_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_sniper_proto_goTypes = []any{
+	(*ExecuteCommandRequest)(nil), // 0: sniper.v1.ExecuteCommandRequest
+	(*HistoryToken)(nil),          // 1: sniper.v1.HistoryToken
+	(*ExecutionResult)(nil),       // 2: sniper.v1.ExecutionResult
+	(*ListCommandsRequest)(nil),   // 3: sniper.v1.ListCommandsRequest
+	(*Command)(nil),               // 4: sniper.v1.Command
+	(*ListCommandsResponse)(nil),  // 5: sniper.v1.ListCommandsResponse
+	(*EventsRequest)(nil),         // 6: sniper.v1.EventsRequest
+	(*Event)(nil),                 // 7: sniper.v1.Event
+}
+var file_sniper_proto_depIdxs = []int32{
+	1, // 0: sniper.v1.ExecutionResult.tokens:type_name -> sniper.v1.HistoryToken
+	4, // 1: sniper.v1.ListCommandsResponse.commands:type_name -> sniper.v1.Command
+	0, // 2: sniper.v1.Sniper.ExecuteCommand:input_type -> sniper.v1.ExecuteCommandRequest
+	3, // 3: sniper.v1.Sniper.ListCommands:input_type -> sniper.v1.ListCommandsRequest
+	6, // 4: sniper.v1.Sniper.Events:input_type -> sniper.v1.EventsRequest
+	2, // 5: sniper.v1.Sniper.ExecuteCommand:output_type -> sniper.v1.ExecutionResult
+	5, // 6: sniper.v1.Sniper.ListCommands:output_type -> sniper.v1.ListCommandsResponse
+	7, // 7: sniper.v1.Sniper.Events:output_type -> sniper.v1.Event
+	5, // [5:8] is the sub-list for method output_type
+	2, // [2:5] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_sniper_proto_init() }
+func file_sniper_proto_init() {
+	if File_sniper_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sniper_proto_rawDesc), len(file_sniper_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_sniper_proto_goTypes,
+		DependencyIndexes: file_sniper_proto_depIdxs,
+		MessageInfos:      file_sniper_proto_msgTypes,
+	}.Build()
+	File_sniper_proto = out.File
+	file_sniper_proto_goTypes = nil
+	file_sniper_proto_depIdxs = nil
+}
@@ -0,0 +1,229 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: sniper.proto
+
+package sniperpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Sniper_ExecuteCommand_FullMethodName = "/sniper.v1.Sniper/ExecuteCommand"
+	Sniper_ListCommands_FullMethodName   = "/sniper.v1.Sniper/ListCommands"
+	Sniper_Events_FullMethodName         = "/sniper.v1.Sniper/Events"
+)
+
+// SniperClient is the client API for Sniper service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Sniper mirrors the HTTP control API for clients that prefer gRPC. It is
+// served on --grpc-port; when the API token is set, every call needs it as
+// "authorization: Bearer <token>" metadata.
+//
+// Regenerate the Go code with "make proto".
+type SniperClient interface {
+	// ExecuteCommand parses and runs a phrase, like POST /api/data. A phrase
+	// that fails while running comes back with its result and error set; a
+	// phrase that breaks an input limit is INVALID_ARGUMENT, and a full queue
+	// is RESOURCE_EXHAUSTED.
+	ExecuteCommand(ctx context.Context, in *ExecuteCommandRequest, opts ...grpc.CallOption) (*ExecutionResult, error)
+	// ListCommands returns the registry, like GET /api/commands/full.
+	ListCommands(ctx context.Context, in *ListCommandsRequest, opts ...grpc.CallOption) (*ListCommandsResponse, error)
+	// Events streams engine events as they happen, like GET /api/events.
+	// The response headers are sent once the subscription is in place, so a
+	// client that waits for them misses nothing that happens afterwards.
+	Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+}
+
+type sniperClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSniperClient(cc grpc.ClientConnInterface) SniperClient {
+	return &sniperClient{cc}
+}
+
+func (c *sniperClient) ExecuteCommand(ctx context.Context, in *ExecuteCommandRequest, opts ...grpc.CallOption) (*ExecutionResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExecutionResult)
+	err := c.cc.Invoke(ctx, Sniper_ExecuteCommand_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sniperClient) ListCommands(ctx context.Context, in *ListCommandsRequest, opts ...grpc.CallOption) (*ListCommandsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCommandsResponse)
+	err := c.cc.Invoke(ctx, Sniper_ListCommands_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sniperClient) Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Sniper_ServiceDesc.Streams[0], Sniper_Events_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[EventsRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Sniper_EventsClient = grpc.ServerStreamingClient[Event]
+
+// SniperServer is the server API for Sniper service.
+// All implementations must embed UnimplementedSniperServer
+// for forward compatibility.
+//
+// Sniper mirrors the HTTP control API for clients that prefer gRPC. It is
+// served on --grpc-port; when the API token is set, every call needs it as
+// "authorization: Bearer <token>" metadata.
+//
+// Regenerate the Go code with "make proto".
+type SniperServer interface {
+	// ExecuteCommand parses and runs a phrase, like POST /api/data. A phrase
+	// that fails while running comes back with its result and error set; a
+	// phrase that breaks an input limit is INVALID_ARGUMENT, and a full queue
+	// is RESOURCE_EXHAUSTED.
+	ExecuteCommand(context.Context, *ExecuteCommandRequest) (*ExecutionResult, error)
+	// ListCommands returns the registry, like GET /api/commands/full.
+	ListCommands(context.Context, *ListCommandsRequest) (*ListCommandsResponse, error)
+	// Events streams engine events as they happen, like GET /api/events.
+	// The response headers are sent once the subscription is in place, so a
+	// client that waits for them misses nothing that happens afterwards.
+	Events(*EventsRequest, grpc.ServerStreamingServer[Event]) error
+	mustEmbedUnimplementedSniperServer()
+}
+
+// UnimplementedSniperServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSniperServer struct{}
+
+func (UnimplementedSniperServer) ExecuteCommand(context.Context, *ExecuteCommandRequest) (*ExecutionResult, error) {
+	return nil, status.Error(codes.Unimplemented, "method ExecuteCommand not implemented")
+}
+func (UnimplementedSniperServer) ListCommands(context.Context, *ListCommandsRequest) (*ListCommandsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListCommands not implemented")
+}
+func (UnimplementedSniperServer) Events(*EventsRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Error(codes.Unimplemented, "method Events not implemented")
+}
+func (UnimplementedSniperServer) mustEmbedUnimplementedSniperServer() {}
+func (UnimplementedSniperServer) testEmbeddedByValue()                {}
+
+// UnsafeSniperServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SniperServer will
+// result in compilation errors.
+type UnsafeSniperServer interface {
+	mustEmbedUnimplementedSniperServer()
+}
+
+func RegisterSniperServer(s grpc.ServiceRegistrar, srv SniperServer) {
+	// If the following call panics, it indicates UnimplementedSniperServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Sniper_ServiceDesc, srv)
+}
+
+func _Sniper_ExecuteCommand_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExecuteCommandRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SniperServer).ExecuteCommand(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sniper_ExecuteCommand_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SniperServer).ExecuteCommand(ctx, req.(*ExecuteCommandRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sniper_ListCommands_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCommandsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SniperServer).ListCommands(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Sniper_ListCommands_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SniperServer).ListCommands(ctx, req.(*ListCommandsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Sniper_Events_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(EventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SniperServer).Events(m, &grpc.GenericServerStream[EventsRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Sniper_EventsServer = grpc.ServerStreamingServer[Event]
+
+// Sniper_ServiceDesc is the grpc.ServiceDesc for Sniper service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Sniper_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "sniper.v1.Sniper",
+	HandlerType: (*SniperServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ExecuteCommand",
+			Handler:    _Sniper_ExecuteCommand_Handler,
+		},
+		{
+			MethodName: "ListCommands",
+			Handler:    _Sniper_ListCommands_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Events",
+			Handler:       _Sniper_Events_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "sniper.proto",
+}
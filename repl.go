@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/phillip-england/sniper/sniper"
+)
+
+// --- REPL ---
+
+// replLine is what the REPL prints for each phrase, one JSON object per line.
+type replLine struct {
+	Status string                  `json:"status"`
+	Error  interface{}             `json:"error,omitempty"`
+	Result *sniper.ExecutionResult `json:"result,omitempty"`
+}
+
+// runREPL executes one phrase per line from in and writes a JSON result per
+// line to out, until EOF. A "#mode rapid" line switches the execution mode
+// for the lines after it.
+func runREPL(engine *sniper.Engine, in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	enc := json.NewEncoder(out)
+	mode := "phrase"
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		// Directives
+		if rest, ok := strings.CutPrefix(line, "#mode"); ok {
+			mode = strings.TrimSpace(rest)
+			if err := enc.Encode(map[string]string{"status": "mode", "mode": mode}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		result, err := engine.Submit(line, mode)
+		reply := replLine{Status: "executed", Result: &result}
+		switch {
+		case result.Deduplicated:
+			reply = replLine{Status: "deduplicated"}
+		case err != nil:
+			reply.Status = "failed"
+			var execErr *sniper.ExecError
+			if errors.As(err, &execErr) {
+				reply.Error = execErr
+			} else {
+				reply.Error = map[string]string{"error": err.Error()}
+			}
+		}
+		if err := enc.Encode(reply); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
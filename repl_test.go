@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/phillip-england/sniper/sniper"
+	"github.com/phillip-england/sniper/sniper/snipertest"
+)
+
+// brokenCmd always fails, to see how the REPL reports errors.
+type brokenCmd struct{}
+
+func (brokenCmd) Name() string                            { return "broken" }
+func (brokenCmd) CalledBy() []string                      { return []string{"broken"} }
+func (brokenCmd) Effects() []sniper.NamedEffect           { return nil }
+func (brokenCmd) Action(e *sniper.Engine, p string) error { return errors.New("out of order") }
+
+// replLines runs the REPL over input and decodes each line it printed.
+func replLines(t *testing.T, e *snipertest.Engine, input string) []map[string]any {
+	t.Helper()
+	var out bytes.Buffer
+	if err := runREPL(e.Engine, strings.NewReader(input), &out); err != nil {
+		t.Fatalf("runREPL: %v", err)
+	}
+	lines := make([]map[string]any, 0)
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var reply map[string]any
+		if err := json.Unmarshal([]byte(line), &reply); err != nil {
+			t.Fatalf("line %q is not JSON: %v", line, err)
+		}
+		lines = append(lines, reply)
+	}
+	return lines
+}
+
+func TestREPL(t *testing.T) {
+	e := snipertest.NewTestEngine(t, sniper.WithCommands(brokenCmd{}))
+	lines := replLines(t, e, "east\n\n  \n#mode rapid\nsouth 3\n#mode phrase\nbroken\n")
+
+	want := []string{"executed", "mode", "executed", "mode", "failed"}
+	got := make([]string, len(lines))
+	for i, line := range lines {
+		got[i], _ = line["status"].(string)
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("statuses = %v, want %v (blank lines print nothing)", got, want)
+	}
+
+	if lines[1]["mode"] != "rapid" {
+		t.Errorf("directive reply = %v", lines[1])
+	}
+	rapid, _ := lines[2]["result"].(map[string]any)
+	if rapid["input"] != "south 3" || rapid["mode"] != string(sniper.ModeRapid) {
+		t.Errorf("south 3 after #mode rapid = %v", rapid)
+	}
+	if lines[4]["error"] == nil || lines[4]["result"] == nil {
+		t.Errorf("failure line = %v, want the error and the result", lines[4])
+	}
+	if keys := e.Input.Keys(); !slices.Equal(keys, []string{"right", "down", "down", "down"}) {
+		t.Errorf("tapped %v", keys)
+	}
+}
+
+func TestREPLSharesTheServerFlags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"aliases": {"onward": "east"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defer func(old string) { *configPath = old }(*configPath)
+	*configPath = path
+
+	e := snipertest.NewTestEngine(t, engineOptions()...)
+	if e.ConfigPath != path {
+		t.Fatalf("config path = %q, want %q", e.ConfigPath, path)
+	}
+	replLines(t, e, "onward\n")
+	if keys := e.Input.Keys(); !slices.Equal(keys, []string{"right"}) {
+		t.Errorf("alias from --config tapped %v, want right", keys)
+	}
+}
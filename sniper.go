@@ -1,23 +1,64 @@
 package main
 
 import (
+	"context"
+	"crypto/subtle"
 	"embed"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"io/fs"
 	"log"
+	"log/slog"
+	"mime"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/Phillip-England/vii"
 	"github.com/phillip-england/sniper/sniper"
+	"github.com/phillip-england/sniper/sniper/sniperrpc"
 )
 
 // --- CONFIGURATION ---
 
 const (
 	ServerPort = "9090"
+
+	// maxIconBytes caps an uploaded icon template; icons are small.
+	maxIconBytes = 1 << 20
+)
+
+// insecureListen exposes the server beyond loopback even when no API token is set.
+var insecureListen = flag.Bool("insecure-listen", false, "listen on all interfaces even without an API token")
+
+// Engine construction flags, shared by the server and the REPL
+var (
+	configPath = flag.String("config", "", "config file (default $SNIPER_CONFIG or ~/.sniper.json)")
+	spotsPath  = flag.String("spots", "", "saved mouse spots file (default ~/.sniper_spots.json)")
 )
 
+// stdinMode runs the REPL instead of the server, same as "sniper repl".
+var stdinMode = flag.Bool("stdin", false, "read phrases from stdin instead of serving HTTP")
+
+// tapSmoke times N down-arrow taps through the real input driver, then exits.
+var tapSmoke = flag.Int("tap-smoke", 0, "tap the down arrow N times through the real driver, print how long it took and exit")
+
+// udpPort enables the fire-and-forget UDP listener; 0 leaves it off.
+var udpPort = flag.Int("udp-port", 0, "accept {\"c\":...,\"m\":...} phrases over UDP on this port (0 = off)")
+
+// grpcPort enables the gRPC service (proto/sniper.proto); 0 leaves it off.
+var grpcPort = flag.Int("grpc-port", 0, "serve the gRPC API on this port (0 = off)")
+
 // --- EMBEDDED FILES ---
 
 //go:embed static
@@ -29,8 +70,48 @@ var templatesEmbed embed.FS
 // --- MAIN APPLICATION ---
 
 func main() {
+	flag.Parse()
+
 	// Initialize the new Engine
-	engine := sniper.NewEngine()
+	engine, err := sniper.New(engineOptions()...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Shut the engine down cleanly on Ctrl+C so in-flight waits are interrupted
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		engine.Close()
+		os.Exit(0)
+	}()
+
+	if *tapSmoke > 0 {
+		if err := runTapSmoke(engine, *tapSmoke); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *stdinMode || flag.Arg(0) == "repl" {
+		if err := runREPL(engine, os.Stdin, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *udpPort != 0 {
+		if err := runUDP(engine, *udpPort, *insecureListen); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *grpcPort != 0 {
+		if err := runGRPC(engine, *grpcPort, *insecureListen); err != nil {
+			log.Fatal(err)
+		}
+	}
 
 	fmt.Printf("Server running on port %s\n", ServerPort)
 	if err := runServer(engine); err != nil {
@@ -38,11 +119,79 @@ func main() {
 	}
 }
 
+// engineOptions turns the command-line flags into engine options.
+func engineOptions() []sniper.EngineOption {
+	var opts []sniper.EngineOption
+	if *configPath != "" {
+		opts = append(opts, sniper.WithConfigPath(*configPath))
+	}
+	if *spotsPath != "" {
+		opts = append(opts, sniper.WithSpotsPath(*spotsPath))
+	}
+	return opts
+}
+
+// runTapSmoke runs "south <n>" and reports how long the taps took, to check
+// bulk navigation speed on a real machine.
+func runTapSmoke(engine *sniper.Engine, n int) error {
+	engine.Parse(fmt.Sprintf("south %d", n), "phrase")
+	result, err := engine.Execute()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d taps in %.1fms (%.3fms per tap)\n", n, result.DurationMs, result.DurationMs/float64(n))
+	return nil
+}
+
+// runUDP starts the UDP listener in the background. UDP has no way to carry
+// a token, so it only leaves loopback with --insecure-listen.
+func runUDP(engine *sniper.Engine, port int, insecure bool) error {
+	host := "127.0.0.1"
+	if insecure {
+		host = ""
+	}
+	server, err := engine.ListenUDP(net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("UDP listener on %s\n", server.Addr())
+	go func() {
+		if err := server.Serve(); err != nil {
+			engine.Logger.Error("udp listener stopped", "error", err)
+		}
+	}()
+	return nil
+}
+
+// runGRPC starts the gRPC service in the background. Like the HTTP server it
+// only leaves loopback when a token guards it or with --insecure-listen.
+func runGRPC(engine *sniper.Engine, port int, insecure bool) error {
+	host := "127.0.0.1"
+	if engine.APIToken() != "" || insecure {
+		host = ""
+	}
+	lis, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return err
+	}
+	server := sniperrpc.NewGRPCServer(engine)
+	fmt.Printf("gRPC server on %s\n", lis.Addr())
+	go func() {
+		if err := server.Serve(lis); err != nil {
+			engine.Logger.Error("grpc server stopped", "error", err)
+		}
+	}()
+	return nil
+}
+
 func runServer(engine *sniper.Engine) error {
 	app := vii.NewApp()
 
 	// Removed MwCORS since everything is now on the same origin
-	app.Use(vii.MwTimeout(10))
+	app.Use(timeoutExcept(10, "/api/data", "/api/events"))
+	app.Use(guardMutations())
+	app.Use(requireToken(engine.APIToken()))
+	app.Use(requestLogger(engine.Logger))
 
 	// --- Static Files & Templates ---
 
@@ -60,7 +209,7 @@ func runServer(engine *sniper.Engine) error {
 
 	// --- UI Routes ---
 	app.At("GET /", func(w http.ResponseWriter, r *http.Request) {
-		vii.ExecuteTemplate(w, r, "index.html", nil)
+		vii.ExecuteTemplate(w, r, "index.html", map[string]interface{}{"Token": pageToken(r, engine.APIToken())})
 	})
 
 	app.At("GET /mouse", func(w http.ResponseWriter, r *http.Request) {
@@ -73,38 +222,670 @@ func runServer(engine *sniper.Engine) error {
 	})
 
 	// --- API Routes ---
+	// Endpoint: Liveness, plus whether the configured input backend could be used
 	app.At("GET /api/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("Server is healthy"))
+		if err := engine.BackendError(); err != nil {
+			w.Write([]byte("Server is healthy, but input falls back to " + engine.Backend() + ": " + err.Error()))
+			return
+		}
+		w.Write([]byte("Server is healthy (input: " + engine.Backend() + ")"))
 	})
 
 	// Endpoint: Minimal JSON (Compact)
-	app.At("GET /api/commands/min", func(w http.ResponseWriter, r *http.Request) {
-		minStr, _, err := sniper.RegistryToJSON()
+	app.At("GET /api/commands/min", registryHandler(engine, false))
+
+	// Endpoint: Full JSON (Pretty Printed)
+	app.At("GET /api/commands/full", registryHandler(engine, true))
+
+	// Endpoint: Ranked search over names, triggers and descriptions (?q=page)
+	app.At("GET /api/commands/search", func(w http.ResponseWriter, r *http.Request) {
+		vii.WriteJSON(w, http.StatusOK, engine.SearchCommands(vii.Param(r, "q")))
+	})
+
+	// Endpoint: Duplicate triggers, common-word triggers and shadowed spots
+	app.At("GET /api/registry/audit", func(w http.ResponseWriter, r *http.Request) {
+		vii.WriteJSON(w, http.StatusOK, engine.Audit())
+	})
+
+	// Endpoint: Switch a command off by Name(). ?persist=true also saves it to the config file
+	app.At("POST /api/commands/{name}/disable", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		err := engine.Disable(name)
+		if errors.Is(err, sniper.ErrCommandNotFound) {
+			http.Error(w, "Command not found", http.StatusNotFound)
+			return
+		}
+		if err == nil && vii.ParamIs(r, "persist", "true") {
+			err = engine.PersistDisabled()
+		}
 		if err != nil {
-			http.Error(w, "Failed to encode registry: "+err.Error(), http.StatusInternalServerError)
+			http.Error(w, "Failed to disable command: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.Write([]byte(minStr))
+		vii.WriteJSON(w, http.StatusOK, map[string]interface{}{"disabled": engine.DisabledCommands()})
 	})
 
-	// Endpoint: Full JSON (Pretty Printed)
-	app.At("GET /api/commands/full", func(w http.ResponseWriter, r *http.Request) {
-		_, fullStr, err := sniper.RegistryToJSON()
+	app.At("POST /api/commands/{name}/enable", func(w http.ResponseWriter, r *http.Request) {
+		err := engine.Enable(r.PathValue("name"))
+		if errors.Is(err, sniper.ErrCommandNotFound) {
+			http.Error(w, "Command not found", http.StatusNotFound)
+			return
+		}
 		if err != nil {
-			http.Error(w, "Failed to encode registry: "+err.Error(), http.StatusInternalServerError)
+			http.Error(w, "Failed to enable command: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		vii.WriteJSON(w, http.StatusOK, map[string]interface{}{"disabled": engine.DisabledCommands()})
+	})
+
+	app.At("POST /api/data", dataHandler(engine))
+
+	// Endpoint: Abort the phrase that is currently executing
+	app.At("POST /api/cancel", func(w http.ResponseWriter, r *http.Request) {
+		vii.WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"cancelled": engine.Cancel(),
+		})
+	})
+
+	// Endpoint: Let go of every key and mouse button, in case one is stuck
+	app.At("POST /api/release", func(w http.ResponseWriter, r *http.Request) {
+		engine.EmergencyRelease()
+		vii.WriteJSON(w, http.StatusOK, map[string]string{"status": "released"})
+	})
+
+	// Endpoint: Status and result of a phrase queued with "async": true
+	app.At("GET /api/jobs/{id}", jobHandler(engine))
+
+	// Endpoint: Phrase outcomes and mode switches as server-sent events
+	app.At("GET /api/events", eventsHandler(engine))
+
+	// --- Engine State Routes ---
+
+	app.At("GET /api/state", func(w http.ResponseWriter, r *http.Request) {
+		vii.WriteJSON(w, http.StatusOK, engine.Status())
+	})
+
+	// Endpoint: Put the engine to sleep / wake it up from the web UI
+	app.At("POST /api/listening", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Listening *bool `json:"listening"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Listening == nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		engine.Listening.Store(*req.Listening)
+		vii.WriteJSON(w, http.StatusOK, engine.Status())
+	})
+
+	// --- Mode Routes ---
+
+	app.At("GET /api/modes", func(w http.ResponseWriter, r *http.Request) {
+		vii.WriteJSON(w, http.StatusOK, engine.Modes())
+	})
+
+	// Endpoint: The focused window and the mode active for it
+	app.At("GET /api/window", func(w http.ResponseWriter, r *http.Request) {
+		window, err := engine.ActiveWindow()
+		if err != nil {
+			http.Error(w, "Active window unavailable: "+err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		vii.WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"window": window,
+			"mode":   engine.ActiveMode(),
+		})
+	})
+
+	// --- History Routes ---
+
+	// Endpoint: Executed phrases, newest first. Supports ?offset=&limit=
+	app.At("GET /api/history", func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(vii.Param(r, "offset"))
+		limit, err := strconv.Atoi(vii.Param(r, "limit"))
+		if err != nil || limit <= 0 {
+			limit = 50
+		}
+
+		entries, total := engine.History.Page(offset, limit)
+		vii.WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"entries": entries,
+			"offset":  offset,
+			"limit":   limit,
+			"total":   total,
+		})
+	})
+
+	app.At("DELETE /api/history", func(w http.ResponseWriter, r *http.Request) {
+		engine.History.Clear()
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status":"cleared"}`))
+	})
+
+	// Endpoint: Let commands with a cooldown fire again right away
+	// (?command=name resets just that one)
+	app.At("DELETE /api/cooldowns", func(w http.ResponseWriter, r *http.Request) {
+		if name := r.URL.Query().Get("command"); name != "" {
+			engine.ResetCooldowns(name)
+		} else {
+			engine.ResetCooldowns()
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status":"reset"}`))
+	})
+
+	// --- Typed Output Routes ---
+
+	// Endpoint: What the engine typed most recently, oldest first
+	app.At("GET /api/typed", func(w http.ResponseWriter, r *http.Request) {
+		limit, err := strconv.Atoi(vii.Param(r, "limit"))
+		if err != nil || limit <= 0 {
+			limit = 100
+		}
+		vii.WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"entries": engine.StickyKeyboard.Output(limit),
+			"enabled": engine.Options().TypedLog,
+		})
+	})
+
+	app.At("DELETE /api/typed", func(w http.ResponseWriter, r *http.Request) {
+		engine.StickyKeyboard.ClearOutput()
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status":"cleared"}`))
+	})
+
+	// --- Metrics Routes ---
+
+	// Endpoint: Prometheus text format
+	app.At("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.WriteHeader(http.StatusOK)
+		engine.Metrics.WritePrometheus(w)
+	})
+
+	app.At("POST /api/metrics/reset", func(w http.ResponseWriter, r *http.Request) {
+		engine.Metrics.Reset()
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status":"reset"}`))
+	})
+
+	// --- Macro Routes ---
+
+	app.At("GET /api/macros", func(w http.ResponseWriter, r *http.Request) {
+		vii.WriteJSON(w, http.StatusOK, engine.Macros.List())
+	})
+
+	// --- Screen Routes ---
+
+	// Endpoint: Colour of the pixel at ?x=&y= (the mouse position when omitted)
+	app.At("GET /api/pixel", func(w http.ResponseWriter, r *http.Request) {
+		engine.Mouse.SyncPosition()
+		x, y := engine.Mouse.X, engine.Mouse.Y
+		if vii.Param(r, "x") != "" || vii.Param(r, "y") != "" {
+			var errX, errY error
+			x, errX = strconv.Atoi(vii.Param(r, "x"))
+			y, errY = strconv.Atoi(vii.Param(r, "y"))
+			if errX != nil || errY != nil {
+				http.Error(w, "x and y must both be integers", http.StatusBadRequest)
+				return
+			}
+		}
+		vii.WriteJSON(w, http.StatusOK, engine.PixelColor(x, y))
+	})
+
+	// Endpoint: PNG of the screen for the /mouse overlay. ?display= picks one
+	// display (all of them when omitted), ?width= scales it down
+	app.At("GET /api/screenshot", func(w http.ResponseWriter, r *http.Request) {
+		display, width := -1, 0
+		if v := vii.Param(r, "display"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				http.Error(w, "display must be an integer", http.StatusBadRequest)
+				return
+			}
+			display = n
+		}
+		if v := vii.Param(r, "width"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				http.Error(w, "width must be a positive integer", http.StatusBadRequest)
+				return
+			}
+			width = n
+		}
+
+		data, err := engine.Screenshot(display, width)
+		if errors.Is(err, sniper.ErrScreenshotsDisabled) {
+			http.Error(w, "Screenshots are disabled", http.StatusForbidden)
+			return
+		}
+		if errors.Is(err, sniper.ErrNoSuchDisplay) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to capture the screen: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write(data)
+	})
+
+	// --- Icon Routes ---
+
+	app.At("GET /api/icons", func(w http.ResponseWriter, r *http.Request) {
+		vii.WriteJSON(w, http.StatusOK, engine.Icons.Names())
+	})
+
+	// Endpoint: Register the PNG in the request body (Content-Type: image/png) as the template "icon <name>" finds
+	app.At("PUT /api/icons/{name}", func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxIconBytes)
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Icon too large or unreadable", http.StatusBadRequest)
+			return
+		}
+
+		path, err := engine.Icons.Add(r.PathValue("name"), data)
+		if err != nil {
+			http.Error(w, "Invalid icon: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		vii.WriteJSON(w, http.StatusOK, map[string]string{"name": r.PathValue("name"), "path": path})
+	})
+
+	app.At("DELETE /api/icons/{name}", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := engine.Icons.Get(r.PathValue("name")); !ok {
+			http.Error(w, "Icon not found", http.StatusNotFound)
+			return
+		}
+		engine.Icons.Delete(r.PathValue("name"))
+		vii.WriteJSON(w, http.StatusOK, engine.Icons.Names())
+	})
+
+	// --- Config Routes ---
+
+	app.At("GET /api/config", func(w http.ResponseWriter, r *http.Request) {
+		vii.WriteJSON(w, http.StatusOK, engine.Options())
+	})
+
+	// Endpoint: Partial update, fields missing from the body keep their current value
+	app.At("PUT /api/config", func(w http.ResponseWriter, r *http.Request) {
+		opts := engine.Options()
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		if err := engine.SetOptions(opts); err != nil {
+			http.Error(w, "Invalid config: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		vii.WriteJSON(w, http.StatusOK, engine.Options())
+	})
+
+	app.At("GET /api/config/timings", func(w http.ResponseWriter, r *http.Request) {
+		vii.WriteJSON(w, http.StatusOK, engine.Timings())
+	})
+
+	// Endpoint: Change the delays live and save them to the config file.
+	// Fields missing from the body keep their current value
+	app.At("PUT /api/config/timings", func(w http.ResponseWriter, r *http.Request) {
+		timings := engine.Timings()
+		if err := json.NewDecoder(r.Body).Decode(&timings); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		if err := engine.SetTimings(timings); err != nil {
+			http.Error(w, "Invalid timings: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		vii.WriteJSON(w, http.StatusOK, engine.Timings())
+	})
+
+	// Endpoint: Reread the config file (aliases, macros) without restarting
+	app.At("POST /api/config/reload", func(w http.ResponseWriter, r *http.Request) {
+		conflicts, err := engine.ReloadConfig()
+		if err != nil {
+			http.Error(w, "Failed to reload config: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		vii.WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"status":    "reloaded",
+			"conflicts": conflicts,
+		})
+	})
+
+	// --- Alias Routes ---
+
+	app.At("GET /api/aliases", func(w http.ResponseWriter, r *http.Request) {
+		vii.WriteJSON(w, http.StatusOK, engine.Aliases())
+	})
+
+	app.At("GET /api/aliases/{word}", func(w http.ResponseWriter, r *http.Request) {
+		word := strings.ToLower(r.PathValue("word"))
+		expansion, ok := engine.Aliases()[word]
+		if !ok {
+			http.Error(w, "Alias not found", http.StatusNotFound)
+			return
+		}
+
+		vii.WriteJSON(w, http.StatusOK, map[string]string{"word": word, "expansion": expansion})
+	})
+
+	app.At("PUT /api/aliases/{word}", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Expansion string `json:"expansion"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		if err := engine.SetAlias(r.PathValue("word"), req.Expansion); err != nil {
+			http.Error(w, "Invalid alias: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		vii.WriteJSON(w, http.StatusOK, engine.Aliases())
+	})
+
+	app.At("DELETE /api/aliases/{word}", func(w http.ResponseWriter, r *http.Request) {
+		err := engine.DeleteAlias(r.PathValue("word"))
+		if errors.Is(err, sniper.ErrAliasNotFound) {
+			http.Error(w, "Alias not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to delete alias: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		vii.WriteJSON(w, http.StatusOK, engine.Aliases())
+	})
+
+	// --- Homophone Routes ---
+
+	app.At("GET /api/homophones", func(w http.ResponseWriter, r *http.Request) {
+		vii.WriteJSON(w, http.StatusOK, engine.Homophones())
+	})
+
+	// Endpoint: Replace the whole table; defaults left out of the body are removed
+	app.At("PUT /api/homophones", func(w http.ResponseWriter, r *http.Request) {
+		var table map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&table); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		if err := engine.SetHomophones(table); err != nil {
+			http.Error(w, "Invalid homophones: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		vii.WriteJSON(w, http.StatusOK, engine.Homophones())
+	})
+
+	// --- Clipboard Routes ---
+
+	app.At("GET /api/clipboard", func(w http.ResponseWriter, r *http.Request) {
+		text, err := engine.Clipboard.ReadText()
+		if errors.Is(err, sniper.ErrClipboardNotText) {
+			// Images, file lists, etc. aren't an error from the caller's point of view
+			vii.WriteJSON(w, http.StatusOK, map[string]interface{}{
+				"text":      "",
+				"is_text":   false,
+				"truncated": false,
+			})
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to read clipboard: "+err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.Write([]byte(fullStr))
+		text, truncated := sniper.TruncateClipboardText(text, sniper.MaxClipboardRead)
+		vii.WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"text":      text,
+			"is_text":   true,
+			"truncated": truncated,
+		})
 	})
 
-	app.At("POST /api/data", func(w http.ResponseWriter, r *http.Request) {
+	app.At("POST /api/clipboard", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Text string `json:"text"`
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, sniper.MaxClipboardRead)
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		if err := engine.Clipboard.WriteText(req.Text); err != nil {
+			http.Error(w, "Failed to write clipboard: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status":"copied"}`))
+	})
+
+	// Endpoint: Clipboard history ring (newest first)
+	app.At("GET /api/clipboard/history", func(w http.ResponseWriter, r *http.Request) {
+		vii.WriteJSON(w, http.StatusOK, engine.ClipboardRing.Entries())
+	})
+
+	return serve(app, listenAddr(engine.APIToken(), *insecureListen))
+}
+
+// listenAddr binds to loopback unless a token guards the API or the user
+// explicitly opted into listening on every interface.
+func listenAddr(token string, insecure bool) string {
+	if token == "" && !insecure {
+		return "127.0.0.1:" + ServerPort
+	}
+	return ":" + ServerPort
+}
+
+// serve is app.Serve with a configurable host.
+func serve(app vii.App, addr string) error {
+	var handler http.Handler = app.Mux
+	for _, mw := range app.GlobalMiddleware {
+		handler = mw(handler)
+	}
+	fmt.Printf("Listening on %s\n", addr)
+	return http.ListenAndServe(addr, handler)
+}
+
+// --- AUTHENTICATION ---
+
+// isLoopback reports whether the request came from this machine.
+func isLoopback(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// isLoopbackHost reports whether the request was addressed to this machine
+// by a loopback name or address. A loopback peer isn't enough on its own: a
+// site whose DNS name is rebound to 127.0.0.1 reaches the server from the
+// browser with its own name in Host.
+func isLoopbackHost(r *http.Request) bool {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+// pageToken is the API token to embed in a page served for r. It is only
+// handed to pages loaded from this machine by a loopback name; anything else
+// on the network has to be given it by the user, and a page reached through
+// a rebound DNS name gets nothing.
+func pageToken(r *http.Request, token string) string {
+	if isLoopback(r) && isLoopbackHost(r) {
+		return token
+	}
+	return ""
+}
+
+// privateReads are the GET endpoints that give away what the user typed or
+// is looking at, so they need the token like state-changing requests do.
+var privateReads = []string{
+	"/api/clipboard",
+	"/api/events",
+	"/api/history",
+	"/api/jobs/",
+	"/api/macros",
+	"/api/pixel",
+	"/api/screenshot",
+	"/api/typed",
+	"/api/window",
+}
+
+// needsToken reports whether r has to carry the bearer token: every
+// state-changing /api request, and reads of privateReads.
+func needsToken(r *http.Request) bool {
+	if !strings.HasPrefix(r.URL.Path, "/api/") {
+		return false
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return true
+	}
+	for _, path := range privateReads {
+		if r.URL.Path == path || strings.HasPrefix(r.URL.Path, strings.TrimSuffix(path, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// requireToken rejects requests that need the token (see needsToken) and
+// don't carry "Authorization: Bearer <token>". An empty token disables the
+// check; the server then only listens on loopback.
+func requireToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token == "" || !needsToken(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="sniper"`)
+				vii.WriteJSON(w, http.StatusUnauthorized, map[string]string{"error": "missing or invalid bearer token"})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rawBodies are the endpoints that take a body other than JSON, by path
+// prefix, with the media type they take instead.
+var rawBodies = map[string]string{
+	"/api/icons/": "image/png",
+}
+
+// guardMutations refuses state-changing /api requests a web page on another
+// site could send through the user's browser: those whose Origin isn't this
+// server, and those whose body isn't JSON (a form can post text/plain or
+// urlencoded bodies without a CORS preflight, but not application/json).
+func guardMutations() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasPrefix(r.URL.Path, "/api/") || r.Method == http.MethodGet || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if origin := r.Header.Get("Origin"); origin != "" {
+				u, err := url.Parse(origin)
+				if err != nil || !strings.EqualFold(u.Host, r.Host) {
+					vii.WriteJSON(w, http.StatusForbidden, map[string]string{"error": "cross-origin request refused"})
+					return
+				}
+			}
+
+			if r.ContentLength != 0 {
+				want := "application/json"
+				for prefix, mediaType := range rawBodies {
+					if strings.HasPrefix(r.URL.Path, prefix) {
+						want = mediaType
+					}
+				}
+				got, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+				if err != nil || got != want {
+					vii.WriteJSON(w, http.StatusUnsupportedMediaType, map[string]string{"error": "request body must be " + want})
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// timeoutExcept is vii.MwTimeout for every path but the given ones. Phrases
+// are bounded by the engine's own max_execution_ms option instead, so they
+// fail with a structured error rather than a dropped connection.
+func timeoutExcept(seconds int, paths ...string) func(http.Handler) http.Handler {
+	timeout := vii.MwTimeout(seconds)
+	return func(next http.Handler) http.Handler {
+		limited := timeout(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if slices.Contains(paths, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			limited.ServeHTTP(w, r)
+		})
+	}
+}
+
+// phraseContext is the context a phrase runs under. A waiting client that
+// disconnects cancels its phrase; an async phrase outlives its request.
+func phraseContext(r *http.Request, async bool) context.Context {
+	if async {
+		return context.Background()
+	}
+	return r.Context()
+}
+
+// dataHandler parses and runs a phrase, or queues it with "async": true.
+func dataHandler(engine *sniper.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
 		var req struct {
 			Command string `json:"command"`
 			Mode    string `json:"mode"`
+			// Async queues the phrase and returns a job ID instead of waiting
+			Async bool `json:"async"`
+			// Words optionally replaces Command with per-word confidences
+			Words []struct {
+				W    string   `json:"w"`
+				Conf *float64 `json:"conf"`
+			} `json:"words"`
 		}
 
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -112,16 +893,283 @@ func runServer(engine *sniper.Engine) error {
 			return
 		}
 
-		engine.Parse(req.Command, req.Mode)
+		var job *sniper.Job
+		var err error
+		if len(req.Words) > 0 {
+			words := make([]sniper.SpokenWord, len(req.Words))
+			for i, word := range req.Words {
+				// A word sent without a score is trusted fully
+				words[i] = sniper.SpokenWord{W: word.W, Conf: sniper.DefaultConfidence}
+				if word.Conf != nil {
+					words[i].Conf = *word.Conf
+				}
+			}
+			job, err = engine.EnqueueWordsContext(phraseContext(r, req.Async), words, req.Mode)
+		} else {
+			job, err = engine.EnqueueContext(phraseContext(r, req.Async), req.Command, req.Mode)
+		}
+		if errors.Is(err, sniper.ErrQueueFull) {
+			http.Error(w, "Queue full: "+err.Error(), http.StatusTooManyRequests)
+			return
+		}
+		if rejectInput(w, err) {
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to queue phrase: "+err.Error(), http.StatusServiceUnavailable)
+			return
+		}
 
-		if err := engine.Execute(); err != nil {
-			http.Error(w, "Execution Error: "+err.Error(), http.StatusBadRequest)
+		if req.Async {
+			vii.WriteJSON(w, http.StatusAccepted, map[string]interface{}{
+				"status": "queued",
+				"job_id": job.ID,
+			})
 			return
 		}
 
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status":"executed"}`))
+		result, err := job.Wait()
+
+		// Execution failures are 422, distinct from the 400s for bad requests
+		logResult(r, result)
+		if result.Deduplicated {
+			vii.WriteJSON(w, http.StatusOK, map[string]interface{}{
+				"status": "deduplicated",
+			})
+			return
+		}
+		if rejectInput(w, err) {
+			return
+		}
+		var execErr *sniper.ExecError
+		if errors.As(err, &execErr) {
+			vii.WriteJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+				"status": "failed",
+				"error":  execErr,
+				"result": result,
+			})
+			return
+		}
+		if err != nil {
+			vii.WriteJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{
+				"status": "failed",
+				"error":  map[string]string{"error": err.Error()},
+				"result": result,
+			})
+			return
+		}
+
+		// ?verbose=false keeps the original minimal response
+		if vii.ParamIs(r, "verbose", "false") {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"status":"executed"}`))
+			return
+		}
+
+		vii.WriteJSON(w, http.StatusOK, map[string]interface{}{
+			"status": "executed",
+			"result": result,
+		})
+	}
+}
+
+// jobHandler reports the status and result of a phrase queued with "async": true.
+func jobHandler(engine *sniper.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid job id", http.StatusBadRequest)
+			return
+		}
+		job, ok := engine.Job(id)
+		if !ok {
+			http.Error(w, "Job not found", http.StatusNotFound)
+			return
+		}
+
+		reply := map[string]interface{}{
+			"id":     job.ID,
+			"status": job.Status(),
+		}
+		if job.Finished() {
+			result, err := job.Result()
+			reply["result"] = result
+			if err != nil {
+				reply["error"] = err.Error()
+			}
+		}
+		vii.WriteJSON(w, http.StatusOK, reply)
+	}
+}
+
+// registryHandler serves the encoded registry, pretty printed when full.
+func registryHandler(engine *sniper.Engine, full bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		snap, err := engine.RegistrySnapshot()
+		if err != nil {
+			http.Error(w, "Failed to encode registry: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if full {
+			serveCached(w, r, snap.Full, snap.FullGzip, snap.FullETag)
+			return
+		}
+		serveCached(w, r, snap.Minimal, snap.MinimalGzip, snap.MinimalETag)
+	}
+}
+
+// serveCached writes a JSON body that rarely changes: 304 when the client
+// already has etag, gzipped when it accepts that. The gzipped body has its
+// own ETag, since it is a different representation.
+func serveCached(w http.ResponseWriter, r *http.Request, body, gzipped []byte, etag string) {
+	gzipTag := strings.TrimSuffix(etag, `"`) + `-gzip"`
+	useGzip := acceptsGzip(r)
+
+	w.Header().Set("Vary", "Accept-Encoding")
+	w.Header().Set("Cache-Control", "no-cache")
+	if useGzip {
+		w.Header().Set("ETag", gzipTag)
+	} else {
+		w.Header().Set("ETag", etag)
+	}
+
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == etag || tag == gzipTag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if useGzip {
+		w.Header().Set("Content-Encoding", "gzip")
+		body = gzipped
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.Write(body)
+}
+
+// acceptsGzip reports whether the request's Accept-Encoding allows gzip.
+func acceptsGzip(r *http.Request) bool {
+	for _, coding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(coding, ";")
+		if !strings.EqualFold(strings.TrimSpace(name), "gzip") {
+			continue
+		}
+		// "gzip;q=0" refuses it
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			weight, err := strconv.ParseFloat(q, 64)
+			return err != nil || weight > 0
+		}
+		return true
+	}
+	return false
+}
+
+// rejectInput answers a phrase that broke an input limit: 413 when it was too
+// large, 400 when it was malformed. It reports whether err was such a rejection.
+func rejectInput(w http.ResponseWriter, err error) bool {
+	var inputErr *sniper.InputError
+	if !errors.As(err, &inputErr) {
+		return false
+	}
+	status := http.StatusRequestEntityTooLarge
+	if errors.Is(err, sniper.ErrMalformedInput) {
+		status = http.StatusBadRequest
+	}
+	vii.WriteJSON(w, status, map[string]interface{}{
+		"status": "rejected",
+		"error":  err.Error(),
+		"limit":  inputErr,
 	})
+	return true
+}
 
-	return app.Serve(ServerPort)
+// eventsHandler streams the engine's events as server-sent events, one
+// "event: <type>" and "data: <json>" pair each, until the client goes away.
+func eventsHandler(engine *sniper.Engine) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		events, unsubscribe := engine.Subscribe()
+		defer unsubscribe()
+
+		// Flushing the headers tells the client it is subscribed
+		rc := http.NewResponseController(w)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		if err := rc.Flush(); err != nil {
+			return
+		}
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case event := <-events:
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, event.Data)
+				if err := rc.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// --- REQUEST LOGGING ---
+
+type requestLogKey struct{}
+
+// requestLog is filled in by handlers that have something worth adding to
+// the request's log line.
+type requestLog struct {
+	result *sniper.ExecutionResult
+}
+
+// logResult attaches an execution result to the current request's log line.
+func logResult(r *http.Request, result sniper.ExecutionResult) {
+	if entry, ok := r.Context().Value(requestLogKey{}).(*requestLog); ok {
+		entry.result = &result
+	}
+}
+
+// statusRecorder remembers the status code a handler wrote.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the connection, for streaming.
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// requestLogger logs one line per request with its method, path, status and duration.
+func requestLogger(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			entry := &requestLog{}
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), requestLogKey{}, entry)))
+
+			attrs := []any{
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", rec.status,
+				"duration", time.Since(start),
+			}
+			if entry.result != nil {
+				attrs = append(attrs, "result", *entry.result)
+			}
+			logger.Info("request", attrs...)
+		})
+	}
 }
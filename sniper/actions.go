@@ -0,0 +1,123 @@
+package sniper
+
+import (
+	"sync"
+	"time"
+)
+
+// ----------------------------------------------------------------------------
+// ACTION RECORDING
+// ----------------------------------------------------------------------------
+//
+// The keyboard and mouse report every low-level action they perform into an
+// ActionRecorder, so a phrase's result can list exactly what was done to the
+// machine: which keys, where the mouse went, how long it slept.
+
+// ActionKind names a low-level action.
+type ActionKind string
+
+const (
+	ActionKeyTap     ActionKind = "key_tap"
+	ActionKeyDown    ActionKind = "key_down"
+	ActionKeyUp      ActionKind = "key_up"
+	ActionType       ActionKind = "type"
+	ActionReleaseAll ActionKind = "release_all"
+	ActionMouseMove  ActionKind = "mouse_move"
+	ActionClick      ActionKind = "click"
+	ActionScroll     ActionKind = "scroll"
+	ActionSleep      ActionKind = "sleep"
+)
+
+// MaxRecordedActions caps the actions kept for one phrase, so a long "repeat"
+// can't blow up the response.
+const MaxRecordedActions = 1000
+
+// RecordedAction is one thing the engine did to the keyboard or mouse.
+type RecordedAction struct {
+	Kind       ActionKind `json:"kind"`
+	Key        string     `json:"key,omitempty"`
+	Modifiers  []string   `json:"modifiers,omitempty"`
+	Text       string     `json:"text,omitempty"`
+	X          int        `json:"x,omitempty"`
+	Y          int        `json:"y,omitempty"`
+	Button     string     `json:"button,omitempty"`
+	Clicks     int        `json:"clicks,omitempty"`
+	DurationMs float64    `json:"duration_ms,omitempty"`
+	At         time.Time  `json:"at"`
+}
+
+// ActionRecorder collects the actions of one phrase. A nil recorder records
+// nothing, so drivers can report unconditionally.
+type ActionRecorder struct {
+	actions []RecordedAction
+	limit   int
+	dropped int
+	mu      sync.Mutex
+}
+
+// NewActionRecorder creates a recorder keeping at most limit actions.
+func NewActionRecorder(limit int) *ActionRecorder {
+	return &ActionRecorder{actions: make([]RecordedAction, 0), limit: limit}
+}
+
+// Record appends an action, counting it as dropped once the limit is reached.
+func (r *ActionRecorder) Record(action RecordedAction) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.actions) >= r.limit {
+		r.dropped++
+		return
+	}
+	if action.At.IsZero() {
+		action.At = time.Now()
+	}
+	r.actions = append(r.actions, action)
+}
+
+// Actions returns a copy of the recorded actions, oldest first.
+func (r *ActionRecorder) Actions() []RecordedAction {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]RecordedAction, len(r.actions))
+	copy(out, r.actions)
+	return out
+}
+
+// Dropped returns how many actions didn't fit under the limit.
+func (r *ActionRecorder) Dropped() int {
+	if r == nil {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.dropped
+}
+
+// ----------------------------------------------------------------------------
+// ENGINE INTEGRATION
+// ----------------------------------------------------------------------------
+
+// beginRecording attaches a fresh recorder to the keyboard and mouse for the
+// phrase about to run.
+func (e *Engine) beginRecording() *ActionRecorder {
+	rec := NewActionRecorder(MaxRecordedActions)
+	e.recorder = rec
+	e.StickyKeyboard.SetRecorder(rec)
+	e.Mouse.SetRecorder(rec)
+	return rec
+}
+
+// endRecording detaches the phrase's recorder, so actions from outside a
+// phrase (the stuck-modifier watchdog, /api/release) aren't attributed to it.
+func (e *Engine) endRecording() {
+	e.recorder = nil
+	e.StickyKeyboard.SetRecorder(nil)
+	e.Mouse.SetRecorder(nil)
+}
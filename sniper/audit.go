@@ -0,0 +1,147 @@
+package sniper
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// REGISTRY AUDIT
+// ----------------------------------------------------------------------------
+
+// commonWords are everyday English words that a recognizer will hear in normal
+// speech. A trigger on this list is likely to fire when it wasn't meant to.
+var commonWords = map[string]bool{
+	"a": true, "an": true, "and": true, "as": true, "at": true, "be": true,
+	"but": true, "by": true, "do": true, "for": true, "go": true, "he": true,
+	"i": true, "if": true, "in": true, "is": true, "it": true, "me": true,
+	"my": true, "no": true, "of": true, "off": true, "on": true, "or": true,
+	"out": true, "right": true, "so": true, "that": true, "the": true,
+	"this": true, "to": true, "up": true, "we": true, "what": true, "with": true,
+	"yes": true, "you": true,
+}
+
+// TriggerCollision is a trigger claimed by more than one command.
+// Only the last one registered actually responds.
+type TriggerCollision struct {
+	Trigger  string   `json:"trigger"`
+	Commands []string `json:"commands"`
+}
+
+// NameCollision is a Name() shared by more than one command.
+type NameCollision struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// CommonWordTrigger is a trigger that's also an everyday word.
+type CommonWordTrigger struct {
+	Trigger string `json:"trigger"`
+	Command string `json:"command"`
+}
+
+// ShadowedSpot is a saved mouse spot that can never be reached by voice
+// because a command trigger with the same word is checked first.
+type ShadowedSpot struct {
+	Spot    string `json:"spot"`
+	Command string `json:"command"`
+}
+
+// RegistryAudit is the report produced by AuditRegistry.
+type RegistryAudit struct {
+	DuplicateTriggers []TriggerCollision  `json:"duplicate_triggers"`
+	DuplicateNames    []NameCollision     `json:"duplicate_names"`
+	CommonWords       []CommonWordTrigger `json:"common_words"`
+	ShadowedSpots     []ShadowedSpot      `json:"shadowed_spots"`
+
+	// SuppressedTriggers are switched off by the StrictAlphabet option
+	SuppressedTriggers []string `json:"suppressed_triggers"`
+
+	// Conflicts are words several sources claim, and which one wins
+	Conflicts []TriggerConflict `json:"conflicts"`
+}
+
+// Clean reports whether the audit found no duplicate triggers or names.
+// Common-word triggers and shadowed spots are warnings and don't count.
+func (a RegistryAudit) Clean() bool {
+	return len(a.DuplicateTriggers) == 0 && len(a.DuplicateNames) == 0
+}
+
+// Summary is a one-line description suitable for logging.
+func (a RegistryAudit) Summary() string {
+	return fmt.Sprintf("%d duplicate triggers, %d duplicate names, %d common-word triggers, %d shadowed spots",
+		len(a.DuplicateTriggers), len(a.DuplicateNames), len(a.CommonWords), len(a.ShadowedSpots))
+}
+
+// AuditRegistry checks a command set (and optionally the saved spot names) for
+// problems registerCommands would otherwise hide.
+func AuditRegistry(cmds []Cmd, spots []string) RegistryAudit {
+	audit := RegistryAudit{
+		DuplicateTriggers: make([]TriggerCollision, 0),
+		DuplicateNames:    make([]NameCollision, 0),
+		CommonWords:       make([]CommonWordTrigger, 0),
+		ShadowedSpots:     make([]ShadowedSpot, 0),
+
+		SuppressedTriggers: make([]string, 0),
+		Conflicts:          make([]TriggerConflict, 0),
+	}
+
+	// 1. Group triggers and names
+	owners := make(map[string][]string)
+	names := make(map[string]int)
+	for _, cmd := range cmds {
+		names[cmd.Name()]++
+		for _, trigger := range cmd.CalledBy() {
+			key := strings.ToLower(trigger)
+			owners[key] = append(owners[key], cmd.Name())
+		}
+	}
+
+	// 2. Duplicates and common words
+	for _, trigger := range sortedKeys(owners) {
+		cmdNames := owners[trigger]
+		if len(cmdNames) > 1 {
+			audit.DuplicateTriggers = append(audit.DuplicateTriggers, TriggerCollision{Trigger: trigger, Commands: cmdNames})
+		}
+		if commonWords[trigger] {
+			audit.CommonWords = append(audit.CommonWords, CommonWordTrigger{Trigger: trigger, Command: cmdNames[len(cmdNames)-1]})
+		}
+	}
+	for _, name := range sortedKeys(names) {
+		if names[name] > 1 {
+			audit.DuplicateNames = append(audit.DuplicateNames, NameCollision{Name: name, Count: names[name]})
+		}
+	}
+
+	// 3. Spots hidden behind a trigger
+	sort.Strings(spots)
+	for _, spot := range spots {
+		if cmdNames, ok := owners[strings.ToLower(spot)]; ok {
+			audit.ShadowedSpots = append(audit.ShadowedSpots, ShadowedSpot{Spot: spot, Command: cmdNames[len(cmdNames)-1]})
+		}
+	}
+
+	return audit
+}
+
+// Audit runs AuditRegistry over the engine's commands and saved spots, and
+// lists the triggers StrictAlphabet suppresses and the words several sources claim.
+func (e *Engine) Audit() RegistryAudit {
+	audit := AuditRegistry(e.Commands(), e.Memory.Names())
+	audit.SuppressedTriggers = e.SuppressedTriggers()
+	audit.Conflicts = e.TriggerConflicts()
+	return audit
+}
+
+// logAudit prints the audit summary plus each duplicate, which is almost always a bug.
+func (e *Engine) logAudit() {
+	audit := e.Audit()
+	e.log().Info("registry audit", "summary", audit.Summary())
+	for _, c := range audit.DuplicateTriggers {
+		e.log().Warn("duplicate trigger", "trigger", c.Trigger, "commands", strings.Join(c.Commands, ", "))
+	}
+	for _, c := range audit.DuplicateNames {
+		e.log().Warn("duplicate command name", "name", c.Name, "count", c.Count)
+	}
+}
@@ -0,0 +1,95 @@
+package sniper_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/phillip-england/sniper/sniper"
+	"github.com/phillip-england/sniper/sniper/snipertest"
+)
+
+func TestBuiltinRegistryAuditIsClean(t *testing.T) {
+	audit := sniper.AuditRegistry(sniper.Registry, nil)
+	for _, c := range audit.DuplicateTriggers {
+		t.Errorf("trigger %q is claimed by %q", c.Trigger, c.Commands)
+	}
+	for _, c := range audit.DuplicateNames {
+		t.Errorf("%d built-in commands are named %q", c.Count, c.Name)
+	}
+	if !audit.Clean() {
+		t.Errorf("audit: %s", audit.Summary())
+	}
+
+	// Nor does an engine with the defaults find any words claimed twice
+	e := snipertest.NewTestEngine(t)
+	audit = e.Audit()
+	if !audit.Clean() || len(audit.ShadowedSpots) > 0 {
+		t.Errorf("engine audit: %s", audit.Summary())
+	}
+	for _, c := range audit.Conflicts {
+		t.Errorf("conflict: %+v", c)
+	}
+}
+
+func TestAuditFindsCollisions(t *testing.T) {
+	cmds := append(slices.Clone(sniper.Registry),
+		searchCmd{"south", []string{"beam"}, ""},
+		searchCmd{"beam_up", []string{"north"}, ""},
+	)
+	audit := sniper.AuditRegistry(cmds, []string{"desk", "North"})
+	if audit.Clean() {
+		t.Fatal("a registry with duplicates audited clean")
+	}
+	if len(audit.DuplicateNames) != 1 || audit.DuplicateNames[0].Name != "south" {
+		t.Errorf("duplicate names = %+v, want south", audit.DuplicateNames)
+	}
+	if len(audit.DuplicateTriggers) != 1 || audit.DuplicateTriggers[0].Trigger != "north" {
+		t.Errorf("duplicate triggers = %+v, want north", audit.DuplicateTriggers)
+	}
+	if len(audit.ShadowedSpots) != 1 || audit.ShadowedSpots[0] != (sniper.ShadowedSpot{Spot: "North", Command: "beam_up"}) {
+		t.Errorf("shadowed spots = %+v, want North behind beam_up", audit.ShadowedSpots)
+	}
+}
+
+func TestStrictAlphabet(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	if got := e.Audit().SuppressedTriggers; len(got) != 0 {
+		t.Errorf("suppressed with strict off = %v", got)
+	}
+
+	e.MustRun(t, "strict on")
+	if !e.Options().StrictAlphabet {
+		t.Fatal("strict on left the option off")
+	}
+	if got := e.Audit().SuppressedTriggers; !slices.Contains(got, "right") || !slices.Contains(got, "up") {
+		t.Errorf("suppressed = %v, want right and up among them", got)
+	}
+	if e.MustRun(t, "right"); len(e.Input.Ops()) != 0 {
+		t.Errorf("right in strict mode ran %v", e.Input.Ops())
+	}
+	snipertest.ExpectKeys(t, e, "east", "right")
+
+	// Turning it off puts the words straight back, without a restart
+	e.MustRun(t, "strict off")
+	if e.MustRun(t, "right"); !slices.Equal(e.Input.Ops(), []string{"move 1 0"}) {
+		t.Errorf("right after strict off ran %v, want a nudge", e.Input.Ops())
+	}
+	if got := e.Audit().SuppressedTriggers; len(got) != 0 {
+		t.Errorf("suppressed after strict off = %v", got)
+	}
+}
+
+func TestStrictAlphabetUsesConfiguredWords(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.ApplyConfig(&sniper.Config{AmbiguousWords: []string{"east"}})
+	e.MustRun(t, "strict on")
+	if got := e.Audit().SuppressedTriggers; !slices.Equal(got, []string{"east"}) {
+		t.Errorf("suppressed = %v, want only east", got)
+	}
+	if e.MustRun(t, "right"); len(e.Input.Ops()) == 0 {
+		t.Error("right is off the configured list but did nothing")
+	}
+	if e.MustRun(t, "east"); len(e.Input.Ops()) != 0 {
+		t.Errorf("east in strict mode ran %v", e.Input.Ops())
+	}
+}
@@ -0,0 +1,447 @@
+package sniper
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-vgo/robotgo"
+)
+
+// ----------------------------------------------------------------------------
+// INPUT BACKENDS
+// ----------------------------------------------------------------------------
+//
+// The keyboard and mouse send their key taps, moves, clicks and scrolls
+// through an InputBackend. robotgo is the default; under Wayland, where its
+// synthetic input drops keys, the "ydotool" backend shells out to ydotool, and
+// "xdotool" does the same on X11. Key names are robotgo's everywhere
+// ("enter", "ctrl", "cmd"); the command backends translate them.
+
+// Backend names for the backend option.
+const (
+	BackendRobotgo = "robotgo"
+	BackendXdotool = "xdotool"
+	BackendYdotool = "ydotool"
+)
+
+// Backends lists every backend name.
+var Backends = []string{BackendRobotgo, BackendXdotool, BackendYdotool}
+
+// ErrUnknownKey is returned by a command backend for a key it can't translate.
+var ErrUnknownKey = errors.New("key not supported by backend")
+
+// InputBackend performs the low-level keyboard and mouse operations.
+type InputBackend interface {
+	Name() string
+	KeyTap(key string, modifiers ...string) error
+	KeyDown(key string) error
+	KeyUp(key string) error
+	TypeStr(text string) error
+	Move(x, y int) error
+	Location() (int, int)
+	ScreenSize() (int, int)
+	Click(button string) error
+	MouseUp(button string) error
+	Scroll(x, y int) error
+}
+
+// NewBackend returns the backend called name. A command backend whose binary
+// isn't installed returns an error, along with the backend so it can still be
+// inspected.
+func NewBackend(name string) (InputBackend, error) {
+	switch name {
+	case "", BackendRobotgo:
+		return RobotgoBackend{}, nil
+	case BackendXdotool, BackendYdotool:
+		b := NewCommandBackend(name)
+		if _, err := exec.LookPath(name); err != nil {
+			return b, fmt.Errorf("%s backend: %w", name, err)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("unknown backend '%s' (want one of %s)", name, strings.Join(Backends, ", "))
+}
+
+// WithInputBackend sends the engine's keys and mouse input through b, e.g. a
+// recording fake in tests. Changing the backend option later replaces it.
+func WithInputBackend(b InputBackend) EngineOption {
+	return func(s *engineSetup) {
+		if b == nil {
+			s.errs = append(s.errs, errors.New("WithInputBackend: backend is nil"))
+			return
+		}
+		s.backend = b
+	}
+}
+
+// useBackend switches the keyboard and mouse to the backend called name. When
+// its binary is missing, robotgo stays in use and the problem is kept for
+// BackendError (and the health endpoint).
+func (e *Engine) useBackend(name string) {
+	b, err := NewBackend(name)
+	if err != nil {
+		e.log().Error("input backend unavailable, using robotgo", "backend", name, "error", err)
+		b = RobotgoBackend{}
+	}
+	e.StickyKeyboard.SetBackend(b)
+	e.Mouse.SetBackend(b)
+
+	e.backendMu.Lock()
+	e.backend, e.backendErr = b.Name(), err
+	e.backendMu.Unlock()
+}
+
+// Backend returns the name of the backend in use.
+func (e *Engine) Backend() string {
+	e.backendMu.Lock()
+	defer e.backendMu.Unlock()
+	if e.backend == "" {
+		return BackendRobotgo
+	}
+	return e.backend
+}
+
+// BackendError returns why the configured backend couldn't be used, or nil.
+func (e *Engine) BackendError() error {
+	e.backendMu.Lock()
+	defer e.backendMu.Unlock()
+	return e.backendErr
+}
+
+// ----------------------------------------------------------------------------
+// ROBOTGO
+// ----------------------------------------------------------------------------
+
+// RobotgoBackend sends input through robotgo.
+type RobotgoBackend struct{}
+
+func (RobotgoBackend) Name() string { return BackendRobotgo }
+
+func (RobotgoBackend) KeyTap(key string, modifiers ...string) error {
+	// robotgo takes the modifiers as an interface slice
+	args := make([]interface{}, len(modifiers))
+	for i, v := range modifiers {
+		args[i] = v
+	}
+	return robotgo.KeyTap(key, args...)
+}
+
+func (RobotgoBackend) KeyDown(key string) error    { return robotgo.KeyDown(key) }
+func (RobotgoBackend) KeyUp(key string) error      { return robotgo.KeyUp(key) }
+func (RobotgoBackend) Location() (int, int)        { return robotgo.Location() }
+func (RobotgoBackend) ScreenSize() (int, int)      { return robotgo.GetScreenSize() }
+func (RobotgoBackend) MouseUp(button string) error { return robotgo.MouseUp(button) }
+
+func (RobotgoBackend) TypeStr(text string) error {
+	robotgo.TypeStr(text)
+	return nil
+}
+
+func (RobotgoBackend) Move(x, y int) error {
+	robotgo.Move(x, y)
+	return nil
+}
+
+func (RobotgoBackend) Click(button string) error {
+	robotgo.Click(button)
+	return nil
+}
+
+func (RobotgoBackend) Scroll(x, y int) error {
+	robotgo.Scroll(x, y)
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+// XDOTOOL / YDOTOOL
+// ----------------------------------------------------------------------------
+
+// CommandBackend sends input by running xdotool or ydotool. Run executes an
+// argv and returns its output; replace it to inspect the commands without
+// running them.
+type CommandBackend struct {
+	Tool string
+	Run  func(argv []string) ([]byte, error)
+
+	// ydotool can't report the pointer, so the last position moved to is kept
+	x, y int
+}
+
+// NewCommandBackend creates a backend running tool ("xdotool" or "ydotool").
+func NewCommandBackend(tool string) *CommandBackend {
+	return &CommandBackend{Tool: tool, Run: runCommand}
+}
+
+func runCommand(argv []string) ([]byte, error) {
+	out, err := exec.Command(argv[0], argv[1:]...).Output()
+	if err != nil {
+		return out, fmt.Errorf("%s: %w", strings.Join(argv, " "), err)
+	}
+	return out, nil
+}
+
+func (b *CommandBackend) Name() string { return b.Tool }
+
+func (b *CommandBackend) run(args ...string) error {
+	_, err := b.Run(append([]string{b.Tool}, args...))
+	return err
+}
+
+// KeyTapArgv returns the argv that taps key with modifiers held.
+func (b *CommandBackend) KeyTapArgv(key string, modifiers ...string) ([]string, error) {
+	if b.Tool == BackendYdotool {
+		// Keycodes have no case; a capital is its letter with shift
+		if r := []rune(key); len(r) == 1 && unicode.IsUpper(r[0]) {
+			key, modifiers = strings.ToLower(key), slices.Concat(modifiers, []string{"shift"})
+		}
+		codes, err := ydotoolCodes(slices.Concat(modifiers, []string{key}))
+		if err != nil {
+			return nil, err
+		}
+		// Press in order, release in reverse
+		args := []string{b.Tool, "key"}
+		for _, c := range codes {
+			args = append(args, c+":1")
+		}
+		for i := len(codes) - 1; i >= 0; i-- {
+			args = append(args, codes[i]+":0")
+		}
+		return args, nil
+	}
+
+	keys := make([]string, 0, len(modifiers)+1)
+	for _, k := range slices.Concat(modifiers, []string{key}) {
+		keys = append(keys, xdotoolKey(k))
+	}
+	return []string{b.Tool, "key", "--clearmodifiers", strings.Join(keys, "+")}, nil
+}
+
+func (b *CommandBackend) KeyTap(key string, modifiers ...string) error {
+	argv, err := b.KeyTapArgv(key, modifiers...)
+	if err != nil {
+		return err
+	}
+	_, err = b.Run(argv)
+	return err
+}
+
+// KeyStateArgv returns the argv that presses key, or releases it.
+func (b *CommandBackend) KeyStateArgv(key string, down bool) ([]string, error) {
+	if b.Tool == BackendYdotool {
+		codes, err := ydotoolCodes([]string{key})
+		if err != nil {
+			return nil, err
+		}
+		state := ":0"
+		if down {
+			state = ":1"
+		}
+		return []string{b.Tool, "key", codes[0] + state}, nil
+	}
+	if down {
+		return []string{b.Tool, "keydown", xdotoolKey(key)}, nil
+	}
+	return []string{b.Tool, "keyup", xdotoolKey(key)}, nil
+}
+
+func (b *CommandBackend) keyState(key string, down bool) error {
+	argv, err := b.KeyStateArgv(key, down)
+	if err != nil {
+		return err
+	}
+	_, err = b.Run(argv)
+	return err
+}
+
+func (b *CommandBackend) KeyDown(key string) error { return b.keyState(key, true) }
+func (b *CommandBackend) KeyUp(key string) error   { return b.keyState(key, false) }
+
+// TypeArgv returns the argv that types text. The text is one argument after
+// "--", so nothing in it is read as an option or by a shell.
+func (b *CommandBackend) TypeArgv(text string) []string {
+	return []string{b.Tool, "type", "--", text}
+}
+
+func (b *CommandBackend) TypeStr(text string) error {
+	_, err := b.Run(b.TypeArgv(text))
+	return err
+}
+
+func (b *CommandBackend) Move(x, y int) error {
+	b.x, b.y = x, y
+	if b.Tool == BackendYdotool {
+		return b.run("mousemove", "--absolute", "-x", strconv.Itoa(x), "-y", strconv.Itoa(y))
+	}
+	return b.run("mousemove", strconv.Itoa(x), strconv.Itoa(y))
+}
+
+// Location asks xdotool for the pointer; ydotool returns where it last moved it.
+func (b *CommandBackend) Location() (int, int) {
+	if b.Tool == BackendYdotool {
+		return b.x, b.y
+	}
+	out, err := b.Run([]string{b.Tool, "getmouselocation", "--shell"})
+	if err != nil {
+		return b.x, b.y
+	}
+	for _, line := range strings.Fields(string(out)) {
+		name, value, _ := strings.Cut(line, "=")
+		switch name {
+		case "X":
+			b.x, _ = strconv.Atoi(value)
+		case "Y":
+			b.y, _ = strconv.Atoi(value)
+		}
+	}
+	return b.x, b.y
+}
+
+// ScreenSize asks xdotool for the display geometry. ydotool can't tell, so
+// robotgo is asked instead (XWayland usually knows).
+func (b *CommandBackend) ScreenSize() (int, int) {
+	if b.Tool == BackendYdotool {
+		return robotgo.GetScreenSize()
+	}
+	out, err := b.Run([]string{b.Tool, "getdisplaygeometry"})
+	fields := strings.Fields(string(out))
+	if err != nil || len(fields) != 2 {
+		return robotgo.GetScreenSize()
+	}
+	w, _ := strconv.Atoi(fields[0])
+	h, _ := strconv.Atoi(fields[1])
+	return w, h
+}
+
+// xdotoolButtons and ydotoolButtons number the mouse buttons.
+var (
+	xdotoolButtons = map[string]string{"left": "1", "center": "2", "middle": "2", "right": "3"}
+	ydotoolButtons = map[string]int{"left": 0x00, "right": 0x01, "center": 0x02, "middle": 0x02}
+)
+
+func (b *CommandBackend) button(button string, flags int) (string, error) {
+	if b.Tool == BackendYdotool {
+		code, ok := ydotoolButtons[button]
+		if !ok {
+			return "", fmt.Errorf("unknown mouse button '%s'", button)
+		}
+		return fmt.Sprintf("0x%02X", code|flags), nil
+	}
+	code, ok := xdotoolButtons[button]
+	if !ok {
+		return "", fmt.Errorf("unknown mouse button '%s'", button)
+	}
+	return code, nil
+}
+
+func (b *CommandBackend) Click(button string) error {
+	code, err := b.button(button, 0xC0) // ydotool: down then up
+	if err != nil {
+		return err
+	}
+	return b.run("click", code)
+}
+
+func (b *CommandBackend) MouseUp(button string) error {
+	code, err := b.button(button, 0x80) // ydotool: up only
+	if err != nil {
+		return err
+	}
+	if b.Tool == BackendYdotool {
+		return b.run("click", code)
+	}
+	return b.run("mouseup", code)
+}
+
+// Scroll scrolls by one notch per unit; positive y is up and positive x is
+// left, as with robotgo.
+func (b *CommandBackend) Scroll(x, y int) error {
+	if b.Tool == BackendYdotool {
+		return b.run("mousemove", "--wheel", "-x", strconv.Itoa(-x), "-y", strconv.Itoa(y))
+	}
+	// X11 scrolls with buttons 4 (up), 5 (down), 6 (left) and 7 (right)
+	var args []string
+	for ; y > 0; y-- {
+		args = append(args, "4")
+	}
+	for ; y < 0; y++ {
+		args = append(args, "5")
+	}
+	for ; x > 0; x-- {
+		args = append(args, "6")
+	}
+	for ; x < 0; x++ {
+		args = append(args, "7")
+	}
+	for _, button := range args {
+		if err := b.run("click", button); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// xdotoolKeysyms maps robotgo key names to X keysyms where they differ.
+var xdotoolKeysyms = map[string]string{
+	"enter": "Return", "return": "Return", "esc": "Escape", "escape": "Escape",
+	"backspace": "BackSpace", "delete": "Delete", "tab": "Tab", "space": "space",
+	"up": "Up", "down": "Down", "left": "Left", "right": "Right",
+	"home": "Home", "end": "End", "pageup": "Prior", "pagedown": "Next",
+	"insert": "Insert", "capslock": "Caps_Lock", "menu": "Menu",
+	"ctrl": "ctrl", "lctrl": "Control_L", "rctrl": "Control_R", "control": "ctrl",
+	"shift": "shift", "lshift": "Shift_L", "rshift": "Shift_R",
+	"alt": "alt", "lalt": "Alt_L", "ralt": "Alt_R",
+	"cmd": "super", "lcmd": "Super_L", "rcmd": "Super_R", "command": "super",
+	"-": "minus", "=": "equal", "[": "bracketleft", "]": "bracketright",
+	";": "semicolon", "'": "apostrophe", "`": "grave", "\\": "backslash",
+	",": "comma", ".": "period", "/": "slash",
+}
+
+// xdotoolKey translates a robotgo key name to an X keysym. Letters, digits
+// and function keys ("f5" -> "F5") need no table.
+func xdotoolKey(key string) string {
+	if sym, ok := xdotoolKeysyms[key]; ok {
+		return sym
+	}
+	if len(key) > 1 && key[0] == 'f' {
+		if _, err := strconv.Atoi(key[1:]); err == nil {
+			return "F" + key[1:]
+		}
+	}
+	return key
+}
+
+// ydotoolKeycodes maps robotgo key names to Linux input event codes, which
+// ydotool sends.
+var ydotoolKeycodes = map[string]int{
+	"esc": 1, "escape": 1, "1": 2, "2": 3, "3": 4, "4": 5, "5": 6, "6": 7, "7": 8, "8": 9, "9": 10, "0": 11,
+	"-": 12, "=": 13, "backspace": 14, "tab": 15,
+	"q": 16, "w": 17, "e": 18, "r": 19, "t": 20, "y": 21, "u": 22, "i": 23, "o": 24, "p": 25,
+	"[": 26, "]": 27, "enter": 28, "return": 28, "ctrl": 29, "lctrl": 29, "control": 29,
+	"a": 30, "s": 31, "d": 32, "f": 33, "g": 34, "h": 35, "j": 36, "k": 37, "l": 38,
+	";": 39, "'": 40, "`": 41, "shift": 42, "lshift": 42, "\\": 43,
+	"z": 44, "x": 45, "c": 46, "v": 47, "b": 48, "n": 49, "m": 50,
+	",": 51, ".": 52, "/": 53, "rshift": 54, "alt": 56, "lalt": 56, "space": 57, "capslock": 58,
+	"f1": 59, "f2": 60, "f3": 61, "f4": 62, "f5": 63, "f6": 64, "f7": 65, "f8": 66, "f9": 67, "f10": 68,
+	"f11": 87, "f12": 88, "rctrl": 97, "ralt": 100,
+	"home": 102, "up": 103, "pageup": 104, "left": 105, "right": 106, "end": 107, "down": 108,
+	"pagedown": 109, "insert": 110, "delete": 111,
+	"cmd": 125, "lcmd": 125, "command": 125, "rcmd": 126, "menu": 127,
+}
+
+// ydotoolCodes translates robotgo key names to ydotool keycodes.
+func ydotoolCodes(keys []string) ([]string, error) {
+	codes := make([]string, len(keys))
+	for i, key := range keys {
+		code, ok := ydotoolKeycodes[strings.ToLower(key)]
+		if !ok {
+			return nil, fmt.Errorf("%w: '%s' (ydotool)", ErrUnknownKey, key)
+		}
+		codes[i] = strconv.Itoa(code)
+	}
+	return codes, nil
+}
@@ -0,0 +1,148 @@
+package sniper_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/phillip-england/sniper/sniper"
+)
+
+func TestKeyTapArgv(t *testing.T) {
+	tests := []struct {
+		tool      string
+		key       string
+		modifiers []string
+		want      string
+	}{
+		{sniper.BackendXdotool, "a", nil, "xdotool key --clearmodifiers a"},
+		{sniper.BackendXdotool, "A", nil, "xdotool key --clearmodifiers A"},
+		{sniper.BackendXdotool, "enter", nil, "xdotool key --clearmodifiers Return"},
+		{sniper.BackendXdotool, "pageup", nil, "xdotool key --clearmodifiers Prior"},
+		{sniper.BackendXdotool, "f5", nil, "xdotool key --clearmodifiers F5"},
+		{sniper.BackendXdotool, "f", nil, "xdotool key --clearmodifiers f"},
+		{sniper.BackendXdotool, ".", nil, "xdotool key --clearmodifiers period"},
+		{sniper.BackendXdotool, "c", []string{"ctrl"}, "xdotool key --clearmodifiers ctrl+c"},
+		{sniper.BackendXdotool, "tab", []string{"ctrl", "shift"}, "xdotool key --clearmodifiers ctrl+shift+Tab"},
+		{sniper.BackendXdotool, "space", []string{"cmd"}, "xdotool key --clearmodifiers super+space"},
+
+		// Modifiers go down in order and come up in reverse
+		{sniper.BackendYdotool, "a", nil, "ydotool key 30:1 30:0"},
+		{sniper.BackendYdotool, "enter", nil, "ydotool key 28:1 28:0"},
+		{sniper.BackendYdotool, "c", []string{"ctrl"}, "ydotool key 29:1 46:1 46:0 29:0"},
+		{sniper.BackendYdotool, "tab", []string{"ctrl", "shift"}, "ydotool key 29:1 42:1 15:1 15:0 42:0 29:0"},
+		// A capital is its letter with shift
+		{sniper.BackendYdotool, "A", nil, "ydotool key 42:1 30:1 30:0 42:0"},
+		{sniper.BackendYdotool, "S", []string{"ctrl"}, "ydotool key 29:1 42:1 31:1 31:0 42:0 29:0"},
+		{sniper.BackendYdotool, "F5", nil, "ydotool key 63:1 63:0"},
+	}
+	for _, tt := range tests {
+		argv, err := sniper.NewCommandBackend(tt.tool).KeyTapArgv(tt.key, tt.modifiers...)
+		if err != nil {
+			t.Errorf("%s %q %q: %v", tt.tool, tt.modifiers, tt.key, err)
+			continue
+		}
+		if got := strings.Join(argv, " "); got != tt.want {
+			t.Errorf("%s %q %q:\n got %s\nwant %s", tt.tool, tt.modifiers, tt.key, got, tt.want)
+		}
+	}
+
+	// ydotool only knows the keys on a US keyboard
+	for _, key := range []string{"é", "!", "volumeup"} {
+		if _, err := sniper.NewCommandBackend(sniper.BackendYdotool).KeyTapArgv(key); !errors.Is(err, sniper.ErrUnknownKey) {
+			t.Errorf("ydotool %q: err = %v, want ErrUnknownKey", key, err)
+		}
+	}
+}
+
+func TestKeyStateArgv(t *testing.T) {
+	tests := []struct {
+		tool string
+		key  string
+		down bool
+		want string
+	}{
+		{sniper.BackendXdotool, "shift", true, "xdotool keydown shift"},
+		{sniper.BackendXdotool, "shift", false, "xdotool keyup shift"},
+		{sniper.BackendXdotool, "lctrl", true, "xdotool keydown Control_L"},
+		{sniper.BackendXdotool, "backspace", true, "xdotool keydown BackSpace"},
+		{sniper.BackendYdotool, "shift", true, "ydotool key 42:1"},
+		{sniper.BackendYdotool, "shift", false, "ydotool key 42:0"},
+		{sniper.BackendYdotool, "rcmd", true, "ydotool key 126:1"},
+	}
+	for _, tt := range tests {
+		argv, err := sniper.NewCommandBackend(tt.tool).KeyStateArgv(tt.key, tt.down)
+		if err != nil {
+			t.Errorf("%s %q: %v", tt.tool, tt.key, err)
+			continue
+		}
+		if got := strings.Join(argv, " "); got != tt.want {
+			t.Errorf("%s %q down=%v = %s, want %s", tt.tool, tt.key, tt.down, got, tt.want)
+		}
+	}
+}
+
+func TestTypeArgv(t *testing.T) {
+	// Text is passed through untouched as a single argument
+	for _, text := range []string{
+		"hello world",
+		"--help",
+		"-n 3",
+		`it's "quoted"`,
+		"a; rm -rf $HOME `id` | cat",
+		"tab\tand\nnewline",
+		"café 🎉",
+	} {
+		for _, tool := range []string{sniper.BackendXdotool, sniper.BackendYdotool} {
+			want := []string{tool, "type", "--", text}
+			if got := sniper.NewCommandBackend(tool).TypeArgv(text); !slices.Equal(got, want) {
+				t.Errorf("%s %q: argv %q, want %q", tool, text, got, want)
+			}
+		}
+	}
+}
+
+// commandLog is a CommandBackend.Run that records each argv instead of running it.
+type commandLog struct{ argv []string }
+
+func (c *commandLog) run(argv []string) ([]byte, error) {
+	c.argv = append(c.argv, strings.Join(argv, " "))
+	return nil, nil
+}
+
+func TestCommandBackendMouse(t *testing.T) {
+	tests := []struct {
+		tool string
+		want []string
+	}{
+		{sniper.BackendXdotool, []string{
+			"xdotool mousemove 10 20",
+			"xdotool click 3",
+			"xdotool mouseup 1",
+			"xdotool click 4", "xdotool click 4", "xdotool click 7",
+		}},
+		{sniper.BackendYdotool, []string{
+			"ydotool mousemove --absolute -x 10 -y 20",
+			"ydotool click 0xC1",
+			"ydotool click 0x80",
+			"ydotool mousemove --wheel -x 1 -y 2",
+		}},
+	}
+	for _, tt := range tests {
+		log := &commandLog{}
+		b := sniper.NewCommandBackend(tt.tool)
+		b.Run = log.run
+
+		b.Move(10, 20)
+		b.Click("right")
+		b.MouseUp("left")
+		b.Scroll(-1, 2)
+		if !slices.Equal(log.argv, tt.want) {
+			t.Errorf("%s ran %q\nwant %q", tt.tool, log.argv, tt.want)
+		}
+		if err := b.Click("thumb"); err == nil {
+			t.Errorf("%s clicked an unknown button", tt.tool)
+		}
+	}
+}
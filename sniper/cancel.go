@@ -0,0 +1,111 @@
+package sniper
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrCancelled is returned by Execute when the phrase was cancelled part way
+	// through, by POST /api/cancel or by the caller's context.
+	ErrCancelled = errors.New("phrase cancelled")
+
+	// ErrExecutionTimeout is returned by Execute when a phrase runs past the
+	// MaxExecutionMs option.
+	ErrExecutionTimeout = errors.New("phrase exceeded the maximum execution time")
+)
+
+// ExecuteContext is Execute with a context. Cancelling ctx (or calling Cancel)
+// stops the phrase at the next command, repetition or key tap, releases any
+// held modifiers and mouse buttons, and returns an error wrapping ErrCancelled.
+// A phrase that outlives the MaxExecutionMs option fails with ErrExecutionTimeout.
+func (e *Engine) ExecuteContext(ctx context.Context) (ExecutionResult, error) {
+	var cancel context.CancelFunc
+	if limit := e.Options().MaxExecutionMs; limit > 0 {
+		ctx, cancel = context.WithTimeoutCause(ctx, time.Duration(limit)*time.Millisecond, ErrExecutionTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	e.execMu.Lock()
+	e.execCtx, e.execCancel = ctx, cancel
+	e.execMu.Unlock()
+	defer func() {
+		e.execMu.Lock()
+		e.execCtx, e.execCancel = nil, nil
+		e.execMu.Unlock()
+	}()
+
+	result, err := e.execute(ctx)
+	if !result.Deduplicated && (err != nil || len(result.Tokens) > 0) {
+		e.sendFeedback(result, err)
+		e.publishPhrase(result, err)
+	}
+	return result, err
+}
+
+// Cancel aborts the phrase that is currently executing. It reports whether
+// there was one to cancel.
+func (e *Engine) Cancel() bool {
+	e.execMu.Lock()
+	defer e.execMu.Unlock()
+	if e.execCancel == nil {
+		return false
+	}
+	e.execCancel()
+	return true
+}
+
+// Context returns the context of the phrase being executed, or
+// context.Background() outside of Execute.
+func (e *Engine) Context() context.Context {
+	e.execMu.Lock()
+	defer e.execMu.Unlock()
+	if e.execCtx == nil {
+		return context.Background()
+	}
+	return e.execCtx
+}
+
+// checkCancelled returns ErrCancelled (or ErrExecutionTimeout) once the running
+// phrase's context is done. Long-running loops call it between iterations.
+func (e *Engine) checkCancelled() error {
+	return cancelErr(e.Context())
+}
+
+// cancelErr maps a done context to ErrExecutionTimeout or ErrCancelled.
+func cancelErr(ctx context.Context) error {
+	if ctx.Err() == nil {
+		return nil
+	}
+	if errors.Is(context.Cause(ctx), ErrExecutionTimeout) {
+		return ErrExecutionTimeout
+	}
+	return ErrCancelled
+}
+
+// EmergencyRelease lets go of every key and button a cancelled, failed or
+// crashed phrase may have left down, including modifiers held by "hold".
+func (e *Engine) EmergencyRelease() {
+	e.StickyKeyboard.ReleaseAll()
+	e.Mouse.ReleaseButtons()
+}
+
+// watchModifiers releases modifiers left queued or held for longer than the
+// StuckModifierMs option, until the engine is closed.
+func (e *Engine) watchModifiers() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if ms := e.Options().StuckModifierMs; ms > 0 {
+				e.StickyKeyboard.ReleaseStale(time.Duration(ms) * time.Millisecond)
+			}
+		case <-e.done:
+			return
+		}
+	}
+}
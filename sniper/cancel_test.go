@@ -0,0 +1,205 @@
+package sniper_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phillip-england/sniper/sniper"
+	"github.com/phillip-england/sniper/sniper/snipertest"
+)
+
+// runIn parses phrase in the given mode ("phrase" or "rapid"), executes it
+// and returns the keys it tapped.
+func runIn(t *testing.T, e *snipertest.Engine, mode, phrase string) []string {
+	t.Helper()
+	e.Input.Reset()
+	e.Parse(phrase, mode)
+	if _, err := e.Execute(); err != nil {
+		t.Fatalf("%s %q: %v", mode, phrase, err)
+	}
+	return e.Input.Keys()
+}
+
+func TestCancelCommand(t *testing.T) {
+	tests := []struct {
+		mode, phrase string
+		want         []string
+	}{
+		{"phrase", "south south cancel", nil},
+		{"phrase", "cancel", nil},
+		{"phrase", "south cancel north", []string{"down"}},
+		{"phrase", "south 2 cancel east then west", []string{"down", "down"}},
+		{"phrase", "south cancel north cancel", nil},
+
+		// Rapid mode runs a message's last word, so only a final "cancel" counts
+		{"rapid", "south south cancel", nil},
+		{"rapid", "cancel", nil},
+		{"rapid", "south 3 cancel", nil},
+		{"rapid", "cancel south", []string{"down"}},
+	}
+	for _, tt := range tests {
+		e := snipertest.NewTestEngine(t)
+		if got := runIn(t, e, tt.mode, tt.phrase); !slices.Equal(got, tt.want) {
+			t.Errorf("%s %q tapped %q, want %q", tt.mode, tt.phrase, got, tt.want)
+		}
+	}
+}
+
+func TestCancelledPhraseIsNotRepeated(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	snipertest.ExpectKeys(t, e, "south", "down")
+	snipertest.ExpectKeys(t, e, "east cancel")
+
+	// A lone number repeats the last phrase that ran, not the cancelled one
+	snipertest.ExpectKeys(t, e, "2", "down", "down")
+}
+
+func TestStopBrakesRapidRepetition(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	opts := e.Options()
+	opts.TokenDelayUs = 20_000 // 50 repetitions take a second
+	if err := e.SetOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, word := range []string{"stop", "freeze"} {
+		e.Input.Reset()
+		start := time.Now()
+		job, err := e.Enqueue("west 200", "rapid")
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(50 * time.Millisecond)
+		stop, err := e.Enqueue(word, "rapid")
+		if err != nil {
+			t.Fatal(err)
+		}
+		job.Wait()
+		stop.Wait()
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("%q took %v to stop the repetition", word, elapsed)
+		}
+		if n := len(e.Input.Keys()); n >= sniper.MaxRepeatCount {
+			t.Errorf("%q didn't stop the repetition: %d keys", word, n)
+		}
+
+		// The stopped phrase can't be resumed by a stray number
+		if keys := runIn(t, e, "rapid", "3"); len(keys) > 0 {
+			t.Errorf("a number after %q resumed %q", word, keys)
+		}
+	}
+
+	// In a phrase it drops the phrase like cancel
+	if keys := runIn(t, e, "phrase", "west west stop"); len(keys) > 0 {
+		t.Errorf("phrase ending in stop ran %q", keys)
+	}
+}
+
+func TestHaltStopsThePhraseForwardOnly(t *testing.T) {
+	const (
+		handled = sniper.OutcomeHandled
+		halted  = sniper.OutcomeHalted
+	)
+	tests := []struct {
+		phrase   string
+		keys     []string
+		outcomes []sniper.TokenOutcome
+	}{
+		{"halt west east", nil, []sniper.TokenOutcome{handled, halted, halted}},
+		{"west halt east", []string{"left"}, []sniper.TokenOutcome{handled, handled, halted}},
+		{"west east halt", []string{"left", "right"}, []sniper.TokenOutcome{handled, handled, handled}},
+		{"west halt then east", []string{"left"}, []sniper.TokenOutcome{handled, handled, halted, halted}},
+	}
+	e := snipertest.NewTestEngine(t)
+	for _, tt := range tests {
+		result := e.MustRun(t, tt.phrase)
+		if got := e.Input.Keys(); !slices.Equal(got, tt.keys) {
+			t.Errorf("%q tapped %q, want %q", tt.phrase, got, tt.keys)
+		}
+		if got := outcomes(result); !slices.Equal(got, tt.outcomes) {
+			t.Errorf("%q outcomes = %q, want %q", tt.phrase, got, tt.outcomes)
+		}
+	}
+
+	// Unlike a final cancel, a final halt keeps what already ran
+	if keys := runIn(t, e, "phrase", "west east cancel"); len(keys) > 0 {
+		t.Errorf("west east cancel ran %q", keys)
+	}
+}
+
+// slowInput is a driver that takes its time over every tap and piece of text.
+type slowInput struct {
+	*snipertest.Input
+	delay time.Duration
+}
+
+func (in slowInput) KeyTap(key string, modifiers ...string) error {
+	time.Sleep(in.delay)
+	return in.Input.KeyTap(key, modifiers...)
+}
+
+func (in slowInput) TypeStr(text string) error {
+	time.Sleep(in.delay)
+	return in.Input.TypeStr(text)
+}
+
+// longPhrase is a phrase that types words over many chunks and taps.
+func longPhrase() (phrase, text string) {
+	words := make([]string, 200)
+	for i := range words {
+		words[i] = fmt.Sprintf("word%d", i)
+	}
+	text = strings.Join(words, " ")
+	return "phrase " + text, text
+}
+
+func TestTimeoutStopsLongText(t *testing.T) {
+	phrase, text := longPhrase()
+	for _, compat := range []bool{false, true} {
+		e := snipertest.NewTestEngine(t)
+		e.StickyKeyboard.SetBackend(slowInput{e.Input, 5 * time.Millisecond})
+		opts := e.Options()
+		opts.MaxExecutionMs = 50
+		opts.CompatTyping = compat
+		if err := e.SetOptions(opts); err != nil {
+			t.Fatal(err)
+		}
+
+		start := time.Now()
+		if _, err := e.Run(phrase); !errors.Is(err, sniper.ErrExecutionTimeout) {
+			t.Fatalf("compat=%v: err = %v, want ErrExecutionTimeout", compat, err)
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("compat=%v: the timeout took %v to stop the text", compat, elapsed)
+		}
+		typed := e.Input.Typed() + strings.Join(e.Input.Keys(), "")
+		if len(typed) == 0 || len(typed) >= len(text) || !strings.HasPrefix(text, typed) {
+			t.Errorf("compat=%v: typed %d of %d characters, want a prefix of the text", compat, len(typed), len(text))
+		}
+	}
+}
+
+func TestCancelStopsLongText(t *testing.T) {
+	phrase, text := longPhrase()
+	e := snipertest.NewTestEngine(t)
+	e.StickyKeyboard.SetBackend(slowInput{e.Input, 5 * time.Millisecond})
+
+	job, err := e.Enqueue(phrase, "phrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for e.Input.Typed() == "" {
+		time.Sleep(time.Millisecond)
+	}
+	e.Cancel()
+	if _, err := job.Wait(); !errors.Is(err, sniper.ErrCancelled) {
+		t.Fatalf("err = %v, want ErrCancelled", err)
+	}
+	if typed := e.Input.Typed(); len(typed) >= len(text) || !strings.HasPrefix(text, typed) {
+		t.Errorf("typed %d of %d characters, want a prefix of the text", len(typed), len(text))
+	}
+}
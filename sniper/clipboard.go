@@ -0,0 +1,193 @@
+package sniper
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-vgo/robotgo"
+)
+
+// MaxClipboardRead caps how many bytes of clipboard text are handed back to callers.
+const MaxClipboardRead = 64 * 1024
+
+// Defaults for the engine's ClipboardRing.
+const (
+	DefaultClipboardHistorySize = 10
+	DefaultClipboardEntryMaxLen = 8 * 1024
+)
+
+// ErrClipboardNotText is returned when the clipboard holds something that isn't text
+// (an image, a file list, or bytes that aren't valid UTF-8).
+var ErrClipboardNotText = errors.New("clipboard does not contain text")
+
+// Clipboard is the small abstraction the Engine uses to talk to the system clipboard.
+// Swapping it out lets the engine run without touching the real clipboard.
+type Clipboard interface {
+	ReadText() (string, error)
+	WriteText(text string) error
+}
+
+// SystemClipboard reads and writes the OS clipboard through robotgo.
+type SystemClipboard struct{}
+
+func NewSystemClipboard() *SystemClipboard {
+	return &SystemClipboard{}
+}
+
+// ReadText returns the clipboard contents, or ErrClipboardNotText for non-text data.
+func (c *SystemClipboard) ReadText() (string, error) {
+	text, err := robotgo.ReadAll()
+	if err != nil {
+		return "", err
+	}
+	if !utf8.ValidString(text) {
+		return "", ErrClipboardNotText
+	}
+	return text, nil
+}
+
+// WriteText replaces the clipboard contents with text.
+func (c *SystemClipboard) WriteText(text string) error {
+	return robotgo.WriteAll(text)
+}
+
+// MemoryClipboard is an in-process Clipboard, useful for tests and headless setups.
+type MemoryClipboard struct {
+	text string
+	mu   sync.Mutex
+}
+
+func NewMemoryClipboard() *MemoryClipboard {
+	return &MemoryClipboard{}
+}
+
+func (c *MemoryClipboard) ReadText() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.text, nil
+}
+
+func (c *MemoryClipboard) WriteText(text string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.text = text
+	return nil
+}
+
+// TruncateClipboardText trims text to at most max bytes without splitting a rune.
+// The bool reports whether anything was cut off.
+func TruncateClipboardText(text string, max int) (string, bool) {
+	if len(text) <= max {
+		return text, false
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut], true
+}
+
+// ClipboardEntry is one snapshot held by the ClipboardRing.
+type ClipboardEntry struct {
+	Text     string    `json:"text"`
+	CopiedAt time.Time `json:"copied_at"`
+}
+
+// ClipboardRing keeps the last few clipboard snapshots so older copies can still be pasted.
+type ClipboardRing struct {
+	entries []ClipboardEntry // newest first
+	size    int
+	maxLen  int
+	mu      sync.RWMutex
+}
+
+// NewClipboardRing creates a ring holding at most size entries, each capped at maxLen bytes.
+func NewClipboardRing(size int, maxLen int) *ClipboardRing {
+	if size < 1 {
+		size = 1
+	}
+	return &ClipboardRing{
+		entries: make([]ClipboardEntry, 0, size),
+		size:    size,
+		maxLen:  maxLen,
+	}
+}
+
+// Push records a new snapshot. Empty text and exact repeats of the newest entry are ignored.
+func (r *ClipboardRing) Push(text string) {
+	if text == "" {
+		return
+	}
+	text, _ = TruncateClipboardText(text, r.maxLen)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.entries) > 0 && r.entries[0].Text == text {
+		return
+	}
+
+	entry := ClipboardEntry{Text: text, CopiedAt: time.Now()}
+	r.entries = append([]ClipboardEntry{entry}, r.entries...)
+	if len(r.entries) > r.size {
+		r.entries = r.entries[:r.size]
+	}
+}
+
+// Nth returns the nth most recent entry (1 = newest).
+func (r *ClipboardRing) Nth(n int) (ClipboardEntry, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if n < 1 || n > len(r.entries) {
+		return ClipboardEntry{}, false
+	}
+	return r.entries[n-1], true
+}
+
+// Entries returns a copy of the ring, newest first.
+func (r *ClipboardRing) Entries() []ClipboardEntry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]ClipboardEntry, len(r.entries))
+	copy(out, r.entries)
+	return out
+}
+
+// ordinalWords maps spoken ordinals to their position in the ClipboardRing.
+var ordinalWords = map[string]int{
+	"first": 1, "second": 2, "third": 3, "fourth": 4, "fifth": 5,
+	"sixth": 6, "seventh": 7, "eighth": 8, "ninth": 9, "tenth": 10,
+}
+
+// PasteFromHistory writes the nth ring entry to the clipboard, pastes it,
+// and then puts the original clipboard contents back.
+func (e *Engine) PasteFromHistory(n int) error {
+	entry, ok := e.ClipboardRing.Nth(n)
+	if !ok {
+		return fmt.Errorf("clipboard history has no entry %d", n)
+	}
+
+	// Remember what was on the clipboard so we can restore it afterwards.
+	original, readErr := e.Clipboard.ReadText()
+
+	if err := e.Clipboard.WriteText(entry.Text); err != nil {
+		return err
+	}
+
+	e.StickyKeyboard.Control()
+	e.StickyKeyboard.V()
+
+	// Give the target app a moment to read the clipboard before we swap it
+	// back. The original goes back even when the phrase is cancelled meanwhile.
+	settleErr := e.settle(time.Duration(e.Options().ClipboardDelayMs) * time.Millisecond)
+
+	if readErr == nil {
+		if err := e.Clipboard.WriteText(original); err != nil {
+			return err
+		}
+	}
+	return settleErr
+}
@@ -0,0 +1,116 @@
+package sniper_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/phillip-england/sniper/sniper"
+	"github.com/phillip-england/sniper/sniper/snipertest"
+)
+
+// clipboardLog records every text written to the clipboard.
+type clipboardLog struct {
+	sniper.Clipboard
+	writes []string
+}
+
+func (c *clipboardLog) WriteText(text string) error {
+	c.writes = append(c.writes, text)
+	return c.Clipboard.WriteText(text)
+}
+
+func TestCopySavesToHistory(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	for _, text := range []string{"one", "two", "three"} {
+		e.Clipboard.WriteText(text)
+		e.MustRun(t, "copy")
+	}
+
+	var got []string
+	for _, entry := range e.ClipboardRing.Entries() {
+		got = append(got, entry.Text)
+	}
+	if want := []string{"three", "two", "one"}; !slices.Equal(got, want) {
+		t.Errorf("clipboard history = %q, want %q", got, want)
+	}
+}
+
+func TestPasteSecondRestoresClipboard(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.ClipboardRing.Push("older")
+	e.ClipboardRing.Push("newer")
+	e.Clipboard.WriteText("current")
+	log := &clipboardLog{Clipboard: e.Clipboard}
+	e.Engine.Clipboard = log
+
+	snipertest.ExpectKeys(t, e, "paste second", primary()+"+v")
+	if want := []string{"older", "current"}; !slices.Equal(log.writes, want) {
+		t.Errorf("clipboard writes = %q, want %q", log.writes, want)
+	}
+
+	if _, err := e.Run("paste fifth"); err == nil {
+		t.Error("pasting a missing history entry succeeded")
+	}
+}
+
+func TestClipboardDelayIsCancellable(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	opts := e.Options()
+	opts.ClipboardDelayMs = 5000
+	opts.MaxExecutionMs = 50
+	if err := e.SetOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	e.ClipboardRing.Push("older")
+	e.ClipboardRing.Push("newer")
+	e.Clipboard.WriteText("current")
+
+	start := time.Now()
+	if _, err := e.Run("paste second"); !errors.Is(err, sniper.ErrExecutionTimeout) {
+		t.Fatalf("err = %v, want ErrExecutionTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("paste waited %v for the clipboard", elapsed)
+	}
+	// The original goes back even though the phrase was cut short
+	if got, _ := e.Clipboard.ReadText(); got != "current" {
+		t.Errorf("clipboard = %q after a cancelled paste, want %q", got, "current")
+	}
+}
+
+func TestClickDelayIsCancellable(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	opts := e.Options()
+	opts.MouseDelayMs = 5000
+	opts.MaxExecutionMs = 50
+	if err := e.SetOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	// "top" clicks to focus, waits mouse_delay_ms, then selects all
+	if _, err := e.Run("top"); !errors.Is(err, sniper.ErrExecutionTimeout) {
+		t.Fatalf("err = %v, want ErrExecutionTimeout", err)
+	}
+	if keys := e.Input.Keys(); len(keys) > 0 {
+		t.Errorf("cancelled click still sent %q", keys)
+	}
+}
+
+func TestClipboardDelayTiming(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	timings := e.Timings()
+	timings.ClipboardDelayMs = 250
+	if err := e.SetTimings(timings); err != nil {
+		t.Fatal(err)
+	}
+	if got := e.Options().ClipboardDelayMs; got != 250 {
+		t.Errorf("clipboard_delay_ms = %d after SetTimings, want 250", got)
+	}
+
+	timings.ClipboardDelayMs = -1
+	if err := e.SetTimings(timings); err == nil {
+		t.Error("SetTimings accepted a negative clipboard_delay_ms")
+	}
+}
@@ -3,10 +3,10 @@ package sniper
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
-
-	"github.com/go-vgo/robotgo"
+	"unicode"
 )
 
 // Cmd represents a voice command within the system.
@@ -18,7 +18,7 @@ type Cmd interface {
 	CalledBy() []string
 
 	// Effects returns a list of middleware to run for this command.
-	Effects() []EffectFunc
+	Effects() []NamedEffect
 
 	// Action contains the actual business logic to perform.
 	Action(e *Engine, phrase string) error
@@ -30,9 +30,9 @@ type Cmd interface {
 
 type Shift struct{}
 
-func (Shift) Name() string          { return "shift" }
-func (Shift) CalledBy() []string    { return []string{"shift"} }
-func (Shift) Effects() []EffectFunc { return nil }
+func (Shift) Name() string           { return "shift" }
+func (Shift) CalledBy() []string     { return []string{"shift"} }
+func (Shift) Effects() []NamedEffect { return nil }
 func (c Shift) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Shift()
@@ -42,9 +42,9 @@ func (c Shift) Action(e *Engine, p string) error {
 
 type Control struct{}
 
-func (Control) Name() string          { return "control" }
-func (Control) CalledBy() []string    { return []string{"control"} }
-func (Control) Effects() []EffectFunc { return nil }
+func (Control) Name() string           { return "control" }
+func (Control) CalledBy() []string     { return []string{"control"} }
+func (Control) Effects() []NamedEffect { return nil }
 func (c Control) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Control()
@@ -54,9 +54,9 @@ func (c Control) Action(e *Engine, p string) error {
 
 type Alt struct{}
 
-func (Alt) Name() string          { return "alt" }
-func (Alt) CalledBy() []string    { return []string{"alt", "command"} }
-func (Alt) Effects() []EffectFunc { return nil }
+func (Alt) Name() string           { return "alt" }
+func (Alt) CalledBy() []string     { return []string{"alt", "command"} }
+func (Alt) Effects() []NamedEffect { return nil }
 func (c Alt) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Alt()
@@ -64,11 +64,25 @@ func (c Alt) Action(e *Engine, p string) error {
 	}, c.Effects()...)
 }
 
+// Super queues the Windows key (Command on macOS) for the next key.
+// e.g. "super dash" -> Win+D
+type Super struct{}
+
+func (Super) Name() string           { return "super" }
+func (Super) CalledBy() []string     { return []string{"super", "win key"} }
+func (Super) Effects() []NamedEffect { return nil }
+func (c Super) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.StickyKeyboard.Super()
+		return nil
+	}, c.Effects()...)
+}
+
 type Command struct{}
 
-func (Command) Name() string          { return "command" }
-func (Command) CalledBy() []string    { return []string{""} }
-func (Command) Effects() []EffectFunc { return nil }
+func (Command) Name() string           { return "command" }
+func (Command) CalledBy() []string     { return []string{""} }
+func (Command) Effects() []NamedEffect { return nil }
 func (c Command) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Command()
@@ -76,15 +90,184 @@ func (c Command) Action(e *Engine, p string) error {
 	}, c.Effects()...)
 }
 
+// Hold presses the next modifier and keeps it down until "release", so it
+// applies to every following key: "hold shift east east east release".
+// Any other key is held for a duration instead; see HoldKey.
+type Hold struct{}
+
+func (Hold) Name() string       { return "hold" }
+func (Hold) CalledBy() []string { return []string{"hold"} }
+func (Hold) Description() string {
+	return "Holds the next modifier (shift, control, alt, option, command) down until release, or any other key for a few seconds"
+}
+func (Hold) ArgCount() int          { return 1 }
+func (Hold) Effects() []NamedEffect { return nil }
+func (c Hold) Action(e *Engine, p string) error {
+	return c.ActionWithArgs(e, e.ConsumeNext(c.ArgCount()))
+}
+func (c Hold) ActionWithArgs(e *Engine, args []Token) error {
+	return EffectChain(e, func() error {
+		if len(args) == 0 {
+			return nil
+		}
+		switch name := args[0].Literal(); name {
+		case "shift", "control", "ctrl", "alt", "option", "command", "cmd":
+			e.StickyKeyboard.HoldModifier(name)
+			return nil
+		}
+		// Any other key is held for a while instead: "hold left two seconds"
+		return HoldKey{}.hold(e, args[0])
+	}, c.Effects()...)
+}
+
+// HoldKey holds a key down for a number of seconds, for games and editors
+// that need a key held rather than tapped: "hold key west three seconds".
+// "hold" does the same for anything that isn't a modifier. The key is given
+// like any other command ("alpha", "space", "west") or by its name ("left").
+type HoldKey struct{}
+
+const (
+	DefaultKeyHold = time.Second
+	MaxKeyHold     = 10 * time.Second
+)
+
+func (HoldKey) Name() string       { return "hold_key" }
+func (HoldKey) CalledBy() []string { return []string{"hold key"} }
+func (HoldKey) Description() string {
+	return "Holds the next key down for the number of seconds after it"
+}
+func (HoldKey) ArgCount() int          { return 1 }
+func (HoldKey) Effects() []NamedEffect { return nil }
+func (c HoldKey) Action(e *Engine, p string) error {
+	return c.ActionWithArgs(e, e.ConsumeNext(c.ArgCount()))
+}
+func (c HoldKey) ActionWithArgs(e *Engine, args []Token) error {
+	return EffectChain(e, func() error {
+		if len(args) == 0 {
+			return nil
+		}
+		return c.hold(e, args[0])
+	}, c.Effects()...)
+}
+
+// hold presses the key arg names for the duration that follows it (seconds,
+// or milliseconds with "ms"/"millis"), capped at MaxKeyHold. The key is
+// released however the wait ends.
+func (HoldKey) hold(e *Engine, arg Token) error {
+	key, ok := keyForToken(arg)
+	if !ok {
+		return fmt.Errorf("'%s' is not a key that can be held", arg.Original())
+	}
+
+	d := DefaultKeyHold
+	if num, ok := e.PeekNext().(*NumberToken); ok {
+		e.ConsumeNext(1)
+		unit := time.Second
+		if next := e.PeekNext(); next != nil {
+			switch next.Literal() {
+			case "ms", "millis", "milliseconds":
+				unit = time.Millisecond
+				e.ConsumeNext(1)
+			case "second", "seconds", "sec", "secs":
+				e.ConsumeNext(1)
+			}
+		}
+		d = time.Duration(num.Value()) * unit
+	}
+	d = min(d, MaxKeyHold)
+
+	e.StickyKeyboard.HoldKey(key)
+	defer e.StickyKeyboard.ReleaseKey(key)
+	if !e.Sleep(d) {
+		return e.checkCancelled()
+	}
+	return nil
+}
+
+// commandKeys maps commands that tap a single key to that key, so "hold
+// west" holds the key "west" taps. Letters are their own command names.
+var commandKeys = map[string]string{
+	"north": "up", "south": "down", "east": "right", "west": "left",
+	"enter": "enter", "tab": "tab", "space": "space", "back": "backspace",
+	"delete": "delete", "escape": "escape", "home": "home", "end": "end",
+	"page_up": "pageup", "page_down": "pagedown",
+}
+
+// keyNames are key names that can be spoken directly: "hold left".
+var keyNames = map[string]bool{
+	"left": true, "right": true, "up": true, "down": true, "space": true,
+	"enter": true, "tab": true, "escape": true, "backspace": true, "delete": true,
+	"shift": true, "control": true, "ctrl": true, "alt": true,
+}
+
+// keyForToken returns the key a token names, if any.
+func keyForToken(t Token) (string, bool) {
+	if ct, ok := t.(*CmdToken); ok {
+		name := ct.Command().Name()
+		if key, ok := commandKeys[name]; ok {
+			return key, true
+		}
+		if len(name) == 1 && name[0] >= 'a' && name[0] <= 'z' {
+			return name, true
+		}
+	}
+	word := strings.ToLower(t.Original())
+	if keyNames[word] {
+		return word, true
+	}
+	if len(word) == 1 && (unicode.IsLetter(rune(word[0])) || unicode.IsDigit(rune(word[0]))) {
+		return word, true
+	}
+	if len(word) > 1 && word[0] == 'f' {
+		if n, err := strconv.Atoi(word[1:]); err == nil && n >= 1 && n <= 12 {
+			return word, true
+		}
+	}
+	return "", false
+}
+
+// Release lets go of every modifier pressed by "hold".
+type Release struct{}
+
+func (Release) Name() string           { return "release" }
+func (Release) CalledBy() []string     { return []string{"release"} }
+func (Release) Description() string    { return "Releases every held modifier" }
+func (Release) Effects() []NamedEffect { return nil }
+func (c Release) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.StickyKeyboard.ReleaseHeld()
+		return nil
+	}, c.Effects()...)
+}
+
+// ReleaseEverything lets go of every key and mouse button, for when a
+// modifier seems stuck and every letter has turned into a shortcut.
+type ReleaseEverything struct{}
+
+func (ReleaseEverything) Name() string { return "release_everything" }
+func (ReleaseEverything) CalledBy() []string {
+	return []string{"release everything", "panic release"}
+}
+func (ReleaseEverything) Description() string {
+	return "Releases every modifier and mouse button"
+}
+func (ReleaseEverything) Effects() []NamedEffect { return nil }
+func (c ReleaseEverything) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.EmergencyRelease()
+		return nil
+	}, c.Effects()...)
+}
+
 // ----------------------------------------------------------------------------
 // NAVIGATION (ARROWS mapped to Cardinals)
 // ----------------------------------------------------------------------------
 
 type North struct{} // Up
 
-func (North) Name() string          { return "north" }
-func (North) CalledBy() []string    { return []string{"north"} }
-func (North) Effects() []EffectFunc { return nil }
+func (North) Name() string           { return "north" }
+func (North) CalledBy() []string     { return []string{"north"} }
+func (North) Effects() []NamedEffect { return nil }
 func (c North) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Up()
@@ -94,9 +277,9 @@ func (c North) Action(e *Engine, p string) error {
 
 type South struct{} // Down
 
-func (South) Name() string          { return "south" }
-func (South) CalledBy() []string    { return []string{"south"} }
-func (South) Effects() []EffectFunc { return nil }
+func (South) Name() string           { return "south" }
+func (South) CalledBy() []string     { return []string{"south"} }
+func (South) Effects() []NamedEffect { return nil }
 func (c South) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Down()
@@ -106,9 +289,9 @@ func (c South) Action(e *Engine, p string) error {
 
 type East struct{} // Right
 
-func (East) Name() string          { return "east" }
-func (East) CalledBy() []string    { return []string{"east"} }
-func (East) Effects() []EffectFunc { return nil }
+func (East) Name() string           { return "east" }
+func (East) CalledBy() []string     { return []string{"east"} }
+func (East) Effects() []NamedEffect { return nil }
 func (c East) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Right()
@@ -118,9 +301,9 @@ func (c East) Action(e *Engine, p string) error {
 
 type West struct{} // Left
 
-func (West) Name() string          { return "west" }
-func (West) CalledBy() []string    { return []string{"west"} }
-func (West) Effects() []EffectFunc { return nil }
+func (West) Name() string           { return "west" }
+func (West) CalledBy() []string     { return []string{"west"} }
+func (West) Effects() []NamedEffect { return nil }
 func (c West) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Left()
@@ -134,9 +317,9 @@ func (c West) Action(e *Engine, p string) error {
 
 type Enter struct{}
 
-func (Enter) Name() string          { return "enter" }
-func (Enter) CalledBy() []string    { return []string{"enter", "slap"} }
-func (Enter) Effects() []EffectFunc { return nil }
+func (Enter) Name() string           { return "enter" }
+func (Enter) CalledBy() []string     { return []string{"enter", "slap"} }
+func (Enter) Effects() []NamedEffect { return nil }
 func (c Enter) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Enter()
@@ -146,9 +329,9 @@ func (c Enter) Action(e *Engine, p string) error {
 
 type Tab struct{}
 
-func (Tab) Name() string          { return "tab" }
-func (Tab) CalledBy() []string    { return []string{"tab"} }
-func (Tab) Effects() []EffectFunc { return nil }
+func (Tab) Name() string           { return "tab" }
+func (Tab) CalledBy() []string     { return []string{"tab"} }
+func (Tab) Effects() []NamedEffect { return nil }
 func (c Tab) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Tab()
@@ -158,9 +341,9 @@ func (c Tab) Action(e *Engine, p string) error {
 
 type Space struct{}
 
-func (Space) Name() string          { return "space" }
-func (Space) CalledBy() []string    { return []string{"space", "next"} }
-func (Space) Effects() []EffectFunc { return nil }
+func (Space) Name() string           { return "space" }
+func (Space) CalledBy() []string     { return []string{"space", "next"} }
+func (Space) Effects() []NamedEffect { return nil }
 func (c Space) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Space()
@@ -170,9 +353,9 @@ func (c Space) Action(e *Engine, p string) error {
 
 type Back struct{} // Backspace
 
-func (Back) Name() string          { return "back" }
-func (Back) CalledBy() []string    { return []string{"back"} }
-func (Back) Effects() []EffectFunc { return nil }
+func (Back) Name() string           { return "back" }
+func (Back) CalledBy() []string     { return []string{"back"} }
+func (Back) Effects() []NamedEffect { return nil }
 func (c Back) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Backspace()
@@ -182,9 +365,9 @@ func (c Back) Action(e *Engine, p string) error {
 
 type Delete struct{}
 
-func (Delete) Name() string          { return "delete" }
-func (Delete) CalledBy() []string    { return []string{"delete"} }
-func (Delete) Effects() []EffectFunc { return nil }
+func (Delete) Name() string           { return "delete" }
+func (Delete) CalledBy() []string     { return []string{"delete"} }
+func (Delete) Effects() []NamedEffect { return nil }
 func (c Delete) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Delete()
@@ -194,9 +377,9 @@ func (c Delete) Action(e *Engine, p string) error {
 
 type Escape struct{}
 
-func (Escape) Name() string          { return "escape" }
-func (Escape) CalledBy() []string    { return []string{"escape"} }
-func (Escape) Effects() []EffectFunc { return nil }
+func (Escape) Name() string           { return "escape" }
+func (Escape) CalledBy() []string     { return []string{"escape"} }
+func (Escape) Effects() []NamedEffect { return nil }
 func (c Escape) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Escape()
@@ -206,9 +389,9 @@ func (c Escape) Action(e *Engine, p string) error {
 
 type Home struct{}
 
-func (Home) Name() string          { return "home" }
-func (Home) CalledBy() []string    { return []string{"home"} }
-func (Home) Effects() []EffectFunc { return nil }
+func (Home) Name() string           { return "home" }
+func (Home) CalledBy() []string     { return []string{"home"} }
+func (Home) Effects() []NamedEffect { return nil }
 func (c Home) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Home()
@@ -218,9 +401,9 @@ func (c Home) Action(e *Engine, p string) error {
 
 type End struct{}
 
-func (End) Name() string          { return "end" }
-func (End) CalledBy() []string    { return []string{"end"} }
-func (End) Effects() []EffectFunc { return nil }
+func (End) Name() string           { return "end" }
+func (End) CalledBy() []string     { return []string{"end"} }
+func (End) Effects() []NamedEffect { return nil }
 func (c End) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.End()
@@ -230,9 +413,9 @@ func (c End) Action(e *Engine, p string) error {
 
 type PageUp struct{}
 
-func (PageUp) Name() string          { return "page_up" }
-func (PageUp) CalledBy() []string    { return []string{"climb"} }
-func (PageUp) Effects() []EffectFunc { return nil }
+func (PageUp) Name() string           { return "page_up" }
+func (PageUp) CalledBy() []string     { return []string{"climb", "ascend"} }
+func (PageUp) Effects() []NamedEffect { return nil }
 func (c PageUp) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.PageUp()
@@ -242,9 +425,9 @@ func (c PageUp) Action(e *Engine, p string) error {
 
 type PageDown struct{}
 
-func (PageDown) Name() string          { return "page_down" }
-func (PageDown) CalledBy() []string    { return []string{"drop"} }
-func (PageDown) Effects() []EffectFunc { return nil }
+func (PageDown) Name() string           { return "page_down" }
+func (PageDown) CalledBy() []string     { return []string{"drop", "descend"} }
+func (PageDown) Effects() []NamedEffect { return nil }
 func (c PageDown) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.PageDown()
@@ -260,9 +443,9 @@ func (c PageDown) Action(e *Engine, p string) error {
 
 type Dot struct{} // .
 
-func (Dot) Name() string          { return "." }
-func (Dot) CalledBy() []string    { return []string{"dot", "period"} }
-func (Dot) Effects() []EffectFunc { return nil }
+func (Dot) Name() string           { return "." }
+func (Dot) CalledBy() []string     { return []string{"dot", "period"} }
+func (Dot) Effects() []NamedEffect { return nil }
 func (c Dot) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Period()
@@ -272,9 +455,9 @@ func (c Dot) Action(e *Engine, p string) error {
 
 type Comma struct{} // ,
 
-func (Comma) Name() string          { return "," }
-func (Comma) CalledBy() []string    { return []string{"comma"} }
-func (Comma) Effects() []EffectFunc { return nil }
+func (Comma) Name() string           { return "," }
+func (Comma) CalledBy() []string     { return []string{"comma"} }
+func (Comma) Effects() []NamedEffect { return nil }
 func (c Comma) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Comma()
@@ -284,9 +467,9 @@ func (c Comma) Action(e *Engine, p string) error {
 
 type Semi struct{} // ;
 
-func (Semi) Name() string          { return ";" }
-func (Semi) CalledBy() []string    { return []string{"semi"} }
-func (Semi) Effects() []EffectFunc { return nil }
+func (Semi) Name() string           { return ";" }
+func (Semi) CalledBy() []string     { return []string{"semi"} }
+func (Semi) Effects() []NamedEffect { return nil }
 func (c Semi) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Semicolon()
@@ -296,9 +479,9 @@ func (c Semi) Action(e *Engine, p string) error {
 
 type Colon struct{} // :
 
-func (Colon) Name() string          { return ":" }
-func (Colon) CalledBy() []string    { return []string{"colon"} }
-func (Colon) Effects() []EffectFunc { return nil }
+func (Colon) Name() string           { return ":" }
+func (Colon) CalledBy() []string     { return []string{"colon"} }
+func (Colon) Effects() []NamedEffect { return nil }
 func (c Colon) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Colon()
@@ -310,9 +493,9 @@ func (c Colon) Action(e *Engine, p string) error {
 
 type Quote struct{} // '
 
-func (Quote) Name() string          { return "'" }
-func (Quote) CalledBy() []string    { return []string{"single", "quote"} }
-func (Quote) Effects() []EffectFunc { return nil }
+func (Quote) Name() string           { return "'" }
+func (Quote) CalledBy() []string     { return []string{"single", "quote"} }
+func (Quote) Effects() []NamedEffect { return nil }
 func (c Quote) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Quote()
@@ -322,9 +505,9 @@ func (c Quote) Action(e *Engine, p string) error {
 
 type DoubleQuote struct{} // "
 
-func (DoubleQuote) Name() string          { return "\"" }
-func (DoubleQuote) CalledBy() []string    { return []string{"double", "speech"} }
-func (DoubleQuote) Effects() []EffectFunc { return nil }
+func (DoubleQuote) Name() string           { return "\"" }
+func (DoubleQuote) CalledBy() []string     { return []string{"double", "speech"} }
+func (DoubleQuote) Effects() []NamedEffect { return nil }
 func (c DoubleQuote) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.DoubleQuote()
@@ -334,9 +517,9 @@ func (c DoubleQuote) Action(e *Engine, p string) error {
 
 type Tick struct{} // `
 
-func (Tick) Name() string          { return "`" }
-func (Tick) CalledBy() []string    { return []string{"tick", "backtick"} }
-func (Tick) Effects() []EffectFunc { return nil }
+func (Tick) Name() string           { return "`" }
+func (Tick) CalledBy() []string     { return []string{"tick", "backtick"} }
+func (Tick) Effects() []NamedEffect { return nil }
 func (c Tick) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Backtick()
@@ -348,9 +531,9 @@ func (c Tick) Action(e *Engine, p string) error {
 
 type Slash struct{} // /
 
-func (Slash) Name() string          { return "/" }
-func (Slash) CalledBy() []string    { return []string{"slash"} }
-func (Slash) Effects() []EffectFunc { return nil }
+func (Slash) Name() string           { return "/" }
+func (Slash) CalledBy() []string     { return []string{"slash"} }
+func (Slash) Effects() []NamedEffect { return nil }
 func (c Slash) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Slash()
@@ -360,9 +543,9 @@ func (c Slash) Action(e *Engine, p string) error {
 
 type Backslash struct{} // \
 
-func (Backslash) Name() string          { return "\\" }
-func (Backslash) CalledBy() []string    { return []string{"backslash"} }
-func (Backslash) Effects() []EffectFunc { return nil }
+func (Backslash) Name() string           { return "\\" }
+func (Backslash) CalledBy() []string     { return []string{"backslash"} }
+func (Backslash) Effects() []NamedEffect { return nil }
 func (c Backslash) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Backslash()
@@ -372,9 +555,9 @@ func (c Backslash) Action(e *Engine, p string) error {
 
 type Pipe struct{} // |
 
-func (Pipe) Name() string          { return "|" }
-func (Pipe) CalledBy() []string    { return []string{"pipe"} }
-func (Pipe) Effects() []EffectFunc { return nil }
+func (Pipe) Name() string           { return "|" }
+func (Pipe) CalledBy() []string     { return []string{"pipe"} }
+func (Pipe) Effects() []NamedEffect { return nil }
 func (c Pipe) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Pipe()
@@ -386,9 +569,9 @@ func (c Pipe) Action(e *Engine, p string) error {
 
 type Paren struct{} // (
 
-func (Paren) Name() string          { return "(" }
-func (Paren) CalledBy() []string    { return []string{"open"} }
-func (Paren) Effects() []EffectFunc { return nil }
+func (Paren) Name() string           { return "(" }
+func (Paren) CalledBy() []string     { return []string{"open"} }
+func (Paren) Effects() []NamedEffect { return nil }
 func (c Paren) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.ParenLeft()
@@ -398,9 +581,9 @@ func (c Paren) Action(e *Engine, p string) error {
 
 type CloseParen struct{} // )
 
-func (CloseParen) Name() string          { return ")" }
-func (CloseParen) CalledBy() []string    { return []string{"close"} }
-func (CloseParen) Effects() []EffectFunc { return nil }
+func (CloseParen) Name() string           { return ")" }
+func (CloseParen) CalledBy() []string     { return []string{"close"} }
+func (CloseParen) Effects() []NamedEffect { return nil }
 func (c CloseParen) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.ParenRight()
@@ -410,9 +593,9 @@ func (c CloseParen) Action(e *Engine, p string) error {
 
 type Bracket struct{} // [
 
-func (Bracket) Name() string          { return "[" }
-func (Bracket) CalledBy() []string    { return []string{"bracket", "square"} }
-func (Bracket) Effects() []EffectFunc { return nil }
+func (Bracket) Name() string           { return "[" }
+func (Bracket) CalledBy() []string     { return []string{"bracket", "square"} }
+func (Bracket) Effects() []NamedEffect { return nil }
 func (c Bracket) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.BracketLeft()
@@ -422,9 +605,9 @@ func (c Bracket) Action(e *Engine, p string) error {
 
 type Closing struct{} // ]
 
-func (Closing) Name() string          { return "]" }
-func (Closing) CalledBy() []string    { return []string{"closing", "close bracket"} }
-func (Closing) Effects() []EffectFunc { return nil }
+func (Closing) Name() string           { return "]" }
+func (Closing) CalledBy() []string     { return []string{"closing", "close bracket"} }
+func (Closing) Effects() []NamedEffect { return nil }
 func (c Closing) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.BracketRight()
@@ -434,9 +617,9 @@ func (c Closing) Action(e *Engine, p string) error {
 
 type Brace struct{} // {
 
-func (Brace) Name() string          { return "{" }
-func (Brace) CalledBy() []string    { return []string{"curly", "brace"} }
-func (Brace) Effects() []EffectFunc { return nil }
+func (Brace) Name() string           { return "{" }
+func (Brace) CalledBy() []string     { return []string{"curly", "brace"} }
+func (Brace) Effects() []NamedEffect { return nil }
 func (c Brace) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.BraceLeft()
@@ -446,9 +629,9 @@ func (c Brace) Action(e *Engine, p string) error {
 
 type CloseBrace struct{} // }
 
-func (CloseBrace) Name() string          { return "}" }
-func (CloseBrace) CalledBy() []string    { return []string{"close curly", "end brace"} }
-func (CloseBrace) Effects() []EffectFunc { return nil }
+func (CloseBrace) Name() string           { return "}" }
+func (CloseBrace) CalledBy() []string     { return []string{"close curly", "end brace"} }
+func (CloseBrace) Effects() []NamedEffect { return nil }
 func (c CloseBrace) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.BraceRight()
@@ -458,9 +641,9 @@ func (c CloseBrace) Action(e *Engine, p string) error {
 
 type Angle struct{} // <
 
-func (Angle) Name() string          { return "<" }
-func (Angle) CalledBy() []string    { return []string{"less", "angle"} }
-func (Angle) Effects() []EffectFunc { return nil }
+func (Angle) Name() string           { return "<" }
+func (Angle) CalledBy() []string     { return []string{"less", "angle"} }
+func (Angle) Effects() []NamedEffect { return nil }
 func (c Angle) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.LessThan()
@@ -470,9 +653,9 @@ func (c Angle) Action(e *Engine, p string) error {
 
 type CloseAngle struct{} // >
 
-func (CloseAngle) Name() string          { return ">" }
-func (CloseAngle) CalledBy() []string    { return []string{"greater", "close angle"} }
-func (CloseAngle) Effects() []EffectFunc { return nil }
+func (CloseAngle) Name() string           { return ">" }
+func (CloseAngle) CalledBy() []string     { return []string{"greater", "close angle"} }
+func (CloseAngle) Effects() []NamedEffect { return nil }
 func (c CloseAngle) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.GreaterThan()
@@ -484,9 +667,9 @@ func (c CloseAngle) Action(e *Engine, p string) error {
 
 type Dash struct{} // -
 
-func (Dash) Name() string          { return "-" }
-func (Dash) CalledBy() []string    { return []string{"dash", "minus"} }
-func (Dash) Effects() []EffectFunc { return nil }
+func (Dash) Name() string           { return "-" }
+func (Dash) CalledBy() []string     { return []string{"dash", "minus"} }
+func (Dash) Effects() []NamedEffect { return nil }
 func (c Dash) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Minus()
@@ -496,9 +679,9 @@ func (c Dash) Action(e *Engine, p string) error {
 
 type Underscore struct{} // _
 
-func (Underscore) Name() string          { return "_" }
-func (Underscore) CalledBy() []string    { return []string{"under", "underscore"} }
-func (Underscore) Effects() []EffectFunc { return nil }
+func (Underscore) Name() string           { return "_" }
+func (Underscore) CalledBy() []string     { return []string{"under", "underscore"} }
+func (Underscore) Effects() []NamedEffect { return nil }
 func (c Underscore) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Underscore()
@@ -508,9 +691,9 @@ func (c Underscore) Action(e *Engine, p string) error {
 
 type Equals struct{} // =
 
-func (Equals) Name() string          { return "=" }
-func (Equals) CalledBy() []string    { return []string{"equals", "assign"} }
-func (Equals) Effects() []EffectFunc { return nil }
+func (Equals) Name() string           { return "=" }
+func (Equals) CalledBy() []string     { return []string{"equals", "assign"} }
+func (Equals) Effects() []NamedEffect { return nil }
 func (c Equals) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Equal()
@@ -520,9 +703,9 @@ func (c Equals) Action(e *Engine, p string) error {
 
 type Plus struct{} // +
 
-func (Plus) Name() string          { return "+" }
-func (Plus) CalledBy() []string    { return []string{"plus", "add"} }
-func (Plus) Effects() []EffectFunc { return nil }
+func (Plus) Name() string           { return "+" }
+func (Plus) CalledBy() []string     { return []string{"plus", "add"} }
+func (Plus) Effects() []NamedEffect { return nil }
 func (c Plus) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Plus()
@@ -532,9 +715,9 @@ func (c Plus) Action(e *Engine, p string) error {
 
 type Star struct{} // *
 
-func (Star) Name() string          { return "*" }
-func (Star) CalledBy() []string    { return []string{"star", "times"} }
-func (Star) Effects() []EffectFunc { return nil }
+func (Star) Name() string           { return "*" }
+func (Star) CalledBy() []string     { return []string{"star", "times"} }
+func (Star) Effects() []NamedEffect { return nil }
 func (c Star) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Asterisk()
@@ -544,9 +727,9 @@ func (c Star) Action(e *Engine, p string) error {
 
 type Percent struct{} // %
 
-func (Percent) Name() string          { return "%" }
-func (Percent) CalledBy() []string    { return []string{"percent", "mod"} }
-func (Percent) Effects() []EffectFunc { return nil }
+func (Percent) Name() string           { return "%" }
+func (Percent) CalledBy() []string     { return []string{"percent", "mod"} }
+func (Percent) Effects() []NamedEffect { return nil }
 func (c Percent) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Percent()
@@ -558,9 +741,9 @@ func (c Percent) Action(e *Engine, p string) error {
 
 type Bang struct{} // !
 
-func (Bang) Name() string          { return "!" }
-func (Bang) CalledBy() []string    { return []string{"bang", "not"} }
-func (Bang) Effects() []EffectFunc { return nil }
+func (Bang) Name() string           { return "!" }
+func (Bang) CalledBy() []string     { return []string{"bang", "not"} }
+func (Bang) Effects() []NamedEffect { return nil }
 func (c Bang) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Exclamation()
@@ -570,9 +753,9 @@ func (c Bang) Action(e *Engine, p string) error {
 
 type At struct{} // @
 
-func (At) Name() string          { return "@" }
-func (At) CalledBy() []string    { return []string{"at", "email"} }
-func (At) Effects() []EffectFunc { return nil }
+func (At) Name() string           { return "@" }
+func (At) CalledBy() []string     { return []string{"at", "email"} }
+func (At) Effects() []NamedEffect { return nil }
 func (c At) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.At()
@@ -582,9 +765,9 @@ func (c At) Action(e *Engine, p string) error {
 
 type Hash struct{} // #
 
-func (Hash) Name() string          { return "#" }
-func (Hash) CalledBy() []string    { return []string{"hash", "pound"} }
-func (Hash) Effects() []EffectFunc { return nil }
+func (Hash) Name() string           { return "#" }
+func (Hash) CalledBy() []string     { return []string{"hash", "pound"} }
+func (Hash) Effects() []NamedEffect { return nil }
 func (c Hash) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Hash()
@@ -594,9 +777,9 @@ func (c Hash) Action(e *Engine, p string) error {
 
 type Dollar struct{} // $
 
-func (Dollar) Name() string          { return "$" }
-func (Dollar) CalledBy() []string    { return []string{"dollar", "cash"} }
-func (Dollar) Effects() []EffectFunc { return nil }
+func (Dollar) Name() string           { return "$" }
+func (Dollar) CalledBy() []string     { return []string{"dollar", "cash"} }
+func (Dollar) Effects() []NamedEffect { return nil }
 func (c Dollar) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Dollar()
@@ -606,9 +789,9 @@ func (c Dollar) Action(e *Engine, p string) error {
 
 type Hat struct{} // ^
 
-func (Hat) Name() string          { return "^" }
-func (Hat) CalledBy() []string    { return []string{"hat", "carat"} }
-func (Hat) Effects() []EffectFunc { return nil }
+func (Hat) Name() string           { return "^" }
+func (Hat) CalledBy() []string     { return []string{"hat", "carat"} }
+func (Hat) Effects() []NamedEffect { return nil }
 func (c Hat) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Carat()
@@ -618,9 +801,9 @@ func (c Hat) Action(e *Engine, p string) error {
 
 type Ampersand struct{} // &
 
-func (Ampersand) Name() string          { return "&" }
-func (Ampersand) CalledBy() []string    { return []string{"amp", "and"} }
-func (Ampersand) Effects() []EffectFunc { return nil }
+func (Ampersand) Name() string           { return "&" }
+func (Ampersand) CalledBy() []string     { return []string{"amp", "and"} }
+func (Ampersand) Effects() []NamedEffect { return nil }
 func (c Ampersand) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Ampersand()
@@ -630,9 +813,9 @@ func (c Ampersand) Action(e *Engine, p string) error {
 
 type Question struct{} // ?
 
-func (Question) Name() string          { return "?" }
-func (Question) CalledBy() []string    { return []string{"question"} }
-func (Question) Effects() []EffectFunc { return nil }
+func (Question) Name() string           { return "?" }
+func (Question) CalledBy() []string     { return []string{"question"} }
+func (Question) Effects() []NamedEffect { return nil }
 func (c Question) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Question()
@@ -642,9 +825,9 @@ func (c Question) Action(e *Engine, p string) error {
 
 type Tilde struct{} // ~
 
-func (Tilde) Name() string          { return "~" }
-func (Tilde) CalledBy() []string    { return []string{"tilde", "wave"} }
-func (Tilde) Effects() []EffectFunc { return nil }
+func (Tilde) Name() string           { return "~" }
+func (Tilde) CalledBy() []string     { return []string{"tilde", "wave"} }
+func (Tilde) Effects() []NamedEffect { return nil }
 func (c Tilde) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Tilde()
@@ -658,9 +841,9 @@ func (c Tilde) Action(e *Engine, p string) error {
 
 type A struct{}
 
-func (A) Name() string          { return "a" }
-func (A) CalledBy() []string    { return []string{"alpha"} }
-func (A) Effects() []EffectFunc { return nil }
+func (A) Name() string           { return "a" }
+func (A) CalledBy() []string     { return []string{"alpha"} }
+func (A) Effects() []NamedEffect { return nil }
 func (c A) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.A()
@@ -670,9 +853,9 @@ func (c A) Action(e *Engine, p string) error {
 
 type B struct{}
 
-func (B) Name() string          { return "b" }
-func (B) CalledBy() []string    { return []string{"bravo"} }
-func (B) Effects() []EffectFunc { return nil }
+func (B) Name() string           { return "b" }
+func (B) CalledBy() []string     { return []string{"bravo"} }
+func (B) Effects() []NamedEffect { return nil }
 func (c B) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.B()
@@ -682,9 +865,9 @@ func (c B) Action(e *Engine, p string) error {
 
 type C struct{}
 
-func (C) Name() string          { return "c" }
-func (C) CalledBy() []string    { return []string{"charlie"} }
-func (C) Effects() []EffectFunc { return nil }
+func (C) Name() string           { return "c" }
+func (C) CalledBy() []string     { return []string{"charlie"} }
+func (C) Effects() []NamedEffect { return nil }
 func (c C) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.C()
@@ -694,9 +877,9 @@ func (c C) Action(e *Engine, p string) error {
 
 type D struct{}
 
-func (D) Name() string          { return "d" }
-func (D) CalledBy() []string    { return []string{"delta"} }
-func (D) Effects() []EffectFunc { return nil }
+func (D) Name() string           { return "d" }
+func (D) CalledBy() []string     { return []string{"delta"} }
+func (D) Effects() []NamedEffect { return nil }
 func (c D) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.D()
@@ -706,9 +889,9 @@ func (c D) Action(e *Engine, p string) error {
 
 type E struct{}
 
-func (E) Name() string          { return "e" }
-func (E) CalledBy() []string    { return []string{"echo"} }
-func (E) Effects() []EffectFunc { return nil }
+func (E) Name() string           { return "e" }
+func (E) CalledBy() []string     { return []string{"echo"} }
+func (E) Effects() []NamedEffect { return nil }
 func (c E) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.E()
@@ -718,9 +901,9 @@ func (c E) Action(e *Engine, p string) error {
 
 type F struct{}
 
-func (F) Name() string          { return "f" }
-func (F) CalledBy() []string    { return []string{"foxtrot"} }
-func (F) Effects() []EffectFunc { return nil }
+func (F) Name() string           { return "f" }
+func (F) CalledBy() []string     { return []string{"foxtrot"} }
+func (F) Effects() []NamedEffect { return nil }
 func (c F) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.F()
@@ -730,9 +913,9 @@ func (c F) Action(e *Engine, p string) error {
 
 type G struct{}
 
-func (G) Name() string          { return "g" }
-func (G) CalledBy() []string    { return []string{"golf"} }
-func (G) Effects() []EffectFunc { return nil }
+func (G) Name() string           { return "g" }
+func (G) CalledBy() []string     { return []string{"golf"} }
+func (G) Effects() []NamedEffect { return nil }
 func (c G) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.G()
@@ -742,9 +925,9 @@ func (c G) Action(e *Engine, p string) error {
 
 type H struct{}
 
-func (H) Name() string          { return "h" }
-func (H) CalledBy() []string    { return []string{"hotel"} }
-func (H) Effects() []EffectFunc { return nil }
+func (H) Name() string           { return "h" }
+func (H) CalledBy() []string     { return []string{"hotel"} }
+func (H) Effects() []NamedEffect { return nil }
 func (c H) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.H()
@@ -754,9 +937,9 @@ func (c H) Action(e *Engine, p string) error {
 
 type I struct{}
 
-func (I) Name() string          { return "i" }
-func (I) CalledBy() []string    { return []string{"india"} }
-func (I) Effects() []EffectFunc { return nil }
+func (I) Name() string           { return "i" }
+func (I) CalledBy() []string     { return []string{"india"} }
+func (I) Effects() []NamedEffect { return nil }
 func (c I) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.I()
@@ -766,9 +949,9 @@ func (c I) Action(e *Engine, p string) error {
 
 type J struct{}
 
-func (J) Name() string          { return "j" }
-func (J) CalledBy() []string    { return []string{"juliet"} }
-func (J) Effects() []EffectFunc { return nil }
+func (J) Name() string           { return "j" }
+func (J) CalledBy() []string     { return []string{"juliet"} }
+func (J) Effects() []NamedEffect { return nil }
 func (c J) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.J()
@@ -778,9 +961,9 @@ func (c J) Action(e *Engine, p string) error {
 
 type K struct{}
 
-func (K) Name() string          { return "k" }
-func (K) CalledBy() []string    { return []string{"kilo"} }
-func (K) Effects() []EffectFunc { return nil }
+func (K) Name() string           { return "k" }
+func (K) CalledBy() []string     { return []string{"kilo"} }
+func (K) Effects() []NamedEffect { return nil }
 func (c K) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.K()
@@ -790,9 +973,9 @@ func (c K) Action(e *Engine, p string) error {
 
 type L struct{}
 
-func (L) Name() string          { return "l" }
-func (L) CalledBy() []string    { return []string{"lima"} }
-func (L) Effects() []EffectFunc { return nil }
+func (L) Name() string           { return "l" }
+func (L) CalledBy() []string     { return []string{"lima"} }
+func (L) Effects() []NamedEffect { return nil }
 func (c L) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.L()
@@ -802,9 +985,9 @@ func (c L) Action(e *Engine, p string) error {
 
 type M struct{}
 
-func (M) Name() string          { return "m" }
-func (M) CalledBy() []string    { return []string{"mike"} }
-func (M) Effects() []EffectFunc { return nil }
+func (M) Name() string           { return "m" }
+func (M) CalledBy() []string     { return []string{"mike"} }
+func (M) Effects() []NamedEffect { return nil }
 func (c M) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.M()
@@ -814,9 +997,9 @@ func (c M) Action(e *Engine, p string) error {
 
 type N struct{}
 
-func (N) Name() string          { return "n" }
-func (N) CalledBy() []string    { return []string{"november"} }
-func (N) Effects() []EffectFunc { return nil }
+func (N) Name() string           { return "n" }
+func (N) CalledBy() []string     { return []string{"november"} }
+func (N) Effects() []NamedEffect { return nil }
 func (c N) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.N()
@@ -826,9 +1009,9 @@ func (c N) Action(e *Engine, p string) error {
 
 type O struct{}
 
-func (O) Name() string          { return "o" }
-func (O) CalledBy() []string    { return []string{"oscar"} }
-func (O) Effects() []EffectFunc { return nil }
+func (O) Name() string           { return "o" }
+func (O) CalledBy() []string     { return []string{"oscar"} }
+func (O) Effects() []NamedEffect { return nil }
 func (c O) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.O()
@@ -838,9 +1021,9 @@ func (c O) Action(e *Engine, p string) error {
 
 type P struct{}
 
-func (P) Name() string          { return "p" }
-func (P) CalledBy() []string    { return []string{"papa"} }
-func (P) Effects() []EffectFunc { return nil }
+func (P) Name() string           { return "p" }
+func (P) CalledBy() []string     { return []string{"papa"} }
+func (P) Effects() []NamedEffect { return nil }
 func (c P) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.P()
@@ -850,9 +1033,9 @@ func (c P) Action(e *Engine, p string) error {
 
 type Q struct{}
 
-func (Q) Name() string          { return "q" }
-func (Q) CalledBy() []string    { return []string{"quebec"} }
-func (Q) Effects() []EffectFunc { return nil }
+func (Q) Name() string           { return "q" }
+func (Q) CalledBy() []string     { return []string{"quebec"} }
+func (Q) Effects() []NamedEffect { return nil }
 func (c Q) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Q()
@@ -862,9 +1045,9 @@ func (c Q) Action(e *Engine, p string) error {
 
 type R struct{}
 
-func (R) Name() string          { return "r" }
-func (R) CalledBy() []string    { return []string{"romeo"} }
-func (R) Effects() []EffectFunc { return nil }
+func (R) Name() string           { return "r" }
+func (R) CalledBy() []string     { return []string{"romeo"} }
+func (R) Effects() []NamedEffect { return nil }
 func (c R) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.R()
@@ -874,9 +1057,9 @@ func (c R) Action(e *Engine, p string) error {
 
 type S struct{}
 
-func (S) Name() string          { return "s" }
-func (S) CalledBy() []string    { return []string{"sierra"} }
-func (S) Effects() []EffectFunc { return nil }
+func (S) Name() string           { return "s" }
+func (S) CalledBy() []string     { return []string{"sierra"} }
+func (S) Effects() []NamedEffect { return nil }
 func (c S) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.S()
@@ -886,9 +1069,9 @@ func (c S) Action(e *Engine, p string) error {
 
 type T struct{}
 
-func (T) Name() string          { return "t" }
-func (T) CalledBy() []string    { return []string{"tango"} }
-func (T) Effects() []EffectFunc { return nil }
+func (T) Name() string           { return "t" }
+func (T) CalledBy() []string     { return []string{"tango"} }
+func (T) Effects() []NamedEffect { return nil }
 func (c T) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.T()
@@ -898,9 +1081,9 @@ func (c T) Action(e *Engine, p string) error {
 
 type U struct{}
 
-func (U) Name() string          { return "u" }
-func (U) CalledBy() []string    { return []string{"uniform"} }
-func (U) Effects() []EffectFunc { return nil }
+func (U) Name() string           { return "u" }
+func (U) CalledBy() []string     { return []string{"uniform"} }
+func (U) Effects() []NamedEffect { return nil }
 func (c U) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.U()
@@ -910,9 +1093,9 @@ func (c U) Action(e *Engine, p string) error {
 
 type V struct{}
 
-func (V) Name() string          { return "v" }
-func (V) CalledBy() []string    { return []string{"victor"} }
-func (V) Effects() []EffectFunc { return nil }
+func (V) Name() string           { return "v" }
+func (V) CalledBy() []string     { return []string{"victor"} }
+func (V) Effects() []NamedEffect { return nil }
 func (c V) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.V()
@@ -922,9 +1105,9 @@ func (c V) Action(e *Engine, p string) error {
 
 type W struct{}
 
-func (W) Name() string          { return "w" }
-func (W) CalledBy() []string    { return []string{"whiskey"} }
-func (W) Effects() []EffectFunc { return nil }
+func (W) Name() string           { return "w" }
+func (W) CalledBy() []string     { return []string{"whiskey"} }
+func (W) Effects() []NamedEffect { return nil }
 func (c W) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.W()
@@ -934,9 +1117,9 @@ func (c W) Action(e *Engine, p string) error {
 
 type X struct{}
 
-func (X) Name() string          { return "x" }
-func (X) CalledBy() []string    { return []string{"xray"} }
-func (X) Effects() []EffectFunc { return nil }
+func (X) Name() string           { return "x" }
+func (X) CalledBy() []string     { return []string{"xray"} }
+func (X) Effects() []NamedEffect { return nil }
 func (c X) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.X()
@@ -946,9 +1129,9 @@ func (c X) Action(e *Engine, p string) error {
 
 type Y struct{}
 
-func (Y) Name() string          { return "y" }
-func (Y) CalledBy() []string    { return []string{"yankee"} }
-func (Y) Effects() []EffectFunc { return nil }
+func (Y) Name() string           { return "y" }
+func (Y) CalledBy() []string     { return []string{"yankee"} }
+func (Y) Effects() []NamedEffect { return nil }
 func (c Y) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Y()
@@ -958,9 +1141,9 @@ func (c Y) Action(e *Engine, p string) error {
 
 type Z struct{}
 
-func (Z) Name() string          { return "z" }
-func (Z) CalledBy() []string    { return []string{"zulu"} }
-func (Z) Effects() []EffectFunc { return nil }
+func (Z) Name() string           { return "z" }
+func (Z) CalledBy() []string     { return []string{"zulu"} }
+func (Z) Effects() []NamedEffect { return nil }
 func (c Z) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Z()
@@ -974,9 +1157,9 @@ func (c Z) Action(e *Engine, p string) error {
 
 type FOne struct{}
 
-func (FOne) Name() string          { return "f1" }
-func (FOne) CalledBy() []string    { return []string{"f1"} }
-func (FOne) Effects() []EffectFunc { return nil }
+func (FOne) Name() string           { return "f1" }
+func (FOne) CalledBy() []string     { return []string{"f1"} }
+func (FOne) Effects() []NamedEffect { return nil }
 func (c FOne) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.F1()
@@ -986,9 +1169,9 @@ func (c FOne) Action(e *Engine, p string) error {
 
 type FTwo struct{}
 
-func (FTwo) Name() string          { return "f2" }
-func (FTwo) CalledBy() []string    { return []string{"f2"} }
-func (FTwo) Effects() []EffectFunc { return nil }
+func (FTwo) Name() string           { return "f2" }
+func (FTwo) CalledBy() []string     { return []string{"f2"} }
+func (FTwo) Effects() []NamedEffect { return nil }
 func (c FTwo) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.F2()
@@ -998,9 +1181,9 @@ func (c FTwo) Action(e *Engine, p string) error {
 
 type FThree struct{}
 
-func (FThree) Name() string          { return "f3" }
-func (FThree) CalledBy() []string    { return []string{"f3"} }
-func (FThree) Effects() []EffectFunc { return nil }
+func (FThree) Name() string           { return "f3" }
+func (FThree) CalledBy() []string     { return []string{"f3"} }
+func (FThree) Effects() []NamedEffect { return nil }
 func (c FThree) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.F3()
@@ -1010,9 +1193,9 @@ func (c FThree) Action(e *Engine, p string) error {
 
 type FFour struct{}
 
-func (FFour) Name() string          { return "f4" }
-func (FFour) CalledBy() []string    { return []string{"f4"} }
-func (FFour) Effects() []EffectFunc { return nil }
+func (FFour) Name() string           { return "f4" }
+func (FFour) CalledBy() []string     { return []string{"f4"} }
+func (FFour) Effects() []NamedEffect { return nil }
 func (c FFour) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.F4()
@@ -1022,9 +1205,9 @@ func (c FFour) Action(e *Engine, p string) error {
 
 type FFive struct{}
 
-func (FFive) Name() string          { return "f5" }
-func (FFive) CalledBy() []string    { return []string{"f5"} }
-func (FFive) Effects() []EffectFunc { return nil }
+func (FFive) Name() string           { return "f5" }
+func (FFive) CalledBy() []string     { return []string{"f5"} }
+func (FFive) Effects() []NamedEffect { return nil }
 func (c FFive) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.F5()
@@ -1034,9 +1217,9 @@ func (c FFive) Action(e *Engine, p string) error {
 
 type FSix struct{}
 
-func (FSix) Name() string          { return "f6" }
-func (FSix) CalledBy() []string    { return []string{"f6"} }
-func (FSix) Effects() []EffectFunc { return nil }
+func (FSix) Name() string           { return "f6" }
+func (FSix) CalledBy() []string     { return []string{"f6"} }
+func (FSix) Effects() []NamedEffect { return nil }
 func (c FSix) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.F6()
@@ -1046,9 +1229,9 @@ func (c FSix) Action(e *Engine, p string) error {
 
 type FSeven struct{}
 
-func (FSeven) Name() string          { return "f7" }
-func (FSeven) CalledBy() []string    { return []string{"f7"} }
-func (FSeven) Effects() []EffectFunc { return nil }
+func (FSeven) Name() string           { return "f7" }
+func (FSeven) CalledBy() []string     { return []string{"f7"} }
+func (FSeven) Effects() []NamedEffect { return nil }
 func (c FSeven) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.F7()
@@ -1058,9 +1241,9 @@ func (c FSeven) Action(e *Engine, p string) error {
 
 type FEight struct{}
 
-func (FEight) Name() string          { return "f8" }
-func (FEight) CalledBy() []string    { return []string{"f8"} }
-func (FEight) Effects() []EffectFunc { return nil }
+func (FEight) Name() string           { return "f8" }
+func (FEight) CalledBy() []string     { return []string{"f8"} }
+func (FEight) Effects() []NamedEffect { return nil }
 func (c FEight) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.F8()
@@ -1070,9 +1253,9 @@ func (c FEight) Action(e *Engine, p string) error {
 
 type FNine struct{}
 
-func (FNine) Name() string          { return "f9" }
-func (FNine) CalledBy() []string    { return []string{"f9"} }
-func (FNine) Effects() []EffectFunc { return nil }
+func (FNine) Name() string           { return "f9" }
+func (FNine) CalledBy() []string     { return []string{"f9"} }
+func (FNine) Effects() []NamedEffect { return nil }
 func (c FNine) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.F9()
@@ -1082,9 +1265,9 @@ func (c FNine) Action(e *Engine, p string) error {
 
 type FTen struct{}
 
-func (FTen) Name() string          { return "f10" }
-func (FTen) CalledBy() []string    { return []string{"f10"} }
-func (FTen) Effects() []EffectFunc { return nil }
+func (FTen) Name() string           { return "f10" }
+func (FTen) CalledBy() []string     { return []string{"f10"} }
+func (FTen) Effects() []NamedEffect { return nil }
 func (c FTen) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.F10()
@@ -1094,9 +1277,9 @@ func (c FTen) Action(e *Engine, p string) error {
 
 type FEleven struct{}
 
-func (FEleven) Name() string          { return "f11" }
-func (FEleven) CalledBy() []string    { return []string{"f11"} }
-func (FEleven) Effects() []EffectFunc { return nil }
+func (FEleven) Name() string           { return "f11" }
+func (FEleven) CalledBy() []string     { return []string{"f11"} }
+func (FEleven) Effects() []NamedEffect { return nil }
 func (c FEleven) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.F11()
@@ -1106,9 +1289,9 @@ func (c FEleven) Action(e *Engine, p string) error {
 
 type FTwelve struct{}
 
-func (FTwelve) Name() string          { return "f12" }
-func (FTwelve) CalledBy() []string    { return []string{"f12"} }
-func (FTwelve) Effects() []EffectFunc { return nil }
+func (FTwelve) Name() string           { return "f12" }
+func (FTwelve) CalledBy() []string     { return []string{"f12"} }
+func (FTwelve) Effects() []NamedEffect { return nil }
 func (c FTwelve) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.F12()
@@ -1122,9 +1305,9 @@ func (c FTwelve) Action(e *Engine, p string) error {
 
 type Click struct{}
 
-func (c Click) Name() string        { return "click" }
-func (c Click) CalledBy() []string  { return []string{"click"} }
-func (Click) Effects() []EffectFunc { return []EffectFunc{WaitAfter(50)} }
+func (c Click) Name() string         { return "click" }
+func (c Click) CalledBy() []string   { return []string{"click"} }
+func (Click) Effects() []NamedEffect { return []NamedEffect{WaitAfter(50)} }
 func (c Click) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.Mouse.Click()
@@ -1132,12 +1315,92 @@ func (c Click) Action(e *Engine, p string) error {
 	}, c.Effects()...)
 }
 
+// ClickText clicks the middle of the words after it, found on screen by the
+// engine's OCRProvider. e.g. "point submit", "click on save as"
+type ClickText struct{}
+
+func (ClickText) Name() string       { return "click_text" }
+func (ClickText) CalledBy() []string { return []string{"point", "click on"} }
+func (ClickText) Description() string {
+	return "Finds the rest of the phrase on screen and clicks it"
+}
+func (ClickText) ConsumesArgs() bool     { return true }
+func (ClickText) Effects() []NamedEffect { return []NamedEffect{KillAfter()} }
+func (c ClickText) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		box, err := e.FindText(e.State.RemainingRawWords)
+		if err != nil {
+			return err
+		}
+		e.Mouse.MoveTo(box.Center())
+		e.Mouse.Click()
+		return nil
+	}, c.Effects()...)
+}
+
+// FindIcon clicks the middle of the icon template registered under the next
+// word, found on screen. e.g. "icon save"
+type FindIcon struct{}
+
+func (FindIcon) Name() string       { return "find_icon" }
+func (FindIcon) CalledBy() []string { return []string{"icon"} }
+func (FindIcon) Description() string {
+	return "Finds the icon registered under the next word on screen and clicks it"
+}
+func (FindIcon) ArgCount() int          { return 1 }
+func (FindIcon) Effects() []NamedEffect { return nil }
+func (c FindIcon) Action(e *Engine, p string) error {
+	return c.ActionWithArgs(e, e.ConsumeNext(c.ArgCount()))
+}
+func (c FindIcon) ActionWithArgs(e *Engine, args []Token) error {
+	return EffectChain(e, func() error {
+		if len(args) == 0 {
+			return nil // "icon" with nothing after it
+		}
+		bounds, err := e.FindIcon(args[0].Literal())
+		if err != nil {
+			return err
+		}
+		center := bounds.Min.Add(bounds.Size().Div(2))
+		e.Mouse.MoveTo(center.X, center.Y)
+		e.Mouse.Click()
+		return nil
+	}, c.Effects()...)
+}
+
+// Color reads the colour of the pixel under the mouse into the phrase's
+// result. "color say" also types it.
+// e.g. "color"     -> result outputs {"command":"pixel_color","value":"#1e1e2e"}
+// e.g. "color say" -> types "#1e1e2e"
+type Color struct{}
+
+func (Color) Name() string       { return "pixel_color" }
+func (Color) CalledBy() []string { return []string{"color"} }
+func (Color) Description() string {
+	return "Reads the colour under the mouse; \"color say\" also types it"
+}
+func (Color) Effects() []NamedEffect { return nil }
+func (c Color) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.Mouse.SyncPosition()
+		px := e.PixelColor(e.Mouse.X, e.Mouse.Y)
+		e.State.Outputs = append(e.State.Outputs, CommandOutput{Command: c.Name(), Value: px.Hex})
+		e.log().Info("read pixel color", "x", px.X, "y", px.Y, "hex", px.Hex)
+
+		if next := e.PeekNext(); next != nil && next.Literal() == "say" {
+			e.ConsumeNext(1)
+			return e.StickyKeyboard.TypeStr(px.Hex)
+		}
+		return nil
+	}, c.Effects()...)
+}
+
 // Left represents a command to move the mouse left.
 type Left struct{}
 
-func (Left) Name() string          { return "mouse_left" }
-func (Left) CalledBy() []string    { return []string{"left"} }
-func (Left) Effects() []EffectFunc { return nil }
+func (Left) Name() string           { return "mouse_left" }
+func (Left) CalledBy() []string     { return []string{"left"} }
+func (Left) Effects() []NamedEffect { return nil }
 func (Left) Action(e *Engine, phrase string) error {
 	return EffectChain(e, func() error {
 		e.Mouse.MoveLeft()
@@ -1148,9 +1411,9 @@ func (Left) Action(e *Engine, phrase string) error {
 // Right represents a command to move the mouse right.
 type Right struct{}
 
-func (Right) Name() string          { return "mouse_right" }
-func (Right) CalledBy() []string    { return []string{"right", "write"} }
-func (Right) Effects() []EffectFunc { return nil }
+func (Right) Name() string           { return "mouse_right" }
+func (Right) CalledBy() []string     { return []string{"right"} }
+func (Right) Effects() []NamedEffect { return nil }
 func (Right) Action(e *Engine, phrase string) error {
 	return EffectChain(e, func() error {
 		e.Mouse.MoveRight()
@@ -1161,9 +1424,9 @@ func (Right) Action(e *Engine, phrase string) error {
 // Up represents a command to move the mouse up.
 type Up struct{}
 
-func (Up) Name() string          { return "mouse_up" }
-func (Up) CalledBy() []string    { return []string{"up"} }
-func (Up) Effects() []EffectFunc { return nil }
+func (Up) Name() string           { return "mouse_up" }
+func (Up) CalledBy() []string     { return []string{"up"} }
+func (Up) Effects() []NamedEffect { return nil }
 func (Up) Action(e *Engine, phrase string) error {
 	return EffectChain(e, func() error {
 		e.Mouse.MoveUp()
@@ -1174,9 +1437,9 @@ func (Up) Action(e *Engine, phrase string) error {
 // Down represents a command to move the mouse down.
 type Down struct{}
 
-func (Down) Name() string          { return "mouse_down" }
-func (Down) CalledBy() []string    { return []string{"down"} }
-func (Down) Effects() []EffectFunc { return nil }
+func (Down) Name() string           { return "mouse_down" }
+func (Down) CalledBy() []string     { return []string{"down"} }
+func (Down) Effects() []NamedEffect { return nil }
 func (Down) Action(e *Engine, phrase string) error {
 	return EffectChain(e, func() error {
 		e.Mouse.MoveDown()
@@ -1188,99 +1451,143 @@ func (Down) Action(e *Engine, phrase string) error {
 // TEXT FORMATTING & SPEECH
 // ----------------------------------------------------------------------------
 
+// RawType spells out the rest of the phrase without spaces: NATO letters,
+// number words and symbol words become their characters (see Engine.Spell).
+// e.g. "type hotel tango tango papa sierra colon slash slash" -> "https://"
 type RawType struct{}
 
-func (RawType) Name() string          { return "raw_type" }
-func (RawType) CalledBy() []string    { return []string{"type"} }
-func (RawType) Effects() []EffectFunc { return []EffectFunc{KillAfter()} }
+func (RawType) Name() string       { return "raw_type" }
+func (RawType) CalledBy() []string { return []string{"type"} }
+func (RawType) Description() string {
+	return "Types the rest of the phrase with no spaces, spelling out letter and symbol words"
+}
+func (RawType) ConsumesArgs() bool     { return true }
+func (RawType) Effects() []NamedEffect { return []NamedEffect{KillAfter()} }
 func (c RawType) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
-		// 1. Get the raw text following the "type" command
-		text := e.State.RemainingRawWords
-
-		// 2. Smash the input together (remove all spaces)
-		// e.g., "type a b c" -> "abc"
-		text = strings.ReplaceAll(text, " ", "")
+		return e.StickyKeyboard.TypeStr(e.Spell(strings.Fields(e.State.RemainingRawWords)))
+	}, c.Effects()...)
+}
 
-		// 3. Type the resulting string literal
-		e.StickyKeyboard.TypeStr(text)
+// Verbatim types the rest of the phrase exactly as spoken, spaces removed.
+// e.g. "verbatim alpha dash bravo" -> "alphadashbravo"
+type Verbatim struct{}
 
-		return nil
+func (Verbatim) Name() string       { return "verbatim" }
+func (Verbatim) CalledBy() []string { return []string{"verbatim"} }
+func (Verbatim) Description() string {
+	return "Types the rest of the phrase exactly as spoken, without spaces"
+}
+func (Verbatim) ConsumesArgs() bool     { return true }
+func (Verbatim) Effects() []NamedEffect { return []NamedEffect{KillAfter()} }
+func (c Verbatim) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		// Smash the input together (remove all spaces)
+		// e.g., "verbatim a b c" -> "abc"
+		return e.StickyKeyboard.TypeStr(strings.ReplaceAll(e.State.RemainingRawWords, " ", ""))
 	}, c.Effects()...)
 }
 
 // CamelCase converts the subsequent phrase into camelCase (e.g., "myVariableName").
 type CamelCase struct{}
 
-func (CamelCase) Name() string          { return "camel_case" }
-func (CamelCase) CalledBy() []string    { return []string{"camel"} }
-func (CamelCase) Effects() []EffectFunc { return []EffectFunc{KillAfter()} }
+func (CamelCase) Name() string           { return "camel_case" }
+func (CamelCase) CalledBy() []string     { return []string{"camel"} }
+func (CamelCase) Description() string    { return "Types the rest of the phrase in camelCase" }
+func (CamelCase) ConsumesArgs() bool     { return true }
+func (CamelCase) Effects() []NamedEffect { return []NamedEffect{KillAfter()} }
 func (c CamelCase) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		// Pass the remaining spoken words to the keyboard's Camel handler
-		e.StickyKeyboard.CamelCase(e.State.RemainingRawWords)
-		return nil
+		return e.StickyKeyboard.CamelCase(e.State.RemainingRawWords)
 	}, c.Effects()...)
 }
 
 // PascalCase converts the subsequent phrase into PascalCase (e.g., "MyVariableName").
 type PascalCase struct{}
 
-func (PascalCase) Name() string          { return "pascal_case" }
-func (PascalCase) CalledBy() []string    { return []string{"pascal"} }
-func (PascalCase) Effects() []EffectFunc { return []EffectFunc{KillAfter()} }
+func (PascalCase) Name() string           { return "pascal_case" }
+func (PascalCase) CalledBy() []string     { return []string{"pascal"} }
+func (PascalCase) Description() string    { return "Types the rest of the phrase in PascalCase" }
+func (PascalCase) ConsumesArgs() bool     { return true }
+func (PascalCase) Effects() []NamedEffect { return []NamedEffect{KillAfter()} }
 func (c PascalCase) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		// Pass the remaining spoken words to the keyboard's Pascal handler
-		e.StickyKeyboard.PascalCase(e.State.RemainingRawWords)
-		return nil
+		return e.StickyKeyboard.PascalCase(e.State.RemainingRawWords)
 	}, c.Effects()...)
 }
 
 // SnakeCase converts the subsequent phrase into snake_case (e.g., "my_variable_name").
 type SnakeCase struct{}
 
-func (SnakeCase) Name() string          { return "snake_case" }
-func (SnakeCase) CalledBy() []string    { return []string{"snake"} }
-func (SnakeCase) Effects() []EffectFunc { return []EffectFunc{KillAfter()} }
+func (SnakeCase) Name() string           { return "snake_case" }
+func (SnakeCase) CalledBy() []string     { return []string{"snake"} }
+func (SnakeCase) Description() string    { return "Types the rest of the phrase in snake_case" }
+func (SnakeCase) ConsumesArgs() bool     { return true }
+func (SnakeCase) Effects() []NamedEffect { return []NamedEffect{KillAfter()} }
 func (c SnakeCase) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		// Pass the remaining spoken words to the keyboard's Snake handler
-		e.StickyKeyboard.SnakeCase(e.State.RemainingRawWords)
-		return nil
+		return e.StickyKeyboard.SnakeCase(e.State.RemainingRawWords)
+	}, c.Effects()...)
+}
+
+// Phrase types the rest of the phrase as spoken, single-spaced, with no
+// capitals or punctuation added, e.g. for search queries.
+// e.g. "phrase golang  slog handler" -> "golang slog handler"
+type Phrase struct{}
+
+func (Phrase) Name() string       { return "phrase" }
+func (Phrase) CalledBy() []string { return []string{"phrase", "plain"} }
+func (Phrase) Description() string {
+	return "Types the rest of the phrase with single spaces and no formatting"
+}
+func (Phrase) ConsumesArgs() bool     { return true }
+func (Phrase) Effects() []NamedEffect { return []NamedEffect{KillAfter()} }
+func (c Phrase) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		return e.StickyKeyboard.TypeStr(strings.Join(strings.Fields(e.State.RemainingRawWords), " "))
 	}, c.Effects()...)
 }
 
-// Say types out the subsequent phrase formatted as a sentence.
+// Say types out the subsequent phrase as dictation. With the FormalDictation
+// option it continues the current sentence, otherwise each phrase is a sentence.
 type Say struct{}
 
-func (Say) Name() string          { return "say" }
-func (Say) CalledBy() []string    { return []string{"say"} }
-func (Say) Effects() []EffectFunc { return []EffectFunc{KillAfter()} }
+func (Say) Name() string           { return "say" }
+func (Say) CalledBy() []string     { return []string{"say"} }
+func (Say) Description() string    { return "Types the rest of the phrase as a sentence" }
+func (Say) ConsumesArgs() bool     { return true }
+func (Say) Effects() []NamedEffect { return []NamedEffect{KillAfter()} }
 func (c Say) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
-		// Pass the remaining spoken words to the keyboard's Sentence handler
-		e.StickyKeyboard.Sentence(e.State.RemainingRawWords)
-		return nil
+		// Pass the remaining spoken words to the keyboard's dictation handlers
+		if e.Options().FormalDictation {
+			return e.StickyKeyboard.Dictate(e.State.RemainingRawWords)
+		}
+		return e.StickyKeyboard.Sentence(e.State.RemainingRawWords)
 	}, c.Effects()...)
 }
 
 type Number struct{}
 
-func (Number) Name() string          { return "number" }
-func (Number) CalledBy() []string    { return []string{"number"} }
-func (Number) Effects() []EffectFunc { return []EffectFunc{KillAfter()} }
+func (Number) Name() string           { return "number" }
+func (Number) CalledBy() []string     { return []string{"number"} }
+func (Number) ArgCount() int          { return 1 }
+func (Number) Effects() []NamedEffect { return nil }
 func (c Number) Action(e *Engine, p string) error {
+	return c.ActionWithArgs(e, e.ConsumeNext(c.ArgCount()))
+}
+func (c Number) ActionWithArgs(e *Engine, args []Token) error {
 	return EffectChain(e, func() error {
-		// 1. Check if there is a next token to look at
-		if len(e.State.RemainingTokens) > 0 {
-			nextToken := e.State.RemainingTokens[0]
-
-			// 2. Check if the next token is a number
-			if nextToken.Type() == TokenTypeNumber {
-				// 3. Manually type out the number literal
-				e.StickyKeyboard.TypeStr(nextToken.Literal())
+		// Type the number literal. The dispatcher already claimed it, so it
+		// doesn't also repeat the last command.
+		if len(args) > 0 && args[0].Type() == TokenTypeNumber {
+			if err := e.StickyKeyboard.TypeStr(args[0].Literal()); err != nil {
+				return err
 			}
+			e.log().Debug("number consumed", "typed", args[0].Literal(), "heard", args[0].Original(), "at", args[0].Index())
 		}
 
 		// If it wasn't a number, or there were no tokens left,
@@ -1289,27 +1596,154 @@ func (c Number) Action(e *Engine, p string) error {
 	}, c.Effects()...)
 }
 
-// Word types the single immediate next word and ignores the rest.
-// e.g. "word git commit" -> types "git" (ignores "commit")
+// Word types the next word, or the next N words when given a count, and
+// ignores the rest.
+// e.g. "word git commit"         -> types "git" (ignores "commit")
+// e.g. "word two git status now" -> types "git status"
 type Word struct{}
 
-func (Word) Name() string          { return "word" }
-func (Word) CalledBy() []string    { return []string{"word"} }
-func (Word) Effects() []EffectFunc { return []EffectFunc{KillAfter()} }
+func (Word) Name() string       { return "word" }
+func (Word) CalledBy() []string { return []string{"word"} }
+func (Word) Description() string {
+	return "Types only the next word, or the next N words (\"word two ...\")"
+}
+func (Word) ArgCount() int          { return 1 }
+func (Word) Effects() []NamedEffect { return []NamedEffect{KillAfter()} }
 func (c Word) Action(e *Engine, p string) error {
+	return c.ActionWithArgs(e, e.ConsumeNext(c.ArgCount()))
+}
+func (c Word) ActionWithArgs(e *Engine, args []Token) error {
+	return EffectChain(e, func() error {
+		// A leading number is the word count; the words come after it
+		count := 1
+		if len(args) > 0 {
+			if num, ok := args[0].(*NumberToken); ok {
+				count = max(num.Value(), 1)
+				args = e.ConsumeNext(1)
+			}
+		}
+
+		// Collect words from the claimed tokens, claiming more until we have enough
+		var words, heard []string
+		first := -1
+		for len(args) > 0 {
+			if first < 0 {
+				first = args[0].Index()
+			}
+			words = append(words, strings.Fields(CasedLiteral(args[0]))...)
+			heard = append(heard, args[0].Original())
+			if len(words) >= count {
+				break
+			}
+			args = e.ConsumeNext(1)
+		}
+		if len(words) > 0 {
+			typed := strings.Join(words[:min(count, len(words))], " ")
+			if err := e.StickyKeyboard.TypeStr(typed); err != nil {
+				return err
+			}
+			e.log().Debug("word consumed", "typed", typed, "heard", strings.Join(heard, " "), "at", first)
+		}
+		return nil
+	}, c.Effects()...)
+}
+
+// Clip copies the subsequent phrase straight to the clipboard without typing it.
+// e.g. "clip hello world" -> clipboard now holds "hello world"
+type Clip struct{}
+
+func (Clip) Name() string       { return "clip" }
+func (Clip) CalledBy() []string { return []string{"clip"} }
+func (Clip) Description() string {
+	return "Copies the rest of the phrase to the clipboard without typing it"
+}
+func (Clip) ConsumesArgs() bool     { return true }
+func (Clip) Effects() []NamedEffect { return []NamedEffect{KillAfter()} }
+func (c Clip) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
-		// 1. Get the text following the "word" command
-		// e.g. "git status"
 		text := e.State.RemainingRawWords
+		if text == "" {
+			return nil
+		}
+		return e.Clipboard.WriteText(text)
+	}, c.Effects()...)
+}
 
-		// 2. Split the text into individual words
-		words := strings.Fields(text)
+// Today types the current date using the configured DateLayout.
+type Today struct{}
 
-		// 3. If there is at least one word, type only the first one
-		if len(words) > 0 {
-			e.StickyKeyboard.TypeStr(words[0])
+func (Today) Name() string           { return "today" }
+func (Today) CalledBy() []string     { return []string{"today"} }
+func (Today) Effects() []NamedEffect { return nil }
+func (c Today) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		return e.StickyKeyboard.Type(e.Now().Format(e.Options().DateLayout))
+	}, c.Effects()...)
+}
+
+// Timestamp types the current date and time using the configured TimestampLayout.
+type Timestamp struct{}
+
+func (Timestamp) Name() string           { return "timestamp" }
+func (Timestamp) CalledBy() []string     { return []string{"timestamp"} }
+func (Timestamp) Effects() []NamedEffect { return nil }
+func (c Timestamp) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		return e.StickyKeyboard.Type(e.Now().Format(e.Options().TimestampLayout))
+	}, c.Effects()...)
+}
+
+// Uuid types a freshly generated v4 UUID.
+// "uuid bare" strips the hyphens for this call only.
+type Uuid struct{}
+
+func (Uuid) Name() string           { return "uuid" }
+func (Uuid) CalledBy() []string     { return []string{"uuid", "new id"} }
+func (Uuid) Description() string    { return "Types a new v4 UUID; \"uuid bare\" leaves out the hyphens" }
+func (Uuid) ConsumesArgs() bool     { return true }
+func (Uuid) Effects() []NamedEffect { return nil }
+func (c Uuid) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		opts := e.Options()
+
+		// 1. Check for the optional "bare" argument
+		bare := opts.UUIDBare
+		if len(e.State.RemainingTokens) > 0 && e.State.RemainingTokens[0].Literal() == "bare" {
+			bare = true
+			e.ConsumeNext(1)
 		}
 
+		// 2. Generate
+		id, err := NewUUID(e.Rand)
+		if err != nil {
+			return err
+		}
+
+		// 3. Format and type
+		if bare {
+			id = strings.ReplaceAll(id, "-", "")
+		}
+		if opts.UUIDUppercase {
+			id = strings.ToUpper(id)
+		}
+		return e.StickyKeyboard.Type(id)
+	}, c.Effects()...)
+}
+
+// Scratch erases whatever the previous phrase typed by pressing Backspace once per
+// character. Saying it again walks further back through earlier phrases.
+type Scratch struct{}
+
+func (Scratch) Name() string           { return "scratch" }
+func (Scratch) CalledBy() []string     { return []string{"scratch", "scratch that"} }
+func (Scratch) Description() string    { return "Erases what the previous phrase typed" }
+func (Scratch) Effects() []NamedEffect { return []NamedEffect{KillAfter()} }
+func (c Scratch) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		n := e.StickyKeyboard.PopJournal()
+		for i := 0; i < n; i++ {
+			e.StickyKeyboard.Backspace()
+		}
 		return nil
 	}, c.Effects()...)
 }
@@ -1321,9 +1755,9 @@ func (c Word) Action(e *Engine, p string) error {
 // Copy performs Control+C.
 type Copy struct{}
 
-func (Copy) Name() string          { return "copy" }
-func (Copy) CalledBy() []string    { return []string{"copy"} }
-func (Copy) Effects() []EffectFunc { return nil }
+func (Copy) Name() string           { return "copy" }
+func (Copy) CalledBy() []string     { return []string{"copy"} }
+func (Copy) Effects() []NamedEffect { return []NamedEffect{SnapshotClipboard()} }
 func (c Copy) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Control() // Hold Control
@@ -1335,9 +1769,9 @@ func (c Copy) Action(e *Engine, p string) error {
 // Select performs Control+A (Select All).
 type Select struct{}
 
-func (Select) Name() string          { return "select" }
-func (Select) CalledBy() []string    { return []string{"select", "select all"} }
-func (Select) Effects() []EffectFunc { return nil }
+func (Select) Name() string           { return "select" }
+func (Select) CalledBy() []string     { return []string{"select", "select all"} }
+func (Select) Effects() []NamedEffect { return nil }
 func (c Select) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Control() // Hold Control
@@ -1347,12 +1781,25 @@ func (c Select) Action(e *Engine, p string) error {
 }
 
 // Paste performs Control+V.
+// When followed by an ordinal ("paste second") it hands off to PasteNth instead.
 type Paste struct{}
 
-func (Paste) Name() string          { return "paste" }
-func (Paste) CalledBy() []string    { return []string{"paste"} }
-func (Paste) Effects() []EffectFunc { return nil }
+func (Paste) Name() string       { return "paste" }
+func (Paste) CalledBy() []string { return []string{"paste"} }
+func (Paste) Description() string {
+	return "Pastes the clipboard, or an older copy with \"paste second\""
+}
+func (Paste) ConsumesArgs() bool     { return true }
+func (Paste) Effects() []NamedEffect { return nil }
 func (c Paste) Action(e *Engine, p string) error {
+	if len(e.State.RemainingTokens) > 0 {
+		if n, ok := ordinalWords[e.State.RemainingTokens[0].Literal()]; ok {
+			// Consume the ordinal so it isn't handled as its own token
+			e.ConsumeNext(1)
+			return PasteNth{}.paste(e, n)
+		}
+	}
+
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Control() // Hold Control
 		e.StickyKeyboard.V()       // Press V
@@ -1360,12 +1807,42 @@ func (c Paste) Action(e *Engine, p string) error {
 	}, c.Effects()...)
 }
 
+// PasteNth pastes an older entry from the clipboard history ("paste second"),
+// then restores whatever was on the clipboard before.
+type PasteNth struct{}
+
+func (PasteNth) Name() string { return "paste_nth" }
+func (PasteNth) CalledBy() []string {
+	return []string{"paste second", "paste third", "paste fourth", "paste fifth"}
+}
+func (PasteNth) Description() string    { return "Pastes an older entry from the clipboard history" }
+func (PasteNth) Effects() []NamedEffect { return nil }
+func (c PasteNth) Action(e *Engine, p string) error {
+	// The ordinal is the last word of the trigger that invoked us
+	n := 2
+	if len(e.State.HandledTokens) > 0 {
+		words := strings.Fields(e.State.HandledTokens[len(e.State.HandledTokens)-1].Literal())
+		if len(words) > 0 {
+			if val, ok := ordinalWords[words[len(words)-1]]; ok {
+				n = val
+			}
+		}
+	}
+	return c.paste(e, n)
+}
+
+func (c PasteNth) paste(e *Engine, n int) error {
+	return EffectChain(e, func() error {
+		return e.PasteFromHistory(n)
+	}, c.Effects()...)
+}
+
 // Telescope performs Control+P.
 type Telescope struct{}
 
-func (Telescope) Name() string          { return "telescope" }
-func (Telescope) CalledBy() []string    { return []string{"telescope"} }
-func (Telescope) Effects() []EffectFunc { return nil }
+func (Telescope) Name() string           { return "telescope" }
+func (Telescope) CalledBy() []string     { return []string{"telescope"} }
+func (Telescope) Effects() []NamedEffect { return nil }
 func (c Telescope) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Control() // Hold Control
@@ -1376,9 +1853,9 @@ func (c Telescope) Action(e *Engine, p string) error {
 
 type Find struct{}
 
-func (Find) Name() string          { return "find" }
-func (Find) CalledBy() []string    { return []string{"find"} }
-func (Find) Effects() []EffectFunc { return []EffectFunc{ClickBefore()} }
+func (Find) Name() string           { return "find" }
+func (Find) CalledBy() []string     { return []string{"find"} }
+func (Find) Effects() []NamedEffect { return []NamedEffect{ClickBefore()} }
 func (c Find) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Control() // Hold Control
@@ -1389,9 +1866,9 @@ func (c Find) Action(e *Engine, p string) error {
 
 type DeleteWord struct{}
 
-func (DeleteWord) Name() string          { return "delete_word" }
-func (DeleteWord) CalledBy() []string    { return []string{"oops"} }
-func (DeleteWord) Effects() []EffectFunc { return []EffectFunc{} }
+func (DeleteWord) Name() string           { return "delete_word" }
+func (DeleteWord) CalledBy() []string     { return []string{"oops"} }
+func (DeleteWord) Effects() []NamedEffect { return []NamedEffect{} }
 func (c DeleteWord) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Control()   // Hold Control
@@ -1401,13 +1878,136 @@ func (c DeleteWord) Action(e *Engine, p string) error {
 }
 
 // Grab clicks the mouse (to focus), Selects All, and then Copies.
+// ----------------------------------------------------------------------------
+// SYSTEM
+// ----------------------------------------------------------------------------
+//
+// The chords behind these differ per platform; see SystemChords.
+
+// StartMenu opens the Start menu (the launcher on Linux, Spotlight on macOS).
+type StartMenu struct{}
+
+func (StartMenu) Name() string           { return "start_menu" }
+func (StartMenu) CalledBy() []string     { return []string{"start menu"} }
+func (StartMenu) Description() string    { return "Opens the Start menu, launcher or Spotlight" }
+func (StartMenu) Effects() []NamedEffect { return nil }
+func (c StartMenu) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		return e.StickyKeyboard.PressSystemChord(SystemStartMenu)
+	}, c.Effects()...)
+}
+
+// LockScreen locks the session (Win+L).
+type LockScreen struct{}
+
+func (LockScreen) Name() string           { return "lock_screen" }
+func (LockScreen) CalledBy() []string     { return []string{"lock screen"} }
+func (LockScreen) Description() string    { return "Locks the screen" }
+func (LockScreen) Effects() []NamedEffect { return nil }
+func (c LockScreen) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		return e.StickyKeyboard.PressSystemChord(SystemLockScreen)
+	}, c.Effects()...)
+}
+
+// Explorer opens the file manager (Win+E).
+type Explorer struct{}
+
+func (Explorer) Name() string           { return "explorer" }
+func (Explorer) CalledBy() []string     { return []string{"files"} }
+func (Explorer) Description() string    { return "Opens the file manager" }
+func (Explorer) Effects() []NamedEffect { return nil }
+func (c Explorer) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		return e.StickyKeyboard.PressSystemChord(SystemExplorer)
+	}, c.Effects()...)
+}
+
+// Settings opens the system settings (Win+I). Windows only.
+type Settings struct{}
+
+func (Settings) Name() string           { return "settings" }
+func (Settings) CalledBy() []string     { return []string{"settings"} }
+func (Settings) Description() string    { return "Opens the system settings (Windows)" }
+func (Settings) Effects() []NamedEffect { return nil }
+func (c Settings) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		return e.StickyKeyboard.PressSystemChord(SystemSettings)
+	}, c.Effects()...)
+}
+
+// MissionControl shows every window and Space (macOS).
+type MissionControl struct{}
+
+func (MissionControl) Name() string           { return "mission_control" }
+func (MissionControl) CalledBy() []string     { return []string{"mission control"} }
+func (MissionControl) Description() string    { return "Opens Mission Control (macOS)" }
+func (MissionControl) Effects() []NamedEffect { return nil }
+func (c MissionControl) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		return e.StickyKeyboard.PressSystemChord(SystemMissionControl)
+	}, c.Effects()...)
+}
+
+// SpaceEast moves to the Space on the right (macOS).
+type SpaceEast struct{}
+
+func (SpaceEast) Name() string           { return "space_east" }
+func (SpaceEast) CalledBy() []string     { return []string{"space east"} }
+func (SpaceEast) Description() string    { return "Moves to the next Space to the right (macOS)" }
+func (SpaceEast) Effects() []NamedEffect { return nil }
+func (c SpaceEast) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		return e.StickyKeyboard.PressSystemChord(SystemSpaceEast)
+	}, c.Effects()...)
+}
+
+// SpaceWest moves to the Space on the left (macOS).
+type SpaceWest struct{}
+
+func (SpaceWest) Name() string           { return "space_west" }
+func (SpaceWest) CalledBy() []string     { return []string{"space west"} }
+func (SpaceWest) Description() string    { return "Moves to the next Space to the left (macOS)" }
+func (SpaceWest) Effects() []NamedEffect { return nil }
+func (c SpaceWest) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		return e.StickyKeyboard.PressSystemChord(SystemSpaceWest)
+	}, c.Effects()...)
+}
+
+// ShowDesktop moves the windows aside to show the desktop (macOS).
+type ShowDesktop struct{}
+
+func (ShowDesktop) Name() string           { return "show_desktop" }
+func (ShowDesktop) CalledBy() []string     { return []string{"show desktop"} }
+func (ShowDesktop) Description() string    { return "Shows the desktop (macOS)" }
+func (ShowDesktop) Effects() []NamedEffect { return nil }
+func (c ShowDesktop) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		return e.StickyKeyboard.PressSystemChord(SystemShowDesktop)
+	}, c.Effects()...)
+}
+
+// Spotlight opens Spotlight search (macOS).
+type Spotlight struct{}
+
+func (Spotlight) Name() string           { return "spotlight" }
+func (Spotlight) CalledBy() []string     { return []string{"spotlight"} }
+func (Spotlight) Description() string    { return "Opens Spotlight search (macOS)" }
+func (Spotlight) Effects() []NamedEffect { return nil }
+func (c Spotlight) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		return e.StickyKeyboard.PressSystemChord(SystemSpotlight)
+	}, c.Effects()...)
+}
+
 type Save struct{}
 
 func (Save) Name() string       { return "save" }
-func (Save) CalledBy() []string { return []string{"save", "safe"} }
+func (Save) CalledBy() []string { return []string{"save"} }
 
 // Uses the new ClickBefore effect
-func (Save) Effects() []EffectFunc { return []EffectFunc{} }
+func (Save) Effects() []NamedEffect { return []NamedEffect{} }
 func (c Save) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		// Logic: Ctrl (Hold) -> A (Select All) -> C (Copy) -> Ctrl (Release)
@@ -1420,9 +2020,9 @@ func (c Save) Action(e *Engine, p string) error {
 // Undo performs Control+Z.
 type Undo struct{}
 
-func (Undo) Name() string          { return "undo" }
-func (Undo) CalledBy() []string    { return []string{"undo", "reverse"} }
-func (Undo) Effects() []EffectFunc { return nil }
+func (Undo) Name() string           { return "undo" }
+func (Undo) CalledBy() []string     { return []string{"undo", "reverse"} }
+func (Undo) Effects() []NamedEffect { return nil }
 func (c Undo) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		e.StickyKeyboard.Control() // Hold Control
@@ -1442,7 +2042,9 @@ func (Grab) Name() string       { return "grab" }
 func (Grab) CalledBy() []string { return []string{"grab"} }
 
 // Uses the new ClickBefore effect
-func (Grab) Effects() []EffectFunc { return []EffectFunc{ClickBefore(), ClickAfter()} }
+func (Grab) Effects() []NamedEffect {
+	return []NamedEffect{ClickBefore(), ClickAfter(), SnapshotClipboard()}
+}
 func (c Grab) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		// Logic: Ctrl (Hold) -> A (Select All) -> C (Copy) -> Ctrl (Release)
@@ -1461,7 +2063,7 @@ func (Yank) Name() string       { return "yank" }
 func (Yank) CalledBy() []string { return []string{"yank"} }
 
 // Uses the new ClickBefore effect
-func (Yank) Effects() []EffectFunc { return []EffectFunc{ClickBefore()} }
+func (Yank) Effects() []NamedEffect { return []NamedEffect{ClickBefore(), SnapshotClipboard()} }
 func (c Yank) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		// Logic: Ctrl (Hold) -> A (Select All) -> C (Copy) -> Ctrl (Release)
@@ -1480,7 +2082,7 @@ func (Shove) Name() string       { return "shove" }
 func (Shove) CalledBy() []string { return []string{"shove"} }
 
 // Uses the new ClickBefore effect
-func (Shove) Effects() []EffectFunc { return []EffectFunc{ClickBefore()} }
+func (Shove) Effects() []NamedEffect { return []NamedEffect{ClickBefore()} }
 func (c Shove) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		// Logic: Ctrl (Hold) -> V (Paste) -> Ctrl (Release)
@@ -1497,7 +2099,7 @@ func (Replace) Name() string       { return "replace" }
 func (Replace) CalledBy() []string { return []string{"replace"} }
 
 // Uses the new ClickBefore effect
-func (Replace) Effects() []EffectFunc { return []EffectFunc{ClickBefore()} }
+func (Replace) Effects() []NamedEffect { return []NamedEffect{ClickBefore()} }
 func (c Replace) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		// Logic: Ctrl (Hold) -> V (Paste) -> Ctrl (Release)
@@ -1522,7 +2124,7 @@ func (Bottom) Name() string       { return "bottom" }
 func (Bottom) CalledBy() []string { return []string{"bottom"} }
 
 // Uses the new ClickBefore effect
-func (Bottom) Effects() []EffectFunc { return []EffectFunc{ClickBefore()} }
+func (Bottom) Effects() []NamedEffect { return []NamedEffect{ClickBefore()} }
 func (c Bottom) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		// Logic: Ctrl (Hold) -> V (Paste) -> Ctrl (Release)
@@ -1541,7 +2143,7 @@ func (Top) Name() string       { return "top" }
 func (Top) CalledBy() []string { return []string{"top"} }
 
 // Uses the new ClickBefore effect
-func (Top) Effects() []EffectFunc { return []EffectFunc{ClickBefore()} }
+func (Top) Effects() []NamedEffect { return []NamedEffect{ClickBefore()} }
 func (c Top) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		// Logic: Ctrl (Hold) -> V (Paste) -> Ctrl (Release)
@@ -1557,80 +2159,552 @@ func (c Top) Action(e *Engine, p string) error {
 // HISTORY COMMANDS
 // ----------------------------------------------------------------------------
 
+// Repeat replays previous phrases.
+//
+//	"repeat"          -> replays the last phrase
+//	"repeat three"    -> replays the last phrase three times, same as a bare "three"
+//	"repeat last two" -> replays the two previous phrases, oldest first
+//	"repeat back two" -> replays only the phrase two back (the one before last)
+//
+// Counts are capped at MaxRepeatCount, and phrases further back than the
+// PreviousStates option can't be reached. Phrases that themselves contained
+// "repeat" are never replayed.
 type Repeat struct{}
 
-func (Repeat) Name() string          { return "repeat" }
-func (Repeat) CalledBy() []string    { return []string{"repeat", "again"} }
-func (Repeat) Effects() []EffectFunc { return nil }
+func (Repeat) Name() string       { return "repeat" }
+func (Repeat) CalledBy() []string { return []string{"repeat", "again"} }
+func (Repeat) Description() string {
+	return "Replays the last phrase (N times), the last N phrases, or the Nth one back"
+}
+func (Repeat) ConsumesArgs() bool     { return true }
+func (Repeat) Effects() []NamedEffect { return nil }
 func (c Repeat) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
-		// 1. Check if we have history
-		if e.LastState == nil {
-			return nil
+		remaining := e.State.RemainingTokens
+
+		// 1. "repeat back N": replay a single phrase further back
+		if len(remaining) >= 2 && remaining[0].Literal() == "back" {
+			if num, ok := remaining[1].(*NumberToken); ok {
+				e.ConsumeNext(2)
+				return e.replayIfSafe(e.PreviousState(num.Value() - 1))
+			}
 		}
 
-		// 2. We need to construct a fresh state based on LastState.
-		// We cannot reuse LastState directly because 'Advance' consumes it
-		// (removes RemainingTokens). If we consume it, we can't repeat twice.
-		replayState := &EngineState{
-			Tokens:       e.LastState.Tokens,
-			TokenIndices: e.LastState.TokenIndices,
-			RawWords:     e.LastState.RawWords,
-			// Fresh tracking slices:
-			HandledTokens:   make([]Token, 0, len(e.LastState.Tokens)),
-			RemainingTokens: make([]Token, len(e.LastState.Tokens)),
+		// 2. "repeat last N": replay the last N phrases in the order they were spoken
+		if len(remaining) >= 2 && remaining[0].Literal() == "last" {
+			if num, ok := remaining[1].(*NumberToken); ok {
+				e.ConsumeNext(2)
+
+				for i := min(num.Value(), MaxRepeatCount) - 1; i >= 0; i-- {
+					if err := e.replayIfSafe(e.PreviousState(i)); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
 		}
-		// Copy tokens into remaining
-		copy(replayState.RemainingTokens, e.LastState.Tokens)
-		replayState.RemainingRawWords = strings.Join(e.LastState.RawWords, " ")
 
-		// 3. Swap the Engine State
-		currentState := e.State // Backup current state ("repeat")
-		e.State = replayState   // Swap in the replay
+		// 3. "repeat N": replay the last phrase N times
+		if len(remaining) >= 1 {
+			if num, ok := remaining[0].(*NumberToken); ok {
+				e.ConsumeNext(1)
+				return e.replayTimes(e.PreviousState(0), num.Value())
+			}
+		}
+
+		// 4. Plain "repeat": replay the last phrase
+		return e.replayIfSafe(e.PreviousState(0))
+	}, c.Effects()...)
+}
+
+// Record starts capturing every following phrase into a macro.
+// Usage: "record macro" ... "stop recording banana" ... "play banana"
+type Record struct{}
 
-		// 4. Execute (This will run the logic of the previous commands)
-		if err := e.Execute(); err != nil {
+func (Record) Name() string           { return "record" }
+func (Record) CalledBy() []string     { return []string{"record", "record macro"} }
+func (Record) Description() string    { return "Starts recording phrases into a macro" }
+func (Record) Effects() []NamedEffect { return []NamedEffect{KillAfter()} }
+func (c Record) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.StartRecording()
+		e.log().Info("macro recording started")
+		return nil
+	}, c.Effects()...)
+}
+
+// StopRecording consumes the NEXT word and saves the recording under that name.
+// Usage: "finish banana"
+type StopRecording struct{}
+
+func (StopRecording) Name() string       { return "stop_recording" }
+func (StopRecording) CalledBy() []string { return []string{"finish", "stop recording"} }
+func (StopRecording) Description() string {
+	return "Stops recording and saves the macro under the next word"
+}
+func (StopRecording) Effects() []NamedEffect {
+	return []NamedEffect{ConsumeArgs(1)}
+}
+func (c StopRecording) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		if len(e.State.ConsumedArgs) == 0 {
+			return nil // Keep recording until we're told what to call it
+		}
+
+		macro, err := e.StopRecording(e.State.ConsumedArgs[0])
+		if err != nil {
 			return err
 		}
+		e.log().Info("macro saved", "macro", macro.Name, "phrases", len(macro.Phrases))
+		return nil
+	}, c.Effects()...)
+}
 
-		// 5. Restore State (Optional, but good practice to leave engine clean)
-		e.State = currentState
+// Play consumes the NEXT word and replays the macro saved under that name.
+// Usage: "play banana"
+type Play struct{}
 
-		return nil
+func (Play) Name() string        { return "play" }
+func (Play) CalledBy() []string  { return []string{"play"} }
+func (Play) Description() string { return "Plays the macro named by the next word" }
+func (Play) Effects() []NamedEffect {
+	return []NamedEffect{ConsumeArgs(1)}
+}
+func (c Play) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		if len(e.State.ConsumedArgs) == 0 {
+			return nil
+		}
+		return e.PlayMacro(e.State.ConsumedArgs[0])
 	}, c.Effects()...)
 }
 
+// MacroCmd is a DYNAMIC command registered from the "macros" section of the config file.
+// Saying its name replays its phrases in order. It is not in the static registry.
+type MacroCmd struct {
+	MacroName string
+	Phrases   []string
+}
+
+func NewMacroCmd(name string, phrases []string) *MacroCmd {
+	return &MacroCmd{MacroName: name, Phrases: phrases}
+}
+
+func (m *MacroCmd) Name() string           { return "macro_" + m.MacroName }
+func (m *MacroCmd) CalledBy() []string     { return []string{m.MacroName} }
+func (m *MacroCmd) Effects() []NamedEffect { return nil }
+func (m *MacroCmd) Category() string       { return "Macros" }
+func (m *MacroCmd) Description() string    { return "Plays the config macro '" + m.MacroName + "'" }
+func (m *MacroCmd) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		phrases := make([]MacroPhrase, len(m.Phrases))
+		for i, input := range m.Phrases {
+			phrases[i] = MacroPhrase{Input: input, Mode: "phrase"}
+		}
+		return e.playPhrases(m.Name(), phrases)
+	}, m.Effects()...)
+}
+
+// KeysCmd is a DYNAMIC command that presses a fixed sequence of keys or chords.
+// Modes use it to define their commands as data.
+// e.g. Keys: []string{"escape", ":", "w", "enter"}
+type KeysCmd struct {
+	CmdName  string
+	Triggers []string
+	Keys     []string
+	Desc     string
+}
+
+func (k KeysCmd) Name() string           { return k.CmdName }
+func (k KeysCmd) CalledBy() []string     { return k.Triggers }
+func (k KeysCmd) Description() string    { return k.Desc }
+func (k KeysCmd) Effects() []NamedEffect { return nil }
+func (k KeysCmd) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		for _, key := range k.Keys {
+			e.StickyKeyboard.Press(key)
+		}
+		return nil
+	}, k.Effects()...)
+}
+
 // ----------------------------------------------------------------------------
 // UTILITY COMMANDS
 // ----------------------------------------------------------------------------
 
+// Sleep stops the engine from reacting to anything but "wake",
+// e.g. while talking to a human. The server keeps running.
+type Sleep struct{}
+
+func (Sleep) Name() string           { return "sleep" }
+func (Sleep) CalledBy() []string     { return []string{"standby", "go to sleep"} }
+func (Sleep) Description() string    { return "Ignores everything until \"wake\"" }
+func (Sleep) Effects() []NamedEffect { return []NamedEffect{KillAfter()} }
+func (c Sleep) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.Listening.Store(false)
+		e.log().Info("engine asleep, say 'wake up' to resume")
+		return nil
+	}, c.Effects()...)
+}
+
+// Formal turns the FormalDictation option on or off: "formal on", "formal off".
+type Formal struct{}
+
+func (Formal) Name() string       { return "formal" }
+func (Formal) CalledBy() []string { return []string{"formal"} }
+func (Formal) Description() string {
+	return "Turns sentence continuation for \"say\" on or off"
+}
+func (Formal) ArgCount() int          { return 1 }
+func (Formal) Effects() []NamedEffect { return nil }
+func (c Formal) Action(e *Engine, p string) error {
+	return c.ActionWithArgs(e, e.ConsumeNext(c.ArgCount()))
+}
+func (c Formal) ActionWithArgs(e *Engine, args []Token) error {
+	return EffectChain(e, func() error {
+		if len(args) == 0 {
+			return nil
+		}
+		opts := e.Options()
+		switch args[0].Literal() {
+		case "on":
+			opts.FormalDictation = true
+		case "off":
+			opts.FormalDictation = false
+		default:
+			return nil
+		}
+		return e.SetOptions(opts)
+	}, c.Effects()...)
+}
+
+// Strict turns the StrictAlphabet option on or off: "strict on", "strict off".
+type Strict struct{}
+
+func (Strict) Name() string       { return "strict" }
+func (Strict) CalledBy() []string { return []string{"strict"} }
+func (Strict) Description() string {
+	return "Turns single-letter and ambiguous triggers off (strict on) or back on (strict off)"
+}
+func (Strict) ArgCount() int          { return 1 }
+func (Strict) Effects() []NamedEffect { return nil }
+func (c Strict) Action(e *Engine, p string) error {
+	return c.ActionWithArgs(e, e.ConsumeNext(c.ArgCount()))
+}
+func (c Strict) ActionWithArgs(e *Engine, args []Token) error {
+	return EffectChain(e, func() error {
+		if len(args) == 0 {
+			return nil
+		}
+		opts := e.Options()
+		switch args[0].Literal() {
+		case "on":
+			opts.StrictAlphabet = true
+		case "off":
+			opts.StrictAlphabet = false
+		default:
+			return nil
+		}
+		return e.SetOptions(opts)
+	}, c.Effects()...)
+}
+
+// Typing switches the typing delay to a preset: "typing slow" for apps and
+// remote sessions that drop fast input, "typing fast" to go back.
+type Typing struct{}
+
+func (Typing) Name() string       { return "typing" }
+func (Typing) CalledBy() []string { return []string{"typing"} }
+func (Typing) Description() string {
+	return "Sets the typing speed to the next word: fast, normal or slow"
+}
+func (Typing) ArgCount() int          { return 1 }
+func (Typing) Effects() []NamedEffect { return nil }
+func (c Typing) Action(e *Engine, p string) error {
+	return c.ActionWithArgs(e, e.ConsumeNext(c.ArgCount()))
+}
+func (c Typing) ActionWithArgs(e *Engine, args []Token) error {
+	return EffectChain(e, func() error {
+		if len(args) == 0 {
+			return nil
+		}
+		if err := e.SetTypingPreset(args[0].Literal()); err != nil {
+			return err
+		}
+		e.log().Info("typing speed changed", "preset", args[0].Literal(), "delay_ms", e.Options().TypingDelayMs)
+		return nil
+	}, c.Effects()...)
+}
+
+// Pace sets the pause between tokens and repetitions, e.g. "pace slow" to
+// watch a long phrase play out.
+type Pace struct{}
+
+func (Pace) Name() string       { return "pace" }
+func (Pace) CalledBy() []string { return []string{"pace"} }
+func (Pace) Description() string {
+	return "Sets the pause between commands to the next word: fast, normal or slow"
+}
+func (Pace) ArgCount() int          { return 1 }
+func (Pace) Effects() []NamedEffect { return nil }
+func (c Pace) Action(e *Engine, p string) error {
+	return c.ActionWithArgs(e, e.ConsumeNext(c.ArgCount()))
+}
+func (c Pace) ActionWithArgs(e *Engine, args []Token) error {
+	return EffectChain(e, func() error {
+		if len(args) == 0 {
+			return nil
+		}
+		if err := e.SetPacePreset(args[0].Literal()); err != nil {
+			return err
+		}
+		e.log().Info("pace changed", "preset", args[0].Literal(), "delay_us", e.Options().TokenDelayUs)
+		return nil
+	}, c.Effects()...)
+}
+
+// Wake resumes listening after Sleep. It is the only command processed while asleep.
+type Wake struct{}
+
+func (Wake) Name() string           { return "wake" }
+func (Wake) CalledBy() []string     { return []string{"wake", "wake up"} }
+func (Wake) Description() string    { return "Resumes listening after sleep" }
+func (Wake) Effects() []NamedEffect { return nil }
+func (c Wake) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.Listening.Store(true)
+		e.log().Info("engine awake")
+		return nil
+	}, c.Effects()...)
+}
+
+// ModeCmd consumes the NEXT word and switches to that mode.
+// Usage: "mode browser", "mode off"
+type ModeCmd struct{}
+
+func (ModeCmd) Name() string       { return "mode" }
+func (ModeCmd) CalledBy() []string { return []string{"mode"} }
+func (ModeCmd) Description() string {
+	return "Switches to the mode named by the next word, or \"mode off\""
+}
+func (ModeCmd) Effects() []NamedEffect {
+	return []NamedEffect{ConsumeArgs(1)}
+}
+func (c ModeCmd) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		if len(e.State.ConsumedArgs) == 0 {
+			return nil // User said "mode" but didn't say which
+		}
+		return e.SetMode(e.State.ConsumedArgs[0])
+	}, c.Effects()...)
+}
+
+// Cancel is a spoken circuit breaker: every token after it is dropped.
+// When "cancel" is the final word, Parse marks the whole phrase cancelled and nothing runs.
+type Cancel struct{}
+
+func (Cancel) Name() string       { return "cancel" }
+func (Cancel) CalledBy() []string { return []string{"cancel"} }
+func (Cancel) Description() string {
+	return "Drops the rest of the phrase, or the whole phrase when said last"
+}
+func (Cancel) Effects() []NamedEffect { return nil }
+func (c Cancel) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.State.Cancelled = true
+		return nil
+	}, c.Effects()...)
+}
+
+// Stop is the brake for rapid streams: it aborts whatever is still repeating
+// and forgets the previous phrases, so a stray number after it can't resume
+// them. In a phrase it drops the phrase like Cancel. Enqueue cancels the
+// running phrase as soon as a lone "stop" arrives, without waiting its turn.
+type Stop struct{}
+
+func (Stop) Name() string       { return "stop" }
+func (Stop) CalledBy() []string { return []string{"stop", "freeze"} }
+func (Stop) Description() string {
+	return "Aborts a running repetition and forgets the previous phrase"
+}
+func (Stop) Effects() []NamedEffect { return nil }
+func (c Stop) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.State.Cancelled = true
+		e.ForgetPrevious()
+		return nil
+	}, c.Effects()...)
+}
+
+// Halt stops the phrase where it is: what came before it has run, everything
+// after it is dropped. Unlike a final "cancel" it never undoes the phrase,
+// e.g. for garbage the recognizer tacked onto the end.
+type Halt struct{}
+
+func (Halt) Name() string       { return "halt" }
+func (Halt) CalledBy() []string { return []string{"halt"} }
+func (Halt) Description() string {
+	return "Stops the phrase here, dropping the words after it"
+}
+func (Halt) Effects() []NamedEffect { return nil }
+func (c Halt) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.State.Halted = true
+		return nil
+	}, c.Effects()...)
+}
+
+// Then splits a phrase into segments run one after another, with the
+// ThenDelayMs option's pause between them:
+// e.g. "telescope then say main dot go then enter".
+// Dictation stops at the next "then", a KillAfter only ends its own segment,
+// and a number after "then" doesn't repeat the command before it. The
+// phrase loop handles the boundary itself (see nextSegment); Action is
+// only reached in rapid mode, where it does nothing.
+type Then struct{}
+
+func (Then) Name() string       { return "then" }
+func (Then) CalledBy() []string { return []string{"then"} }
+func (Then) Description() string {
+	return "Separates the steps of a phrase, pausing between them"
+}
+func (Then) Effects() []NamedEffect { return nil }
+func (c Then) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error { return nil }, c.Effects()...)
+}
+
+// Times repeats the rest of its segment as a group: "3 times tab" presses
+// tab three times, "2 times left down" goes left, down, left, down. The count
+// comes first; Parse merges "<number> times" into this command, so a lone
+// "times" still types "*". At most MaxRepeatCount passes run.
+type Times struct {
+	Count int
+}
+
+func (Times) Name() string       { return "times" }
+func (Times) CalledBy() []string { return []string{"times"} }
+func (Times) Description() string {
+	return "Repeats the rest of the phrase (up to the next then) the given number of times"
+}
+func (Times) Effects() []NamedEffect { return nil }
+func (c Times) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		s := e.State
+		from, to := s.current+1, s.segmentEnd(s.current)
+		if from >= to {
+			return nil
+		}
+		// The group's tokens run here, not in the phrase loop
+		s.SkipCount = to - from
+
+		for k := range min(c.Count, MaxRepeatCount) {
+			if k > 0 {
+				e.pace()
+			}
+			if err := e.checkCancelled(); err != nil {
+				return err
+			}
+			g := s.group(from, to)
+			start := time.Now()
+			err := e.runGroup(g)
+			s.traceTiming(fmt.Sprintf("pass %d", k+1), time.Since(start))
+			if err != nil {
+				return err
+			}
+			// "halt" or "cancel" inside the group stops the phrase, not just the pass
+			if g.Halted || g.Cancelled {
+				s.Halted = s.Halted || g.Halted
+				s.Cancelled = s.Cancelled || g.Cancelled
+				break
+			}
+		}
+		return nil
+	}, c.Effects()...)
+}
+
+// Wait pauses before the next token in the phrase, e.g. "telescope wait enter".
+// A following number is read as tenths of a second: "wait five" = 500ms, "wait twenty" = 2s.
+// Without a number it waits DefaultWait. The pause is capped at MaxWait so a misheard
+// number can't freeze the engine.
+type Wait struct{}
+
+const (
+	DefaultWait = 500 * time.Millisecond
+	MaxWait     = 5 * time.Second
+)
+
+func (Wait) Name() string           { return "wait" }
+func (Wait) CalledBy() []string     { return []string{"wait", "hold on"} }
+func (Wait) Description() string    { return "Pauses for the next number in tenths of a second" }
+func (Wait) ConsumesArgs() bool     { return true }
+func (Wait) Effects() []NamedEffect { return nil }
+func (c Wait) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		d := DefaultWait
+
+		// 1. Consume an optional number argument (in tenths of a second)
+		if len(e.State.RemainingTokens) > 0 {
+			if num, ok := e.State.RemainingTokens[0].(*NumberToken); ok {
+				d = time.Duration(num.Value()) * 100 * time.Millisecond
+				e.ConsumeNext(1)
+			}
+		}
+
+		// 2. Cap it
+		if d > MaxWait {
+			d = MaxWait
+		}
+
+		// 3. Sleep, bailing out early if the engine shuts down
+		e.Sleep(d)
+		return nil
+	}, c.Effects()...)
+}
+
 // Help prints the command registry in a line-by-line JSON format (NDJSON style)
-// which serves as the "minimal" readable format for the console.
+// which serves as the "minimal" readable format for the console, and answers
+// with a summary of the engine. "help ascend" instead looks the next word up
+// and answers what it does. Answers are typed unless the SilentHelp option is
+// set; either way they are returned in the execution result.
 type Help struct{}
 
-func (Help) Name() string          { return "help" }
-func (Help) CalledBy() []string    { return []string{"help", "commands"} }
-func (Help) Effects() []EffectFunc { return nil }
+func (Help) Name() string       { return "help" }
+func (Help) CalledBy() []string { return []string{"help", "commands"} }
+func (Help) Description() string {
+	return "Says what the next word does, or summarizes every command"
+}
+func (Help) Effects() []NamedEffect { return nil }
 func (c Help) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
-		fmt.Println("--- Command Registry (JSON Lines) ---")
-		for _, cmd := range Registry {
-			// Create simplified struct
-			simpleCmd := CmdJSON{
-				Name:     cmd.Name(),
-				CalledBy: cmd.CalledBy(),
-			}
-
-			// Marshal to minimal JSON for this specific line
-			bytes, err := json.Marshal(simpleCmd)
-			if err != nil {
-				continue
+		var answer string
+		if next := e.PeekNext(); next != nil {
+			e.ConsumeNext(1)
+			answer = e.ExplainWord(CasedLiteral(next))
+		} else {
+			fmt.Println("--- Command Registry (JSON Lines) ---")
+			for _, cmd := range e.Commands() {
+				// Create simplified struct
+				simpleCmd := cmdToJSON(cmd)
+
+				// Marshal to minimal JSON for this specific line
+				bytes, err := json.Marshal(simpleCmd)
+				if err != nil {
+					continue
+				}
+
+				// Print the single-line JSON
+				fmt.Println(string(bytes))
 			}
+			fmt.Println("-------------------------------------")
+			answer = e.HelpSummary()
+		}
 
-			// Print the single-line JSON
-			fmt.Println(string(bytes))
+		e.State.Outputs = append(e.State.Outputs, CommandOutput{Command: c.Name(), Value: answer})
+		e.log().Info("help", "answer", answer)
+		if !e.Options().SilentHelp {
+			return e.StickyKeyboard.TypeStr(answer)
 		}
-		fmt.Println("-------------------------------------")
 		return nil
 	}, c.Effects()...)
 }
@@ -1639,11 +2713,12 @@ func (c Help) Action(e *Engine, p string) error {
 // to that word. Usage: "remember banana"
 type Remember struct{}
 
-func (Remember) Name() string       { return "remember" }
-func (Remember) CalledBy() []string { return []string{"remember", "mark"} }
-func (Remember) Effects() []EffectFunc {
+func (Remember) Name() string        { return "remember" }
+func (Remember) CalledBy() []string  { return []string{"remember", "mark"} }
+func (Remember) Description() string { return "Saves the mouse position under the next word" }
+func (Remember) Effects() []NamedEffect {
 	// Consume the next 1 token (the name of the spot)
-	return []EffectFunc{ConsumeArgs(1)}
+	return []NamedEffect{ConsumeArgs(1)}
 }
 func (c Remember) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
@@ -1659,7 +2734,7 @@ func (c Remember) Action(e *Engine, p string) error {
 
 		// 3. Save to memory
 		e.Memory.Set(name, e.Mouse.X, e.Mouse.Y)
-		fmt.Printf("Remembered spot '%s' at %d, %d\n", name, e.Mouse.X, e.Mouse.Y)
+		e.log().Info("remembered spot", "spot", name, "x", e.Mouse.X, "y", e.Mouse.Y)
 
 		return nil
 	}, c.Effects()...)
@@ -1669,10 +2744,11 @@ func (c Remember) Action(e *Engine, p string) error {
 // Usage: "forget banana"
 type Forget struct{}
 
-func (Forget) Name() string       { return "forget" }
-func (Forget) CalledBy() []string { return []string{"forget"} }
-func (Forget) Effects() []EffectFunc {
-	return []EffectFunc{ConsumeArgs(1)}
+func (Forget) Name() string        { return "forget" }
+func (Forget) CalledBy() []string  { return []string{"forget"} }
+func (Forget) Description() string { return "Deletes the saved spot named by the next word" }
+func (Forget) Effects() []NamedEffect {
+	return []NamedEffect{ConsumeArgs(1)}
 }
 func (c Forget) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
@@ -1682,7 +2758,7 @@ func (c Forget) Action(e *Engine, p string) error {
 
 		name := e.State.ConsumedArgs[0]
 		e.Memory.Delete(name)
-		fmt.Printf("Forgot spot '%s'\n", name)
+		e.log().Info("forgot spot", "spot", name)
 
 		return nil
 	}, c.Effects()...)
@@ -1700,16 +2776,17 @@ func NewSpotCmd(name string, x, y int) *SpotCmd {
 	return &SpotCmd{SpotName: name, TargetX: x, TargetY: y}
 }
 
-func (s *SpotCmd) Name() string          { return "goto_" + s.SpotName }
-func (s *SpotCmd) CalledBy() []string    { return []string{s.SpotName} }
-func (s *SpotCmd) Effects() []EffectFunc { return nil }
+func (s *SpotCmd) Name() string           { return "goto_" + s.SpotName }
+func (s *SpotCmd) CalledBy() []string     { return []string{s.SpotName} }
+func (s *SpotCmd) Effects() []NamedEffect { return nil }
+func (s *SpotCmd) Category() string       { return "Memory" }
+func (s *SpotCmd) Description() string {
+	return "Moves the mouse to the saved spot '" + s.SpotName + "'"
+}
 func (s *SpotCmd) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		// Move mouse to the stored coordinates
-		robotgo.Move(s.TargetX, s.TargetY)
-		// Update engine mouse state
-		e.Mouse.X = s.TargetX
-		e.Mouse.Y = s.TargetY
+		e.Mouse.MoveTo(s.TargetX, s.TargetY)
 		return nil
 	}, nil...)
 }
@@ -1718,9 +2795,10 @@ func (s *SpotCmd) Action(e *Engine, p string) error {
 // Usage: "spots" or "memory"
 type ListSpots struct{}
 
-func (ListSpots) Name() string          { return "list_spots" }
-func (ListSpots) CalledBy() []string    { return []string{"spots"} }
-func (ListSpots) Effects() []EffectFunc { return nil }
+func (ListSpots) Name() string           { return "list_spots" }
+func (ListSpots) CalledBy() []string     { return []string{"spots"} }
+func (ListSpots) Description() string    { return "Prints all saved mouse spots" }
+func (ListSpots) Effects() []NamedEffect { return nil }
 func (c ListSpots) Action(e *Engine, p string) error {
 	return EffectChain(e, func() error {
 		// Header
@@ -1745,84 +2823,104 @@ func (c ListSpots) Action(e *Engine, p string) error {
 // COMMAND REGISTRY
 // ----------------------------------------------------------------------------
 
-// Registry contains a slice of all available commands to be used elsewhere.
-var Registry = []Cmd{
-	// Modifiers
-	Shift{}, Control{}, Alt{}, Command{},
-
-	// Navigation
-	North{}, South{}, East{}, West{},
-
-	// Editing
-	Enter{}, Tab{}, Space{}, Back{}, Delete{}, Escape{},
-	Home{}, End{}, PageUp{}, PageDown{},
-
-	// Symbols (Basic Punctuation)
-	Dot{}, Comma{}, Semi{}, Colon{},
-	Quote{}, DoubleQuote{}, Tick{},
-
-	// Symbols (Slashes & Bars)
-	Slash{}, Backslash{}, Pipe{},
-
-	// Symbols (Grouping)
-	Paren{}, CloseParen{},
-	Bracket{}, Closing{},
-	Brace{}, CloseBrace{},
-	Angle{}, CloseAngle{},
-
-	// Symbols (Math & Logic)
-	Dash{}, Underscore{}, Equals{}, Plus{},
-	Star{}, Percent{},
-
-	// Symbols (Special Characters)
-	Bang{}, At{}, Hash{}, Dollar{},
-	Hat{}, Ampersand{}, Question{}, Tilde{},
-
-	// Alphabet
-	A{}, B{}, C{}, D{}, E{}, F{},
-	G{}, H{}, I{}, J{}, K{}, L{},
-	M{}, N{}, O{}, P{}, Q{}, R{},
-	S{}, T{}, U{}, V{}, W{}, X{},
-	Y{}, Z{},
-
-	// Numbers
-	Number{},
-
-	// Function Keys
-	FOne{}, FTwo{}, FThree{}, FFour{}, FFive{}, FSix{},
-	FSeven{}, FEight{}, FNine{}, FTen{}, FEleven{}, FTwelve{},
-
-	// Mouse
-	Click{}, Left{}, Right{}, Up{}, Down{},
-
-	// Formatting
-	CamelCase{}, PascalCase{}, SnakeCase{}, Say{}, RawType{}, Word{},
-
-	// SHORTCUTS (Combos)
-	Copy{}, Select{}, Paste{}, Telescope{}, Undo{}, Save{},
-
-	// ADVANCED ACTIONS (Click+Combo)
-	Grab{}, Shove{}, Find{}, DeleteWord{}, Yank{}, Bottom{}, Top{}, Replace{},
-
-	// HISTORY
-	Repeat{},
-
-	// UTILITY
-	Help{},
-
-	// MEMORY
-	Remember{}, Forget{}, ListSpots{},
+// RegistryGroups lists every built-in command under the category it is shown with
+// in the /signs cheat sheet. Registry is flattened from it.
+var RegistryGroups = []RegistryGroup{
+	{Category: "Modifiers", Commands: []Cmd{
+		Shift{}, Control{}, Alt{}, Command{}, Super{}, Hold{}, HoldKey{}, Release{}, ReleaseEverything{},
+	}},
+	{Category: "Navigation", Commands: []Cmd{
+		North{}, South{}, East{}, West{},
+	}},
+	{Category: "Editing", Commands: []Cmd{
+		Enter{}, Tab{}, Space{}, Back{}, Delete{}, Escape{},
+		Home{}, End{}, PageUp{}, PageDown{},
+	}},
+	{Category: "Symbols (Basic Punctuation)", Commands: []Cmd{
+		Dot{}, Comma{}, Semi{}, Colon{},
+		Quote{}, DoubleQuote{}, Tick{},
+	}},
+	{Category: "Symbols (Slashes & Bars)", Commands: []Cmd{
+		Slash{}, Backslash{}, Pipe{},
+	}},
+	{Category: "Symbols (Grouping)", Commands: []Cmd{
+		Paren{}, CloseParen{},
+		Bracket{}, Closing{},
+		Brace{}, CloseBrace{},
+		Angle{}, CloseAngle{},
+	}},
+	{Category: "Symbols (Math & Logic)", Commands: []Cmd{
+		Dash{}, Underscore{}, Equals{}, Plus{},
+		Star{}, Percent{},
+	}},
+	{Category: "Symbols (Special Characters)", Commands: []Cmd{
+		Bang{}, At{}, Hash{}, Dollar{},
+		Hat{}, Ampersand{}, Question{}, Tilde{},
+	}},
+	{Category: "Alphabet", Commands: []Cmd{
+		A{}, B{}, C{}, D{}, E{}, F{},
+		G{}, H{}, I{}, J{}, K{}, L{},
+		M{}, N{}, O{}, P{}, Q{}, R{},
+		S{}, T{}, U{}, V{}, W{}, X{},
+		Y{}, Z{},
+	}},
+	{Category: "Numbers", Commands: []Cmd{
+		Number{},
+	}},
+	{Category: "Function Keys", Commands: []Cmd{
+		FOne{}, FTwo{}, FThree{}, FFour{}, FFive{}, FSix{},
+		FSeven{}, FEight{}, FNine{}, FTen{}, FEleven{}, FTwelve{},
+	}},
+	{Category: "Mouse", Commands: []Cmd{
+		Click{}, ClickText{}, FindIcon{}, Color{}, Left{}, Right{}, Up{}, Down{},
+	}},
+	{Category: "Formatting", Commands: []Cmd{
+		CamelCase{}, PascalCase{}, SnakeCase{}, Say{}, Phrase{}, RawType{}, Verbatim{}, Word{}, Clip{}, Scratch{},
+	}},
+	{Category: "Generated Text", Commands: []Cmd{
+		Today{}, Timestamp{}, Uuid{},
+	}},
+	{Category: "Shortcuts", Commands: []Cmd{
+		Copy{}, Select{}, Paste{}, PasteNth{}, Telescope{}, Undo{}, Save{},
+	}},
+	{Category: "System", Commands: []Cmd{
+		StartMenu{}, LockScreen{}, Explorer{}, Settings{},
+		MissionControl{}, SpaceEast{}, SpaceWest{}, ShowDesktop{}, Spotlight{},
+	}},
+	{Category: "Advanced Actions", Commands: []Cmd{
+		Grab{}, Shove{}, Find{}, DeleteWord{}, Yank{}, Bottom{}, Top{}, Replace{},
+	}},
+	{Category: "History", Commands: []Cmd{
+		Repeat{},
+	}},
+	{Category: "Macros", Commands: []Cmd{
+		Record{}, StopRecording{}, Play{},
+	}},
+	{Category: "Utility", Commands: []Cmd{
+		Help{}, Wait{}, Cancel{}, Stop{}, Halt{}, Then{}, Sleep{}, Wake{}, ModeCmd{}, Typing{}, Pace{}, Formal{}, Strict{},
+	}},
+	{Category: "Memory", Commands: []Cmd{
+		Remember{}, Forget{}, ListSpots{},
+	}},
 }
 
+// Registry contains a slice of all available commands to be used elsewhere.
+var Registry = flattenRegistryGroups(RegistryGroups)
+
 // ----------------------------------------------------------------------------
 // JSON UTILITIES
 // ----------------------------------------------------------------------------
 
 // CmdJSON is a simplified structure used only for JSON exporting.
-// It captures the name and triggers, as Action/Effects cannot be easily serialized.
+// Actions can't be serialized, so effects are exported by name only.
 type CmdJSON struct {
-	Name     string   `json:"name"`
-	CalledBy []string `json:"called_by"`
+	Name         string   `json:"name"`
+	CalledBy     []string `json:"called_by"`
+	Description  string   `json:"description"`
+	Category     string   `json:"category"`
+	Effects      []string `json:"effects"`
+	ConsumesArgs bool     `json:"consumes_args"`
+	Disabled     bool     `json:"disabled,omitempty"`
 }
 
 // RegistryToJSON returns the registry in two formats:
@@ -1832,10 +2930,7 @@ func RegistryToJSON() (minimal string, full string, err error) {
 	var export []CmdJSON
 
 	for _, cmd := range Registry {
-		export = append(export, CmdJSON{
-			Name:     cmd.Name(),
-			CalledBy: cmd.CalledBy(),
-		})
+		export = append(export, cmdToJSON(cmd))
 	}
 
 	// 1. Generate Minimal (Compact) JSON
@@ -0,0 +1,225 @@
+package sniper_test
+
+import (
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/phillip-england/sniper/sniper"
+	"github.com/phillip-england/sniper/sniper/snipertest"
+)
+
+// primary is the modifier shortcuts use: cmd on macOS, ctrl elsewhere.
+func primary() string {
+	if runtime.GOOS == "darwin" {
+		return "cmd"
+	}
+	return "ctrl"
+}
+
+func TestNavigationCommands(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+
+	snipertest.ExpectKeys(t, e, "south", "down")
+	snipertest.ExpectKeys(t, e, "south 3", "down", "down", "down")
+	snipertest.ExpectKeys(t, e, "east then west", "right", "left")
+	snipertest.ExpectKeys(t, e, "climb", "pageup")
+	snipertest.ExpectKeys(t, e, "drop", "pagedown")
+	snipertest.ExpectKeys(t, e, "back 2", "backspace", "backspace")
+	snipertest.ExpectKeys(t, e, "enter", "enter")
+}
+
+func TestSymbolCommands(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	snipertest.ExpectKeys(t, e, "dot comma", ".", ",")
+}
+
+func TestShortcutCommands(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	mod := primary()
+
+	snipertest.ExpectKeys(t, e, "copy", mod+"+c")
+	snipertest.ExpectKeys(t, e, "paste", mod+"+v")
+	snipertest.ExpectKeys(t, e, "undo", mod+"+z")
+	snipertest.ExpectKeys(t, e, "select all", mod+"+a")
+}
+
+func TestModifierCommands(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+
+	snipertest.ExpectKeys(t, e, "control alpha", "ctrl+a")
+	snipertest.ExpectKeys(t, e, "shift bravo", "shift+b")
+
+	// A held key stays down across segments until released
+	e.MustRun(t, "hold shift then east then release")
+	want := []string{"down shift", "tap right", "up shift"}
+	if got := e.Input.Ops(); !slices.Equal(got, want) {
+		t.Errorf("hold traced %q, want %q", got, want)
+	}
+}
+
+func TestFormattingCommands(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+
+	snipertest.ExpectTyped(t, e, "snake get user name", "get_user_name")
+	snipertest.ExpectTyped(t, e, "pascal my widget", "MyWidget")
+	snipertest.ExpectTyped(t, e, "camel my widget", "myWidget")
+}
+
+func TestCaseFormattersDigitsAndAcronyms(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+
+	tests := []struct{ phrase, want string }{
+		// Digits are segments of their own
+		{"camel user id two", "userID2"},
+		{"pascal user 2 name", "User2Name"},
+		{"snake user id two", "user_id_2"},
+		{"snake version 10", "version_10"},
+		// Acronyms are capitalized unless they lead a camel name
+		{"pascal url parser", "URLParser"},
+		{"camel url parser", "urlParser"},
+		{"camel get http client", "getHTTPClient"},
+		{"pascal parse json api response", "ParseJSONAPIResponse"},
+		{"snake http client", "http_client"},
+	}
+	for _, tt := range tests {
+		snipertest.ExpectTyped(t, e, tt.phrase, tt.want)
+	}
+
+	// The config's list replaces the defaults
+	e.ApplyConfig(&sniper.Config{Acronyms: []string{"SDK"}})
+	snipertest.ExpectTyped(t, e, "pascal aws sdk url", "AwsSDKUrl")
+	e.ApplyConfig(&sniper.Config{})
+	snipertest.ExpectTyped(t, e, "pascal aws sdk url", "AwsSdkURL")
+}
+
+func TestTypeSpellsWords(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+
+	tests := []struct{ phrase, want string }{
+		{"type hotel tango tango papa sierra colon slash slash", "https://"},
+		{"type alpha dash bravo", "a-b"},
+		{"type user under id dot json", "user_id.json"},
+		{"type version two dot ten", "version2.10"},
+		{"type hello world", "helloworld"}, // no mapping: typed as spoken
+		// verbatim keeps the old behaviour
+		{"verbatim alpha dash bravo", "alphadashbravo"},
+	}
+	for _, tt := range tests {
+		snipertest.ExpectTyped(t, e, tt.phrase, tt.want)
+	}
+}
+
+func TestGeneratedTextCommands(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.Now = func() time.Time { return time.Date(2024, 3, 9, 14, 5, 0, 0, time.UTC) }
+
+	snipertest.ExpectTyped(t, e, "today", "2024-03-09")
+}
+
+func TestMouseCommands(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.Input.SetCursor(100, 100)
+
+	snipertest.ExpectMouse(t, e, "click", "click left")
+	snipertest.ExpectMouse(t, e, "left 3", "move 99 100", "move 98 100", "move 97 100")
+}
+
+func TestPhraseTrace(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.Input.SetCursor(100, 100)
+
+	snipertest.ExpectGolden(t, e, "south 2 then hold shift then east then release then say hello there then click", "phrase_trace")
+}
+
+func TestThenSegments(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+
+	tests := []struct {
+		phrase   string
+		keys     []string
+		segments []int
+	}{
+		{"south then east", []string{"down", "right"}, []int{0, 1, 1}},
+		{"say hi then east 2 then west", []string{"right", "right", "left"}, []int{0, 0, 1, 1, 1, 2, 2}},
+		{"south then", []string{"down"}, []int{0, 1}},
+		// A number opening a segment doesn't repeat the last one's command
+		{"south then 3 east", []string{"down", "right"}, []int{0, 1, 1, 1}},
+	}
+	for _, tt := range tests {
+		result := e.MustRun(t, tt.phrase)
+		if got := e.Input.Keys(); !slices.Equal(got, tt.keys) {
+			t.Errorf("%q tapped %q, want %q", tt.phrase, got, tt.keys)
+		}
+		var segments []int
+		for _, tok := range result.Tokens {
+			segments = append(segments, tok.Segment)
+		}
+		if !slices.Equal(segments, tt.segments) {
+			t.Errorf("%q segments = %v, want %v", tt.phrase, segments, tt.segments)
+		}
+	}
+}
+
+func TestThenPausesBetweenSegments(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	opts := e.Options()
+	opts.ThenDelayMs = 30
+	if err := e.SetOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	result := e.MustRun(t, "south then east then west then")
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("two boundaries took %v, want at least 60ms", elapsed)
+	}
+	// Each boundary is traced; the trailing "then" doesn't pause
+	var boundaries []string
+	for _, tok := range result.Tokens {
+		for _, timing := range tok.Timings {
+			boundaries = append(boundaries, timing.Name)
+		}
+	}
+	if want := []string{"segment 1", "segment 2"}; !slices.Equal(boundaries, want) {
+		t.Errorf("traced %q, want %q", boundaries, want)
+	}
+}
+
+func TestTimesRepeatsAGroup(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+
+	tests := []struct {
+		phrase string
+		keys   []string
+	}{
+		{"3 times tab", []string{"tab", "tab", "tab"}},
+		{"2 times west south", []string{"left", "down", "left", "down"}},
+		{"two times west 2", []string{"left", "left", "left", "left"}},
+		// The group ends at "then"
+		{"2 times west then east", []string{"left", "left", "right"}},
+		// halt inside the group stops the phrase, not just the pass
+		{"3 times west halt east", []string{"left"}},
+		{"0 times west", nil},
+	}
+	for _, tt := range tests {
+		snipertest.ExpectKeys(t, e, tt.phrase, tt.keys...)
+	}
+
+	// A KillAfter command ends its own pass only
+	snipertest.ExpectTyped(t, e, "2 times say hi", "Hi. Hi. ")
+
+	// Passes are traced on the times token
+	result := e.MustRun(t, "2 times west")
+	var names []string
+	for _, timing := range result.Tokens[0].Timings {
+		names = append(names, timing.Name)
+	}
+	if want := []string{"pass 1", "pass 2"}; !slices.Equal(names, want) {
+		t.Errorf("2 times traced %q, want %q", names, want)
+	}
+
+	// Without a count "times" is the word for "*"
+	snipertest.ExpectKeys(t, e, "times", "shift+8")
+}
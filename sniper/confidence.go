@@ -0,0 +1,23 @@
+package sniper
+
+import "strings"
+
+// DefaultConfidence is given to words that arrive without a confidence score.
+const DefaultConfidence = 1.0
+
+// SpokenWord is one recognized word and how sure the recognizer was about it.
+// This matches the per-word output of recognizers like Vosk.
+type SpokenWord struct {
+	W    string  `json:"w"`
+	Conf float64 `json:"conf"`
+}
+
+// SpokenWordsFromText splits plain text into words with full confidence.
+func SpokenWordsFromText(text string) []SpokenWord {
+	fields := strings.Fields(text)
+	words := make([]SpokenWord, len(fields))
+	for i, f := range fields {
+		words[i] = SpokenWord{W: f, Conf: DefaultConfidence}
+	}
+	return words
+}
@@ -0,0 +1,705 @@
+package sniper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ConfigPathEnv overrides the default config file location when set.
+const ConfigPathEnv = "SNIPER_CONFIG"
+
+// APITokenEnv sets the control API's bearer token, taking precedence over the config file.
+const APITokenEnv = "SNIPER_TOKEN"
+
+// Config is the user-editable ~/.sniper.json file.
+type Config struct {
+	// Aliases expand a spoken word into a phrase before tokenization ("scoot" -> "left 10").
+	Aliases map[string]string `json:"aliases,omitempty"`
+
+	// Macros map a spoken name to a list of phrases replayed in order.
+	Macros map[string][]string `json:"macros,omitempty"`
+
+	// Effects replaces the built-in effects of a command by name, e.g.
+	// {"click": ["wait_after:150"], "find": ["click_before"]}.
+	Effects map[string][]string `json:"effects,omitempty"`
+
+	// Homophones rewrite misheard words before tokenization ("cheerio" -> "charlie").
+	// Entries are layered over DefaultHomophones; an empty value removes a default.
+	Homophones map[string]string `json:"homophones,omitempty"`
+
+	// NumberHomophones turn ordinary words into number words ("to" -> "two")
+	// while the aggressive_numbers option is on. Entries are layered over
+	// DefaultNumberHomophones; an empty value removes a default.
+	NumberHomophones map[string]string `json:"number_homophones,omitempty"`
+
+	// AggressiveNumbers overrides the option of the same name when set.
+	AggressiveNumbers *bool `json:"aggressive_numbers,omitempty"`
+
+	// Modes add triggers that only apply while a mode is active:
+	// {"slides": {"slide": ["control right"]}} maps trigger -> phrases, like Macros.
+	Modes map[string]map[string][]string `json:"modes,omitempty"`
+
+	// WindowModes activate a mode while the focused window matches, e.g.
+	// [{"title": "- Visual Studio Code$", "mode": "vscode"}]. The first
+	// matching rule wins; see WindowRule.
+	WindowModes []WindowRule `json:"window_modes,omitempty"`
+
+	// Disabled lists command names (Name(), not triggers) that should not respond.
+	Disabled []string `json:"disabled,omitempty"`
+
+	// OverrideBuiltins lets aliases and macros take over built-in triggers. Defaults to true.
+	OverrideBuiltins *bool `json:"override_builtins,omitempty"`
+
+	// Layout selects the keyboard layout ("qwerty", "azerty"), overriding the
+	// layout option when set.
+	Layout string `json:"layout,omitempty"`
+
+	// Backend overrides the backend option ("robotgo", "xdotool", "ydotool") when set.
+	Backend string `json:"backend,omitempty"`
+
+	// Feedback names the built-in feedback providers told how every phrase
+	// went: "beep" and "notify". See FeedbackProvider.
+	Feedback []string `json:"feedback,omitempty"`
+
+	// Acronyms replaces DefaultAcronyms, the words "camel" and "pascal" write in
+	// capitals.
+	Acronyms []string `json:"acronyms,omitempty"`
+
+	// Priorities changes the order sources win in when they claim the same
+	// word, e.g. {"spot": 50} to let saved spots beat everything. Keys are
+	// "mode", "user", "spot" and "builtin"; see DefaultSourcePriorities.
+	Priorities map[string]int `json:"priorities,omitempty"`
+
+	// Cooldowns is how many milliseconds a command (by name) must rest after
+	// firing before it fires again, e.g. {"select_all": 1000}. It overrides
+	// the command's own Cooldown().
+	Cooldowns map[string]int `json:"cooldowns,omitempty"`
+
+	// AmbiguousWords replaces DefaultAmbiguousWords, the triggers the
+	// StrictAlphabet option switches off.
+	AmbiguousWords []string `json:"ambiguous_words,omitempty"`
+
+	// TypingDelayMs and PostReleaseDelayMs override the options of the same
+	// name when set.
+	TypingDelayMs      *int `json:"typing_delay_ms,omitempty"`
+	PostReleaseDelayMs *int `json:"post_release_delay_ms,omitempty"`
+
+	// TokenDelayUs overrides the option of the same name (Engine.Delay) when set.
+	TokenDelayUs *int `json:"token_delay_us,omitempty"`
+
+	// ThenDelayMs, MouseDelayMs, ClickGapMs and ClipboardDelayMs override the
+	// options of the same name when set. PUT /api/config/timings writes all
+	// of these.
+	ThenDelayMs      *int `json:"then_delay_ms,omitempty"`
+	MouseDelayMs     *int `json:"mouse_delay_ms,omitempty"`
+	ClickGapMs       *int `json:"click_gap_ms,omitempty"`
+	ClipboardDelayMs *int `json:"clipboard_delay_ms,omitempty"`
+
+	// APIToken, when set, must be sent as "Authorization: Bearer <token>" on
+	// every state-changing /api request. $SNIPER_TOKEN overrides it.
+	APIToken string `json:"api_token,omitempty"`
+}
+
+// ConfigConflict describes a user entry that collides with a built-in trigger.
+type ConfigConflict struct {
+	Word    string `json:"word"`
+	Kind    string `json:"kind"`    // "alias" or "macro"
+	Builtin string `json:"builtin"` // Name() of the built-in command
+	Winner  string `json:"winner"`  // "user" or "builtin"
+}
+
+// DefaultConfigPath returns $SNIPER_CONFIG, or ~/.sniper.json when unset.
+func DefaultConfigPath() string {
+	if path := os.Getenv(ConfigPathEnv); path != "" {
+		return path
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".sniper.json")
+}
+
+// LoadConfig reads the config file at path. A missing file is an empty config, not an error.
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// SaveConfig writes cfg to path as indented JSON.
+func SaveConfig(path string, cfg *Config) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// Validate checks that no alias expands into another alias (expansion is one level only)
+// and that every effect spec names a known effect.
+func (c *Config) Validate() error {
+	for name := range c.Modes {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if key == "" || key == ModeOff {
+			return fmt.Errorf("invalid mode name '%s'", name)
+		}
+	}
+	if c.Layout != "" {
+		if _, ok := LookupLayout(c.Layout); !ok {
+			return fmt.Errorf("unknown layout '%s' (want one of %s)", c.Layout, strings.Join(LayoutNames(), ", "))
+		}
+	}
+	if c.Backend != "" && !slices.Contains(Backends, c.Backend) {
+		return fmt.Errorf("unknown backend '%s' (want one of %s)", c.Backend, strings.Join(Backends, ", "))
+	}
+	for _, name := range c.Feedback {
+		if !slices.Contains(FeedbackNames, name) {
+			return fmt.Errorf("unknown feedback '%s' (want one of %s)", name, strings.Join(FeedbackNames, ", "))
+		}
+	}
+	if err := validPriorities(c.Priorities); err != nil {
+		return err
+	}
+	if err := validCooldowns(c.Cooldowns); err != nil {
+		return err
+	}
+	if _, err := compileWindowRules(c.WindowModes); err != nil {
+		return err
+	}
+	for _, delay := range []*int{c.TypingDelayMs, c.PostReleaseDelayMs, c.ThenDelayMs, c.MouseDelayMs, c.ClickGapMs, c.ClipboardDelayMs} {
+		if delay != nil && (*delay < 0 || *delay > MaxDelayMs) {
+			return fmt.Errorf("delays must be between 0 and %d milliseconds", MaxDelayMs)
+		}
+	}
+	if c.TokenDelayUs != nil && (*c.TokenDelayUs < 0 || *c.TokenDelayUs > MaxTokenDelayUs) {
+		return fmt.Errorf("token_delay_us must be between 0 and %d", MaxTokenDelayUs)
+	}
+	for word := range c.Homophones {
+		if len(strings.Fields(word)) != 1 {
+			return fmt.Errorf("homophone '%s' must be a single word", word)
+		}
+	}
+	for word, number := range c.NumberHomophones {
+		if len(strings.Fields(word)) != 1 || len(strings.Fields(number)) > 1 {
+			return fmt.Errorf("number homophone '%s' must map one word to one word", word)
+		}
+	}
+	for _, name := range sortedKeys(c.Effects) {
+		if _, err := ParseEffectSpecs(c.Effects[name]); err != nil {
+			return fmt.Errorf("effects for '%s': %w", name, err)
+		}
+	}
+
+	for _, word := range sortedKeys(c.Aliases) {
+		for _, target := range strings.Fields(c.Aliases[word]) {
+			if _, ok := c.Aliases[strings.ToLower(target)]; ok {
+				return fmt.Errorf("alias '%s' references alias '%s': aliases cannot expand into other aliases", word, target)
+			}
+		}
+	}
+	return nil
+}
+
+// clone returns a copy whose maps can be edited without touching c.
+func (c *Config) clone() *Config {
+	out := &Config{
+		Aliases:          make(map[string]string, len(c.Aliases)),
+		Macros:           make(map[string][]string, len(c.Macros)),
+		OverrideBuiltins: c.OverrideBuiltins,
+		APIToken:         c.APIToken,
+		Layout:           c.Layout,
+		Backend:          c.Backend,
+
+		TypingDelayMs:      c.TypingDelayMs,
+		PostReleaseDelayMs: c.PostReleaseDelayMs,
+		TokenDelayUs:       c.TokenDelayUs,
+		AggressiveNumbers:  c.AggressiveNumbers,
+		ThenDelayMs:        c.ThenDelayMs,
+		MouseDelayMs:       c.MouseDelayMs,
+		ClickGapMs:         c.ClickGapMs,
+		ClipboardDelayMs:   c.ClipboardDelayMs,
+	}
+	for k, v := range c.Aliases {
+		out.Aliases[k] = v
+	}
+	for k, v := range c.Macros {
+		out.Macros[k] = append([]string(nil), v...)
+	}
+	out.Disabled = append([]string(nil), c.Disabled...)
+	out.Acronyms = slices.Clone(c.Acronyms)
+	out.Feedback = slices.Clone(c.Feedback)
+	out.AmbiguousWords = slices.Clone(c.AmbiguousWords)
+	out.WindowModes = slices.Clone(c.WindowModes)
+	if c.Cooldowns != nil {
+		out.Cooldowns = make(map[string]int, len(c.Cooldowns))
+		for k, v := range c.Cooldowns {
+			out.Cooldowns[k] = v
+		}
+	}
+	if c.Priorities != nil {
+		out.Priorities = make(map[string]int, len(c.Priorities))
+		for k, v := range c.Priorities {
+			out.Priorities[k] = v
+		}
+	}
+	if c.Modes != nil {
+		out.Modes = make(map[string]map[string][]string, len(c.Modes))
+		for name, triggers := range c.Modes {
+			out.Modes[name] = make(map[string][]string, len(triggers))
+			for k, v := range triggers {
+				out.Modes[name][k] = append([]string(nil), v...)
+			}
+		}
+	}
+	if c.NumberHomophones != nil {
+		out.NumberHomophones = make(map[string]string, len(c.NumberHomophones))
+		for k, v := range c.NumberHomophones {
+			out.NumberHomophones[k] = v
+		}
+	}
+	if c.Homophones != nil {
+		out.Homophones = make(map[string]string, len(c.Homophones))
+		for k, v := range c.Homophones {
+			out.Homophones[k] = v
+		}
+	}
+	if c.Effects != nil {
+		out.Effects = make(map[string][]string, len(c.Effects))
+		for k, v := range c.Effects {
+			out.Effects[k] = append([]string(nil), v...)
+		}
+	}
+	return out
+}
+
+// overrideBuiltins reports whether user entries win over built-in triggers.
+func (c *Config) overrideBuiltins() bool {
+	return c.OverrideBuiltins == nil || *c.OverrideBuiltins
+}
+
+// ----------------------------------------------------------------------------
+// ENGINE INTEGRATION
+// ----------------------------------------------------------------------------
+
+// ReloadConfig rereads the config file and re-applies aliases and macros.
+// On error the previous configuration stays in effect.
+func (e *Engine) ReloadConfig() ([]ConfigConflict, error) {
+	e.configMu.Lock()
+	defer e.configMu.Unlock()
+
+	cfg, err := LoadConfig(e.ConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	return e.ApplyConfig(cfg), nil
+}
+
+// ApplyConfig rebuilds the registry from the built-in commands plus the user's
+// aliases and macros, returning any collisions with built-in triggers.
+func (e *Engine) ApplyConfig(cfg *Config) []ConfigConflict {
+	e.registryMu.Lock()
+	defer e.registryMu.Unlock()
+
+	e.config = cfg
+	e.registry = make(map[string]Cmd)
+	e.registerCommands()
+	builtins := e.registry
+
+	registry := make(map[string]Cmd, len(builtins))
+	for k, v := range builtins {
+		registry[k] = v
+	}
+	aliases := make(map[string]string)
+	user := make(map[string]Cmd)
+	conflicts := make([]ConfigConflict, 0)
+	priorities := e.sourcePriorities()
+	override := priorities[SourceUser] > priorities[SourceBuiltin]
+
+	// claim records a conflict (if any) and reports whether the user entry wins
+	claim := func(word, kind string) bool {
+		cmd, taken := builtins[word]
+		if !taken {
+			return true
+		}
+		winner := "builtin"
+		if override {
+			winner = "user"
+		}
+		conflicts = append(conflicts, ConfigConflict{Word: word, Kind: kind, Builtin: cmd.Name(), Winner: winner})
+		return override
+	}
+
+	for _, name := range sortedKeys(cfg.Macros) {
+		word := strings.ToLower(strings.TrimSpace(name))
+		if word == "" || !claim(word, "macro") {
+			continue
+		}
+		registry[word] = NewMacroCmd(word, cfg.Macros[name])
+		user[word] = registry[word]
+	}
+
+	for _, word := range sortedKeys(cfg.Aliases) {
+		key := strings.ToLower(strings.TrimSpace(word))
+		if key == "" || !claim(key, "alias") {
+			continue
+		}
+		// The alias shadows any command with the same trigger
+		delete(registry, key)
+		delete(user, key)
+		aliases[key] = cfg.Aliases[word]
+	}
+
+	// Specs were checked by Validate, so parse errors can't happen here
+	overrides := make(map[string][]NamedEffect, len(cfg.Effects))
+	for name, specs := range cfg.Effects {
+		if effects, err := ParseEffectSpecs(specs); err == nil {
+			overrides[strings.ToLower(name)] = effects
+		}
+	}
+
+	e.registry = registry
+	e.builtinTriggers = make(map[string]Cmd, len(builtins))
+	for k, v := range builtins {
+		if _, shadowed := aliases[k]; !shadowed {
+			e.builtinTriggers[k] = v
+		}
+	}
+	e.userTriggers = user
+	e.aliases = aliases
+	e.homophones = cfg.homophoneTable()
+	e.numberHomophones = cfg.numberHomophoneTable()
+	e.modes = e.buildModes(cfg)
+	if _, ok := e.modes[e.activeMode]; !ok && e.activeMode != "" {
+		e.activeMode = ""
+		e.publishMode("")
+	}
+	if _, ok := e.modes[e.manualMode]; !ok {
+		e.manualMode = ""
+	}
+	// Rules were checked by Validate
+	e.windowRules, _ = compileWindowRules(cfg.WindowModes)
+	e.effectOverrides = overrides
+
+	e.applyConfigOptions(cfg)
+	e.useFeedback(cfg.Feedback)
+	if cfg.Acronyms != nil {
+		e.StickyKeyboard.SetAcronyms(cfg.Acronyms)
+	} else {
+		e.StickyKeyboard.SetAcronyms(DefaultAcronyms)
+	}
+
+	for _, c := range conflicts {
+		e.log().Warn("config entry collides with built-in", "kind", c.Kind, "word", c.Word, "builtin", c.Builtin, "winner", c.Winner)
+	}
+	return conflicts
+}
+
+// applyConfigOptions copies the engine options set in the config file over
+// the current ones.
+func (e *Engine) applyConfigOptions(cfg *Config) {
+	opts := e.Options()
+	if layout, ok := LookupLayout(cfg.Layout); ok && cfg.Layout != "" {
+		opts.Layout = layout.Name
+	}
+	if cfg.Backend != "" {
+		opts.Backend = cfg.Backend
+	}
+	if cfg.TypingDelayMs != nil {
+		opts.TypingDelayMs = *cfg.TypingDelayMs
+	}
+	if cfg.PostReleaseDelayMs != nil {
+		opts.PostReleaseDelayMs = *cfg.PostReleaseDelayMs
+	}
+	if cfg.TokenDelayUs != nil {
+		opts.TokenDelayUs = *cfg.TokenDelayUs
+	}
+	if cfg.AggressiveNumbers != nil {
+		opts.AggressiveNumbers = *cfg.AggressiveNumbers
+	}
+	if cfg.ThenDelayMs != nil {
+		opts.ThenDelayMs = *cfg.ThenDelayMs
+	}
+	if cfg.MouseDelayMs != nil {
+		opts.MouseDelayMs = *cfg.MouseDelayMs
+	}
+	if cfg.ClickGapMs != nil {
+		opts.ClickGapMs = *cfg.ClickGapMs
+	}
+	if cfg.ClipboardDelayMs != nil {
+		opts.ClipboardDelayMs = *cfg.ClipboardDelayMs
+	}
+	if err := e.SetOptions(opts); err != nil {
+		e.log().Error("ignoring config options", "error", err)
+	}
+}
+
+// expandAliases replaces every whole word that is an alias with its expansion.
+// Expansions are not themselves expanded again.
+func (e *Engine) expandAliases(input string) string {
+	if len(e.aliases) == 0 {
+		return input
+	}
+
+	words := strings.Fields(input)
+	for i, w := range words {
+		if expansion, ok := e.aliases[strings.ToLower(w)]; ok {
+			words[i] = expansion
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ----------------------------------------------------------------------------
+// RUNTIME ALIASES
+// ----------------------------------------------------------------------------
+
+// ErrAliasNotFound is returned when deleting or reading an alias that doesn't exist.
+var ErrAliasNotFound = errors.New("alias not found")
+
+// Aliases returns a copy of the active aliases.
+func (e *Engine) Aliases() map[string]string {
+	e.registryMu.RLock()
+	defer e.registryMu.RUnlock()
+
+	out := make(map[string]string, len(e.aliases))
+	for k, v := range e.aliases {
+		out[k] = v
+	}
+	return out
+}
+
+// SetAlias teaches the engine that word expands to expansion, effective immediately,
+// and persists it to the config file.
+func (e *Engine) SetAlias(word, expansion string) error {
+	word = strings.ToLower(strings.TrimSpace(word))
+	expansion = strings.TrimSpace(expansion)
+	if word == "" || strings.ContainsAny(word, " \t") {
+		return fmt.Errorf("alias must be a single word")
+	}
+	if expansion == "" {
+		return fmt.Errorf("alias '%s' needs an expansion", word)
+	}
+
+	return e.updateConfig(func(cfg *Config) {
+		cfg.Aliases[word] = expansion
+	})
+}
+
+// DeleteAlias removes an alias and persists the change.
+func (e *Engine) DeleteAlias(word string) error {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if _, ok := e.Aliases()[word]; !ok {
+		return ErrAliasNotFound
+	}
+
+	return e.updateConfig(func(cfg *Config) {
+		delete(cfg.Aliases, word)
+	})
+}
+
+// updateConfig applies edit to a copy of the current config, validates it,
+// saves it to ConfigPath and, once that worked, makes it live. Edits are
+// serialized, so concurrent ones can't lose each other's changes.
+func (e *Engine) updateConfig(edit func(cfg *Config)) error {
+	e.configMu.Lock()
+	defer e.configMu.Unlock()
+
+	e.registryMu.RLock()
+	current := e.config
+	e.registryMu.RUnlock()
+	if current == nil {
+		current = &Config{}
+	}
+
+	cfg := current.clone()
+	edit(cfg)
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	if err := SaveConfig(e.ConfigPath, cfg); err != nil {
+		return err
+	}
+	e.ApplyConfig(cfg)
+	return nil
+}
+
+// APIToken returns the control API's bearer token: $SNIPER_TOKEN, or the
+// config file's api_token. An empty string means no token is configured.
+func (e *Engine) APIToken() string {
+	if token := os.Getenv(APITokenEnv); token != "" {
+		return token
+	}
+	e.registryMu.RLock()
+	defer e.registryMu.RUnlock()
+	if e.config == nil {
+		return ""
+	}
+	return e.config.APIToken
+}
+
+// effectOverride returns the configured effects for a command name, if any.
+func (e *Engine) effectOverride(name string) ([]NamedEffect, bool) {
+	e.registryMu.RLock()
+	defer e.registryMu.RUnlock()
+	effects, ok := e.effectOverrides[strings.ToLower(name)]
+	return effects, ok
+}
+
+// ----------------------------------------------------------------------------
+// HOMOPHONES
+// ----------------------------------------------------------------------------
+
+// DefaultHomophones are the recognizer quirks every engine starts with.
+// Users can remove one by mapping it to "" in the config file.
+var DefaultHomophones = map[string]string{
+	"write": "right",
+	"safe":  "save",
+}
+
+// DefaultNumberHomophones are words recognizers hear in place of numbers.
+// They are also ordinary words ("I want to go"), so they only apply with
+// the AggressiveNumbers option, and never to dictated words.
+var DefaultNumberHomophones = map[string]string{
+	"too": "two",
+	"to":  "two",
+	"tin": "ten",
+}
+
+// homophoneTable layers the config entries over DefaultHomophones.
+func (c *Config) homophoneTable() map[string]string {
+	return layerHomophones(DefaultHomophones, c.Homophones)
+}
+
+// numberHomophoneTable layers the config entries over DefaultNumberHomophones.
+func (c *Config) numberHomophoneTable() map[string]string {
+	return layerHomophones(DefaultNumberHomophones, c.NumberHomophones)
+}
+
+// layerHomophones copies defaults and applies entries over them; an empty
+// entry removes a default.
+func layerHomophones(defaults, entries map[string]string) map[string]string {
+	table := make(map[string]string, len(defaults)+len(entries))
+	for k, v := range defaults {
+		table[k] = v
+	}
+	for k, v := range entries {
+		key := strings.ToLower(strings.TrimSpace(k))
+		if v == "" {
+			delete(table, key)
+			continue
+		}
+		table[key] = strings.ToLower(v)
+	}
+	return table
+}
+
+// applyHomophones replaces whole words (case-insensitively) using the homophone table.
+// Callers hold registryMu.
+func (e *Engine) applyHomophones(input string) string {
+	if len(e.homophones) == 0 {
+		return input
+	}
+
+	words := strings.Fields(input)
+	for i, w := range words {
+		if replacement, ok := e.homophones[strings.ToLower(w)]; ok {
+			words[i] = replacement
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// numberWords returns words with the number homophones applied, or words
+// itself while the AggressiveNumbers option is off. Callers hold registryMu.
+func (e *Engine) numberWords(words []string) []string {
+	if !e.Options().AggressiveNumbers || len(e.numberHomophones) == 0 {
+		return words
+	}
+	out := make([]string, len(words))
+	for i, w := range words {
+		out[i] = w
+		if number, ok := e.numberHomophones[strings.ToLower(w)]; ok {
+			out[i] = number
+		}
+	}
+	return out
+}
+
+// Homophones returns a copy of the active homophone table (defaults included).
+func (e *Engine) Homophones() map[string]string {
+	e.registryMu.RLock()
+	defer e.registryMu.RUnlock()
+
+	out := make(map[string]string, len(e.homophones))
+	for k, v := range e.homophones {
+		out[k] = v
+	}
+	return out
+}
+
+// SetHomophones replaces the whole active table and saves it to the config file.
+// Defaults missing from table are recorded as removed.
+func (e *Engine) SetHomophones(table map[string]string) error {
+	stored := make(map[string]string)
+	for k, v := range table {
+		key := strings.ToLower(strings.TrimSpace(k))
+		if v == "" || DefaultHomophones[key] == strings.ToLower(v) {
+			continue
+		}
+		stored[key] = strings.ToLower(v)
+	}
+	for k := range DefaultHomophones {
+		if _, kept := table[k]; !kept {
+			stored[k] = ""
+		}
+	}
+
+	return e.updateConfig(func(cfg *Config) {
+		cfg.Homophones = stored
+	})
+}
+
+// multiWordTriggers indexes a registry's triggers that contain spaces, keyed the
+// way they will appear after normalize ("go to sleep" is looked up as "go two sleep").
+// It also returns the longest trigger length in words.
+func multiWordTriggers(registry map[string]Cmd, normalize func(string) string) (map[string]Cmd, int) {
+	triggers := make(map[string]Cmd)
+	maxSpan := 0
+	for key, cmd := range registry {
+		if !strings.Contains(key, " ") {
+			continue
+		}
+		normalized := strings.Join(strings.Fields(normalize(key)), " ")
+		triggers[normalized] = cmd
+		maxSpan = max(maxSpan, len(strings.Fields(normalized)))
+	}
+	return triggers, maxSpan
+}
@@ -0,0 +1,81 @@
+package sniper_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/phillip-england/sniper/sniper"
+	"github.com/phillip-england/sniper/sniper/snipertest"
+)
+
+func TestConcurrentAliasEditsAreAllKept(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := e.SetAlias(fmt.Sprintf("scoot%d", i), "left 10"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := len(e.Aliases()); got != 20 {
+		t.Errorf("%d aliases live, want 20", got)
+	}
+	saved, err := sniper.LoadConfig(e.ConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(saved.Aliases); got != 20 {
+		t.Errorf("%d aliases saved, want 20", got)
+	}
+}
+
+func TestFailedSaveLeavesConfigUnchanged(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.ConfigPath = t.TempDir() // a directory can't be written as a file
+
+	if err := e.SetAlias("scoot", "left 10"); err == nil {
+		t.Fatal("SetAlias succeeded without saving")
+	}
+	if _, ok := e.Aliases()["scoot"]; ok {
+		t.Error("alias went live although it wasn't saved")
+	}
+}
+
+func TestAliasesCannotReferenceAliases(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	if err := e.SetAlias("scoot", "south 3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SetAlias("dash", "scoot then up"); err == nil {
+		t.Error("an alias expanding into another alias was accepted")
+	}
+	snipertest.ExpectKeys(t, e, "scoot", "down", "down", "down")
+}
+
+func TestNumberHomophonesConfig(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	on := true
+	e.ApplyConfig(&sniper.Config{
+		AggressiveNumbers: &on,
+		NumberHomophones:  map[string]string{"for": "four", "tin": ""},
+	})
+	if !e.Options().AggressiveNumbers {
+		t.Fatal("aggressive_numbers from the config wasn't applied")
+	}
+	snipertest.ExpectKeys(t, e, "west for", "left", "left", "left", "left")
+	snipertest.ExpectKeys(t, e, "west tin", "left") // the default was removed
+	snipertest.ExpectKeys(t, e, "west to", "left", "left")
+	snipertest.ExpectTyped(t, e, "say for to tin", "For to tin. ")
+
+	bad := &sniper.Config{NumberHomophones: map[string]string{"to": "two three"}}
+	if err := bad.Validate(); err == nil {
+		t.Error("a number homophone of two words was accepted")
+	}
+}
@@ -0,0 +1,88 @@
+package sniper
+
+import (
+	"fmt"
+	"time"
+)
+
+// ----------------------------------------------------------------------------
+// COOLDOWNS
+// ----------------------------------------------------------------------------
+//
+// A command with a cooldown won't fire again, anywhere in the engine, until
+// the cooldown has passed since it last fired. Firing it early skips the
+// token (ErrSkip) instead of failing the phrase.
+
+// Cooldowner is implemented by commands that must not fire twice within
+// Cooldown(). The config file's "cooldowns" override it by command name.
+type Cooldowner interface {
+	Cooldown() time.Duration
+}
+
+// ErrCooldown is returned for a command fired again within its cooldown. It
+// wraps ErrSkip, so the token is recorded as skipped.
+var ErrCooldown = fmt.Errorf("%w: command is cooling down", ErrSkip)
+
+// cooldownFor returns how long cmd must rest after firing; 0 means no cooldown.
+func (e *Engine) cooldownFor(cmd Cmd) time.Duration {
+	e.registryMu.RLock()
+	ms, configured := 0, false
+	if e.config != nil {
+		ms, configured = e.config.Cooldowns[cmd.Name()]
+	}
+	e.registryMu.RUnlock()
+
+	if configured {
+		return time.Duration(ms) * time.Millisecond
+	}
+	if c, ok := cmd.(Cooldowner); ok {
+		return c.Cooldown()
+	}
+	return 0
+}
+
+// checkCooldown returns ErrCooldown when cmd fired less than its cooldown
+// ago, and otherwise records now as its last firing.
+func (e *Engine) checkCooldown(cmd Cmd) error {
+	d := e.cooldownFor(cmd)
+	if d <= 0 {
+		return nil
+	}
+	now := e.Now()
+
+	e.cooldownMu.Lock()
+	last, fired := e.lastFired[cmd.Name()]
+	if fired && now.Sub(last) < d {
+		e.cooldownMu.Unlock()
+		e.State.traceEffects([]string{"cooldown"})
+		e.log().Debug("command cooling down", "command", cmd.Name(), "remaining", d-now.Sub(last))
+		return ErrCooldown
+	}
+	e.lastFired[cmd.Name()] = now
+	e.cooldownMu.Unlock()
+	return nil
+}
+
+// ResetCooldowns forgets when the named commands last fired, or every
+// command when no names are given, so they can fire again immediately.
+func (e *Engine) ResetCooldowns(names ...string) {
+	e.cooldownMu.Lock()
+	defer e.cooldownMu.Unlock()
+	if len(names) == 0 {
+		clear(e.lastFired)
+		return
+	}
+	for _, name := range names {
+		delete(e.lastFired, name)
+	}
+}
+
+// validCooldowns checks the config's "cooldowns".
+func validCooldowns(cooldowns map[string]int) error {
+	for name, ms := range cooldowns {
+		if ms < 0 {
+			return fmt.Errorf("cooldown of '%s' cannot be negative", name)
+		}
+	}
+	return nil
+}
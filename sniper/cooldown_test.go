@@ -0,0 +1,129 @@
+package sniper_test
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phillip-england/sniper/sniper"
+	"github.com/phillip-england/sniper/sniper/snipertest"
+)
+
+// chillCmd presses enter, at most once a second.
+type chillCmd struct{}
+
+func (chillCmd) Name() string                  { return "chill" }
+func (chillCmd) CalledBy() []string            { return []string{"chill"} }
+func (chillCmd) Effects() []sniper.NamedEffect { return nil }
+func (chillCmd) Cooldown() time.Duration       { return time.Second }
+func (chillCmd) Action(e *sniper.Engine, p string) error {
+	e.StickyKeyboard.Enter()
+	return nil
+}
+
+// fakeClock is an Engine.Now that only moves when told to.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func cooldownEngine(t *testing.T) (*snipertest.Engine, *fakeClock) {
+	e := snipertest.NewTestEngine(t)
+	if err := e.Register(chillCmd{}); err != nil {
+		t.Fatal(err)
+	}
+	clock := &fakeClock{now: time.Date(2024, 3, 9, 14, 5, 0, 0, time.UTC)}
+	e.Now = clock.Now
+	return e, clock
+}
+
+func TestCooldownSkipsEarlyFiring(t *testing.T) {
+	e, clock := cooldownEngine(t)
+
+	snipertest.ExpectKeys(t, e, "chill", "enter")
+	clock.Advance(500 * time.Millisecond)
+	result := e.MustRun(t, "chill east")
+	if got := e.Input.Keys(); !slices.Equal(got, []string{"right"}) {
+		t.Errorf("chill within its cooldown tapped %q, want only right", got)
+	}
+	want := []sniper.TokenOutcome{sniper.OutcomeEffectSkipped, sniper.OutcomeHandled}
+	if got := outcomes(result); !slices.Equal(got, want) {
+		t.Errorf("outcomes = %q, want %q", got, want)
+	}
+	if trace := result.Tokens[0].Effects; !slices.Contains(trace, "cooldown") {
+		t.Errorf("trace = %q, want it to note the cooldown", trace)
+	}
+
+	// A skipped firing doesn't restart the cooldown
+	clock.Advance(500 * time.Millisecond)
+	snipertest.ExpectKeys(t, e, "chill", "enter")
+
+	// Repeats within the phrase are firings too
+	clock.Advance(time.Second)
+	snipertest.ExpectKeys(t, e, "chill 3", "enter")
+}
+
+func TestCooldownConfig(t *testing.T) {
+	e, clock := cooldownEngine(t)
+
+	// The config gives built-ins a cooldown and overrides a command's own
+	e.ApplyConfig(&sniper.Config{Cooldowns: map[string]int{"east": 1000, "chill": 0}})
+	snipertest.ExpectKeys(t, e, "east then east", "right")
+	snipertest.ExpectKeys(t, e, "chill then chill", "enter", "enter")
+	clock.Advance(time.Second)
+	snipertest.ExpectKeys(t, e, "east", "right")
+
+	if err := (&sniper.Config{Cooldowns: map[string]int{"east": -1}}).Validate(); err == nil {
+		t.Error("a negative cooldown validated")
+	}
+}
+
+func TestResetCooldowns(t *testing.T) {
+	e, _ := cooldownEngine(t)
+	e.ApplyConfig(&sniper.Config{Cooldowns: map[string]int{"east": 1000}})
+
+	e.MustRun(t, "chill east")
+	e.ResetCooldowns("chill")
+	snipertest.ExpectKeys(t, e, "chill east", "enter")
+
+	e.ResetCooldowns()
+	snipertest.ExpectKeys(t, e, "chill east", "enter", "right")
+}
+
+func TestCooldownsAreEngineWide(t *testing.T) {
+	e, _ := cooldownEngine(t)
+
+	// Phrases from several goroutines share one cooldown, and resets can come
+	// in at any time
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := e.Submit("chill", "phrase"); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			e.ResetCooldowns("east")
+		}()
+	}
+	wg.Wait()
+	if got := len(e.Input.Keys()); got != 1 {
+		t.Errorf("chill fired %d times under one clock tick, want 1", got)
+	}
+}
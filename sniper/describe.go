@@ -0,0 +1,244 @@
+package sniper
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// COMMAND METADATA
+// ----------------------------------------------------------------------------
+//
+// Commands only have to implement Cmd. The interfaces below are optional and
+// let a command describe itself for the registry JSON and the /signs page.
+
+// Describer is implemented by commands with a human-readable description.
+type Describer interface {
+	Description() string
+}
+
+// Categorizer is implemented by commands that pick their own category.
+// Built-ins get theirs from RegistryGroups.
+type Categorizer interface {
+	Category() string
+}
+
+// ArgConsumer is implemented by commands that read the words after them
+// without using the ConsumeArgs effect (e.g. "say", "wait 5").
+type ArgConsumer interface {
+	ConsumesArgs() bool
+}
+
+// ArgTaker is implemented by commands that take a fixed number of the tokens
+// after them as arguments. The dispatcher claims up to ArgCount() tokens (fewer
+// at the end of a phrase) and passes them to ActionWithArgs instead of calling Action.
+type ArgTaker interface {
+	ArgCount() int
+	ActionWithArgs(e *Engine, args []Token) error
+}
+
+// RegistryGroup is one section of the built-in registry.
+type RegistryGroup struct {
+	Category string
+	Commands []Cmd
+}
+
+func flattenRegistryGroups(groups []RegistryGroup) []Cmd {
+	cmds := make([]Cmd, 0)
+	for _, g := range groups {
+		cmds = append(cmds, g.Commands...)
+	}
+	return cmds
+}
+
+// categoryDescriptions are used for commands that don't implement Describer.
+var categoryDescriptions = map[string]string{
+	"Modifiers":                    "Holds a modifier for the next key",
+	"Navigation":                   "Presses an arrow key",
+	"Editing":                      "Presses an editing key",
+	"Symbols (Basic Punctuation)":  "Types a punctuation mark",
+	"Symbols (Slashes & Bars)":     "Types a slash or bar",
+	"Symbols (Grouping)":           "Types an opening or closing bracket",
+	"Symbols (Math & Logic)":       "Types a math or logic symbol",
+	"Symbols (Special Characters)": "Types a special character",
+	"Alphabet":                     "Types a letter",
+	"Numbers":                      "Types the number that follows",
+	"Function Keys":                "Presses a function key",
+	"Mouse":                        "Moves or clicks the mouse",
+	"Formatting":                   "Types text",
+	"Generated Text":               "Types generated text",
+	"Shortcuts":                    "Sends a keyboard shortcut",
+	"Advanced Actions":             "Clicks to focus, then sends a shortcut",
+	"History":                      "Replays earlier phrases",
+	"Macros":                       "Records or plays macros",
+	"Utility":                      "Controls the engine",
+	"Memory":                       "Manages saved mouse spots",
+}
+
+// CustomCategory is the category of commands that aren't built-in and don't implement Categorizer.
+const CustomCategory = "Custom"
+
+// builtinCategories maps a built-in command's Name() to its RegistryGroups category.
+var builtinCategories = func() map[string]string {
+	out := make(map[string]string)
+	for _, g := range RegistryGroups {
+		for _, cmd := range g.Commands {
+			out[cmd.Name()] = g.Category
+		}
+	}
+	return out
+}()
+
+// CommandCategory returns the command's category.
+func CommandCategory(cmd Cmd) string {
+	if c, ok := cmd.(Categorizer); ok {
+		return c.Category()
+	}
+	if category, ok := builtinCategories[cmd.Name()]; ok {
+		return category
+	}
+	return CustomCategory
+}
+
+// CommandDescription returns the command's description, falling back to its category's.
+func CommandDescription(cmd Cmd) string {
+	if d, ok := cmd.(Describer); ok {
+		return d.Description()
+	}
+	return categoryDescriptions[CommandCategory(cmd)]
+}
+
+// CommandConsumesArgs reports whether the command reads the words after it.
+func CommandConsumesArgs(cmd Cmd) bool {
+	if a, ok := cmd.(ArgConsumer); ok && a.ConsumesArgs() {
+		return true
+	}
+	if a, ok := cmd.(ArgTaker); ok && a.ArgCount() > 0 {
+		return true
+	}
+	for _, name := range EffectNames(cmd.Effects()) {
+		if name == "consume_args" {
+			return true
+		}
+	}
+	return false
+}
+
+// CommandDictates reports whether the command types or copies the rest of
+// its segment as text, like "say" and the case formatters: it consumes the
+// words after it and ends the segment with KillAfter.
+func CommandDictates(cmd Cmd) bool {
+	if a, ok := cmd.(ArgConsumer); !ok || !a.ConsumesArgs() {
+		return false
+	}
+	return slices.Contains(EffectNames(cmd.Effects()), "kill_after")
+}
+
+// EffectNames returns the config-file names ("wait_after", "click_before", ...)
+// of a list of effects. Arguments such as durations are not recoverable.
+func EffectNames(effects []NamedEffect) []string {
+	names := make([]string, 0, len(effects))
+	for _, eff := range effects {
+		switch {
+		case eff.Fn == nil:
+		case eff.Name == "":
+			names = append(names, "custom")
+		default:
+			names = append(names, eff.Name)
+		}
+	}
+	return names
+}
+
+// cmdToJSON builds the exported view of a command.
+func cmdToJSON(cmd Cmd) CmdJSON {
+	return CmdJSON{
+		Name:         cmd.Name(),
+		CalledBy:     cmd.CalledBy(),
+		Description:  CommandDescription(cmd),
+		Category:     CommandCategory(cmd),
+		Effects:      EffectNames(cmd.Effects()),
+		ConsumesArgs: CommandConsumesArgs(cmd),
+	}
+}
+
+// ----------------------------------------------------------------------------
+// WORD LOOKUP
+// ----------------------------------------------------------------------------
+
+// ExplainWord answers "what does this word do?" in one line, resolving word
+// the way Parse would: a command (with its description and the other words
+// that trigger it), a saved spot, a number, or nothing.
+func (e *Engine) ExplainWord(word string) string {
+	fuzzyDistance := 0
+	if opts := e.Options(); opts.FuzzyMatch {
+		fuzzyDistance = opts.FuzzyDistance
+	}
+
+	e.registryMu.RLock()
+	defer e.registryMu.RUnlock()
+
+	resolver := e.resolver()
+	word = strings.ToLower(trimWordPunct(word))
+	switch t := TokenFactory(word, resolver, fuzzyDistance).(type) {
+	case *CmdToken:
+		cmd := t.Command()
+		if spot, ok := cmd.(*SpotCmd); ok {
+			return fmt.Sprintf("%s: saved spot at %d, %d", word, spot.TargetX, spot.TargetY)
+		}
+
+		line := word + ": " + cmd.Name()
+		if t.FuzzyTrigger() != "" {
+			line += " (heard as " + t.FuzzyTrigger() + ")"
+		}
+		if d := CommandDescription(cmd); d != "" {
+			line += " - " + d
+		}
+
+		siblings := make([]string, 0)
+		for trigger, other := range resolver.Triggers() {
+			if other.Name() == cmd.Name() && trigger != t.Literal() && trigger != t.FuzzyTrigger() {
+				siblings = append(siblings, trigger)
+			}
+		}
+		if len(siblings) > 0 {
+			slices.Sort(siblings)
+			line += " (also: " + strings.Join(siblings, ", ") + ")"
+		}
+		return line
+	case *NumberToken:
+		return fmt.Sprintf("%s: the number %d, repeats the command before it", word, t.Value())
+	default:
+		return word + ": not a command, spot or number"
+	}
+}
+
+// DryRun parses a phrase the way Submit would and reports what each token
+// resolved to, without running it: nothing is typed or clicked, and the
+// phrase never becomes history or something a number can repeat. Every
+// token is OutcomeNotRun. A phrase that breaks an input limit returns the
+// InputError.
+func (e *Engine) DryRun(input string, mode string) (ExecutionResult, error) {
+	result := ExecutionResult{Input: input}
+	if err := e.CheckInput(input); err != nil {
+		return result, err
+	}
+
+	state := e.parseWords(SpokenWordsFromText(input), mode)
+	result.Normalized = strings.Join(state.RawWords, " ")
+	result.Mode = state.ExecutionMode
+	result.ModeDetected = state.ModeDetected
+	result.Tokens = state.historyTokens()
+	return result, e.checkRepeats(state)
+}
+
+// HelpSummary says how many commands, saved spots and modes the engine has.
+func (e *Engine) HelpSummary() string {
+	e.registryMu.RLock()
+	commands, modes := len(e.commands), len(e.modes)
+	e.registryMu.RUnlock()
+
+	return fmt.Sprintf("%d commands, %d spots, %d modes; say help and a word to look it up",
+		commands, len(e.Memory.Names()), modes)
+}
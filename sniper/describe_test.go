@@ -0,0 +1,222 @@
+package sniper_test
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/phillip-england/sniper/sniper"
+	"github.com/phillip-england/sniper/sniper/snipertest"
+)
+
+func TestHelpLooksUpWords(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.Memory.Set("banana", 10, 20)
+
+	tests := []struct{ phrase, want string }{
+		{"help ascend", "ascend: page_up - Presses an editing key (also: climb)"},
+		{"help banana", "banana: saved spot at 10, 20"},
+		{"help five", "5: the number 5, repeats the command before it"},
+		{"help zebra", "zebra: not a command, spot or number"},
+		{"help Climb.", "climb: page_up - Presses an editing key (also: ascend)"},
+	}
+	for _, tt := range tests {
+		result := e.MustRun(t, tt.phrase)
+		if len(result.Outputs) != 1 || result.Outputs[0].Value != tt.want {
+			t.Errorf("%q answered %+v, want %q", tt.phrase, result.Outputs, tt.want)
+		}
+		if got := e.Input.Typed(); got != tt.want {
+			t.Errorf("%q typed %q, want %q", tt.phrase, got, tt.want)
+		}
+		if keys := e.Input.Keys(); len(keys) > 0 {
+			t.Errorf("%q also ran %q", tt.phrase, keys)
+		}
+	}
+}
+
+func TestHelpSummary(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.Memory.Set("banana", 10, 20)
+
+	result := e.MustRun(t, "help")
+	want := e.HelpSummary()
+	if len(result.Outputs) != 1 || result.Outputs[0].Value != want {
+		t.Errorf("help answered %+v, want %q", result.Outputs, want)
+	}
+	if want != "" && e.Input.Typed() != want {
+		t.Errorf("help typed %q, want %q", e.Input.Typed(), want)
+	}
+}
+
+func TestSilentHelp(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	opts := e.Options()
+	opts.SilentHelp = true
+	if err := e.SetOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	result := e.MustRun(t, "help ascend")
+	if len(result.Outputs) != 1 || result.Outputs[0].Command != "help" {
+		t.Fatalf("outputs = %+v, want the answer", result.Outputs)
+	}
+	if ops := e.Input.Ops(); len(ops) > 0 {
+		t.Errorf("silent help sent %q", ops)
+	}
+}
+
+func TestExplainWordMatchesParse(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	for _, word := range []string{"climb", "copy", "south"} {
+		cmd, ok := findCommand(e.Commands(), word)
+		if !ok {
+			t.Fatalf("no command for %q", word)
+		}
+		want := word + ": " + cmd.Name() + " - " + sniper.CommandDescription(cmd)
+		if got := e.ExplainWord(word); !strings.HasPrefix(got, want) {
+			t.Errorf("ExplainWord(%q) = %q, want it to start with %q", word, got, want)
+		}
+	}
+}
+
+func findCommand(cmds []sniper.Cmd, trigger string) (sniper.Cmd, bool) {
+	for _, cmd := range cmds {
+		if slices.Contains(cmd.CalledBy(), trigger) {
+			return cmd, true
+		}
+	}
+	return nil, false
+}
+
+func TestEffectNames(t *testing.T) {
+	always := func(*sniper.Engine) bool { return true }
+	unnamed := func(e *sniper.Engine, next func() error) error { return next() }
+
+	effects := []sniper.NamedEffect{
+		sniper.WaitBefore(10), sniper.WaitAfter(10), sniper.KillAfter(),
+		sniper.ClickBefore(), sniper.ClickAfter(), sniper.When(always, sniper.KillAfter()),
+		sniper.Timed("x"), sniper.RestorePositionAfter(), sniper.Retry(2, 0),
+		sniper.RetryExponential(2, 0), sniper.SnapshotClipboard(), sniper.ConsumeArgs(1),
+		{Fn: unnamed}, sniper.Named("beep_after", unnamed), {},
+	}
+	want := []string{
+		"wait_before", "wait_after", "kill_after",
+		"click_before", "click_after", "when",
+		"timed", "restore_position_after", "retry",
+		"retry", "snapshot_clipboard", "consume_args",
+		"custom", "beep_after",
+	}
+	if got := sniper.EffectNames(effects); !slices.Equal(got, want) {
+		t.Errorf("EffectNames = %q\nwant %q", got, want)
+	}
+}
+
+func TestRegistryJSON(t *testing.T) {
+	minimal, full, err := sniper.RegistryToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	snipertest.ExpectGoldenText(t, "registry_full", full+"\n")
+	snipertest.ExpectGoldenText(t, "registry_min", minimal+"\n")
+}
+
+func TestEngineRegistryJSON(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	if err := e.Disable("page_up"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, full, err := e.RegistryToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cmds []sniper.CmdJSON
+	if err := json.Unmarshal([]byte(full), &cmds); err != nil {
+		t.Fatal(err)
+	}
+	for _, cmd := range cmds {
+		if cmd.Disabled != (cmd.Name == "page_up") {
+			t.Errorf("%s: disabled = %v", cmd.Name, cmd.Disabled)
+		}
+	}
+}
+
+func TestRegistrySnapshotIsCached(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	snapshot := func() *sniper.RegistrySnapshot {
+		t.Helper()
+		snap, err := e.RegistrySnapshot()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return snap
+	}
+
+	first := snapshot()
+	if snapshot() != first {
+		t.Error("the snapshot was rebuilt without a registry change")
+	}
+	e.MustRun(t, "east")
+	if snapshot() != first {
+		t.Error("running a phrase rebuilt the snapshot")
+	}
+
+	changes := []struct {
+		name   string
+		change func() error
+	}{
+		{"disable", func() error { return e.Disable("page_up") }},
+		{"enable", func() error { return e.Enable("page_up") }},
+		{"mode switch", func() error { return e.SetMode("vim") }},
+	}
+	last := first
+	for _, tt := range changes {
+		if err := tt.change(); err != nil {
+			t.Fatal(err)
+		}
+		snap := snapshot()
+		if snap == last {
+			t.Errorf("%s: the snapshot wasn't rebuilt", tt.name)
+		}
+		last = snap
+	}
+
+	// Disabling shows in the JSON, so the ETag moves with it
+	if err := e.Disable("page_up"); err != nil {
+		t.Fatal(err)
+	}
+	if snapshot().FullETag == first.FullETag {
+		t.Error("disabling a command left the ETag unchanged")
+	}
+}
+
+func TestDryRunLeavesTheEngineAlone(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.MustRun(t, "east")
+	history := len(e.History.Recent(10))
+	e.Input.Reset()
+
+	result, err := e.DryRun("West 3", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Normalized != "west 3" || result.Mode != sniper.ModeRapid || !result.ModeDetected {
+		t.Errorf("result = %+v", result)
+	}
+	if len(result.Tokens) != 2 || result.Tokens[0].Command != "west" || result.Tokens[0].Outcome != sniper.OutcomeNotRun {
+		t.Errorf("tokens = %+v", result.Tokens)
+	}
+	if ops := e.Input.Ops(); len(ops) > 0 {
+		t.Errorf("a dry run sent %q", ops)
+	}
+	if got := len(e.History.Recent(10)); got != history {
+		t.Errorf("history grew from %d to %d entries", history, got)
+	}
+	// "2" still repeats the last phrase that ran
+	snipertest.ExpectKeys(t, e, "2", "right", "right")
+
+	if _, err := e.DryRun("west 5000", ""); err == nil {
+		t.Error("a dry run over max_repeat wasn't rejected")
+	}
+}
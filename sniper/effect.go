@@ -1,24 +1,75 @@
 package sniper
 
-import "time"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // EffectFunc is the signature for an effect (middleware).
 // It takes the Engine and a 'next' function which represents the next link in the chain.
+//
+// An effect that returns ErrSkip without calling next stops the command from
+// running; the token is recorded as skipped and the phrase continues. To stop
+// the rest of the phrase instead, use KillAfter.
 type EffectFunc func(e *Engine, next func() error) error
 
+// ErrSkip is returned by an effect to skip its command without failing the phrase.
+var ErrSkip = errors.New("skipped by effect")
+
+// NamedEffect is an EffectFunc with the name it is listed under in the
+// execution trace, the registry and the config file. Commands list their
+// effects as NamedEffects, and every constructor below returns one named
+// after the config spec that builds it ("wait_after").
+type NamedEffect struct {
+	Name string
+	Fn   EffectFunc
+}
+
+// Named gives a custom effect a name. An effect without one is listed as
+// "custom".
+func Named(name string, fn EffectFunc) NamedEffect {
+	return NamedEffect{Name: name, Fn: fn}
+}
+
 // EffectChain wraps a core action function with a slice of effects.
 // It executes effects in order: effects[0] wraps effects[1], which wraps... the handler.
-func EffectChain(e *Engine, handler func() error, effects ...EffectFunc) error {
+//
+// If the config file overrides the effects of the command currently being
+// dispatched, those overrides REPLACE the effects passed in.
+//
+// The names of the effects that ran are recorded in the execution trace.
+func EffectChain(e *Engine, handler func() error, effects ...NamedEffect) error {
+	if e != nil && e.activeCmd != nil {
+		if override, ok := e.effectOverride(e.activeCmd.Name()); ok {
+			effects = override
+		}
+		// Outermost, so the cursor is restored after every other effect
+		if restoreMouseCommands[e.activeCmd.Name()] && e.Options().RestoreMouse {
+			effects = append([]NamedEffect{RestorePositionAfter()}, effects...)
+		}
+	}
+	effects = slices.DeleteFunc(slices.Clone(effects), func(eff NamedEffect) bool { return eff.Fn == nil })
+
 	// If there are no effects, just run the core handler.
 	if len(effects) == 0 {
 		return handler()
 	}
 
+	if e != nil && e.State != nil {
+		e.State.traceEffects(EffectNames(effects))
+	}
+
 	// We wrap the handler with the effects.
 	// We loop backwards so that effects[0] becomes the outermost wrapper.
 	next := handler
 	for i := len(effects) - 1; i >= 0; i-- {
-		eff := effects[i]
+		eff := effects[i].Fn
 		currentNext := next
 		next = func() error {
 			return eff(e, currentNext)
@@ -29,19 +80,21 @@ func EffectChain(e *Engine, handler func() error, effects ...EffectFunc) error {
 	return next()
 }
 
-// WaitBefore returns an EffectFunc that sleeps for the specified milliseconds
+// WaitBefore returns an effect that sleeps for the specified milliseconds
 // BEFORE executing the next function in the chain.
-func WaitBefore(ms int) EffectFunc {
-	return func(e *Engine, next func() error) error {
-		time.Sleep(time.Duration(ms) * time.Millisecond)
+func WaitBefore(ms int) NamedEffect {
+	return Named("wait_before", func(e *Engine, next func() error) error {
+		if !e.Sleep(time.Duration(ms) * time.Millisecond) {
+			return e.checkCancelled()
+		}
 		return next()
-	}
+	})
 }
 
-// WaitAfter returns an EffectFunc that executes the next function in the chain,
+// WaitAfter returns an effect that executes the next function in the chain,
 // and then sleeps for the specified milliseconds AFTER it completes.
-func WaitAfter(ms int) EffectFunc {
-	return func(e *Engine, next func() error) error {
+func WaitAfter(ms int) NamedEffect {
+	return Named("wait_after", func(e *Engine, next func() error) error {
 		// Execute the action first
 		err := next()
 		if err != nil {
@@ -49,15 +102,16 @@ func WaitAfter(ms int) EffectFunc {
 		}
 
 		// If successful, wait the specified duration
-		time.Sleep(time.Duration(ms) * time.Millisecond)
-		return nil
-	}
+		e.Sleep(time.Duration(ms) * time.Millisecond)
+		return e.checkCancelled()
+	})
 }
 
-// KillAfter returns an EffectFunc that sets the Engine.IsOperating flag to false
-// AFTER the command executes successfully.
-func KillAfter() EffectFunc {
-	return func(e *Engine, next func() error) error {
+// KillAfter returns an effect that sets the Engine.IsOperating flag to false
+// AFTER the command executes successfully, so no later token in the phrase runs.
+// Unlike ErrSkip, the command itself does run.
+func KillAfter() NamedEffect {
+	return Named("kill_after", func(e *Engine, next func() error) error {
 		// Execute the action first
 		err := next()
 		if err != nil {
@@ -67,24 +121,26 @@ func KillAfter() EffectFunc {
 		// Change IsOperating to false to stop the engine
 		e.IsOperating = false
 		return nil
-	}
+	})
 }
 
-// ClickBefore returns an EffectFunc that performs a mouse click
+// ClickBefore returns an effect that performs a mouse click
 // BEFORE executing the next function in the chain.
-func ClickBefore() EffectFunc {
-	return func(e *Engine, next func() error) error {
+func ClickBefore() NamedEffect {
+	return Named("click_before", func(e *Engine, next func() error) error {
 		// Click to focus or position cursor
 		e.Mouse.DoubleClick()
-		time.Sleep(time.Millisecond * 50)
+		if err := e.settle(time.Duration(e.Options().MouseDelayMs) * time.Millisecond); err != nil {
+			return err
+		}
 		return next()
-	}
+	})
 }
 
-// ClickAfter returns an EffectFunc that performs a mouse click
+// ClickAfter returns an effect that performs a mouse click
 // AFTER executing the next function in the chain.
-func ClickAfter() EffectFunc {
-	return func(e *Engine, next func() error) error {
+func ClickAfter() NamedEffect {
+	return Named("click_after", func(e *Engine, next func() error) error {
 		// Execute the action first
 		err := next()
 		if err != nil {
@@ -93,15 +149,139 @@ func ClickAfter() EffectFunc {
 
 		// Click mouse after the action completes
 		e.Mouse.DoubleClick()
-		time.Sleep(time.Millisecond * 50)
-		return nil
+		return e.settle(time.Duration(e.Options().MouseDelayMs) * time.Millisecond)
+	})
+}
+
+// When returns an effect that applies eff only while pred holds; otherwise
+// the chain carries on straight to next.
+func When(pred func(e *Engine) bool, eff NamedEffect) NamedEffect {
+	return Named("when", func(e *Engine, next func() error) error {
+		if !pred(e) || eff.Fn == nil {
+			return next()
+		}
+		return eff.Fn(e, next)
+	})
+}
+
+// InMode is a When predicate that holds while the named mode is active.
+func InMode(name string) func(e *Engine) bool {
+	return func(e *Engine) bool {
+		return e.ActiveMode() == strings.ToLower(name)
 	}
 }
 
+// IsRemoteSession is a When predicate that holds when the RemoteSession option is on.
+func IsRemoteSession(e *Engine) bool {
+	return e.Options().RemoteSession
+}
+
+// Timed returns an effect that measures the rest of the chain and records
+// the duration under name in the token's execution trace.
+func Timed(name string) NamedEffect {
+	return Named("timed", func(e *Engine, next func() error) error {
+		start := time.Now()
+		err := next()
+		if e.State != nil {
+			e.State.traceTiming(name, time.Since(start))
+		}
+		return err
+	})
+}
+
+// RestorePositionAfter returns an effect that puts the mouse back where it
+// was before the rest of the chain ran, even if it failed. List it first so it
+// wraps ClickBefore/ClickAfter and undoes their movement too.
+func RestorePositionAfter() NamedEffect {
+	return Named("restore_position_after", func(e *Engine, next func() error) error {
+		e.Mouse.SyncPosition()
+		x, y := e.Mouse.X, e.Mouse.Y
+		defer e.Mouse.MoveTo(x, y)
+		return next()
+	})
+}
+
+// DefaultRetryDelay is the delay used by the "retry" effect spec.
+const DefaultRetryDelay = 100 * time.Millisecond
+
+// Retry returns an effect that re-runs the rest of the chain when it fails,
+// up to attempts times in total, waiting delay, 2*delay, 3*delay... in between.
+// A cancelled or timed-out phrase is never retried.
+func Retry(attempts int, delay time.Duration) NamedEffect {
+	return retry(attempts, func(attempt int) time.Duration {
+		return delay * time.Duration(attempt)
+	})
+}
+
+// RetryExponential is Retry with the wait doubling after each failure.
+func RetryExponential(attempts int, delay time.Duration) NamedEffect {
+	return retry(attempts, func(attempt int) time.Duration {
+		return delay << (attempt - 1)
+	})
+}
+
+func retry(attempts int, backoff func(attempt int) time.Duration) NamedEffect {
+	return Named("retry", func(e *Engine, next func() error) error {
+		var err error
+		attempt := 1
+		for ; ; attempt++ {
+			if err = next(); err == nil || !retryable(err) {
+				return err
+			}
+			// Out of attempts, or the engine is shutting down
+			if attempt >= attempts || !e.Sleep(backoff(attempt)) {
+				break
+			}
+		}
+		return fmt.Errorf("failed after %d attempts: %w", attempt, err)
+	})
+}
+
+// retryable reports whether a failure is worth another attempt.
+func retryable(err error) bool {
+	return !errors.Is(err, ErrSkip) &&
+		!errors.Is(err, ErrCancelled) &&
+		!errors.Is(err, ErrExecutionTimeout) &&
+		!errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded)
+}
+
+// restoreMouseCommands get RestorePositionAfter wrapped around their effects
+// when the RestoreMouse option is on.
+var restoreMouseCommands = map[string]bool{
+	"grab":  true,
+	"shove": true,
+	"find":  true,
+}
+
+// SnapshotClipboard returns an effect that records the clipboard contents
+// into the Engine's ClipboardRing AFTER a copy-style command completes.
+func SnapshotClipboard() NamedEffect {
+	return Named("snapshot_clipboard", func(e *Engine, next func() error) error {
+		err := next()
+		if err != nil {
+			return err
+		}
+
+		// The OS needs a moment to actually place the copied data on the clipboard
+		if err := e.settle(time.Duration(e.Options().ClipboardDelayMs) * time.Millisecond); err != nil {
+			return err
+		}
+
+		// A failed read (e.g. an image was copied) shouldn't fail the copy itself
+		text, err := e.Clipboard.ReadText()
+		if err != nil {
+			return nil
+		}
+		e.ClipboardRing.Push(text)
+		return nil
+	})
+}
+
 // ConsumeArgs looks ahead n tokens, stores their string literals in e.State.ConsumedArgs,
 // and tells the Engine to skip processing them as commands.
-func ConsumeArgs(n int) EffectFunc {
-	return func(e *Engine, next func() error) error {
+func ConsumeArgs(n int) NamedEffect {
+	return Named("consume_args", func(e *Engine, next func() error) error {
 		// 1. Check availability
 		if len(e.State.RemainingTokens) < n {
 			// Not enough tokens to consume.
@@ -110,19 +290,91 @@ func ConsumeArgs(n int) EffectFunc {
 			return next()
 		}
 
-		// 2. Clear previous args to be safe
-		e.State.ConsumedArgs = make([]string, 0, n)
+		// 2. Claim them so the Engine skips them
+		e.ConsumeNext(n)
 
-		// 3. Consume
-		for i := 0; i < n; i++ {
-			// Grab literal of the next token
-			val := e.State.RemainingTokens[i].Literal()
-			e.State.ConsumedArgs = append(e.State.ConsumedArgs, val)
+		return next()
+	})
+}
+
+// ----------------------------------------------------------------------------
+// EFFECT SPECS (config file)
+// ----------------------------------------------------------------------------
+
+// effectSpecs maps the names usable in the config file's "effects" section to
+// constructors. Effects that take a number are written "name:value".
+var effectSpecs = map[string]func(arg int, hasArg bool) (NamedEffect, error){
+	"wait_before": func(arg int, hasArg bool) (NamedEffect, error) {
+		if !hasArg {
+			return NamedEffect{}, fmt.Errorf("wait_before needs milliseconds, e.g. wait_before:100")
+		}
+		return WaitBefore(arg), nil
+	},
+	"wait_after": func(arg int, hasArg bool) (NamedEffect, error) {
+		if !hasArg {
+			return NamedEffect{}, fmt.Errorf("wait_after needs milliseconds, e.g. wait_after:150")
 		}
+		return WaitAfter(arg), nil
+	},
+	"consume_args": func(arg int, hasArg bool) (NamedEffect, error) {
+		if !hasArg {
+			arg = 1
+		}
+		return ConsumeArgs(arg), nil
+	},
+	"kill_after":         func(int, bool) (NamedEffect, error) { return KillAfter(), nil },
+	"click_before":       func(int, bool) (NamedEffect, error) { return ClickBefore(), nil },
+	"click_after":        func(int, bool) (NamedEffect, error) { return ClickAfter(), nil },
+	"snapshot_clipboard": func(int, bool) (NamedEffect, error) { return SnapshotClipboard(), nil },
+	"retry": func(arg int, hasArg bool) (NamedEffect, error) {
+		if !hasArg {
+			arg = 3
+		}
+		if arg < 1 {
+			return NamedEffect{}, fmt.Errorf("retry needs at least 1 attempt")
+		}
+		return Retry(arg, DefaultRetryDelay), nil
+	},
+	"restore_position_after": func(int, bool) (NamedEffect, error) {
+		return RestorePositionAfter(), nil
+	},
+}
 
-		// 4. Instruct Engine to skip execution of these tokens
-		e.State.SkipCount = n
+// ParseEffectSpec builds an effect from a config string like "wait_after:150".
+func ParseEffectSpec(spec string) (NamedEffect, error) {
+	name, rawArg, hasArg := strings.Cut(strings.TrimSpace(spec), ":")
+	name = strings.ToLower(name)
 
-		return next()
+	build, ok := effectSpecs[name]
+	if !ok {
+		known := make([]string, 0, len(effectSpecs))
+		for k := range effectSpecs {
+			known = append(known, k)
+		}
+		sort.Strings(known)
+		return NamedEffect{}, fmt.Errorf("unknown effect '%s' (known effects: %s)", name, strings.Join(known, ", "))
+	}
+
+	arg := 0
+	if hasArg {
+		val, err := strconv.Atoi(rawArg)
+		if err != nil || val < 0 {
+			return NamedEffect{}, fmt.Errorf("effect '%s' has an invalid argument '%s'", name, rawArg)
+		}
+		arg = val
+	}
+	return build(arg, hasArg)
+}
+
+// ParseEffectSpecs builds an effect chain from a list of specs, failing on the first bad one.
+func ParseEffectSpecs(specs []string) ([]NamedEffect, error) {
+	effects := make([]NamedEffect, 0, len(specs))
+	for _, spec := range specs {
+		eff, err := ParseEffectSpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		effects = append(effects, eff)
 	}
+	return effects, nil
 }
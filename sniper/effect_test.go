@@ -0,0 +1,398 @@
+package sniper_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phillip-england/sniper/sniper"
+	"github.com/phillip-england/sniper/sniper/snipertest"
+)
+
+func TestParseEffectSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		name    string
+		wantErr string
+	}{
+		{"wait_after:150", "wait_after", ""},
+		{" Wait_Before:0 ", "wait_before", ""},
+		{"click_before", "click_before", ""},
+		{"consume_args", "consume_args", ""},
+		{"retry:2", "retry", ""},
+		{"explode", "", "unknown effect 'explode' (known effects: click_after, click_before,"},
+		{"wait_after", "", "wait_after needs milliseconds"},
+		{"wait_after:soon", "", "invalid argument 'soon'"},
+		{"wait_after:-5", "", "invalid argument '-5'"},
+		{"retry:0", "", "at least 1 attempt"},
+	}
+	for _, tt := range tests {
+		eff, err := sniper.ParseEffectSpec(tt.spec)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseEffectSpec(%q) error = %v, want %q", tt.spec, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseEffectSpec(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := sniper.EffectNames([]sniper.NamedEffect{eff}); got[0] != tt.name {
+			t.Errorf("ParseEffectSpec(%q) built %s, want %s", tt.spec, got[0], tt.name)
+		}
+	}
+}
+
+func TestUnknownEffectFailsConfigLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sniper.json")
+	if err := os.WriteFile(path, []byte(`{"effects": {"click": ["wait_after:150", "explode"]}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := sniper.LoadConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "effects for 'click': unknown effect 'explode'") {
+		t.Errorf("LoadConfig error = %v, want the unknown effect named", err)
+	}
+}
+
+func TestEffectOverridesReplaceDefaults(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	copyKey := primary() + "+c"
+
+	// By default copy saves the clipboard to the history
+	e.Clipboard.WriteText("first")
+	e.MustRun(t, "copy")
+	if n := len(e.ClipboardRing.Entries()); n != 1 {
+		t.Fatalf("default copy left %d history entries, want 1", n)
+	}
+
+	e.ApplyConfig(&sniper.Config{Effects: map[string][]string{
+		"copy":  {"kill_after"},
+		"south": {"kill_after"},
+	}})
+
+	// The override replaces snapshot_clipboard instead of adding to it
+	e.Clipboard.WriteText("second")
+	snipertest.ExpectKeys(t, e, "copy east", copyKey)
+	if n := len(e.ClipboardRing.Entries()); n != 1 {
+		t.Errorf("overridden copy left %d history entries, want 1", n)
+	}
+
+	// A command without effects of its own gets the override too
+	snipertest.ExpectKeys(t, e, "south east", "down")
+	snipertest.ExpectKeys(t, e, "east south", "right", "down")
+
+	// Dropping the override brings the built-in effects back
+	e.ApplyConfig(&sniper.Config{})
+	e.MustRun(t, "copy")
+	if n := len(e.ClipboardRing.Entries()); n != 2 {
+		t.Errorf("copy left %d history entries after the override was removed, want 2", n)
+	}
+}
+
+// flakyCmd fails with err the first failures times it runs, then presses enter.
+type flakyCmd struct {
+	failures int
+	err      error
+	calls    *int
+	effect   sniper.NamedEffect
+}
+
+func (c flakyCmd) Name() string                  { return "flaky" }
+func (c flakyCmd) CalledBy() []string            { return []string{"flaky"} }
+func (c flakyCmd) Effects() []sniper.NamedEffect { return []sniper.NamedEffect{c.effect} }
+func (c flakyCmd) Action(e *sniper.Engine, p string) error {
+	return sniper.EffectChain(e, func() error {
+		*c.calls++
+		if *c.calls <= c.failures {
+			return c.err
+		}
+		e.StickyKeyboard.Enter()
+		return nil
+	}, c.Effects()...)
+}
+
+// runFlaky registers a flakyCmd and runs it, returning how many times its
+// handler ran and the pauses the effect took between attempts.
+func runFlaky(t *testing.T, cmd flakyCmd) (int, []float64, error) {
+	t.Helper()
+	e := snipertest.NewTestEngine(t)
+	calls := 0
+	cmd.calls = &calls
+	if err := e.Register(cmd); err != nil {
+		t.Fatal(err)
+	}
+	result, err := e.Run("flaky")
+
+	var sleeps []float64
+	for _, action := range result.Actions {
+		if action.Kind == sniper.ActionSleep {
+			sleeps = append(sleeps, action.DurationMs)
+		}
+	}
+	return calls, sleeps, err
+}
+
+func TestRetryUntilSuccess(t *testing.T) {
+	calls, sleeps, err := runFlaky(t, flakyCmd{
+		failures: 2, err: errors.New("didn't register"), effect: sniper.Retry(5, 2*time.Millisecond),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Errorf("handler ran %d times, want 3", calls)
+	}
+	if want := []float64{2, 4}; !slices.Equal(sleeps, want) {
+		t.Errorf("waited %v ms between attempts, want %v", sleeps, want)
+	}
+}
+
+func TestRetryExponentialBackoff(t *testing.T) {
+	_, sleeps, err := runFlaky(t, flakyCmd{
+		failures: 3, err: errors.New("didn't register"), effect: sniper.RetryExponential(5, 2*time.Millisecond),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []float64{2, 4, 8}; !slices.Equal(sleeps, want) {
+		t.Errorf("waited %v ms between attempts, want %v", sleeps, want)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	flaked := errors.New("didn't register")
+	calls, _, err := runFlaky(t, flakyCmd{failures: 10, err: flaked, effect: sniper.Retry(3, time.Millisecond)})
+	if calls != 3 {
+		t.Errorf("handler ran %d times, want 3", calls)
+	}
+	if !errors.Is(err, flaked) || !strings.Contains(err.Error(), "failed after 3 attempts") {
+		t.Errorf("err = %v, want the last failure wrapped with the attempt count", err)
+	}
+}
+
+func TestRetrySkipsCancellation(t *testing.T) {
+	for _, cause := range []error{sniper.ErrCancelled, sniper.ErrExecutionTimeout, context.DeadlineExceeded, sniper.ErrSkip} {
+		calls, _, _ := runFlaky(t, flakyCmd{failures: 10, err: cause, effect: sniper.Retry(3, time.Millisecond)})
+		if calls != 1 {
+			t.Errorf("%v was retried: handler ran %d times", cause, calls)
+		}
+	}
+}
+
+// effectsCmd presses enter through the given effects.
+type effectsCmd []sniper.NamedEffect
+
+func (c effectsCmd) Name() string                  { return "gated" }
+func (c effectsCmd) CalledBy() []string            { return []string{"gated"} }
+func (c effectsCmd) Effects() []sniper.NamedEffect { return c }
+func (c effectsCmd) Action(e *sniper.Engine, p string) error {
+	return sniper.EffectChain(e, func() error {
+		e.StickyKeyboard.Enter()
+		return nil
+	}, c.Effects()...)
+}
+
+func TestWhenInMode(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	if err := e.Register(effectsCmd{sniper.When(sniper.InMode("Browser"), sniper.ClickBefore())}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Outside the mode the chain goes straight to the command
+	snipertest.ExpectMouse(t, e, "gated")
+	if got := e.Input.Keys(); !slices.Equal(got, []string{"enter"}) {
+		t.Errorf("gated tapped %q outside browser mode", got)
+	}
+
+	e.MustRun(t, "mode browser")
+	snipertest.ExpectMouse(t, e, "gated", "click left", "click left")
+	if got := e.Input.Keys(); !slices.Equal(got, []string{"enter"}) {
+		t.Errorf("gated tapped %q in browser mode", got)
+	}
+}
+
+func TestWhenNested(t *testing.T) {
+	remoteVim := sniper.When(sniper.IsRemoteSession, sniper.When(sniper.InMode("vim"), sniper.KillAfter()))
+	e := snipertest.NewTestEngine(t)
+	if err := e.Register(effectsCmd{sniper.Retry(2, time.Millisecond), sniper.Timed("gate"), remoteVim}); err != nil {
+		t.Fatal(err)
+	}
+
+	e.MustRun(t, "mode vim")
+	snipertest.ExpectKeys(t, e, "gated south", "enter", "down")
+
+	opts := e.Options()
+	opts.RemoteSession = true
+	if err := e.SetOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	// Both predicates hold, so KillAfter ends the phrase after the command
+	snipertest.ExpectKeys(t, e, "gated south", "enter")
+
+	e.MustRun(t, "mode off")
+	snipertest.ExpectKeys(t, e, "gated south", "enter", "down")
+}
+
+// outcomes lists the outcome of each token of a phrase.
+func outcomes(result sniper.ExecutionResult) []sniper.TokenOutcome {
+	out := make([]sniper.TokenOutcome, len(result.Tokens))
+	for i, tok := range result.Tokens {
+		out[i] = tok.Outcome
+	}
+	return out
+}
+
+func TestErrSkipSkipsOnlyTheCommand(t *testing.T) {
+	skip := func(e *sniper.Engine, next func() error) error { return sniper.ErrSkip }
+	e := snipertest.NewTestEngine(t)
+	if err := e.Register(effectsCmd{sniper.Named("skip", skip)}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := e.Run("south gated east")
+	if err != nil {
+		t.Fatalf("a skipped command failed the phrase: %v", err)
+	}
+	if got := e.Input.Keys(); !slices.Equal(got, []string{"down", "right"}) {
+		t.Errorf("tapped %q, want the skipped command's enter left out", got)
+	}
+	want := []sniper.TokenOutcome{sniper.OutcomeHandled, sniper.OutcomeEffectSkipped, sniper.OutcomeHandled}
+	if got := outcomes(result); !slices.Equal(got, want) {
+		t.Errorf("outcomes = %q, want %q", got, want)
+	}
+	if result.Error != "" {
+		t.Errorf("result carries error %q", result.Error)
+	}
+}
+
+func TestEffectErrorFailsThePhrase(t *testing.T) {
+	refuse := func(e *sniper.Engine, next func() error) error { return errors.New("refused") }
+	e := snipertest.NewTestEngine(t)
+	if err := e.Register(effectsCmd{sniper.Named("refuse", refuse)}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := e.Run("south gated east")
+	var execErr *sniper.ExecError
+	if !errors.As(err, &execErr) || execErr.Command != "gated" {
+		t.Fatalf("err = %v, want an ExecError for gated", err)
+	}
+	if got := e.Input.Keys(); !slices.Equal(got, []string{"down"}) {
+		t.Errorf("tapped %q, want the phrase to stop at the failure", got)
+	}
+	want := []sniper.TokenOutcome{sniper.OutcomeHandled, sniper.OutcomeFailed, sniper.OutcomeNotRun}
+	if got := outcomes(result); !slices.Equal(got, want) {
+		t.Errorf("outcomes = %q, want %q", got, want)
+	}
+	if result.Error == "" {
+		t.Error("result doesn't carry the error")
+	}
+}
+
+// wanderCmd moves the mouse to (40, 30) through the given effects, failing
+// afterwards when err is set.
+type wanderCmd struct {
+	effects []sniper.NamedEffect
+	err     error
+}
+
+func (c wanderCmd) Name() string                  { return "wander" }
+func (c wanderCmd) CalledBy() []string            { return []string{"wander"} }
+func (c wanderCmd) Effects() []sniper.NamedEffect { return c.effects }
+func (c wanderCmd) Action(e *sniper.Engine, p string) error {
+	return sniper.EffectChain(e, func() error {
+		e.Mouse.MoveTo(40, 30)
+		return c.err
+	}, c.Effects()...)
+}
+
+func TestRestorePositionAfterOrdering(t *testing.T) {
+	tests := []struct {
+		name    string
+		effects []sniper.NamedEffect
+		err     error
+		want    []string
+	}{
+		{
+			// Outermost, it undoes the clicks' movement too
+			"restore first",
+			[]sniper.NamedEffect{sniper.RestorePositionAfter(), sniper.ClickBefore(), sniper.ClickAfter()},
+			nil,
+			[]string{"click left", "click left", "move 40 30", "click left", "click left", "move 5 6"},
+		},
+		{
+			// Innermost, the cursor is back before ClickAfter clicks
+			"restore last",
+			[]sniper.NamedEffect{sniper.ClickBefore(), sniper.ClickAfter(), sniper.RestorePositionAfter()},
+			nil,
+			[]string{"click left", "click left", "move 40 30", "move 5 6", "click left", "click left"},
+		},
+		{
+			// A failed command is still put back, and ClickAfter doesn't click
+			"failure",
+			[]sniper.NamedEffect{sniper.RestorePositionAfter(), sniper.ClickBefore(), sniper.ClickAfter()},
+			errJammed,
+			[]string{"click left", "click left", "move 40 30", "move 5 6"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := snipertest.NewTestEngine(t)
+			if err := e.Register(wanderCmd{tt.effects, tt.err}); err != nil {
+				t.Fatal(err)
+			}
+			e.Input.SetCursor(5, 6)
+
+			if _, err := e.Run("wander"); !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if got := mouseOps(e); !slices.Equal(got, tt.want) {
+				t.Errorf("mouse ops %q\nwant %q", got, tt.want)
+			}
+			if x, y := e.Input.Location(); x != 5 || y != 6 {
+				t.Errorf("cursor left at %d,%d, want 5,6", x, y)
+			}
+		})
+	}
+}
+
+func TestRestoreMouseOption(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.Input.SetCursor(5, 6)
+
+	e.MustRun(t, "grab")
+	if got := mouseOps(e); slices.Contains(got, "move 5 6") {
+		t.Errorf("grab moved the mouse with restore_mouse off: %q", got)
+	}
+
+	opts := e.Options()
+	opts.RestoreMouse = true
+	if err := e.SetOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	result := e.MustRun(t, "grab")
+	want := []string{"click left", "click left", "click left", "click left", "move 5 6"}
+	if got := mouseOps(e); !slices.Equal(got, want) {
+		t.Errorf("grab with restore_mouse: mouse ops %q\nwant %q", got, want)
+	}
+	if effects := result.Tokens[0].Effects; len(effects) == 0 || effects[0] != "restore_position_after" {
+		t.Errorf("grab ran effects %q, want restore_position_after outermost", effects)
+	}
+}
+
+// mouseOps is the moves and clicks the test driver saw.
+func mouseOps(e *snipertest.Engine) []string {
+	var ops []string
+	for _, op := range e.Input.Ops() {
+		if strings.HasPrefix(op, "move ") || strings.HasPrefix(op, "click ") {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
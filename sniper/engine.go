@@ -1,38 +1,202 @@
 package sniper
 
 import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"io"
+	"log/slog"
+	"runtime/debug"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 )
 
-type ExecutonMode string
+type ExecutionMode string
+
+// ExecutonMode is the old, misspelled name of ExecutionMode.
+//
+// Deprecated: use ExecutionMode.
+type ExecutonMode = ExecutionMode
 
 const (
-	ModeRapid  ExecutonMode = "RAPID"
-	ModePhrase ExecutonMode = "PHRASE"
+	ModeRapid  ExecutionMode = "RAPID"
+	ModePhrase ExecutionMode = "PHRASE"
 )
 
+// DefaultRapidMaxTokens is the longest message DetectMode sends to rapid mode.
+const DefaultRapidMaxTokens = 2
+
+// DetectMode picks the mode for a message sent without one: a lone command or
+// number, or a command with a count ("left 5"), goes rapid; dictation commands
+// ("say", "type", "camel", ...), plain words and anything longer go phrase.
+func DetectMode(tokens []Token) ExecutionMode {
+	return detectMode(tokens, DefaultRapidMaxTokens)
+}
+
+func detectMode(tokens []Token, rapidMaxTokens int) ExecutionMode {
+	if len(tokens) == 0 || len(tokens) > rapidMaxTokens {
+		return ModePhrase
+	}
+	for _, token := range tokens {
+		switch t := token.(type) {
+		case *NumberToken:
+		case *CmdToken:
+			if CommandConsumesArgs(t.Command()) {
+				return ModePhrase
+			}
+		default:
+			return ModePhrase
+		}
+	}
+	return ModeRapid
+}
+
 // EngineState holds the transient state for a single parse/execute cycle.
 type EngineState struct {
-	ExecutionMode     ExecutonMode
+	ExecutionMode     ExecutionMode
+	ModeDetected      bool // ExecutionMode was picked by DetectMode, not the caller
 	Tokens            []Token
 	RemainingTokens   []Token
 	HandledTokens     []Token
 	RemainingRawWords string
-	TokenIndices      []int
+	TokenIndices      []int // Index of the first raw word of each token
 	RawWords          []string
+	OriginalWords     []string // RawWords in the casing they were heard in; RemainingRawWords is built from these
 	LastCmd           Cmd
+	LastArgs          []Token // Arguments LastCmd claimed, if it is an ArgTaker
 	FirstCmdIsValid   bool
-	ConsumedArgs      []string // Stores words like "banana" consumed by commands
-	SkipCount         int      // How many tokens to skip in the main loop
+	ConsumedArgs      []string       // Stores words like "banana" consumed by commands
+	SkipCount         int            // How many tokens to skip in the main loop
+	Cancelled         bool           // Set by "cancel"; stops the phrase (or skips it entirely when "cancel" is last)
+	Halted            bool           // Set by "halt"; stops the phrase, keeping what already ran
+	Segment           int            // How many "then" boundaries have been passed
+	Outcomes          []TokenOutcome // What happened to each token, indexed like Tokens
+	Confidences       []float64      // Recognizer confidence of each token, indexed like Tokens
+	Durations         []time.Duration
+	Outputs           []CommandOutput // Values commands read back, like the colour "color" read
+	Window            *WindowInfo     // The focused window when the phrase was parsed, if it could be read
+
+	// Repeated is set when the phrase replayed an earlier one, with "repeat" or
+	// a bare number; it never becomes a previous state.
+	// IsReplay marks the state Replay builds, so "repeat" can't replay from inside one.
+	Repeated bool
+	IsReplay bool
+
+	// current is the index of the token being handled; traces holds what its
+	// effects recorded, indexed like Tokens
+	current int
+	traces  []tokenTrace
+}
+
+// Clone returns a deep copy of the state, so executing or replaying one never
+// changes the other. Tokens are shared; they aren't modified after parsing.
+func (s *EngineState) Clone() *EngineState {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	c.Tokens = slices.Clone(s.Tokens)
+	c.RemainingTokens = slices.Clone(s.RemainingTokens)
+	c.HandledTokens = slices.Clone(s.HandledTokens)
+	c.TokenIndices = slices.Clone(s.TokenIndices)
+	c.RawWords = slices.Clone(s.RawWords)
+	c.OriginalWords = slices.Clone(s.OriginalWords)
+	c.ConsumedArgs = slices.Clone(s.ConsumedArgs)
+	c.Outcomes = slices.Clone(s.Outcomes)
+	c.Confidences = slices.Clone(s.Confidences)
+	c.Durations = slices.Clone(s.Durations)
+	c.Outputs = slices.Clone(s.Outputs)
+	c.traces = make([]tokenTrace, len(s.traces))
+	for i, t := range s.traces {
+		c.traces[i] = tokenTrace{effects: slices.Clone(t.effects), timings: slices.Clone(t.timings)}
+	}
+	return &c
+}
+
+// setDuration records how long the token at index i took to handle.
+func (s *EngineState) setDuration(i int, d time.Duration) {
+	if i >= 0 && i < len(s.Durations) {
+		s.Durations[i] = d
+	}
+}
+
+// confidence returns the confidence of the token at index i (1.0 when unknown).
+func (s *EngineState) confidence(i int) float64 {
+	if i >= 0 && i < len(s.Confidences) {
+		return s.Confidences[i]
+	}
+	return DefaultConfidence
+}
+
+// lowConfidence reports whether token i is a command heard too unclearly to run.
+// Raw words and numbers are never held back.
+func (e *Engine) lowConfidence(i int, token Token) bool {
+	if token.Type() != TokenTypeCmd {
+		return false
+	}
+	conf, threshold := e.State.confidence(i), e.Options().MinConfidence
+	if conf >= threshold {
+		return false
+	}
+	e.log().Info("skipping low-confidence command", "literal", token.Literal(), "confidence", conf, "threshold", threshold)
+	return true
+}
+
+// setOutcome records the outcome of the token at index i (replays pass -1 and are ignored).
+func (s *EngineState) setOutcome(i int, outcome TokenOutcome) {
+	if i >= 0 && i < len(s.Outcomes) {
+		s.Outcomes[i] = outcome
+	}
+}
+
+// PeekNext returns the token ConsumeNext(1) would claim without claiming it,
+// or nil when the segment has no more tokens.
+func (e *Engine) PeekNext() Token {
+	s := e.State
+	if next := s.current + 1 + s.SkipCount; next < s.segmentEnd(s.current) {
+		return s.Tokens[next]
+	}
+	return nil
+}
+
+// ConsumeNext claims up to n of the tokens after the current one as arguments:
+// they are appended to ConsumedArgs and skipped by the main loop instead of
+// being handled as tokens of their own. Tokens already claimed are not handed
+// out twice, and asking for more than remain claims only what is left.
+func (e *Engine) ConsumeNext(n int) []Token {
+	s := e.State
+
+	// Arguments start after the current token and anything it already claimed,
+	// and never reach past a "then"
+	end := s.segmentEnd(s.current)
+	start := min(s.current+1+s.SkipCount, end)
+	claimed := s.Tokens[start:min(start+max(n, 0), end)]
+
+	for _, token := range claimed {
+		s.ConsumedArgs = append(s.ConsumedArgs, token.Literal())
+	}
+	s.SkipCount += len(claimed)
+
+	// The claimed words are no longer waiting to be handled
+	next := start + len(claimed)
+	if next < end {
+		s.RemainingRawWords = strings.Join(s.OriginalWords[next:end], " ")
+	} else {
+		s.RemainingRawWords = ""
+	}
+	return claimed
 }
 
 // Advance updates the tracking slices and strings for the current execution step.
 func (s *EngineState) Advance(i int, token Token) {
-	// 1. Update RemainingRawWords
-	if i+1 < len(s.RawWords) {
-		s.RemainingRawWords = strings.Join(s.RawWords[i+1:], " ")
+	// 1. Update RemainingRawWords, up to the end of the segment
+	if end := s.segmentEnd(i); i+1 < end {
+		s.RemainingRawWords = strings.Join(s.OriginalWords[i+1:end], " ")
 	} else {
 		s.RemainingRawWords = ""
 	}
@@ -49,78 +213,621 @@ func (s *EngineState) Advance(i int, token Token) {
 type Engine struct {
 	StickyKeyboard *StickyKeyboard
 	registry       map[string]Cmd
-	Mouse          *Mouse
-	Memory         *MouseMemory // New: Persistence layer
-	Delay          time.Duration
+	registryMu     sync.RWMutex
+
+	// builtinTriggers and userTriggers are the layers of registry, kept apart
+	// so Parse can resolve them by priority (see Resolver)
+	builtinTriggers map[string]Cmd
+	userTriggers    map[string]Cmd
+
+	commands      []Cmd           // built-ins plus anything added with Register; the registry is rebuilt from these
+	disabled      map[string]bool // command names switched off at runtime with Disable
+	modeSpecs     []ModeSpec      // modes defined in Go; config modes are layered on in ApplyConfig
+	modes         map[string]*Mode
+	activeMode    string
+	manualMode    string       // the mode picked with SetMode, which window rules fall back to
+	windowRules   []windowRule // from the config's "window_modes"
+	Mouse         *Mouse
+	recorder      *ActionRecorder // actions of the running phrase, see beginRecording
+	Memory        *MouseMemory    // New: Persistence layer
+	Icons         *IconMemory     // templates for "icon <name>"
+	Macros        *MacroMemory
+	Clipboard     Clipboard
+	Screen        Screen         // reads pixels for "color"
+	Windows       WindowProvider // reports the focused window for window rules
+	ClipboardRing *ClipboardRing // Recent copies, newest first
+	Delay         time.Duration  // pause between tokens and repetitions; see pace
+
+	// Logger receives structured engine logs; keyboard, mouse and memory share it.
+	Logger *slog.Logger
+
+	// Now is the clock used by time-aware commands. Swap it for a fixed clock in tests.
+	Now func() time.Time
+
+	// Rand is the randomness source for generated values like UUIDs.
+	Rand io.Reader
+
+	opts   EngineOptions
+	optsMu sync.RWMutex
+
+	// backend is the input backend in use; backendErr is why the one the
+	// options asked for couldn't be (see useBackend)
+	backend    string
+	backendErr error
+	backendMu  sync.Mutex
+
+	// ConfigPath is where the user's aliases and macros are read from.
+	ConfigPath       string
+	config           *Config
+	configMu         sync.Mutex // held from reading the config to applying it; see updateConfig
+	aliases          map[string]string
+	homophones       map[string]string
+	numberHomophones map[string]string
+	effectOverrides  map[string][]NamedEffect
+
+	// registryVersion counts registry changes; snapshot is the registry JSON
+	// encoded at one of them (see RegistrySnapshot)
+	registryVersion atomic.Uint64
+	snapshot        *RegistrySnapshot
+	snapshotMu      sync.Mutex
+
+	// activeCmd is the command currently being dispatched, used to look up effect overrides
+	activeCmd Cmd
+
+	// jobs is the bounded queue Submit feeds and the single worker drains
+	jobs       chan *Job
+	jobTable   map[uint64]*Job
+	jobOrder   []uint64 // oldest first, for evicting finished jobs
+	nextJobID  uint64
+	jobsMu     sync.Mutex
+	workerDone chan struct{}
 
-	State     *EngineState
-	LastState *EngineState
+	// execCtx is the running phrase's context; execCancel cancels it (see Cancel)
+	execCtx    context.Context
+	execCancel context.CancelFunc
+	execMu     sync.Mutex
 
+	// done is closed by Close to interrupt anything waiting on the engine
+	done      chan struct{}
+	closeOnce sync.Once
+
+	State *EngineState
+
+	// previous holds clones of earlier phrases, newest first (see PreviousState)
+	previous []*EngineState
+
+	// History records every executed phrase, newest last.
+	History *History
+
+	// Metrics counts executions per command and phrase latency for GET /metrics.
+	Metrics *Metrics
+
+	// Recording is true between "record" and "finish"; executed phrases are captured into recorded.
+	Recording bool
+	recorded  []MacroPhrase
+
+	// playing tracks the macros currently being played, to refuse recursion
+	playing map[string]bool
+
+	// IsOperating is cleared by KillAfter to stop the rest of the current phrase.
+	// Execute sets it back at the start of every phrase.
 	IsOperating bool
-	RawInput    string
+
+	// Listening is false while the engine is asleep; only "wake" is processed then.
+	// Unlike IsOperating it persists across phrases until toggled.
+	Listening atomic.Bool
+
+	RawInput string
+
+	// rejected is why the last parsed phrase broke an input limit (see CheckInput)
+	rejected error
+
+	// lastNormalized and lastExecutedAt describe the previous phrase, for debouncing
+	lastNormalized string
+	lastExecutedAt time.Time
+
+	// ocr finds on-screen text for "point"; ocrCache keeps its last answer
+	ocr      OCRProvider
+	ocrCache ocrCache
+	ocrMu    sync.Mutex
+
+	// shotCache keeps the last screenshot of each display; see Screenshot
+	shotCache map[int]screenshotCache
+	shotMu    sync.Mutex
+
+	// lastFired is when each command with a cooldown last fired, by name
+	lastFired  map[string]time.Time
+	cooldownMu sync.Mutex
+
+	// feedbackCode and feedbackConfig are the feedback providers added in Go
+	// and named by the config; feedbackQueue feeds them (see runFeedback)
+	feedbackCode   []FeedbackProvider
+	feedbackConfig []FeedbackProvider
+	feedbackQueue  chan feedbackEvent
+	feedbackMu     sync.Mutex
+
+	// events fans phrase outcomes and mode switches out to Subscribe
+	events eventHub
+}
+
+// EngineOption customizes an Engine in NewEngine.
+type EngineOption func(*engineSetup)
+
+// engineSetup collects options before the engine is built.
+type engineSetup struct {
+	configPath      string
+	spotsPath       string
+	logger          *slog.Logger
+	withoutDefaults bool
+	commands        []Cmd
+	modes           []ModeSpec
+	opts            *EngineOptions
+	keyboard        *StickyKeyboard
+	mouse           *Mouse
+	memory          *MouseMemory
+	delay           *time.Duration
+	ocr             OCRProvider
+	screen          Screen
+	windows         WindowProvider
+	backend         InputBackend
+	feedback        []FeedbackProvider
+	errs            []error // options that can't be applied, reported by New
+}
+
+// WithCommands registers extra commands on the new engine (after the built-ins, if any).
+func WithCommands(cmds ...Cmd) EngineOption {
+	return func(s *engineSetup) {
+		s.commands = append(s.commands, cmds...)
+	}
+}
+
+// WithConfigPath reads the config file from path instead of DefaultConfigPath().
+func WithConfigPath(path string) EngineOption {
+	return func(s *engineSetup) {
+		s.configPath = path
+	}
+}
+
+// WithConfigFile is WithConfigPath.
+func WithConfigFile(path string) EngineOption {
+	return WithConfigPath(path)
 }
 
-func NewEngine() *Engine {
+// WithSpotsPath keeps the remembered mouse spots in path instead of ~/.sniper_spots.json.
+func WithSpotsPath(path string) EngineOption {
+	return func(s *engineSetup) {
+		s.spotsPath = path
+	}
+}
+
+// WithLogger sends the engine's logs (and its keyboard's, mouse's and memories') to logger.
+// By default slog.Default() is used.
+func WithLogger(logger *slog.Logger) EngineOption {
+	return func(s *engineSetup) {
+		s.logger = logger
+	}
+}
+
+// WithModes defines extra modes on the new engine.
+func WithModes(specs ...ModeSpec) EngineOption {
+	return func(s *engineSetup) {
+		s.modes = append(s.modes, specs...)
+	}
+}
+
+// WithOptions starts the engine with opts instead of DefaultEngineOptions().
+// Settings in the config file (layout, typing delays) still take precedence.
+func WithOptions(opts EngineOptions) EngineOption {
+	return func(s *engineSetup) {
+		s.opts = &opts
+	}
+}
+
+// WithKeyboard drives keys through k instead of a fresh NewStickyKeyboard().
+func WithKeyboard(k *StickyKeyboard) EngineOption {
+	return func(s *engineSetup) {
+		if k == nil {
+			s.errs = append(s.errs, errors.New("WithKeyboard: keyboard is nil"))
+			return
+		}
+		s.keyboard = k
+	}
+}
+
+// WithMouse drives the pointer through m instead of a fresh NewMouse().
+func WithMouse(m *Mouse) EngineOption {
+	return func(s *engineSetup) {
+		if m == nil {
+			s.errs = append(s.errs, errors.New("WithMouse: mouse is nil"))
+			return
+		}
+		s.mouse = m
+	}
+}
+
+// WithMemory keeps the remembered mouse spots in m instead of loading
+// ~/.sniper_spots.json. It can't be combined with WithSpotsPath.
+func WithMemory(m *MouseMemory) EngineOption {
+	return func(s *engineSetup) {
+		if m == nil {
+			s.errs = append(s.errs, errors.New("WithMemory: memory is nil"))
+			return
+		}
+		s.memory = m
+	}
+}
+
+// WithDelay sets Engine.Delay, overriding the TokenDelayUs of WithOptions.
+func WithDelay(d time.Duration) EngineOption {
+	return func(s *engineSetup) {
+		if d < 0 {
+			s.errs = append(s.errs, errors.New("WithDelay: delay can't be negative"))
+			return
+		}
+		s.delay = &d
+	}
+}
+
+// WithoutDefaults leaves the built-in Registry out, so the engine only knows
+// the commands passed with WithCommands (or added later with Register).
+func WithoutDefaults() EngineOption {
+	return func(s *engineSetup) {
+		s.withoutDefaults = true
+	}
+}
+
+// New builds an Engine from options, failing when one of them can't be
+// applied: an invalid EngineOptions, a nil driver, a command whose trigger is
+// taken. Without options it is the engine NewEngine() has always built.
+func New(options ...EngineOption) (*Engine, error) {
+	e, err := newEngine(options)
+	if err != nil {
+		e.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+// NewEngine is New for callers that can't handle an error: options that
+// can't be applied are logged and skipped.
+func NewEngine(options ...EngineOption) *Engine {
+	e, err := newEngine(options)
+	if err != nil {
+		e.log().Error("ignoring engine options", "error", err)
+	}
+	return e
+}
+
+func newEngine(options []EngineOption) (*Engine, error) {
+	setup := &engineSetup{}
+	for _, opt := range options {
+		opt(setup)
+	}
+	if setup.memory != nil && setup.spotsPath != "" {
+		setup.errs = append(setup.errs, errors.New("WithMemory and WithSpotsPath can't be combined"))
+		setup.spotsPath = ""
+	}
+	if setup.keyboard == nil {
+		setup.keyboard = NewStickyKeyboard()
+	}
+	if setup.mouse == nil {
+		setup.mouse = NewMouse()
+	}
+	if setup.memory == nil {
+		setup.memory = NewMouseMemory()
+	}
+
 	e := &Engine{
-		StickyKeyboard: NewStickyKeyboard(),
-		registry:       make(map[string]Cmd),
-		Mouse:          NewMouse(),
-		Memory:         NewMouseMemory(), // Initialize Memory
-		Delay:          time.Microsecond * 800,
-		State:          nil,
-		LastState:      nil,
-		IsOperating:    true,
+		StickyKeyboard:   setup.keyboard,
+		registry:         make(map[string]Cmd),
+		Mouse:            setup.mouse,
+		Memory:           setup.memory,
+		Icons:            NewIconMemory(),
+		Macros:           NewMacroMemory(),
+		Clipboard:        NewSystemClipboard(),
+		Screen:           NewSystemScreen(),
+		Windows:          NewSystemWindows(),
+		ClipboardRing:    NewClipboardRing(DefaultClipboardHistorySize, DefaultClipboardEntryMaxLen),
+		Delay:            time.Microsecond * 800,
+		Now:              time.Now,
+		Rand:             rand.Reader,
+		opts:             DefaultEngineOptions(),
+		ConfigPath:       DefaultConfigPath(),
+		aliases:          make(map[string]string),
+		homophones:       (&Config{}).homophoneTable(),
+		numberHomophones: (&Config{}).numberHomophoneTable(),
+		disabled:         make(map[string]bool),
+		modes:            make(map[string]*Mode),
+		done:             make(chan struct{}),
+		State:            nil,
+		History:          NewHistory(DefaultHistorySize),
+		Metrics:          NewMetrics(),
+		playing:          make(map[string]bool),
+		lastFired:        make(map[string]time.Time),
+		ocr:              noOCR{},
+		shotCache:        make(map[int]screenshotCache),
+		jobs:             make(chan *Job, DefaultQueueSize),
+		jobTable:         make(map[uint64]*Job),
+		workerDone:       make(chan struct{}),
+		feedbackQueue:    make(chan feedbackEvent, FeedbackQueueSize),
+		feedbackCode:     setup.feedback,
+		IsOperating:      true,
+	}
+
+	if setup.configPath != "" {
+		e.ConfigPath = setup.configPath
+	}
+	if setup.spotsPath != "" {
+		e.Memory.FilePath = setup.spotsPath
+		e.Memory.Spots = make(map[string]MouseSpot)
+		e.Memory.Load()
 	}
 
+	e.Logger = setup.logger
+	if e.Logger == nil {
+		e.Logger = slog.Default()
+	}
+	e.StickyKeyboard.Logger = e.Logger
+	e.StickyKeyboard.cancelled = func() bool { return e.checkCancelled() != nil }
+	e.Mouse.Logger = e.Logger
+	e.Memory.Logger = e.Logger
+	e.Icons.Logger = e.Logger
+	e.Macros.Logger = e.Logger
+
+	if setup.ocr != nil {
+		e.ocr = setup.ocr
+	}
+	if setup.screen != nil {
+		e.Screen = setup.screen
+	}
+	if setup.windows != nil {
+		e.Windows = setup.windows
+	}
+	if setup.backend != nil {
+		e.StickyKeyboard.SetBackend(setup.backend)
+		e.Mouse.SetBackend(setup.backend)
+		e.backend = setup.backend.Name()
+	}
+	if setup.delay != nil {
+		opts := e.Options()
+		if setup.opts != nil {
+			opts = *setup.opts
+		}
+		opts.TokenDelayUs = int(*setup.delay / time.Microsecond)
+		setup.opts = &opts
+	}
+	if setup.opts != nil {
+		if err := e.SetOptions(*setup.opts); err != nil {
+			setup.errs = append(setup.errs, err)
+		}
+	}
+
+	e.Listening.Store(true)
+	if !setup.withoutDefaults {
+		e.commands = append(e.commands, Registry...)
+		for _, build := range BuiltinModes {
+			e.modeSpecs = append(e.modeSpecs, build())
+		}
+	}
+	e.modeSpecs = append(e.modeSpecs, setup.modes...)
 	e.registerCommands()
-	return e
+	for _, cmd := range setup.commands {
+		if err := e.Register(cmd); err != nil {
+			setup.errs = append(setup.errs, err)
+		}
+	}
+
+	e.logAudit()
+
+	// Layer the user's config on top of the built-ins
+	if _, err := e.ReloadConfig(); err != nil {
+		e.log().Error("failed to load config", "path", e.ConfigPath, "error", err)
+	}
+
+	go e.runJobs()
+	go e.watchModifiers()
+	go e.runFeedback()
+	return e, errors.Join(setup.errs...)
+}
+
+// Close shuts the engine down, interrupting any in-progress Sleep.
+// It is safe to call more than once.
+func (e *Engine) Close() {
+	e.closeOnce.Do(func() {
+		close(e.done)
+	})
+	// Let the job worker finish the phrase it is on and fail the rest
+	if e.workerDone != nil {
+		<-e.workerDone
+	}
+	e.EmergencyRelease()
+}
+
+// Sleep pauses for d, returning early (with false) if the engine is closed or
+// the running phrase is cancelled meanwhile.
+func (e *Engine) Sleep(d time.Duration) bool {
+	e.recorder.Record(RecordedAction{Kind: ActionSleep, DurationMs: durationMs(d)})
+	return e.wait(d)
+}
+
+// pace waits Engine.Delay between two tokens of a phrase or two iterations
+// of a repetition. It isn't recorded as an action; a cancelled phrase is
+// caught by the caller's next checkCancelled.
+func (e *Engine) pace() {
+	e.optsMu.RLock()
+	d := e.Delay
+	e.optsMu.RUnlock()
+	if d > 0 {
+		e.wait(d)
+	}
+}
+
+// settle gives the target app d to catch up with the input so far. Like pace
+// it isn't recorded; unlike pace it returns the phrase's cancellation, so the
+// caller can stop before sending more input.
+func (e *Engine) settle(d time.Duration) error {
+	if d > 0 && !e.wait(d) {
+		return e.checkCancelled()
+	}
+	return nil
+}
+
+// wait is Sleep without recording the pause.
+func (e *Engine) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-e.done:
+		return false
+	case <-e.Context().Done():
+		return false
+	}
 }
 
 func (e *Engine) registerCommands() {
-	for _, cmd := range Registry {
+	e.registryVersion.Add(1)
+	e.builtinTriggers = make(map[string]Cmd)
+	for _, cmd := range e.commands {
+		if e.isDisabled(cmd.Name()) {
+			continue
+		}
 		for _, trigger := range cmd.CalledBy() {
 			key := strings.ToLower(trigger)
+			if e.suppressed(key) {
+				continue
+			}
 			e.registry[key] = cmd
+			e.builtinTriggers[key] = cmd
 		}
 	}
 }
 
 func (e *Engine) Parse(input string, mode string) {
-	// 1. Determine if we should preserve the LastState.
-	// We preserve it if the user explicitly says "repeat",
-	// OR if the input consists ENTIRELY of numbers (e.g. "2", "2 10", "twenty").
-	shouldPreserveState := strings.Contains(strings.ToLower(input), "repeat")
-
-	if !shouldPreserveState {
-		// Check if the entire input is just numbers
-		prep := NewNumberPreprocessor()
-		words := strings.Fields(input)
-		if len(words) > 0 {
-			allNumbers := true
-			for _, w := range words {
-				// Convert word to digit form (e.g., "two" -> "2")
-				processed := prep.Process(w)
-				// If it's not an integer, then this phrase contains a real command
-				if _, err := strconv.Atoi(processed); err != nil {
-					allNumbers = false
-					break
-				}
-			}
-			if allNumbers {
-				shouldPreserveState = true
+	e.parse(input, SpokenWordsFromText(input), mode)
+}
+
+// ParseWords is Parse for recognizers that report a confidence per word.
+// Command words below the MinConfidence option are skipped by Execute.
+func (e *Engine) ParseWords(words []SpokenWord, mode string) {
+	text := make([]string, len(words))
+	for i, w := range words {
+		text[i] = w.W
+	}
+	e.parse(strings.Join(text, " "), words, mode)
+}
+
+func (e *Engine) parse(input string, words []SpokenWord, mode string) {
+	// A rejected phrase leaves the current state alone, so it can still be
+	// repeated; Execute reports the rejection instead of running anything
+	e.rejected = e.CheckInput(input)
+	if e.rejected != nil {
+		e.RawInput = input
+		e.log().Warn("phrase rejected", "error", e.rejected, "bytes", len(input))
+		return
+	}
+
+	// The phrase we're leaving becomes PreviousState(0), unless it was itself
+	// a replay: "left" is kept, but "2" (or "repeat") after it keeps "left"
+	// there, so repeating twice replays the same phrase twice. A cancelled
+	// phrase never becomes history.
+	if e.State != nil && !e.State.Repeated && !e.State.Cancelled {
+		e.pushPrevious(e.State)
+	}
+
+	e.RawInput = input
+	window := e.followWindow()
+	e.State = e.parseWords(words, mode)
+	e.State.Window = window
+	// A phrase ending in "stop" is dropped before Stop can run, so forget the
+	// previous phrases here: a stray number after it can't resume them
+	if n := len(e.State.Tokens); n > 0 {
+		if ct, ok := e.State.Tokens[n-1].(*CmdToken); ok {
+			if _, isStop := ct.Command().(Stop); isStop {
+				e.ForgetPrevious()
 			}
 		}
 	}
+	if e.rejected = e.checkRepeats(e.State); e.rejected != nil {
+		e.State.Cancelled = true // never becomes a previous state
+		e.log().Warn("phrase rejected", "error", e.rejected, "input", input)
+	}
+}
 
-	// 2. Rotate State to LastState if we aren't preserving it.
-	// This ensures "Left" sets LastState, but "2" keeps "Left" as LastState.
-	if e.State != nil && !shouldPreserveState {
-		e.LastState = e.State
+// PreviousState returns the nth phrase before the current one (0 is the most
+// recent), or nil when the ring doesn't reach that far. Phrases that ran a
+// Repeat, were cancelled, or were only numbers are not counted.
+func (e *Engine) PreviousState(n int) *EngineState {
+	if n < 0 || n >= len(e.previous) {
+		return nil
 	}
+	return e.previous[n]
+}
 
-	e.RawInput = input
+// ForgetPrevious drops every previous phrase, so nothing can be repeated
+// until a new phrase runs.
+func (e *Engine) ForgetPrevious() {
+	clear(e.previous)
+	e.previous = e.previous[:0]
+}
 
-	var executionMode ExecutonMode
+// pushPrevious stores a clone of state as PreviousState(0), dropping the
+// oldest beyond the PreviousStates option.
+func (e *Engine) pushPrevious(state *EngineState) {
+	depth := e.Options().PreviousStates
+	e.previous = slices.Insert(e.previous, 0, state.Clone())
+	if len(e.previous) > depth {
+		clear(e.previous[depth:])
+		e.previous = e.previous[:depth]
+	}
+}
+
+// parseState tokenizes input into a fresh EngineState without touching the
+// engine's current or last state.
+func (e *Engine) parseState(input string, mode string) *EngineState {
+	return e.parseWords(SpokenWordsFromText(input), mode)
+}
+
+// parseWords is parseState for words that carry a confidence. Words produced
+// by a homophone or alias inherit the confidence of the word they replaced.
+func (e *Engine) parseWords(words []SpokenWord, mode string) *EngineState {
+	e.registryMu.RLock()
+	defer e.registryMu.RUnlock()
+
+	// Mode, user, spot and built-in triggers are resolved by priority
+	resolver := e.resolver()
+
+	// Triggers match case-insensitively and without the punctuation
+	// recognizers add ("Copy."), but heard keeps both for dictation
+	rawInput := make([]string, 0, len(words))
+	heard := make([]string, 0, len(words))
+	confidences := make([]float64, 0, len(words))
+	for _, w := range words {
+		for _, field := range strings.Fields(w.W) {
+			match := field
+			if _, _, ok := resolver.Resolve(strings.ToLower(field)); !ok {
+				match = trimWordPunct(field)
+			}
+
+			// Homophones first, so an alias can be triggered by a misheard word
+			expanded := e.expandAliases(e.applyHomophones(match))
+			if expanded == match {
+				rawInput = append(rawInput, strings.ToLower(match))
+				heard = append(heard, field)
+				confidences = append(confidences, w.Conf)
+				continue
+			}
+			for _, word := range strings.Fields(expanded) {
+				rawInput = append(rawInput, strings.ToLower(word))
+				heard = append(heard, word)
+				confidences = append(confidences, w.Conf)
+			}
+		}
+	}
+
+	var executionMode ExecutionMode
 	if mode == "rapid" {
 		executionMode = ModeRapid
 	}
@@ -135,38 +842,223 @@ func (e *Engine) Parse(input string, mode string) {
 		ExecutionMode:   executionMode,
 	}
 
-	input = strings.ToLower(input)
-	rawInput := strings.Fields(input)
+	fuzzyDistance := 0
+	if opts := e.Options(); opts.FuzzyMatch {
+		fuzzyDistance = opts.FuzzyDistance
+	}
 
 	s.Tokens = make([]Token, 0, len(rawInput))
 	s.TokenIndices = make([]int, 0, len(rawInput))
 	s.RawWords = make([]string, 0, len(rawInput))
+	s.OriginalWords = make([]string, 0, len(rawInput))
+	s.Confidences = make([]float64, 0, len(rawInput))
+
+	multiWord, maxSpan := multiWordTriggers(resolver.Triggers(), func(trigger string) string {
+		return strings.Join(e.numberWords(strings.Fields(e.applyHomophones(trigger))), " ")
+	})
+
+	// Words a dictation command ("say", "camel") will type stay words: no
+	// number homophones, no "two" -> "2"
+	numbered := e.numberWords(rawInput)
+	dictating := false
+
+	// RawWords holds one entry per token (a multi-word trigger is one entry),
+	// so Advance and RemainingRawWords work in token positions.
+	for i := 0; i < len(rawInput); {
+		words := numbered
+		if dictating {
+			words = rawInput
+		}
+
+		// 1. Longest multi-word trigger starting here ("select all" beats "select")
+		token, span := MultiWordTokenFactory(words[i:], multiWord, maxSpan)
+		if ct, ok := token.(*CmdToken); ok {
+			_, ct.source, _ = resolver.Resolve(ct.literal)
+		}
+		if token == nil {
+			token, span = TokenFactory(words[i], resolver, fuzzyDistance), 1
+			if num, ok := token.(*NumberToken); ok && dictating && num.Literal() != words[i] {
+				token = &RawToken{literal: words[i]}
+			}
+		}
+		// "3 times ..." repeats the rest of the segment; "times" alone stays "*"
+		if num, ok := token.(*NumberToken); ok && !dictating && i+span < len(rawInput) && rawInput[i+span] == "times" {
+			span++
+			token = &CmdToken{
+				cmd:     Times{Count: num.Value()},
+				literal: num.Literal() + " times",
+				span:    span,
+			}
+		}
+		if p, ok := token.(placer); ok {
+			p.place(i, strings.Join(heard[i:i+span], " "))
+		}
+
+		// 2. A multi-word token is only as trustworthy as its least certain word
+		conf := confidences[i]
+		for _, c := range confidences[i : i+span] {
+			conf = min(conf, c)
+		}
 
-	for i, word := range rawInput {
-		// Pass e.Memory to TokenFactory so we can recognize saved spots
-		token := TokenFactory(word, e.registry, e.Memory)
 		s.Tokens = append(s.Tokens, token)
 		s.RawWords = append(s.RawWords, token.Literal())
+		s.OriginalWords = append(s.OriginalWords, CasedLiteral(token))
 		s.TokenIndices = append(s.TokenIndices, i)
+		s.Confidences = append(s.Confidences, conf)
 
 		if i == 0 && token.Type() == TokenTypeCmd {
 			s.FirstCmdIsValid = true
 		}
+		if ct, ok := token.(*CmdToken); ok {
+			// Dictation runs to the end of its segment
+			dictating = CommandDictates(ct.Command()) || (dictating && !isThen(token))
+		}
+		i += span
+	}
+
+	// If the phrase ENDS with "cancel", the user wants the whole thing dropped.
+	if len(s.Tokens) > 0 {
+		if ct, ok := s.Tokens[len(s.Tokens)-1].(*CmdToken); ok {
+			switch ct.Command().(type) {
+			case Cancel, Stop:
+				s.Cancelled = true
+			}
+		}
+	}
+
+	// No mode given: guess it from the tokens, unless the DefaultMode option fixes it
+	if s.ExecutionMode == "" {
+		s.ExecutionMode = e.defaultMode(s.Tokens)
+		s.ModeDetected = true
+	}
+
+	s.Durations = make([]time.Duration, len(s.Tokens))
+	s.Outcomes = make([]TokenOutcome, len(s.Tokens))
+	for i := range s.Outcomes {
+		s.Outcomes[i] = OutcomeNotRun
 	}
 
 	s.HandledTokens = make([]Token, 0, len(s.Tokens))
 	s.RemainingTokens = make([]Token, len(s.Tokens))
 	copy(s.RemainingTokens, s.Tokens)
-	s.RemainingRawWords = strings.Join(s.RawWords, " ")
+	s.RemainingRawWords = strings.Join(s.OriginalWords, " ")
+
+	return s
+}
+
+// defaultMode is the mode of a message sent without one, per the DefaultMode
+// and RapidMaxTokens options.
+func (e *Engine) defaultMode(tokens []Token) ExecutionMode {
+	opts := e.Options()
+	switch opts.DefaultMode {
+	case "rapid":
+		return ModeRapid
+	case "phrase":
+		return ModePhrase
+	}
+	return detectMode(tokens, opts.RapidMaxTokens)
+}
 
-	e.State = s
+// Execute runs the parsed phrase and records it in History, whether or not it succeeded.
+// The returned ExecutionResult describes what happened to every token.
+func (e *Engine) Execute() (ExecutionResult, error) {
+	return e.ExecuteContext(context.Background())
 }
 
-func (e *Engine) Execute() error {
+func (e *Engine) execute(ctx context.Context) (ExecutionResult, error) {
+	if e.rejected != nil {
+		return ExecutionResult{Input: e.RawInput, Error: e.rejected.Error()}, e.rejected
+	}
 	if e.State == nil {
+		return ExecutionResult{}, nil
+	}
+
+	// The same phrase delivered twice in quick succession only runs once
+	if e.isDuplicate() {
+		return ExecutionResult{
+			Input:        e.RawInput,
+			Normalized:   strings.Join(e.State.RawWords, " "),
+			Mode:         e.State.ExecutionMode,
+			Deduplicated: true,
+		}, nil
+	}
+
+	// Everything typed from here on belongs to this phrase (for "scratch that")
+	e.StickyKeyboard.BeginPhrase(e.History.NextID())
+
+	// A KillAfter in an earlier phrase (rapid mode, or a replay) must not
+	// silence this one
+	e.IsOperating = true
+
+	rec := e.beginRecording()
+	started := e.Now()
+	clock := time.Now()
+	err := e.run()
+	elapsed := time.Since(clock)
+
+	// A cancelled phrase may have stopped between taps with keys still down
+	if stopped := cancelErr(ctx); stopped != nil {
+		e.EmergencyRelease()
+		e.IsOperating = true
+		if !errors.Is(err, stopped) {
+			err = stopped
+		}
+	} else if err != nil {
+		// Nothing a failed phrase pressed (or held) stays down
+		e.EmergencyRelease()
+	}
+
+	e.endRecording()
+
+	entry := e.newHistoryEntry(started, err)
+	entry.Actions = rec.Actions()
+	entry.ActionsDropped = rec.Dropped()
+	entry.ID = e.History.Append(entry)
+	e.Metrics.ObservePhrase(entry, elapsed)
+
+	if err == nil {
+		e.recordPhrase()
+	}
+	return newExecutionResult(entry, e.State, elapsed), err
+}
+
+// isDuplicate reports whether the current phrase repeats the previous one within
+// the debounce window, and otherwise remembers it as the previous phrase.
+// Texts are compared after normalization, so "Click" and "click" match.
+func (e *Engine) isDuplicate() bool {
+	now := e.Now()
+	normalized := strings.Join(e.State.RawWords, " ")
+	opts := e.Options()
+
+	duplicate := opts.Debounce &&
+		normalized != "" &&
+		normalized == e.lastNormalized &&
+		now.Sub(e.lastExecutedAt) < time.Duration(opts.DebounceMs)*time.Millisecond
+
+	if !duplicate {
+		e.lastNormalized = normalized
+		e.lastExecutedAt = now
+	}
+	return duplicate
+}
+
+func (e *Engine) run() error {
+
+	// Parse already decided this phrase was cancelled as a whole
+	if e.State.Cancelled {
+		return nil
+	}
+
+	// An empty or whitespace-only phrase has nothing to run
+	if len(e.State.Tokens) == 0 {
 		return nil
 	}
 
+	// While asleep, the only thing we react to is "wake"
+	if !e.Listening.Load() {
+		return e.handleAsleep()
+	}
+
 	if e.State.ExecutionMode == ModePhrase {
 		err := e.handlePhraseMode()
 		if err != nil {
@@ -177,38 +1069,62 @@ func (e *Engine) Execute() error {
 	}
 
 	if e.State.ExecutionMode == ModeRapid {
-		// handle rapid execution
+		// handle rapid execution: "left 5" and "5 left" in one message first
+		if handled, err := e.handleRapidCount(); handled || err != nil {
+			return err
+		}
+
 		lastTok := e.State.Tokens[len(e.State.Tokens)-1]
 
+		lastIdx := len(e.State.Tokens) - 1
+
+		if e.lowConfidence(lastIdx, lastTok) {
+			e.State.setOutcome(lastIdx, OutcomeLowConfidence)
+			return nil
+		}
+
 		// handling regular commands
-		if lastTok.Type() == 1 {
-			shouldStop, err := lastTok.Handle(e, 0)
+		if lastTok.Type() == TokenTypeCmd {
+			start := time.Now()
+			shouldStop, err := e.handleToken(lastTok, lastIdx)
+			e.State.setDuration(lastIdx, time.Since(start))
+			if errors.Is(err, ErrSkip) {
+				e.State.setOutcome(lastIdx, OutcomeEffectSkipped)
+				return nil
+			}
 			if err != nil {
-				return err
+				e.State.setOutcome(lastIdx, OutcomeFailed)
+				return e.newExecError(lastIdx, lastTok, err)
 			}
+			e.State.setOutcome(lastIdx, OutcomeHandled)
 			if shouldStop {
 				e.IsOperating = false
 			}
 		}
 
 		// handling numbers
-		if lastTok.Type() == 2 {
+		if lastTok.Type() == TokenTypeNumber {
 			amt, err := strconv.Atoi(lastTok.Literal())
 			if err != nil {
 				return err
 			}
-			// In Rapid mode, we might need similar logic to token.go
-			// but for now, assuming Rapid uses simple command repetition:
-			if e.LastState != nil && len(e.LastState.Tokens) > 0 {
-				prevTok := e.LastState.Tokens[len(e.LastState.Tokens)-1]
-				amt = amt - 1
+			// A number repeats the last command of the previous message
+			e.State.Repeated = true
+			if prevTok := lastCmdToken(e.PreviousState(0)); prevTok != nil {
+				amt = min(amt, MaxRepeatCount) - 1
 				for {
 					if amt <= 0 {
 						break
 					}
-					shouldStop, err := prevTok.Handle(e, 0)
-					if err != nil {
-						return err
+					e.pace()
+					if err := e.checkCancelled(); err != nil {
+						e.State.setOutcome(lastIdx, OutcomeFailed)
+						return e.newExecError(lastIdx, lastTok, err)
+					}
+					shouldStop, err := e.handleToken(prevTok, lastIdx)
+					if err != nil && !errors.Is(err, ErrSkip) {
+						e.State.setOutcome(lastIdx, OutcomeFailed)
+						return e.newExecError(lastIdx, lastTok, err)
 					}
 					if shouldStop {
 						e.IsOperating = false
@@ -216,11 +1132,16 @@ func (e *Engine) Execute() error {
 					amt -= 1
 				}
 			}
+			e.State.setOutcome(lastIdx, OutcomeHandled)
 		}
 
-		// handling raw value
-		if lastTok.Type() == 0 {
-			// skip for now..
+		// handling raw value: a dictated word is typed rather than dropped
+		if lastTok.Type() == TokenTypeRaw {
+			if err := e.StickyKeyboard.TypeStr(CasedLiteral(lastTok) + e.Options().RapidRawSuffix); err != nil {
+				e.State.setOutcome(lastIdx, OutcomeFailed)
+				return e.newExecError(lastIdx, lastTok, err)
+			}
+			e.State.setOutcome(lastIdx, OutcomeHandled)
 		}
 
 	}
@@ -228,10 +1149,103 @@ func (e *Engine) Execute() error {
 	return nil
 }
 
+// lastCmdToken returns the last command in state, which a rapid number
+// repeats: "west" for both "west" and "west 5". It returns nil for a nil
+// state or one without commands.
+func lastCmdToken(state *EngineState) *CmdToken {
+	if state == nil {
+		return nil
+	}
+	for i := len(state.Tokens) - 1; i >= 0; i-- {
+		if ct, ok := state.Tokens[i].(*CmdToken); ok {
+			return ct
+		}
+	}
+	return nil
+}
+
+// handleRapidCount runs a rapid message ending in "<cmd> <number>" or
+// "<number> <cmd>" as the command repeated number times (at most
+// MaxRepeatCount), and reports whether the message had that shape. A command
+// that reads the words after it takes the number as its argument instead, so
+// "number 5" types "5".
+func (e *Engine) handleRapidCount() (bool, error) {
+	n := len(e.State.Tokens)
+	if n < 2 {
+		return false, nil
+	}
+
+	cmdIdx, numIdx := n-2, n-1
+	if e.State.Tokens[cmdIdx].Type() == TokenTypeNumber {
+		cmdIdx, numIdx = n-1, n-2
+	}
+	cmdTok, ok := e.State.Tokens[cmdIdx].(*CmdToken)
+	if !ok {
+		return false, nil
+	}
+	numTok, ok := e.State.Tokens[numIdx].(*NumberToken)
+	if !ok {
+		return false, nil
+	}
+	takesNumber := CommandConsumesArgs(cmdTok.Command())
+	if takesNumber && cmdIdx > numIdx {
+		return false, nil
+	}
+	if e.lowConfidence(cmdIdx, cmdTok) {
+		e.State.setOutcome(cmdIdx, OutcomeLowConfidence)
+		e.State.setOutcome(numIdx, OutcomeSkipped)
+		return true, nil
+	}
+
+	count := min(numTok.Value(), MaxRepeatCount)
+	if takesNumber {
+		count = 1
+	}
+
+	start := time.Now()
+	defer func() { e.State.setDuration(cmdIdx, time.Since(start)) }()
+	for k := range count {
+		if k > 0 {
+			e.pace()
+		}
+		if err := e.checkCancelled(); err != nil {
+			e.State.setOutcome(cmdIdx, OutcomeFailed)
+			return true, e.newExecError(cmdIdx, cmdTok, err)
+		}
+		shouldStop, err := e.handleToken(cmdTok, cmdIdx)
+		if err != nil && !errors.Is(err, ErrSkip) {
+			e.State.setOutcome(cmdIdx, OutcomeFailed)
+			return true, e.newExecError(cmdIdx, cmdTok, err)
+		}
+		if shouldStop {
+			e.IsOperating = false
+			break
+		}
+	}
+	e.State.setOutcome(cmdIdx, OutcomeHandled)
+	e.State.setOutcome(numIdx, OutcomeSkipped)
+	return true, nil
+}
+
 func (e *Engine) handlePhraseMode() error {
 	for i, token := range e.State.Tokens {
+		// "then" starts a new segment, which runs even if a KillAfter ended the last one
+		if isThen(token) {
+			if err := e.nextSegment(i, token); err != nil {
+				return err
+			}
+			continue
+		}
 		if !e.IsOperating {
-			break
+			// The rest of this segment was stopped; a later segment may still run
+			e.State.Advance(i, token)
+			continue
+		}
+		if i > 0 && e.State.SkipCount == 0 {
+			e.pace()
+		}
+		if err := e.checkCancelled(); err != nil {
+			return err
 		}
 
 		// 1. Check if we need to skip this token (because it was consumed as an argument)
@@ -239,16 +1253,38 @@ func (e *Engine) handlePhraseMode() error {
 			e.State.SkipCount--
 			// We still need to advance internal state tracking for accuracy
 			e.State.Advance(i, token)
+			e.State.setOutcome(i, OutcomeSkipped)
 			continue
 		}
 
 		e.State.Advance(i, token)
+		e.State.ConsumedArgs = e.State.ConsumedArgs[:0]
+
+		// 2. Hold back commands the recognizer wasn't sure about
+		if e.lowConfidence(i, token) {
+			e.State.setOutcome(i, OutcomeLowConfidence)
+			continue
+		}
 
-		stop, err := token.Handle(e, i)
+		start := time.Now()
+		stop, err := e.handleToken(token, i)
+		e.State.setDuration(i, time.Since(start))
+		if errors.Is(err, ErrSkip) {
+			// An effect declined to run the command; the phrase carries on
+			e.State.setOutcome(i, OutcomeEffectSkipped)
+			continue
+		}
 		if err != nil {
-			return err
+			e.State.setOutcome(i, OutcomeFailed)
+			return e.newExecError(i, token, err)
 		}
+		e.State.setOutcome(i, OutcomeHandled)
 		if stop {
+			if e.State.Halted {
+				for j := i + 1; j < len(e.State.Tokens); j++ {
+					e.State.setOutcome(j, OutcomeHalted)
+				}
+			}
 			return nil
 		}
 	}
@@ -257,6 +1293,379 @@ func (e *Engine) handlePhraseMode() error {
 	return nil
 }
 
+// handleToken runs token.Handle, turning a panic inside a command into a
+// *PanicError and releasing anything the command may have left held down.
+func (e *Engine) handleToken(token Token, i int) (stop bool, err error) {
+	e.State.current = i
+	defer func() {
+		if r := recover(); r != nil {
+			e.EmergencyRelease()
+			e.IsOperating = true
+			err = &PanicError{Value: r, Stack: string(debug.Stack())}
+			e.log().Error("command panicked", "literal", token.Literal(), "panic", r)
+		}
+	}()
+	return token.Handle(e, i)
+}
+
+// handleAsleep scans the phrase for a Wake command and runs only that.
+func (e *Engine) handleAsleep() error {
+	for _, token := range e.State.Tokens {
+		ct, ok := token.(*CmdToken)
+		if !ok {
+			continue
+		}
+		if _, isWake := ct.Command().(Wake); isWake {
+			return e.Invoke(ct.Command())
+		}
+	}
+	return nil
+}
+
+// EngineStatus is a read-only snapshot of the Engine's runtime state,
+// served by the /api/state endpoint.
+type EngineStatus struct {
+	Listening          bool   `json:"listening"`
+	Mode               string `json:"mode"` // "" when no mode is active
+	TypingDelayMs      int    `json:"typing_delay_ms"`
+	PostReleaseDelayMs int    `json:"post_release_delay_ms"`
+	TokenDelayUs       int    `json:"token_delay_us"`
+	MouseDelayMs       int    `json:"mouse_delay_ms"`
+	ClickGapMs         int    `json:"click_gap_ms"`
+	ClipboardDelayMs   int    `json:"clipboard_delay_ms"`
+	Pacing             string `json:"pacing"` // how the delays above relate
+	Backend            string `json:"backend"`
+	BackendError       string `json:"backend_error,omitempty"`
+
+	// Feedback names the feedback providers told how each phrase went
+	Feedback []string `json:"feedback"`
+
+	// HeldKeys are the keys "hold" is keeping down, so a stuck one shows
+	HeldKeys []string `json:"held_keys"`
+}
+
+// pacingNote explains the engine's delays in the status endpoint.
+const pacingNote = "token_delay_us (Engine.Delay) is waited between the tokens of a phrase and between repetitions; " +
+	"typing_delay_ms and post_release_delay_ms (StickyKeyboard) are waited after every key tap, inside a token; " +
+	"mouse_delay_ms (Mouse.Delay) is waited between the steps of a scroll and after an effect's click, click_gap_ms between the clicks of a double click; " +
+	"clipboard_delay_ms is waited for the clipboard to settle after a copy or a paste from the clipboard history."
+
+// Status returns the current runtime state of the Engine.
+func (e *Engine) Status() EngineStatus {
+	opts := e.Options()
+	status := EngineStatus{
+		Listening:          e.Listening.Load(),
+		Mode:               e.ActiveMode(),
+		TypingDelayMs:      opts.TypingDelayMs,
+		PostReleaseDelayMs: opts.PostReleaseDelayMs,
+		TokenDelayUs:       opts.TokenDelayUs,
+		MouseDelayMs:       opts.MouseDelayMs,
+		ClickGapMs:         opts.ClickGapMs,
+		ClipboardDelayMs:   opts.ClipboardDelayMs,
+		Pacing:             pacingNote,
+		Backend:            e.Backend(),
+		Feedback:           e.Feedback(),
+		HeldKeys:           e.StickyKeyboard.Held(),
+	}
+	if err := e.BackendError(); err != nil {
+		status.BackendError = err.Error()
+	}
+	return status
+}
+
 func (e *Engine) UpdateInternalState(i int, token Token) {
 	e.State.Advance(i, token)
 }
+
+// Replay re-executes a previously parsed phrase.
+//
+// A fresh state is built from the stored tokens because 'Advance' consumes
+// RemainingTokens; reusing the stored state directly would mean it could only be
+// replayed once. The current state is restored afterwards, and the replay is not
+// logged as its own History entry.
+func (e *Engine) Replay(state *EngineState) error {
+	if state == nil || len(state.Tokens) == 0 {
+		return nil
+	}
+	mode := state.ExecutionMode
+	if mode == "" {
+		mode = ModePhrase
+	}
+
+	replayState := &EngineState{
+		ExecutionMode: mode,
+		Tokens:        slices.Clone(state.Tokens),
+		TokenIndices:  slices.Clone(state.TokenIndices),
+		RawWords:      slices.Clone(state.RawWords),
+		OriginalWords: slices.Clone(state.OriginalWords),
+		ConsumedArgs:  make([]string, 0),
+		IsReplay:      true,
+		// Fresh tracking slices:
+		HandledTokens:   make([]Token, 0, len(state.Tokens)),
+		RemainingTokens: make([]Token, len(state.Tokens)),
+	}
+	copy(replayState.RemainingTokens, state.Tokens)
+	replayState.RemainingRawWords = strings.Join(state.OriginalWords, " ")
+
+	// Swap the Engine State, restoring it however the replay ends. A KillAfter
+	// inside the replayed phrase stops the replay, not the phrase that asked for it.
+	currentState, operating := e.State, e.IsOperating
+	e.State = replayState
+	defer func() { e.State, e.IsOperating = currentState, operating }()
+
+	return e.run()
+}
+
+// MaxRepeatCount caps how many times a single "repeat N" or bare "N" replays a phrase.
+const MaxRepeatCount = 50
+
+// replayIfSafe replays state once. See replayTimes.
+func (e *Engine) replayIfSafe(state *EngineState) error {
+	return e.replayTimes(state, 1)
+}
+
+// replayTimes replays state n times (at most MaxRepeatCount), unless it
+// contains a Repeat command, which would otherwise let "repeat" chase its own
+// tail, or we're already inside a replay (replays nest at most one deep).
+// Each iteration is recorded in the current token's trace. It marks the
+// current phrase as Repeated either way.
+func (e *Engine) replayTimes(state *EngineState, n int) error {
+	if e.State == nil || e.State.IsReplay {
+		return nil
+	}
+	e.State.Repeated = true
+	if state == nil || containsCmd[Repeat](state) {
+		return nil
+	}
+
+	current := e.State
+	for i := range min(n, MaxRepeatCount) {
+		if i > 0 {
+			e.pace()
+		}
+		if err := e.checkCancelled(); err != nil {
+			return err
+		}
+		start := time.Now()
+		err := e.Replay(state)
+		current.traceTiming("replay "+strconv.Itoa(i+1), time.Since(start))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tightRepeat reports whether repetitions of cmd can run back to back without
+// Engine.Delay between them: plain navigation keys ("south 40") with no key
+// held, unless compat typing asks for slow input.
+func (e *Engine) tightRepeat(cmd Cmd) bool {
+	if _, ok := commandKeys[cmd.Name()]; !ok || e.Options().CompatTyping {
+		return false
+	}
+	return len(e.StickyKeyboard.Held()) == 0
+}
+
+// containsCmd reports whether any token in the state resolves to a command of
+// type T. Once the state has run, only the tokens that ran count, so the word
+// "repeat" dictated in "say repeat after me" isn't the Repeat command.
+func containsCmd[T Cmd](state *EngineState) bool {
+	for i, token := range state.Tokens {
+		if i < len(state.Outcomes) && state.Outcomes[i] != OutcomeHandled && state.Outcomes[i] != OutcomeFailed {
+			continue
+		}
+		if ct, ok := token.(*CmdToken); ok {
+			if _, match := ct.Command().(T); match {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Spell turns spoken words into the characters they name, using the
+// single-character commands in the registry: NATO letters, symbol words
+// ("dash", "close curly") and number words become their character, other
+// words are kept as they are. The results are joined without spaces.
+func (e *Engine) Spell(words []string) string {
+	e.registryMu.RLock()
+	defer e.registryMu.RUnlock()
+
+	char := func(trigger string) (string, bool) {
+		cmd, ok := e.registry[strings.ToLower(trigger)]
+		if !ok || utf8.RuneCountInString(cmd.Name()) != 1 {
+			return "", false
+		}
+		return cmd.Name(), true
+	}
+
+	prep := NewNumberPreprocessor()
+	var b strings.Builder
+	for i := 0; i < len(words); i++ {
+		// Two-word triggers first ("close curly")
+		if i+1 < len(words) {
+			if c, ok := char(words[i] + " " + words[i+1]); ok {
+				b.WriteString(c)
+				i++
+				continue
+			}
+		}
+		if c, ok := char(words[i]); ok {
+			b.WriteString(c)
+			continue
+		}
+		if digits := prep.Process(strings.ToLower(words[i])); isDigits(digits) {
+			b.WriteString(digits)
+			continue
+		}
+		b.WriteString(words[i])
+	}
+	return b.String()
+}
+
+// isDigits reports whether s is a non-empty run of ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Invoke runs a command's Action, marking it as the active command so
+// configured effect overrides apply to it.
+func (e *Engine) Invoke(cmd Cmd) error {
+	return e.invoke(cmd, func() error { return cmd.Action(e, "") })
+}
+
+// InvokeWithArgs is Invoke for an ArgTaker whose arguments were already claimed.
+func (e *Engine) InvokeWithArgs(cmd Cmd, args []Token) error {
+	taker, ok := cmd.(ArgTaker)
+	if !ok {
+		return e.Invoke(cmd)
+	}
+	return e.invoke(cmd, func() error { return taker.ActionWithArgs(e, args) })
+}
+
+func (e *Engine) invoke(cmd Cmd, action func() error) error {
+	previous := e.activeCmd
+	e.activeCmd = cmd
+	defer func() { e.activeCmd = previous }()
+
+	if err := e.checkCancelled(); err != nil {
+		return err
+	}
+	if err := e.checkCooldown(cmd); err != nil {
+		return err
+	}
+	if before := e.activeBeforeCmd(); before != nil {
+		before(e, cmd)
+	}
+
+	start := time.Now()
+	err := action()
+	if err != nil {
+		e.log().Error("command failed", "command", cmd.Name(), "duration", time.Since(start), "error", err)
+		return err
+	}
+	e.log().Debug("command", "command", cmd.Name(), "duration", time.Since(start))
+	return nil
+}
+
+// log returns the engine's Logger, falling back to slog.Default() for engines
+// not built with NewEngine.
+func (e *Engine) log() *slog.Logger {
+	if e.Logger != nil {
+		return e.Logger
+	}
+	return slog.Default()
+}
+
+// ----------------------------------------------------------------------------
+// SEGMENTS AND GROUPS
+// ----------------------------------------------------------------------------
+
+// isThen reports whether token is the "then" segment separator.
+func isThen(token Token) bool {
+	ct, ok := token.(*CmdToken)
+	if !ok {
+		return false
+	}
+	_, then := ct.Command().(Then)
+	return then
+}
+
+// segmentEnd returns the index of the first "then" after token i, or
+// len(Tokens) when i is in the last segment.
+func (s *EngineState) segmentEnd(i int) int {
+	for j := max(i+1, 0); j < len(s.Tokens); j++ {
+		if isThen(s.Tokens[j]) {
+			return j
+		}
+	}
+	return len(s.Tokens)
+}
+
+// nextSegment crosses the "then" at token i: repetition and KillAfter don't
+// carry over, and the ThenDelayMs pause lets the target app catch up. A
+// trailing "then" has no segment after it and doesn't pause.
+func (e *Engine) nextSegment(i int, token Token) error {
+	s := e.State
+	s.Advance(i, token)
+	s.SkipCount = 0
+	s.LastCmd, s.LastArgs = nil, nil
+	s.Segment++
+	e.IsOperating = true
+
+	if i == len(s.Tokens)-1 {
+		s.setOutcome(i, OutcomeHandled)
+		return nil
+	}
+	s.current = i
+	delay := time.Duration(e.Options().ThenDelayMs) * time.Millisecond
+	if delay > 0 && !e.Sleep(delay) {
+		if err := e.checkCancelled(); err != nil {
+			s.setOutcome(i, OutcomeFailed)
+			return err
+		}
+	}
+	s.traceTiming("segment "+strconv.Itoa(s.Segment), delay)
+	s.setOutcome(i, OutcomeHandled)
+	return nil
+}
+
+// group copies tokens [from, to) into a fresh state that can run on its own,
+// as the body of "N times".
+func (s *EngineState) group(from, to int) *EngineState {
+	g := &EngineState{
+		ExecutionMode: ModePhrase,
+		Tokens:        slices.Clone(s.Tokens[from:to]),
+		RawWords:      slices.Clone(s.RawWords[from:to]),
+		OriginalWords: slices.Clone(s.OriginalWords[from:to]),
+		TokenIndices:  slices.Clone(s.TokenIndices[from:to]),
+		Confidences:   slices.Clone(s.Confidences[from:to]),
+		ConsumedArgs:  make([]string, 0),
+		IsReplay:      s.IsReplay,
+		Segment:       s.Segment,
+	}
+	g.HandledTokens = make([]Token, 0, len(g.Tokens))
+	g.RemainingTokens = slices.Clone(g.Tokens)
+	g.RemainingRawWords = strings.Join(g.OriginalWords, " ")
+	g.Outcomes = make([]TokenOutcome, len(g.Tokens))
+	g.Durations = make([]time.Duration, len(g.Tokens))
+	return g
+}
+
+// runGroup executes g in place of the current state, restoring it after. A
+// KillAfter inside g ends that pass only.
+func (e *Engine) runGroup(g *EngineState) error {
+	current, operating := e.State, e.IsOperating
+	e.State = g
+	e.IsOperating = true
+	defer func() { e.State, e.IsOperating = current, operating }()
+	return e.handlePhraseMode()
+}
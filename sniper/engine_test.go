@@ -0,0 +1,738 @@
+package sniper_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phillip-england/sniper/sniper"
+	"github.com/phillip-england/sniper/sniper/snipertest"
+)
+
+func TestKillAfterDoesNotLeakAcrossPhrases(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+
+	// A rapid "type" ends with KillAfter, which used to silence the next phrase
+	e.Parse("type foo", "rapid")
+	if _, err := e.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	snipertest.ExpectMouse(t, e, "click", "click left")
+
+	e.Parse("say hello", "rapid")
+	if _, err := e.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	snipertest.ExpectKeys(t, e, "south", "down")
+}
+
+func TestRepeatingDictationKeepsTheEngineRunning(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.MustRun(t, "say hi")
+
+	snipertest.ExpectTyped(t, e, "repeat", "Hi. ")
+
+	// The KillAfter in the first replay doesn't stop the second
+	snipertest.ExpectTyped(t, e, "repeat two", "Hi. Hi. ")
+	snipertest.ExpectKeys(t, e, "south", "down")
+	snipertest.ExpectMouse(t, e, "click", "click left")
+}
+
+// claimCmd claims the next n tokens as its arguments and records what it got.
+type claimCmd struct {
+	n         int
+	claimed   *[]string
+	remaining *string
+}
+
+func (c claimCmd) Name() string                  { return "claim" }
+func (c claimCmd) CalledBy() []string            { return []string{"claim"} }
+func (c claimCmd) Effects() []sniper.NamedEffect { return nil }
+func (c claimCmd) Action(e *sniper.Engine, p string) error {
+	*c.claimed = nil
+	for _, token := range e.ConsumeNext(c.n) {
+		*c.claimed = append(*c.claimed, token.Literal())
+	}
+	*c.remaining = e.State.RemainingRawWords
+	if !slices.Equal(e.State.ConsumedArgs, *c.claimed) {
+		return fmt.Errorf("ConsumedArgs = %q, want %q", e.State.ConsumedArgs, *c.claimed)
+	}
+	return nil
+}
+
+func TestConsumeNext(t *testing.T) {
+	var claimed []string
+	var remaining string
+	e := snipertest.NewTestEngine(t)
+	if err := e.Register(claimCmd{n: 2, claimed: &claimed, remaining: &remaining}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		phrase    string
+		claimed   []string
+		remaining string
+		keys      []string
+	}{
+		{"claim south east west", []string{"south", "east"}, "west", []string{"left"}},
+		{"claim south east", []string{"south", "east"}, "", nil},
+		{"south claim", nil, "", []string{"down"}},                          // at the end of the phrase
+		{"claim south", []string{"south"}, "", nil},                         // fewer tokens than asked for
+		{"claim south then east", []string{"south"}, "", []string{"right"}}, // never past "then"
+		{"claim 3 west", []string{"3", "west"}, "", nil},                    // a claimed number doesn't repeat
+	}
+	for _, tt := range tests {
+		e.MustRun(t, tt.phrase)
+		if !slices.Equal(claimed, tt.claimed) || remaining != tt.remaining {
+			t.Errorf("%q claimed %q leaving %q, want %q leaving %q", tt.phrase, claimed, remaining, tt.claimed, tt.remaining)
+		}
+		if got := e.Input.Keys(); !slices.Equal(got, tt.keys) {
+			t.Errorf("%q tapped %q, want %q", tt.phrase, got, tt.keys)
+		}
+	}
+}
+
+func TestNumberConsumesItsNumber(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.MustRun(t, "south")
+
+	// The number is typed, not also taken as a repetition of "south"
+	snipertest.ExpectTyped(t, e, "number five", "5")
+	if got := e.Input.Keys(); len(got) > 0 {
+		t.Errorf("number five also tapped %q", got)
+	}
+	snipertest.ExpectKeys(t, e, "south then number 3 east", "down", "right")
+
+	// The following command still runs, once
+	e.MustRun(t, "number five enter")
+	if got := e.Input.Typed(); got != "5" {
+		t.Errorf("number five enter typed %q, want %q", got, "5")
+	}
+	if got := e.Input.Keys(); !slices.Equal(got, []string{"enter"}) {
+		t.Errorf("number five enter tapped %q, want one enter", got)
+	}
+	snipertest.ExpectTyped(t, e, "number five then number six", "56")
+}
+
+// pairCmd takes two arguments and records every call it gets.
+type pairCmd struct{ calls *[][]string }
+
+func (c pairCmd) Name() string                            { return "pair" }
+func (c pairCmd) CalledBy() []string                      { return []string{"pair"} }
+func (c pairCmd) Effects() []sniper.NamedEffect           { return nil }
+func (c pairCmd) ArgCount() int                           { return 2 }
+func (c pairCmd) Action(e *sniper.Engine, p string) error { return c.ActionWithArgs(e, nil) }
+func (c pairCmd) ActionWithArgs(e *sniper.Engine, args []sniper.Token) error {
+	literals := make([]string, 0, len(args))
+	for _, arg := range args {
+		literals = append(literals, arg.Literal())
+	}
+	*c.calls = append(*c.calls, literals)
+	return nil
+}
+
+func TestArgTakerDispatch(t *testing.T) {
+	var calls [][]string
+	e := snipertest.NewTestEngine(t)
+	if err := e.Register(pairCmd{calls: &calls}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		phrase string
+		calls  [][]string
+		keys   []string
+	}{
+		{"pair alpha bravo east", [][]string{{"alpha", "bravo"}}, []string{"right"}},
+		{"pair alpha", [][]string{{"alpha"}}, nil},
+		{"east pair", [][]string{{}}, []string{"right"}}, // no arguments left
+		{"pair alpha then east", [][]string{{"alpha"}}, []string{"right"}},
+		// A repeat reuses the claimed arguments instead of claiming more
+		{"pair alpha bravo 3", [][]string{{"alpha", "bravo"}, {"alpha", "bravo"}, {"alpha", "bravo"}}, nil},
+	}
+	for _, tt := range tests {
+		calls = nil
+		e.MustRun(t, tt.phrase)
+		if !slices.EqualFunc(calls, tt.calls, slices.Equal) {
+			t.Errorf("%q called pair with %q, want %q", tt.phrase, calls, tt.calls)
+		}
+		if got := e.Input.Keys(); !slices.Equal(got, tt.keys) {
+			t.Errorf("%q tapped %q, want %q", tt.phrase, got, tt.keys)
+		}
+	}
+}
+
+func TestBuiltinArgTakers(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+
+	snipertest.ExpectTyped(t, e, "word git commit", "git")
+	snipertest.ExpectTyped(t, e, "word two git status now", "git status")
+	snipertest.ExpectTyped(t, e, "number 42", "42")
+
+	// At the end of a phrase there is nothing to type
+	snipertest.ExpectTyped(t, e, "word", "")
+	snipertest.ExpectTyped(t, e, "number", "")
+	snipertest.ExpectTyped(t, e, "south number", "")
+}
+
+func TestRepeatGuards(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.MustRun(t, "south")
+
+	// "repeat repeat" replays the last phrase once per repeat, never itself
+	snipertest.ExpectKeys(t, e, "repeat repeat", "down", "down")
+
+	// Repeating after a repeat replays the same phrase again
+	snipertest.ExpectKeys(t, e, "repeat", "down")
+	snipertest.ExpectKeys(t, e, "repeat", "down")
+
+	// Dictating the word "repeat" is an ordinary phrase that can be repeated
+	snipertest.ExpectTyped(t, e, "say repeat after me", "Repeat after me. ")
+	snipertest.ExpectTyped(t, e, "repeat", "Repeat after me. ")
+}
+
+func TestRepeatCount(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.MustRun(t, "west")
+	e.MustRun(t, "south east")
+	thrice := []string{"down", "right", "down", "right", "down", "right"}
+
+	// A bare "three" replays the phrase just run, and "repeat three" after it
+	// still means the same phrase
+	snipertest.ExpectKeys(t, e, "3", thrice...)
+	snipertest.ExpectKeys(t, e, "repeat three", thrice...)
+	snipertest.ExpectKeys(t, e, "repeat 3", thrice...)
+
+	// Each replay is timed in the trace of the "repeat" token
+	result := e.MustRun(t, "repeat 2")
+	var names []string
+	for _, timing := range result.Tokens[0].Timings {
+		names = append(names, timing.Name)
+	}
+	if want := []string{"replay 1", "replay 2"}; !slices.Equal(names, want) {
+		t.Errorf("repeat 2 traced %q, want %q", names, want)
+	}
+}
+
+func TestRepeatCountIsCapped(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.MustRun(t, "south")
+
+	e.MustRun(t, "repeat 400")
+	if got := len(e.Input.Keys()); got != sniper.MaxRepeatCount {
+		t.Errorf("repeat 400 replayed %d times, want %d", got, sniper.MaxRepeatCount)
+	}
+}
+
+// snapshot describes the parts of a state that running later phrases could
+// disturb.
+func snapshot(s *sniper.EngineState) string {
+	literals := func(tokens []sniper.Token) []string {
+		out := make([]string, len(tokens))
+		for i, tok := range tokens {
+			out[i] = tok.Literal()
+		}
+		return out
+	}
+	return fmt.Sprintf("tokens=%q remaining=%q handled=%q raw=%q outcomes=%q words=%q",
+		literals(s.Tokens), literals(s.RemainingTokens), literals(s.HandledTokens),
+		s.RawWords, s.Outcomes, s.RemainingRawWords)
+}
+
+func TestPreviousStateIsIsolated(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.MustRun(t, "south east then say hi")
+	e.MustRun(t, "west")
+	previous := e.PreviousState(0)
+	if previous == nil {
+		t.Fatal("no previous state after a phrase")
+	}
+	before := snapshot(previous)
+
+	// Repeats replay the stored state; new phrases parse into their own
+	for _, phrase := range []string{"repeat back 2", "2", "repeat", "north 3", "repeat last 2"} {
+		e.MustRun(t, phrase)
+	}
+	if after := snapshot(previous); after != before {
+		t.Errorf("previous state changed while later phrases ran\nbefore %s\nafter  %s", before, after)
+	}
+}
+
+func TestEngineStateClone(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.MustRun(t, "south east")
+	original := e.State
+	before := snapshot(original)
+
+	clone := original.Clone()
+	clone.Tokens[0] = clone.Tokens[1]
+	clone.RawWords[0] = "changed"
+	clone.Outcomes[0] = sniper.OutcomeFailed
+	clone.RemainingTokens = clone.RemainingTokens[:0]
+	clone.HandledTokens = append(clone.HandledTokens[:0], clone.Tokens[1])
+
+	if after := snapshot(original); after != before {
+		t.Errorf("editing a clone changed the original\nbefore %s\nafter  %s", before, after)
+	}
+}
+
+func TestPreviousStatesRing(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	opts := e.Options()
+	opts.PreviousStates = 3
+	if err := e.SetOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	// The phrase that is running only becomes PreviousState(0) once the next is parsed
+	for _, phrase := range []string{"south", "east", "west", "north", "enter"} {
+		e.MustRun(t, phrase)
+	}
+	var got []string
+	for n := range 4 {
+		if s := e.PreviousState(n); s != nil {
+			got = append(got, s.Tokens[0].Literal())
+		}
+	}
+	if want := []string{"north", "west", "east"}; !slices.Equal(got, want) {
+		t.Errorf("previous states = %q, want %q newest first", got, want)
+	}
+	if e.PreviousState(-1) != nil {
+		t.Error("PreviousState(-1) isn't nil")
+	}
+
+	// "repeat back N" reaches into the ring; beyond it nothing happens
+	snipertest.ExpectKeys(t, e, "repeat back 2", "up")
+	snipertest.ExpectKeys(t, e, "repeat back 9")
+
+	e.ForgetPrevious()
+	snipertest.ExpectKeys(t, e, "repeat")
+}
+
+func TestWordCount(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.MustRun(t, "south")
+
+	tests := []struct{ phrase, want string }{
+		{"word git status", "git"},
+		{"word two git status now", "git status"},
+		{"word 3 go mod tidy please", "go mod tidy"},
+		{"word three alpha", "alpha"}, // fewer words than asked for
+		{"word zero git status", "git"},
+		{"word two", ""}, // a count with nothing to type
+	}
+	for _, tt := range tests {
+		e.MustRun(t, tt.phrase)
+		if got := e.Input.Typed(); got != tt.want {
+			t.Errorf("%q typed %q, want %q", tt.phrase, got, tt.want)
+		}
+		// The count is the word's argument, not a repetition of "south"
+		if keys := e.Input.Keys(); len(keys) > 0 {
+			t.Errorf("%q also tapped %q", tt.phrase, keys)
+		}
+	}
+}
+
+func TestRapidLastToken(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+
+	// A raw word is typed with the suffix rather than dropped
+	runIn(t, e, "rapid", "hello")
+	if got := e.Input.Typed(); got != "hello " {
+		t.Errorf("rapid raw word typed %q, want %q", got, "hello ")
+	}
+	if got := runIn(t, e, "rapid", "hello south"); !slices.Equal(got, []string{"down"}) {
+		t.Errorf("rapid command tapped %q, want down", got)
+	}
+	// A number repeats the previous message's command up to that count, so
+	// "south" then "3" is three downs in all
+	if got := runIn(t, e, "rapid", "3"); !slices.Equal(got, []string{"down", "down"}) {
+		t.Errorf("rapid number tapped %q, want down twice more", got)
+	}
+
+	opts := e.Options()
+	opts.RapidRawSuffix = ""
+	if err := e.SetOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	runIn(t, e, "rapid", "hello")
+	if got := e.Input.Typed(); got != "hello" {
+		t.Errorf("rapid raw word typed %q with no suffix, want %q", got, "hello")
+	}
+}
+
+func TestRapidCommandCount(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+
+	tests := []struct {
+		phrase string
+		want   []string
+	}{
+		{"west 3", []string{"left", "left", "left"}},
+		{"3 west", []string{"left", "left", "left"}},
+		{"north south 2", []string{"down", "down"}}, // only the last pair counts
+		{"west 0", nil},
+	}
+	for _, tt := range tests {
+		if got := runIn(t, e, "rapid", tt.phrase); !slices.Equal(got, tt.want) {
+			t.Errorf("rapid %q tapped %q, want %q", tt.phrase, got, tt.want)
+		}
+	}
+
+	if got := runIn(t, e, "rapid", "west 400"); len(got) != sniper.MaxRepeatCount {
+		t.Errorf("rapid west 400 tapped %d keys, want %d", len(got), sniper.MaxRepeatCount)
+	}
+	// A command reading the words after it keeps its number
+	if got := runIn(t, e, "rapid", "number 5"); len(got) > 0 {
+		t.Errorf("rapid number 5 tapped %q", got)
+	}
+	if got := e.Input.Typed(); got != "5" {
+		t.Errorf("rapid number 5 typed %q, want %q", got, "5")
+	}
+
+	// A lone number repeats the command of a counted message, not its count
+	runIn(t, e, "rapid", "west 5")
+	if got := runIn(t, e, "rapid", "3"); !slices.Equal(got, []string{"left", "left"}) {
+		t.Errorf("rapid 3 after west 5 tapped %q, want left twice", got)
+	}
+
+	// A lone number with nothing before it does nothing
+	fresh := snipertest.NewTestEngine(t)
+	if got := runIn(t, fresh, "rapid", "3"); len(got) > 0 {
+		t.Errorf("rapid 3 on a fresh engine tapped %q", got)
+	}
+}
+
+// quietOptions are the engine options that keep sniper.New away from the
+// user's files and input devices.
+func quietOptions(t *testing.T) []sniper.EngineOption {
+	dir := t.TempDir()
+	return []sniper.EngineOption{
+		sniper.WithConfigPath(filepath.Join(dir, "sniper.json")),
+		sniper.WithMemory(&sniper.MouseMemory{Spots: map[string]sniper.MouseSpot{}, FilePath: filepath.Join(dir, "spots.json")}),
+		sniper.WithKeyboard(sniper.NewStickyKeyboard()),
+		sniper.WithMouse(&sniper.Mouse{Jump: 1}),
+		sniper.WithInputBackend(snipertest.NewInput()),
+		sniper.WithScreen(snipertest.NewScreen(64, 64, color.White)),
+		sniper.WithWindowProvider(&snipertest.Window{}),
+		sniper.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+	}
+}
+
+func TestNewRejectsBadOptions(t *testing.T) {
+	badOpts := sniper.DefaultEngineOptions()
+	badOpts.Layout = "dvorak"
+
+	tests := []struct {
+		name   string
+		option sniper.EngineOption
+	}{
+		{"nil keyboard", sniper.WithKeyboard(nil)},
+		{"nil mouse", sniper.WithMouse(nil)},
+		{"nil memory", sniper.WithMemory(nil)},
+		{"nil feedback", sniper.WithFeedback(nil)},
+		{"negative delay", sniper.WithDelay(-time.Millisecond)},
+		{"memory and spots path", sniper.WithSpotsPath(filepath.Join(t.TempDir(), "spots.json"))},
+		{"invalid options", sniper.WithOptions(badOpts)},
+		{"taken trigger", sniper.WithCommands(jamCmd{}, jamCmd{})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := sniper.New(append(quietOptions(t), tt.option)...)
+			if err == nil {
+				e.Close()
+				t.Fatal("New succeeded")
+			}
+			if e != nil {
+				t.Error("New returned an engine along with its error")
+			}
+		})
+	}
+}
+
+func TestNewAppliesOptions(t *testing.T) {
+	keyboard := sniper.NewStickyKeyboard()
+	mouse := &sniper.Mouse{Jump: 1}
+	var logs bytes.Buffer
+	e := snipertest.NewTestEngine(t,
+		sniper.WithKeyboard(keyboard),
+		sniper.WithMouse(mouse),
+		sniper.WithDelay(3*time.Millisecond),
+		sniper.WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+	)
+
+	if e.StickyKeyboard != keyboard || e.Mouse != mouse {
+		t.Error("New didn't use the keyboard and mouse it was given")
+	}
+	if e.Delay != 3*time.Millisecond || e.Options().TokenDelayUs != 3000 {
+		t.Errorf("delay = %v (token_delay_us %d), want 3ms", e.Delay, e.Options().TokenDelayUs)
+	}
+	e.Run("jam")
+	if logs.Len() == 0 {
+		t.Error("nothing was logged to the given logger")
+	}
+}
+
+func TestWithoutDefaults(t *testing.T) {
+	e := snipertest.NewTestEngine(t, sniper.WithoutDefaults(), sniper.WithCommands(jamCmd{}))
+
+	if _, err := e.Run("jam"); !errors.Is(err, errJammed) {
+		t.Errorf("jam: err = %v, want errJammed", err)
+	}
+	if keys := runIn(t, e, "phrase", "south east"); len(keys) > 0 {
+		t.Errorf("built-ins ran without defaults: %q", keys)
+	}
+}
+
+func TestNavigationRepeatsSkipPacing(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	opts := e.Options()
+	opts.TokenDelayUs = 10_000
+	opts.PostReleaseDelayMs = sniper.DefaultEngineOptions().PostReleaseDelayMs
+	if err := e.SetOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	paced := 40 * 10 * time.Millisecond
+
+	start := time.Now()
+	e.MustRun(t, "south 40")
+	if elapsed := time.Since(start); elapsed > paced/2 {
+		t.Errorf("south 40 took %v, as if each tap were paced", elapsed)
+	}
+	if n := len(e.Input.Keys()); n != 40 {
+		t.Errorf("south 40 tapped %d keys", n)
+	}
+
+	// A held key or compat typing keeps the pacing
+	tests := []struct {
+		name  string
+		setup func()
+	}{
+		{"held shift", func() { e.MustRun(t, "hold shift") }},
+		{"compat typing", func() {
+			e.MustRun(t, "release")
+			opts.CompatTyping = true
+			if err := e.SetOptions(opts); err != nil {
+				t.Fatal(err)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		tt.setup()
+		start := time.Now()
+		e.MustRun(t, "south 40")
+		if elapsed := time.Since(start); elapsed < paced*3/4 {
+			t.Errorf("%s: south 40 took only %v", tt.name, elapsed)
+		}
+	}
+}
+
+// BenchmarkNavigationRepeat taps down 100 times at the default delays, with
+// and without compat typing.
+func BenchmarkNavigationRepeat(b *testing.B) {
+	for _, compat := range []bool{false, true} {
+		name := "tight"
+		if compat {
+			name = "compat"
+		}
+		b.Run(name, func(b *testing.B) {
+			e := snipertest.NewTestEngine(b)
+			opts := e.Options()
+			defaults := sniper.DefaultEngineOptions()
+			opts.TokenDelayUs = defaults.TokenDelayUs
+			opts.PostReleaseDelayMs = defaults.PostReleaseDelayMs
+			opts.CompatTyping = compat
+			if err := e.SetOptions(opts); err != nil {
+				b.Fatal(err)
+			}
+			for b.Loop() {
+				e.MustRun(b, "south 100")
+			}
+		})
+	}
+}
+
+func TestNumberHomophonesSpareDictation(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+
+	// Off by default: "to" is just a word
+	snipertest.ExpectKeys(t, e, "west to", "left")
+
+	opts := e.Options()
+	opts.AggressiveNumbers = true
+	if err := e.SetOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	snipertest.ExpectKeys(t, e, "west to", "left", "left")
+	snipertest.ExpectKeys(t, e, "west two", "left", "left")
+
+	// Dictated words are typed as heard, in the same phrase as a count
+	e.MustRun(t, "west to then say two of them went to town")
+	if got := e.Input.Keys(); !slices.Equal(got, []string{"left", "left"}) {
+		t.Errorf("west to then say ... tapped %q, want two lefts", got)
+	}
+	if got := e.Input.Typed(); got != "Two of them went to town. " {
+		t.Errorf("say typed %q", got)
+	}
+	snipertest.ExpectTyped(t, e, "say I want to go", "I want to go. ")
+
+	// A formatter still turns number words into digits, but not "to"
+	snipertest.ExpectTyped(t, e, "camel go to page two", "goToPage2")
+
+	// The segment after the dictation counts again
+	e.MustRun(t, "say go then west too")
+	if got := e.Input.Keys(); !slices.Equal(got, []string{"left", "left"}) {
+		t.Errorf("say go then west too tapped %q, want two lefts", got)
+	}
+}
+
+func TestDebounceFollowsTheEngineClock(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	clock := &fakeClock{now: time.Date(2024, 3, 9, 14, 5, 0, 0, time.UTC)}
+	e.Now = clock.Now
+	opts := e.Options()
+	opts.Debounce, opts.DebounceMs = true, 500
+	if err := e.SetOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	e.MustRun(t, "east")
+	clock.Advance(499 * time.Millisecond)
+	if result := e.MustRun(t, "East"); !result.Deduplicated {
+		t.Error("a repeat inside the window wasn't debounced")
+	}
+	clock.Advance(time.Millisecond)
+	if result := e.MustRun(t, "east"); result.Deduplicated {
+		t.Error("a repeat once the window passed was debounced")
+	}
+	snipertest.ExpectKeys(t, e, "west", "left")
+}
+
+func TestDetectMode(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	tests := []struct {
+		phrase string
+		want   sniper.ExecutionMode
+	}{
+		// Lone commands and numbers, and a command with a count
+		{"south", sniper.ModeRapid},
+		{"5", sniper.ModeRapid},
+		{"five", sniper.ModeRapid},
+		{"south 5", sniper.ModeRapid},
+		{"south east", sniper.ModeRapid},
+
+		// Dictation and other commands that read the words after them
+		{"say", sniper.ModePhrase},
+		{"say hello", sniper.ModePhrase},
+		{"camel get user", sniper.ModePhrase},
+		{"remember", sniper.ModePhrase},
+		{"mode vim", sniper.ModePhrase},
+
+		// Plain words, alone or mixed with commands
+		{"hello", sniper.ModePhrase},
+		{"south hello", sniper.ModePhrase},
+		{"hello 5", sniper.ModePhrase},
+
+		// Anything longer than a command and its count
+		{"south east west", sniper.ModePhrase},
+		{"south 5 east", sniper.ModePhrase},
+		{"", sniper.ModePhrase},
+	}
+	for _, tt := range tests {
+		e.Parse(tt.phrase, "phrase")
+		if got := sniper.DetectMode(e.State.Tokens); got != tt.want {
+			t.Errorf("DetectMode(%q) = %s, want %s", tt.phrase, got, tt.want)
+		}
+
+		// A message without a mode gets the same answer, and says so
+		e.Parse(tt.phrase, "")
+		if e.State.ExecutionMode != tt.want || !e.State.ModeDetected {
+			t.Errorf("Parse(%q) without a mode = %s (detected %v), want %s", tt.phrase, e.State.ExecutionMode, e.State.ModeDetected, tt.want)
+		}
+	}
+}
+
+func TestDetectModeOptions(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	opts := e.Options()
+	opts.RapidMaxTokens = 3
+	if err := e.SetOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	e.Parse("south east west", "")
+	if e.State.ExecutionMode != sniper.ModeRapid {
+		t.Errorf("three commands with rapid_max_tokens 3 = %s, want rapid", e.State.ExecutionMode)
+	}
+
+	// A fixed default mode turns detection off; a mode sent along always wins
+	opts.DefaultMode = "phrase"
+	if err := e.SetOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	e.Parse("south", "")
+	if e.State.ExecutionMode != sniper.ModePhrase {
+		t.Errorf("south with default_mode phrase = %s", e.State.ExecutionMode)
+	}
+	e.Parse("say hello", "rapid")
+	if e.State.ExecutionMode != sniper.ModeRapid || e.State.ModeDetected {
+		t.Errorf("say hello sent as rapid = %s (detected %v)", e.State.ExecutionMode, e.State.ModeDetected)
+	}
+}
+
+func TestLowConfidenceCommandsAreSkipped(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	run := func(mode string, words ...sniper.SpokenWord) sniper.ExecutionResult {
+		t.Helper()
+		e.Input.Reset()
+		e.ParseWords(words, mode)
+		result, err := e.Execute()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	result := run("phrase", sniper.SpokenWord{W: "south", Conf: 0.3}, sniper.SpokenWord{W: "east", Conf: 0.9})
+	if keys := e.Input.Keys(); !slices.Equal(keys, []string{"right"}) {
+		t.Errorf("tapped %v, want only right", keys)
+	}
+	if tok := result.Tokens[0]; tok.Outcome != sniper.OutcomeLowConfidence || tok.Confidence != 0.3 {
+		t.Errorf("south traced as %s at %v, want low_confidence at 0.3", tok.Outcome, tok.Confidence)
+	}
+
+	// Rapid mode holds the command back the same way
+	run("rapid", sniper.SpokenWord{W: "south", Conf: 0.1})
+	if keys := e.Input.Keys(); len(keys) != 0 {
+		t.Errorf("rapid low-confidence south tapped %v", keys)
+	}
+
+	// Dictated words are typed however unsure the recognizer was
+	run("phrase", sniper.SpokenWord{W: "say", Conf: 0.9}, sniper.SpokenWord{W: "hello", Conf: 0.1})
+	if got := e.Input.Typed(); !strings.Contains(strings.ToLower(got), "hello") {
+		t.Errorf("typed %q, want hello", got)
+	}
+
+	// Plain text is trusted fully
+	result = e.MustRun(t, "south")
+	if tok := result.Tokens[0]; tok.Confidence != sniper.DefaultConfidence || tok.Outcome == sniper.OutcomeLowConfidence {
+		t.Errorf("plain south traced as %s at %v", tok.Outcome, tok.Confidence)
+	}
+
+	opts := e.Options()
+	opts.MinConfidence = 0.2
+	if err := e.SetOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	run("phrase", sniper.SpokenWord{W: "south", Conf: 0.3})
+	if keys := e.Input.Keys(); !slices.Equal(keys, []string{"down"}) {
+		t.Errorf("south at 0.3 with min_confidence 0.2 tapped %v, want down", keys)
+	}
+}
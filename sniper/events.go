@@ -0,0 +1,102 @@
+package sniper
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// ----------------------------------------------------------------------------
+// EVENTS
+// ----------------------------------------------------------------------------
+//
+// Clients that want to follow the engine without polling subscribe to its
+// events: GET /api/events streams them as server-sent events and the gRPC
+// Events RPC as messages. An event carries its payload already encoded as
+// JSON, so every transport sends the same bytes. A subscriber that falls
+// EventBufferSize events behind misses the newest ones instead of holding
+// up the phrase that caused them.
+
+// Event types.
+const (
+	// EventPhrase follows every phrase that ran or failed; Data is the
+	// ExecutionResult, with Error set when it failed.
+	EventPhrase = "phrase"
+
+	// EventMode follows a mode switch, by "mode" or by a window rule; Data
+	// is {"mode": name}, "" when no mode is active.
+	EventMode = "mode"
+)
+
+// EventBufferSize is how many events can wait for a slow subscriber.
+const EventBufferSize = 64
+
+// Event is something that happened in the engine.
+type Event struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// eventHub fans events out to the engine's subscribers.
+type eventHub struct {
+	subscribers map[chan Event]struct{}
+	mu          sync.Mutex
+}
+
+// Subscribe returns a channel of the engine's events from now on, and a
+// function that unsubscribes and closes it.
+func (e *Engine) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, EventBufferSize)
+
+	e.events.mu.Lock()
+	if e.events.subscribers == nil {
+		e.events.subscribers = make(map[chan Event]struct{})
+	}
+	e.events.subscribers[ch] = struct{}{}
+	e.events.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			e.events.mu.Lock()
+			delete(e.events.subscribers, ch)
+			e.events.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// publish encodes data and hands it to every subscriber without waiting.
+func (e *Engine) publish(eventType string, data any) {
+	e.events.mu.Lock()
+	defer e.events.mu.Unlock()
+	if len(e.events.subscribers) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		e.log().Error("event not encoded", "type", eventType, "error", err)
+		return
+	}
+	event := Event{Type: eventType, Data: payload}
+	for ch := range e.events.subscribers {
+		select {
+		case ch <- event:
+		default:
+			e.log().Warn("event subscriber too slow, dropping event", "type", eventType)
+		}
+	}
+}
+
+// publishPhrase reports a phrase's outcome to the subscribers.
+func (e *Engine) publishPhrase(result ExecutionResult, err error) {
+	if err != nil && result.Error == "" {
+		result.Error = err.Error()
+	}
+	e.publish(EventPhrase, result)
+}
+
+// publishMode reports the active mode to the subscribers.
+func (e *Engine) publishMode(mode string) {
+	e.publish(EventMode, map[string]string{"mode": mode})
+}
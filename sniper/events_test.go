@@ -0,0 +1,53 @@
+package sniper_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/phillip-england/sniper/sniper"
+	"github.com/phillip-england/sniper/sniper/snipertest"
+)
+
+func TestSubscribe(t *testing.T) {
+	e := snipertest.NewTestEngine(t, sniper.WithCommands(jamCmd{}))
+	events, unsubscribe := e.Subscribe()
+
+	// A subscriber nobody reads from never holds up a phrase
+	slow, unsubscribeSlow := e.Subscribe()
+	defer unsubscribeSlow()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range sniper.EventBufferSize + 10 {
+			e.MustRun(t, "east")
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("phrases waited for a full subscriber")
+	}
+	if n := len(slow); n != sniper.EventBufferSize {
+		t.Errorf("the slow subscriber holds %d events, want %d", n, sniper.EventBufferSize)
+	}
+
+	unsubscribe()
+	for range events {
+		// ends once unsubscribe has closed the channel
+	}
+	unsubscribe() // a second call is harmless
+
+	// Failures carry their error
+	failures, stop := e.Subscribe()
+	defer stop()
+	e.Run("jam")
+	event := <-failures
+	var result sniper.ExecutionResult
+	if err := json.Unmarshal(event.Data, &result); err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != sniper.EventPhrase || result.Input != "jam" || result.Error == "" {
+		t.Errorf("event = %s %+v, want the failed jam phrase", event.Type, result)
+	}
+}
@@ -0,0 +1,80 @@
+package sniper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ExecError reports which token of a phrase failed. Execute wraps token
+// failures in it, so callers can use errors.As to get at the details.
+type ExecError struct {
+	Index    int      // Position of the failing token in the phrase
+	Word     int      // Position of the failing token's first spoken word
+	Literal  string   // The word(s) the token was parsed from
+	Original string   // The word(s) as heard, before number words were rewritten
+	Command  string   // Name() of the command, when the token was a command
+	Err      error    // The underlying failure
+	Executed []string // Literals of the tokens that already ran before the failure
+	Stack    string   // Goroutine stack, when the failure was a panic
+}
+
+func (e *ExecError) Error() string {
+	if e.Command != "" {
+		return fmt.Sprintf("token %d '%s' (%s): %v", e.Index, e.Literal, e.Command, e.Err)
+	}
+	return fmt.Sprintf("token %d '%s': %v", e.Index, e.Literal, e.Err)
+}
+
+func (e *ExecError) Unwrap() error {
+	return e.Err
+}
+
+// MarshalJSON flattens the underlying error to its message.
+func (e *ExecError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Index    int      `json:"index"`
+		Word     int      `json:"word"`
+		Literal  string   `json:"literal"`
+		Original string   `json:"original"`
+		Command  string   `json:"command,omitempty"`
+		Error    string   `json:"error"`
+		Executed []string `json:"executed"`
+		Stack    string   `json:"stack,omitempty"`
+	}{e.Index, e.Word, e.Literal, e.Original, e.Command, e.Err.Error(), e.Executed, e.Stack})
+}
+
+// PanicError is what a command that panicked fails with.
+type PanicError struct {
+	Value any    // The value passed to panic
+	Stack string // Goroutine stack at the time of the panic
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("command panicked: %v", p.Value)
+}
+
+// newExecError describes the failure of token i, listing the tokens handled before it.
+func (e *Engine) newExecError(i int, token Token, err error) *ExecError {
+	execErr := &ExecError{
+		Index:    i,
+		Word:     token.Index(),
+		Literal:  token.Literal(),
+		Original: token.Original(),
+		Err:      err,
+		Executed: make([]string, 0),
+	}
+	if ct, ok := token.(*CmdToken); ok {
+		execErr.Command = ct.Command().Name()
+	}
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) {
+		execErr.Stack = panicErr.Stack
+	}
+	for j, outcome := range e.State.Outcomes {
+		if j < i && outcome == OutcomeHandled {
+			execErr.Executed = append(execErr.Executed, e.State.Tokens[j].Literal())
+		}
+	}
+	return execErr
+}
@@ -0,0 +1,134 @@
+package sniper_test
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/phillip-england/sniper/sniper"
+	"github.com/phillip-england/sniper/sniper/snipertest"
+)
+
+var errJammed = errors.New("keyboard jammed")
+
+// jamCmd fails every time it runs.
+type jamCmd struct{}
+
+func (jamCmd) Name() string                            { return "jam" }
+func (jamCmd) CalledBy() []string                      { return []string{"jam"} }
+func (jamCmd) Effects() []sniper.NamedEffect           { return nil }
+func (jamCmd) Action(e *sniper.Engine, p string) error { return errJammed }
+
+func TestExecErrorNamesTheFailingToken(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	if err := e.Register(jamCmd{}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := e.Run("south east jam west north")
+	var execErr *sniper.ExecError
+	if !errors.As(err, &execErr) {
+		t.Fatalf("err = %v, want an ExecError", err)
+	}
+	if !errors.Is(err, errJammed) {
+		t.Errorf("ExecError doesn't unwrap to the command's error: %v", err)
+	}
+	if execErr.Index != 2 || execErr.Word != 2 || execErr.Literal != "jam" || execErr.Command != "jam" {
+		t.Errorf("ExecError = %+v, want token 2 'jam'", execErr)
+	}
+	if want := []string{"south", "east"}; !slices.Equal(execErr.Executed, want) {
+		t.Errorf("Executed = %q, want %q", execErr.Executed, want)
+	}
+	if got := e.Input.Keys(); !slices.Equal(got, []string{"down", "right"}) {
+		t.Errorf("tapped %q, want only the tokens before the failure", got)
+	}
+	if got := execErr.Error(); got != "token 2 'jam' (jam): keyboard jammed" {
+		t.Errorf("Error() = %q", got)
+	}
+}
+
+func TestExecErrorJSON(t *testing.T) {
+	execErr := &sniper.ExecError{
+		Index: 1, Word: 2, Literal: "paste", Original: "paste", Command: "paste_nth",
+		Err: errJammed, Executed: []string{"south"},
+	}
+	data, err := json.Marshal(execErr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"index":1,"word":2,"literal":"paste","original":"paste","command":"paste_nth","error":"keyboard jammed","executed":["south"]}`
+	if string(data) != want {
+		t.Errorf("JSON = %s\nwant %s", data, want)
+	}
+}
+
+// panicCmd holds shift down and then panics, as a buggy command might.
+type panicCmd struct{}
+
+func (panicCmd) Name() string                  { return "boom" }
+func (panicCmd) CalledBy() []string            { return []string{"boom"} }
+func (panicCmd) Effects() []sniper.NamedEffect { return nil }
+func (panicCmd) Action(e *sniper.Engine, p string) error {
+	e.StickyKeyboard.HoldKey("shift")
+	var spots map[string]int
+	spots["x"] = 1 // nil map write
+	return nil
+}
+
+func TestPanickingCommandIsRecovered(t *testing.T) {
+	for _, mode := range []string{"phrase", "rapid"} {
+		e := snipertest.NewTestEngine(t)
+		if err := e.Register(panicCmd{}); err != nil {
+			t.Fatal(err)
+		}
+
+		e.Parse("south boom", mode)
+		_, err := e.Execute()
+
+		var execErr *sniper.ExecError
+		var panicErr *sniper.PanicError
+		if !errors.As(err, &execErr) || !errors.As(err, &panicErr) {
+			t.Fatalf("%s: err = %v, want an ExecError wrapping a PanicError", mode, err)
+		}
+		if execErr.Command != "boom" || execErr.Stack == "" {
+			t.Errorf("%s: ExecError = %+v, want the boom command and its stack", mode, execErr)
+		}
+
+		// Everything the command left down was let go
+		if held := e.StickyKeyboard.Held(); len(held) > 0 {
+			t.Errorf("%s: %q still held after the panic", mode, held)
+		}
+		ops := e.Input.Ops()
+		for _, op := range []string{"down shift", "up shift", "mouseup left"} {
+			if !slices.Contains(ops, op) {
+				t.Errorf("%s: ops %q are missing %q", mode, ops, op)
+			}
+		}
+
+		// And the engine carries on
+		snipertest.ExpectKeys(t, e, "east", "right")
+	}
+}
+
+func TestQueueSurvivesPanic(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	if err := e.Register(panicCmd{}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, phrase := range []string{"boom", "east"} {
+		job, err := e.Enqueue(phrase, "phrase")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = job.Wait()
+		var panicErr *sniper.PanicError
+		if wantPanic := phrase == "boom"; errors.As(err, &panicErr) != wantPanic {
+			t.Errorf("%q: err = %v", phrase, err)
+		}
+	}
+	if !slices.Contains(e.Input.Keys(), "right") {
+		t.Errorf("the phrase after the panic didn't run: %q", e.Input.Ops())
+	}
+}
@@ -0,0 +1,7 @@
+package sniper
+
+// Unexported helpers the sniper_test package tests directly.
+var NormalizeModifier = normalizeModifier
+var ResolveSystemChord = resolveSystemChord
+
+const MaxFinishedJobs = maxFinishedJobs
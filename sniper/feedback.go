@@ -0,0 +1,235 @@
+package sniper
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// FEEDBACK
+// ----------------------------------------------------------------------------
+//
+// Working eyes-free there is no way to see whether a phrase ran. After every
+// phrase the engine hands its result to the feedback providers: the config's
+// "feedback" list picks built-ins by name ("beep", "notify"), and WithFeedback
+// adds providers from Go. Providers run on their own goroutine, so a slow
+// sound player or notification daemon never holds up the next phrase; when
+// they fall FeedbackQueueSize phrases behind, the newest feedback is dropped.
+
+// Feedback provider names for the config's "feedback" list.
+const (
+	FeedbackBeep   = "beep"
+	FeedbackNotify = "notify"
+)
+
+// FeedbackNames lists every built-in feedback provider.
+var FeedbackNames = []string{FeedbackBeep, FeedbackNotify}
+
+// FeedbackQueueSize is how many phrases of feedback can wait for the providers.
+const FeedbackQueueSize = 32
+
+// ErrFeedbackUnsupported is returned by a built-in provider with no command
+// for the current platform.
+var ErrFeedbackUnsupported = errors.New("feedback not supported on this platform")
+
+// FeedbackProvider tells the user how a phrase went. OnSuccess is called after
+// a phrase runs, OnError after it fails (including phrases rejected by the
+// input limits). Deduplicated and empty phrases get no feedback.
+type FeedbackProvider interface {
+	Name() string
+	OnSuccess(result ExecutionResult)
+	OnError(err error)
+}
+
+// feedbackEvent is one phrase's outcome on its way to the providers.
+type feedbackEvent struct {
+	result ExecutionResult
+	err    error
+}
+
+// NewFeedbackProvider returns the built-in provider called name. A provider
+// whose command isn't installed returns an error, along with the provider.
+func NewFeedbackProvider(name string) (FeedbackProvider, error) {
+	var p *CommandFeedback
+	switch name {
+	case FeedbackBeep:
+		p = NewBeepFeedback()
+	case FeedbackNotify:
+		p = NewNotifyFeedback()
+	default:
+		return nil, fmt.Errorf("unknown feedback '%s' (want one of %s)", name, strings.Join(FeedbackNames, ", "))
+	}
+	if len(p.SuccessArgv) == 0 && len(p.ErrorArgv) == 0 {
+		return p, fmt.Errorf("%s feedback: %w", name, ErrFeedbackUnsupported)
+	}
+	for _, argv := range [][]string{p.SuccessArgv, p.ErrorArgv} {
+		if len(argv) == 0 {
+			continue
+		}
+		if _, err := exec.LookPath(argv[0]); err != nil {
+			return p, fmt.Errorf("%s feedback: %w", name, err)
+		}
+	}
+	return p, nil
+}
+
+// WithFeedback adds feedback providers to the new engine, alongside the ones
+// the config file names.
+func WithFeedback(providers ...FeedbackProvider) EngineOption {
+	return func(s *engineSetup) {
+		for _, p := range providers {
+			if p == nil {
+				s.errs = append(s.errs, errors.New("WithFeedback: provider is nil"))
+				continue
+			}
+			s.feedback = append(s.feedback, p)
+		}
+	}
+}
+
+// AddFeedback adds a feedback provider to a running engine.
+func (e *Engine) AddFeedback(p FeedbackProvider) {
+	e.feedbackMu.Lock()
+	defer e.feedbackMu.Unlock()
+	e.feedbackCode = append(e.feedbackCode, p)
+}
+
+// Feedback returns the names of the providers in use, config ones last.
+func (e *Engine) Feedback() []string {
+	names := make([]string, 0)
+	for _, p := range e.feedbackProviders() {
+		names = append(names, p.Name())
+	}
+	return names
+}
+
+// feedbackProviders returns the providers added in Go followed by the
+// config's.
+func (e *Engine) feedbackProviders() []FeedbackProvider {
+	e.feedbackMu.Lock()
+	defer e.feedbackMu.Unlock()
+	return slices.Concat(e.feedbackCode, e.feedbackConfig)
+}
+
+// useFeedback switches the config's providers to the built-ins called names.
+// One whose command is missing is logged and left out.
+func (e *Engine) useFeedback(names []string) {
+	providers := make([]FeedbackProvider, 0, len(names))
+	for _, name := range names {
+		p, err := NewFeedbackProvider(name)
+		if err != nil {
+			e.log().Error("feedback unavailable", "feedback", name, "error", err)
+			continue
+		}
+		providers = append(providers, p)
+	}
+
+	e.feedbackMu.Lock()
+	e.feedbackConfig = providers
+	e.feedbackMu.Unlock()
+}
+
+// sendFeedback queues a phrase's outcome for the providers without waiting
+// for them.
+func (e *Engine) sendFeedback(result ExecutionResult, err error) {
+	if len(e.feedbackProviders()) == 0 {
+		return
+	}
+	select {
+	case e.feedbackQueue <- feedbackEvent{result: result, err: err}:
+	default:
+		e.log().Warn("feedback queue full, dropping feedback", "input", result.Input)
+	}
+}
+
+// runFeedback hands queued outcomes to the providers until Close.
+func (e *Engine) runFeedback() {
+	for {
+		select {
+		case <-e.done:
+			return
+		case ev := <-e.feedbackQueue:
+			for _, p := range e.feedbackProviders() {
+				if ev.err != nil {
+					p.OnError(ev.err)
+				} else {
+					p.OnSuccess(ev.result)
+				}
+			}
+		}
+	}
+}
+
+// ----------------------------------------------------------------------------
+// BUILT-IN PROVIDERS
+// ----------------------------------------------------------------------------
+
+// CommandFeedback gives feedback by running a command: SuccessArgv after a
+// phrase runs and ErrorArgv after one fails. In ErrorArgv, "{error}" is
+// replaced by the error's text. An empty argv gives no feedback for that
+// outcome.
+type CommandFeedback struct {
+	Label       string
+	SuccessArgv []string
+	ErrorArgv   []string
+	Run         func(argv []string) ([]byte, error)
+}
+
+func (f *CommandFeedback) Name() string { return f.Label }
+
+func (f *CommandFeedback) OnSuccess(ExecutionResult) {
+	f.run(f.SuccessArgv, "")
+}
+
+func (f *CommandFeedback) OnError(err error) {
+	f.run(f.ErrorArgv, err.Error())
+}
+
+func (f *CommandFeedback) run(argv []string, message string) {
+	if len(argv) == 0 {
+		return
+	}
+	expanded := make([]string, len(argv))
+	for i, arg := range argv {
+		expanded[i] = strings.ReplaceAll(arg, "{error}", message)
+	}
+	// Feedback is best effort; a missing sound is no reason to log every phrase
+	f.Run(expanded)
+}
+
+// NewBeepFeedback plays a short sound after every phrase, and a different
+// one after errors: afplay on macOS, paplay (PulseAudio or PipeWire) on Linux
+// and the console beep on Windows.
+func NewBeepFeedback() *CommandFeedback {
+	f := &CommandFeedback{Label: FeedbackBeep, Run: runCommand}
+	switch runtime.GOOS {
+	case "darwin":
+		f.SuccessArgv = []string{"afplay", "/System/Library/Sounds/Tink.aiff"}
+		f.ErrorArgv = []string{"afplay", "/System/Library/Sounds/Basso.aiff"}
+	case "linux":
+		f.SuccessArgv = []string{"paplay", "/usr/share/sounds/freedesktop/stereo/complete.oga"}
+		f.ErrorArgv = []string{"paplay", "/usr/share/sounds/freedesktop/stereo/dialog-error.oga"}
+	case "windows":
+		f.SuccessArgv = []string{"powershell", "-NoProfile", "-Command", "[console]::beep(880,80)"}
+		f.ErrorArgv = []string{"powershell", "-NoProfile", "-Command", "[console]::beep(220,300)"}
+	}
+	return f
+}
+
+// NewNotifyFeedback shows a desktop notification when a phrase fails, with
+// notify-send on Linux and osascript on macOS. Successes are silent, since a
+// notification per phrase would bury the desktop.
+func NewNotifyFeedback() *CommandFeedback {
+	f := &CommandFeedback{Label: FeedbackNotify, Run: runCommand}
+	switch runtime.GOOS {
+	case "darwin":
+		f.ErrorArgv = []string{"osascript", "-e", "on run argv\ndisplay notification (item 1 of argv) with title \"sniper\"\nend run", "{error}"}
+	case "linux":
+		f.ErrorArgv = []string{"notify-send", "--app-name=sniper", "sniper", "{error}"}
+	}
+	return f
+}
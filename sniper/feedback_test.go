@@ -0,0 +1,211 @@
+package sniper_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phillip-england/sniper/sniper"
+	"github.com/phillip-england/sniper/sniper/snipertest"
+)
+
+// feedbackWait bounds how long a test waits for asynchronous feedback.
+const feedbackWait = 2 * time.Second
+
+func TestFeedbackReportsOutcomes(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	if err := e.Register(jamCmd{}); err != nil {
+		t.Fatal(err)
+	}
+
+	e.MustRun(t, "south east")
+	if _, err := e.Run("jam"); !errors.Is(err, errJammed) {
+		t.Fatalf("jam: err = %v", err)
+	}
+	if _, err := e.Run("south 5000"); err == nil {
+		t.Fatal("south 5000 wasn't rejected")
+	}
+	e.MustRun(t, "west")
+
+	got := e.Feedback.Wait(4, feedbackWait)
+	if len(got) != 4 {
+		t.Fatalf("feedback = %q, want four outcomes", got)
+	}
+	if got[0] != "ok south east" || got[3] != "ok west" {
+		t.Errorf("successes = %q, %q", got[0], got[3])
+	}
+	if !strings.HasPrefix(got[1], "error ") || !strings.Contains(got[1], errJammed.Error()) {
+		t.Errorf("failed command gave %q", got[1])
+	}
+	if !strings.HasPrefix(got[2], "error ") {
+		t.Errorf("rejected phrase gave %q", got[2])
+	}
+}
+
+func TestNoFeedbackForSkippedPhrases(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	opts := e.Options()
+	opts.Debounce, opts.DebounceMs = true, 60_000
+	if err := e.SetOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	e.MustRun(t, "east")
+	if result := e.MustRun(t, "east"); !result.Deduplicated {
+		t.Fatal("the repeated phrase wasn't debounced")
+	}
+	e.Parse("   ", "phrase")
+	if _, err := e.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	e.MustRun(t, "west")
+
+	if got := e.Feedback.Wait(2, feedbackWait); !slices.Equal(got, []string{"ok east", "ok west"}) {
+		t.Errorf("feedback = %q, want only the phrases that ran", got)
+	}
+}
+
+// blockedFeedback is a provider that doesn't return until released.
+type blockedFeedback struct {
+	release chan struct{}
+	calls   chan string
+}
+
+func (f *blockedFeedback) Name() string { return "blocked" }
+
+func (f *blockedFeedback) OnSuccess(result sniper.ExecutionResult) {
+	<-f.release
+	f.calls <- result.Input
+}
+
+func (f *blockedFeedback) OnError(err error) {
+	<-f.release
+	f.calls <- err.Error()
+}
+
+func TestFeedbackNeverDelaysPhrases(t *testing.T) {
+	slow := &blockedFeedback{release: make(chan struct{}), calls: make(chan string, 8)}
+	e := snipertest.NewTestEngine(t, sniper.WithFeedback(slow))
+
+	// The provider is stuck on the first phrase; the rest still run
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, phrase := range []string{"east", "west", "north"} {
+			e.MustRun(t, phrase)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(feedbackWait):
+		t.Fatal("phrases waited for a blocked feedback provider")
+	}
+
+	close(slow.release)
+	var got []string
+	for range 3 {
+		select {
+		case input := <-slow.calls:
+			got = append(got, input)
+		case <-time.After(feedbackWait):
+			t.Fatalf("the provider was told about %q, want all three phrases", got)
+		}
+	}
+	if !slices.Equal(got, []string{"east", "west", "north"}) {
+		t.Errorf("the provider was told about %q, in that order", got)
+	}
+}
+
+func TestEveryProviderIsTold(t *testing.T) {
+	second := snipertest.NewFeedback()
+	e := snipertest.NewTestEngine(t, sniper.WithFeedback(second))
+	third := snipertest.NewFeedback()
+	e.AddFeedback(third)
+
+	if got := e.Engine.Feedback(); !slices.Equal(got, []string{"snipertest", "snipertest", "snipertest"}) {
+		t.Errorf("Feedback() = %q", got)
+	}
+	e.MustRun(t, "east")
+	for i, f := range []*snipertest.Feedback{e.Feedback, second, third} {
+		if got := f.Wait(1, feedbackWait); !slices.Equal(got, []string{"ok east"}) {
+			t.Errorf("provider %d got %q", i, got)
+		}
+	}
+}
+
+// commandRuns records the argv a CommandFeedback would have run.
+type commandRuns struct {
+	mu   sync.Mutex
+	argv [][]string
+}
+
+func (c *commandRuns) run(argv []string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.argv = append(c.argv, argv)
+	return nil, nil
+}
+
+func TestCommandFeedback(t *testing.T) {
+	runs := &commandRuns{}
+	f := &sniper.CommandFeedback{
+		Label:       "say",
+		SuccessArgv: []string{"say", "done"},
+		ErrorArgv:   []string{"say", "failed: {error}"},
+		Run:         runs.run,
+	}
+	f.OnSuccess(sniper.ExecutionResult{Input: "east"})
+	f.OnError(errJammed)
+
+	want := [][]string{{"say", "done"}, {"say", "failed: keyboard jammed"}}
+	if !slices.EqualFunc(runs.argv, want, slices.Equal) {
+		t.Errorf("ran %q, want %q", runs.argv, want)
+	}
+	if f.ErrorArgv[1] != "failed: {error}" {
+		t.Error("expanding {error} changed ErrorArgv")
+	}
+
+	// The notification provider stays quiet on success
+	runs.argv = nil
+	notify := sniper.NewNotifyFeedback()
+	notify.Run = runs.run
+	notify.OnSuccess(sniper.ExecutionResult{Input: "east"})
+	if len(runs.argv) != 0 {
+		t.Errorf("a successful phrase sent a notification: %q", runs.argv)
+	}
+	notify.OnError(errJammed)
+	if len(notify.ErrorArgv) > 0 && (len(runs.argv) != 1 || !slices.Contains(runs.argv[0], errJammed.Error())) {
+		t.Errorf("a failed phrase ran %q, want one notification with the error", runs.argv)
+	}
+}
+
+func TestFeedbackConfig(t *testing.T) {
+	if _, err := sniper.NewFeedbackProvider("bell"); err == nil {
+		t.Error("an unknown provider was built")
+	}
+	if err := (&sniper.Config{Feedback: []string{"beep", "bell"}}).Validate(); err == nil {
+		t.Error("a config naming an unknown provider was accepted")
+	}
+
+	// A provider whose command is missing is left out; the engine keeps its own
+	e := snipertest.NewTestEngine(t)
+	e.ApplyConfig(&sniper.Config{Feedback: sniper.FeedbackNames})
+	want := []string{"snipertest"}
+	for _, name := range sniper.FeedbackNames {
+		if _, err := sniper.NewFeedbackProvider(name); err == nil {
+			want = append(want, name)
+		}
+	}
+	if got := e.Engine.Feedback(); !slices.Equal(got, want) {
+		t.Errorf("Feedback() = %q, want %q", got, want)
+	}
+
+	// Applying a config without feedback drops the config's providers
+	e.ApplyConfig(&sniper.Config{})
+	if got := e.Engine.Feedback(); !slices.Equal(got, []string{"snipertest"}) {
+		t.Errorf("Feedback() = %q after the config dropped its providers", got)
+	}
+}
@@ -0,0 +1,299 @@
+package sniper
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultHistorySize is how many executed phrases the Engine remembers.
+const DefaultHistorySize = 200
+
+// TokenOutcome records what happened to a single token during execution.
+type TokenOutcome string
+
+const (
+	OutcomeNotRun  TokenOutcome = "not_run" // never reached (phrase stopped early)
+	OutcomeHandled TokenOutcome = "handled"
+	OutcomeSkipped TokenOutcome = "skipped" // consumed as an argument by an earlier command
+	OutcomeFailed  TokenOutcome = "failed"
+
+	OutcomeLowConfidence TokenOutcome = "low_confidence" // a command below the MinConfidence option
+	OutcomeEffectSkipped TokenOutcome = "effect_skipped" // an effect returned ErrSkip
+	OutcomeHalted        TokenOutcome = "halted"         // skipped because "halt" came earlier
+)
+
+// HistoryToken is the per-token breakdown stored with each HistoryEntry.
+type HistoryToken struct {
+	Literal string       `json:"literal"`
+	Type    string       `json:"type"`
+	Outcome TokenOutcome `json:"outcome"`
+
+	// Word is the position of the token's first spoken word in the phrase
+	Word int `json:"word"`
+
+	// Original is what was heard, before number words were rewritten
+	Original string `json:"original"`
+
+	// Segment is which part of a phrase split by "then" the token is in,
+	// counting from 0; a "then" starts the segment it is in
+	Segment int `json:"segment"`
+
+	// Confidence is the recognizer's confidence in the word (1.0 for plain text input)
+	Confidence float64 `json:"confidence"`
+
+	// Command is the Name() of the command the token resolved to, if any
+	Command string `json:"command,omitempty"`
+
+	// DurationMs is how long handling the token took
+	DurationMs float64 `json:"duration_ms"`
+
+	// Source is where the command came from: mode, user, spot or builtin
+	Source string `json:"source,omitempty"`
+
+	// FuzzyMatch is the trigger a misheard word was matched to by the fuzzy fallback
+	FuzzyMatch string `json:"fuzzy_match,omitempty"`
+
+	// Effects names the effects that wrapped the token's command
+	Effects []string `json:"effects,omitempty"`
+
+	// Timings are the measurements taken by Timed effects
+	Timings []EffectTiming `json:"timings,omitempty"`
+}
+
+// HistoryEntry describes one executed phrase.
+type HistoryEntry struct {
+	ID         uint64         `json:"id"`
+	RawInput   string         `json:"raw_input"`
+	Mode       ExecutionMode  `json:"mode"`
+	Tokens     []HistoryToken `json:"tokens"`
+	Error      string         `json:"error,omitempty"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at"`
+
+	// Actions are the key taps, mouse moves, clicks, scrolls and sleeps the
+	// phrase performed, up to MaxRecordedActions; ActionsDropped counts the rest
+	Actions        []RecordedAction `json:"actions"`
+	ActionsDropped int              `json:"actions_dropped,omitempty"`
+
+	// Window is the window that had focus when the phrase was parsed
+	Window *WindowInfo `json:"window,omitempty"`
+
+	// state is the parsed phrase, kept so it can be replayed later
+	state *EngineState
+}
+
+// State returns the parsed phrase behind this entry.
+func (h HistoryEntry) State() *EngineState {
+	return h.state
+}
+
+// History is a mutex-protected ring buffer of executed phrases.
+type History struct {
+	entries []HistoryEntry // oldest first
+	size    int
+	nextID  uint64
+	mu      sync.RWMutex
+}
+
+// NewHistory creates a History holding at most size entries.
+func NewHistory(size int) *History {
+	if size < 1 {
+		size = 1
+	}
+	return &History{
+		entries: make([]HistoryEntry, 0, size),
+		size:    size,
+		nextID:  1,
+	}
+}
+
+// Append stores an entry, evicting the oldest one when full. It assigns and returns the entry ID.
+func (h *History) Append(entry HistoryEntry) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entry.ID = h.nextID
+	h.nextID++
+
+	if len(h.entries) >= h.size {
+		h.entries = h.entries[1:]
+	}
+	h.entries = append(h.entries, entry)
+	return entry.ID
+}
+
+// NextID returns the ID the next Append will assign.
+func (h *History) NextID() uint64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.nextID
+}
+
+// Page returns up to limit entries starting offset entries back from the newest,
+// newest first, along with the total number of stored entries.
+func (h *History) Page(offset, limit int) ([]HistoryEntry, int) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	total := len(h.entries)
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 || offset >= total {
+		return []HistoryEntry{}, total
+	}
+
+	page := make([]HistoryEntry, 0, limit)
+	for i := total - 1 - offset; i >= 0 && len(page) < limit; i-- {
+		page = append(page, h.entries[i])
+	}
+	return page, total
+}
+
+// Recent returns the n newest entries, newest first.
+func (h *History) Recent(n int) []HistoryEntry {
+	page, _ := h.Page(0, n)
+	return page
+}
+
+// Clear drops every stored entry.
+func (h *History) Clear() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = h.entries[:0]
+}
+
+// newHistoryEntry snapshots the current state into a HistoryEntry.
+func (e *Engine) newHistoryEntry(started time.Time, err error) HistoryEntry {
+	entry := HistoryEntry{
+		RawInput:   e.RawInput,
+		Mode:       e.State.ExecutionMode,
+		Tokens:     e.State.historyTokens(),
+		StartedAt:  started,
+		FinishedAt: e.Now(),
+		Window:     e.State.Window,
+		state:      e.State,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	return entry
+}
+
+// historyTokens breaks the phrase down per token, with what happened to each
+// so far; tokens that haven't run are OutcomeNotRun.
+func (s *EngineState) historyTokens() []HistoryToken {
+	tokens := make([]HistoryToken, len(s.Tokens))
+	segment := 0
+	for i, token := range s.Tokens {
+		if isThen(token) {
+			segment++
+		}
+		outcome := OutcomeNotRun
+		if i < len(s.Outcomes) {
+			outcome = s.Outcomes[i]
+		}
+		tokens[i] = HistoryToken{
+			Literal:    token.Literal(),
+			Type:       token.Type().String(),
+			Outcome:    outcome,
+			Word:       token.Index(),
+			Original:   token.Original(),
+			Segment:    segment,
+			Confidence: s.confidence(i),
+		}
+		if i < len(s.Durations) {
+			tokens[i].DurationMs = durationMs(s.Durations[i])
+		}
+		trace := s.tokenTrace(i)
+		tokens[i].Effects = trace.effects
+		tokens[i].Timings = trace.timings
+		if ct, ok := token.(*CmdToken); ok {
+			tokens[i].Command = ct.Command().Name()
+			tokens[i].FuzzyMatch = ct.FuzzyTrigger()
+			tokens[i].Source = string(ct.Source())
+		}
+	}
+	return tokens
+}
+
+// ExecutionResult is what Execute reports back about a phrase: the normalized
+// input and, per token, its type, the command it resolved to, and its outcome.
+type ExecutionResult struct {
+	HistoryID  uint64         `json:"history_id"`
+	Input      string         `json:"input"`
+	Normalized string         `json:"normalized"` // after homophones, aliases and number words
+	Mode       ExecutionMode  `json:"mode"`
+	Tokens     []HistoryToken `json:"tokens"`
+	DurationMs float64        `json:"duration_ms"`
+
+	// ModeDetected is set when no mode was sent and DetectMode picked it
+	ModeDetected bool   `json:"mode_detected,omitempty"`
+	Error        string `json:"error,omitempty"`
+
+	// Actions are what the phrase did to the keyboard and mouse (see HistoryEntry)
+	Actions        []RecordedAction `json:"actions,omitempty"`
+	ActionsDropped int              `json:"actions_dropped,omitempty"`
+
+	// Window is the window that had focus when the phrase was parsed
+	Window *WindowInfo `json:"window,omitempty"`
+
+	// Outputs are values commands read back, e.g. the colour "color" read
+	Outputs []CommandOutput `json:"outputs,omitempty"`
+
+	// Deduplicated is set when the phrase was skipped by the debounce
+	Deduplicated bool `json:"deduplicated,omitempty"`
+}
+
+// CommandOutput is a value a command read back instead of (or as well as)
+// acting on the machine.
+type CommandOutput struct {
+	Command string `json:"command"`
+	Value   string `json:"value"`
+}
+
+func newExecutionResult(entry HistoryEntry, state *EngineState, elapsed time.Duration) ExecutionResult {
+	return ExecutionResult{
+		HistoryID:  entry.ID,
+		Input:      entry.RawInput,
+		Normalized: strings.Join(state.RawWords, " "),
+		Mode:       entry.Mode,
+		Tokens:     entry.Tokens,
+
+		ModeDetected: state.ModeDetected,
+		DurationMs:   durationMs(elapsed),
+		Error:        entry.Error,
+
+		Actions:        entry.Actions,
+		ActionsDropped: entry.ActionsDropped,
+		Outputs:        state.Outputs,
+		Window:         entry.Window,
+	}
+}
+
+// LogValue summarizes the result for structured logs without dumping every token.
+func (r ExecutionResult) LogValue() slog.Value {
+	handled := 0
+	for _, t := range r.Tokens {
+		if t.Outcome == OutcomeHandled {
+			handled++
+		}
+	}
+	attrs := []slog.Attr{
+		slog.Uint64("history_id", r.HistoryID),
+		slog.String("normalized", r.Normalized),
+		slog.Int("tokens", len(r.Tokens)),
+		slog.Int("handled", handled),
+		slog.Float64("duration_ms", r.DurationMs),
+	}
+	if r.Error != "" {
+		attrs = append(attrs, slog.String("error", r.Error))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
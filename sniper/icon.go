@@ -0,0 +1,131 @@
+package sniper
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+
+	"github.com/go-vgo/robotgo"
+)
+
+// ----------------------------------------------------------------------------
+// ON-SCREEN ICONS
+// ----------------------------------------------------------------------------
+//
+// "icon save" captures the screen, looks for the PNG template registered as
+// "save" and clicks its middle. Matching compares pixels directly, allowing
+// each colour channel to differ by IconTolerance; transparent template pixels
+// match anything, so icons cut out of their background still match.
+
+// ErrIconNotRegistered is returned by FindIcon for a name with no template.
+var ErrIconNotRegistered = errors.New("icon template not registered")
+
+// ErrIconNotFound is returned by FindIcon when the template isn't on screen.
+var ErrIconNotFound = errors.New("icon not found on screen")
+
+// FindIcon finds the template registered as name on screen and returns the
+// bounds of the match. When it appears more than once, the topmost (then
+// leftmost) match wins.
+func (e *Engine) FindIcon(name string) (image.Rectangle, error) {
+	path, ok := e.Icons.Get(name)
+	if !ok {
+		return image.Rectangle{}, fmt.Errorf("%w: '%s'", ErrIconNotRegistered, name)
+	}
+	tmpl, err := loadTemplate(path)
+	if err != nil {
+		return image.Rectangle{}, fmt.Errorf("loading icon '%s': %w", name, err)
+	}
+	screen, err := robotgo.CaptureImg()
+	if err != nil {
+		return image.Rectangle{}, fmt.Errorf("capturing the screen: %w", err)
+	}
+
+	tolerance := uint8(e.Options().IconTolerance * 255)
+	at, ok := matchTemplate(toRGBA(screen), tmpl, tolerance)
+	if !ok {
+		return image.Rectangle{}, fmt.Errorf("%w: '%s'", ErrIconNotFound, name)
+	}
+	return image.Rectangle{Min: at, Max: at.Add(tmpl.Bounds().Size())}, nil
+}
+
+// loadTemplate decodes a PNG template.
+func loadTemplate(path string) (*image.RGBA, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	return toRGBA(img), nil
+}
+
+// toRGBA returns img as an *image.RGBA with its origin at 0,0.
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba
+}
+
+// matchTemplate returns the top-left corner of the first place, in reading
+// order, where tmpl appears in screen with every opaque pixel's channels
+// within tolerance.
+func matchTemplate(screen, tmpl *image.RGBA, tolerance uint8) (image.Point, bool) {
+	sw, sh := screen.Bounds().Dx(), screen.Bounds().Dy()
+	tw, th := tmpl.Bounds().Dx(), tmpl.Bounds().Dy()
+	if tw == 0 || th == 0 || tw > sw || th > sh {
+		return image.Point{}, false
+	}
+
+	// Only opaque pixels are compared; checking them in order bails out of
+	// most positions on the first pixel
+	var opaque []int
+	for i := 0; i < len(tmpl.Pix); i += 4 {
+		if tmpl.Pix[i+3] >= 128 {
+			opaque = append(opaque, i)
+		}
+	}
+	if len(opaque) == 0 {
+		return image.Point{}, false
+	}
+
+	for y := 0; y+th <= sh; y++ {
+		for x := 0; x+tw <= sw; x++ {
+			if templateAt(screen, tmpl, opaque, x, y, tolerance) {
+				return image.Pt(x, y), true
+			}
+		}
+	}
+	return image.Point{}, false
+}
+
+// templateAt reports whether tmpl's opaque pixels match screen at x, y.
+func templateAt(screen, tmpl *image.RGBA, opaque []int, x, y int, tolerance uint8) bool {
+	for _, i := range opaque {
+		tx, ty := (i%tmpl.Stride)/4, i/tmpl.Stride
+		j := (y+ty)*screen.Stride + (x+tx)*4
+		for c := 0; c < 3; c++ {
+			if channelDiff(screen.Pix[j+c], tmpl.Pix[i+c]) > tolerance {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func channelDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
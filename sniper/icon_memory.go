@@ -0,0 +1,138 @@
+package sniper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// IconMemory manages the persistence of icon templates: a name and the path
+// of the PNG that "icon <name>" looks for on screen.
+type IconMemory struct {
+	Icons    map[string]string `json:"icons"`
+	FilePath string
+	Dir      string // where uploaded templates are written
+	mu       sync.RWMutex
+
+	// Logger receives save errors. Nil means slog.Default().
+	Logger *slog.Logger
+}
+
+func (im *IconMemory) log() *slog.Logger {
+	if im.Logger != nil {
+		return im.Logger
+	}
+	return slog.Default()
+}
+
+// NewIconMemory creates the manager and loads existing icons.
+func NewIconMemory() *IconMemory {
+	home, _ := os.UserHomeDir()
+
+	im := &IconMemory{
+		Icons:    make(map[string]string),
+		FilePath: filepath.Join(home, ".sniper_icons.json"),
+		Dir:      filepath.Join(home, ".sniper_icons"),
+	}
+	im.Load()
+	return im
+}
+
+// Load reads the JSON file from disk.
+func (im *IconMemory) Load() {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+
+	data, err := os.ReadFile(im.FilePath)
+	if err != nil {
+		// If file doesn't exist, start fresh
+		return
+	}
+
+	json.Unmarshal(data, &im.Icons)
+}
+
+// Save writes the current map to disk.
+func (im *IconMemory) Save() {
+	im.mu.RLock()
+	defer im.mu.RUnlock()
+
+	data, err := json.MarshalIndent(im.Icons, "", "  ")
+	if err != nil {
+		im.log().Error("failed to save icon memory", "path", im.FilePath, "error", err)
+		return
+	}
+
+	if err := writeFileAtomic(im.FilePath, data); err != nil {
+		im.log().Error("failed to save icon memory", "path", im.FilePath, "error", err)
+	}
+}
+
+// Set registers the template at path under name (normalized to lower case).
+func (im *IconMemory) Set(name, path string) {
+	im.mu.Lock()
+	name = strings.ToLower(name)
+	im.Icons[name] = path
+	im.mu.Unlock()
+	im.Save()
+}
+
+// Get returns the template path of an icon. Returns bool indicating existence.
+func (im *IconMemory) Get(name string) (string, bool) {
+	im.mu.RLock()
+	defer im.mu.RUnlock()
+	name = strings.ToLower(name)
+	path, ok := im.Icons[name]
+	return path, ok
+}
+
+// Delete unregisters an icon. The template file is left alone.
+func (im *IconMemory) Delete(name string) {
+	im.mu.Lock()
+	name = strings.ToLower(name)
+	delete(im.Icons, name)
+	im.mu.Unlock()
+	im.Save()
+}
+
+// Names returns the registered icon names, sorted.
+func (im *IconMemory) Names() []string {
+	im.mu.RLock()
+	defer im.mu.RUnlock()
+	names := make([]string, 0, len(im.Icons))
+	for name := range im.Icons {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Add writes a PNG template into Dir as <name>.png and registers it. Names
+// are a single spoken word, so only letters and digits are allowed.
+func (im *IconMemory) Add(name string, data []byte) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || strings.IndexFunc(name, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) >= 0 {
+		return "", fmt.Errorf("icon name '%s' must be a single word of letters and digits", name)
+	}
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		return "", fmt.Errorf("icon template is not a PNG: %w", err)
+	}
+	if err := os.MkdirAll(im.Dir, 0755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(im.Dir, name+".png")
+	if err := writeFileAtomic(path, data); err != nil {
+		return "", err
+	}
+	im.Set(name, path)
+	return path, nil
+}
@@ -0,0 +1,146 @@
+package sniper
+
+import (
+	"sort"
+	"strings"
+)
+
+// KeyStroke is the key, plus modifiers, that produces a character on a layout.
+// Key names are robotgo's, which follow the physical keys of a US keyboard.
+type KeyStroke struct {
+	Key       string
+	Modifiers []string
+}
+
+// Layout maps characters to the keystroke that types them on a keyboard layout.
+// Upper-case letters are their lower-case key plus shift unless listed;
+// other characters it doesn't list are tapped as-is.
+type Layout struct {
+	Name    string
+	strokes map[string]KeyStroke
+}
+
+// Stroke returns the keystroke that types char, and whether the layout remaps
+// it. A nil layout is QWERTY.
+func (l *Layout) Stroke(char string) (KeyStroke, bool) {
+	if l == nil {
+		l = QWERTY
+	}
+	if stroke, ok := l.strokes[char]; ok {
+		return stroke, true
+	}
+	if len(char) == 1 && char[0] >= 'A' && char[0] <= 'Z' {
+		return KeyStroke{Key: strings.ToLower(char), Modifiers: shifted}, true
+	}
+	return KeyStroke{}, false
+}
+
+// shifted is the modifier list of every shifted keystroke.
+var shifted = []string{"shift"}
+
+// DefaultLayout is the layout used when none is configured.
+const DefaultLayout = "qwerty"
+
+// QWERTY is the US layout robotgo's key names assume. Shifted symbols are
+// spelled out rather than left to robotgo, which often drops the shift on X11.
+var QWERTY = &Layout{Name: "qwerty", strokes: map[string]KeyStroke{
+	"~":  {Key: "`", Modifiers: shifted},
+	"!":  {Key: "1", Modifiers: shifted},
+	"@":  {Key: "2", Modifiers: shifted},
+	"#":  {Key: "3", Modifiers: shifted},
+	"$":  {Key: "4", Modifiers: shifted},
+	"%":  {Key: "5", Modifiers: shifted},
+	"^":  {Key: "6", Modifiers: shifted},
+	"&":  {Key: "7", Modifiers: shifted},
+	"*":  {Key: "8", Modifiers: shifted},
+	"(":  {Key: "9", Modifiers: shifted},
+	")":  {Key: "0", Modifiers: shifted},
+	"_":  {Key: "-", Modifiers: shifted},
+	"+":  {Key: "=", Modifiers: shifted},
+	"{":  {Key: "[", Modifiers: shifted},
+	"}":  {Key: "]", Modifiers: shifted},
+	"|":  {Key: "\\", Modifiers: shifted},
+	":":  {Key: ";", Modifiers: shifted},
+	"\"": {Key: "'", Modifiers: shifted},
+	"<":  {Key: ",", Modifiers: shifted},
+	">":  {Key: ".", Modifiers: shifted},
+	"?":  {Key: "/", Modifiers: shifted},
+}}
+
+// AZERTY is the French layout. Digits need shift, and most punctuation sits
+// on different keys or behind AltGr.
+var AZERTY = &Layout{Name: "azerty", strokes: map[string]KeyStroke{
+	// Number row: symbols unshifted, digits shifted
+	"&":  {Key: "1"},
+	"\"": {Key: "3"},
+	"'":  {Key: "4"},
+	"(":  {Key: "5"},
+	"-":  {Key: "6"},
+	"_":  {Key: "8"},
+	")":  {Key: "-"},
+	"=":  {Key: "="},
+	"+":  {Key: "=", Modifiers: shifted},
+	"1":  {Key: "1", Modifiers: shifted},
+	"2":  {Key: "2", Modifiers: shifted},
+	"3":  {Key: "3", Modifiers: shifted},
+	"4":  {Key: "4", Modifiers: shifted},
+	"5":  {Key: "5", Modifiers: shifted},
+	"6":  {Key: "6", Modifiers: shifted},
+	"7":  {Key: "7", Modifiers: shifted},
+	"8":  {Key: "8", Modifiers: shifted},
+	"9":  {Key: "9", Modifiers: shifted},
+	"0":  {Key: "0", Modifiers: shifted},
+
+	// AltGr layer of the number row
+	"~":  {Key: "2", Modifiers: []string{"ralt"}},
+	"#":  {Key: "3", Modifiers: []string{"ralt"}},
+	"{":  {Key: "4", Modifiers: []string{"ralt"}},
+	"[":  {Key: "5", Modifiers: []string{"ralt"}},
+	"|":  {Key: "6", Modifiers: []string{"ralt"}},
+	"`":  {Key: "7", Modifiers: []string{"ralt"}},
+	"\\": {Key: "8", Modifiers: []string{"ralt"}},
+	"^":  {Key: "9", Modifiers: []string{"ralt"}},
+	"@":  {Key: "0", Modifiers: []string{"ralt"}},
+	"]":  {Key: "-", Modifiers: []string{"ralt"}},
+	"}":  {Key: "=", Modifiers: []string{"ralt"}},
+
+	// Right-hand punctuation
+	"$": {Key: "]"},
+	"*": {Key: "\\"},
+	"%": {Key: "'", Modifiers: shifted},
+
+	// Bottom row
+	",": {Key: "m"},
+	";": {Key: ","},
+	":": {Key: "."},
+	"!": {Key: "/"},
+	"?": {Key: "m", Modifiers: shifted},
+	".": {Key: ",", Modifiers: shifted},
+	"/": {Key: ".", Modifiers: shifted},
+}}
+
+// Layouts lists the built-in layouts by name.
+var Layouts = map[string]*Layout{
+	QWERTY.Name: QWERTY,
+	AZERTY.Name: AZERTY,
+}
+
+// LookupLayout returns the named layout. An empty name is the default layout.
+func LookupLayout(name string) (*Layout, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = DefaultLayout
+	}
+	layout, ok := Layouts[name]
+	return layout, ok
+}
+
+// LayoutNames returns the built-in layout names, sorted.
+func LayoutNames() []string {
+	names := make([]string, 0, len(Layouts))
+	for name := range Layouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,72 @@
+package sniper_test
+
+import (
+	"testing"
+
+	"github.com/phillip-england/sniper/sniper"
+	"github.com/phillip-england/sniper/sniper/snipertest"
+)
+
+func TestLayoutStrokes(t *testing.T) {
+	tests := []struct {
+		layout string
+		phrase string
+		want   []string
+	}{
+		{"qwerty", "dot", []string{"."}},
+		{"qwerty", "slash", []string{"/"}},
+		{"qwerty", "colon", []string{"shift+;"}},
+		{"qwerty", "question", []string{"shift+/"}},
+		{"azerty", "dot", []string{"shift+,"}},
+		{"azerty", "slash", []string{"shift+."}},
+		{"azerty", "colon", []string{"."}},
+		{"azerty", "comma", []string{"m"}},
+		{"azerty", "question", []string{"shift+m"}},
+		{"azerty", "bang", []string{"/"}},
+		// Shortcuts keep their physical key whatever the layout
+		{"azerty", "copy", []string{primary() + "+c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.layout+" "+tt.phrase, func(t *testing.T) {
+			e := snipertest.NewTestEngine(t)
+			opts := e.Options()
+			opts.Layout = tt.layout
+			if err := e.SetOptions(opts); err != nil {
+				t.Fatal(err)
+			}
+			snipertest.ExpectKeys(t, e, tt.phrase, tt.want...)
+		})
+	}
+}
+
+func TestLayoutConfig(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+
+	e.ApplyConfig(&sniper.Config{Layout: "AZERTY"})
+	if got := e.Options().Layout; got != "azerty" {
+		t.Errorf("layout = %q after loading the config, want azerty", got)
+	}
+	snipertest.ExpectKeys(t, e, "dot", "shift+,")
+
+	if err := (&sniper.Config{Layout: "dvorak"}).Validate(); err == nil {
+		t.Error("a config with an unknown layout validated")
+	}
+	opts := e.Options()
+	opts.Layout = "dvorak"
+	if err := e.SetOptions(opts); err == nil {
+		t.Error("SetOptions accepted an unknown layout")
+	}
+}
+
+func TestCapitalsAndSymbolsSendShift(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	opts := e.Options()
+	opts.CompatTyping = true
+	if err := e.SetOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	snipertest.ExpectKeys(t, e, "pascal hello world",
+		"shift+h", "e", "l", "l", "o", "shift+w", "o", "r", "l", "d")
+	snipertest.ExpectKeys(t, e, "type Hi!?", "shift+h", "i", "shift+1", "shift+/")
+}
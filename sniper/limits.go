@@ -0,0 +1,97 @@
+package sniper
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ----------------------------------------------------------------------------
+// INPUT LIMITS
+// ----------------------------------------------------------------------------
+//
+// A recognizer gone wrong can send a whole transcript, or a repetition count
+// in the thousands, as one phrase. Parse checks every phrase against the
+// MaxInputBytes, MaxInputTokens and MaxRepeat options, and rejects control
+// characters, before anything runs; Execute then fails with an *InputError
+// without touching the keyboard. Submit and Enqueue check the size up front,
+// so an oversized phrase never reaches the queue.
+
+var (
+	// ErrInputTooLarge is wrapped by InputErrors for phrases over a limit.
+	ErrInputTooLarge = errors.New("input too large")
+
+	// ErrMalformedInput is wrapped by InputErrors for phrases with control
+	// characters or invalid UTF-8.
+	ErrMalformedInput = errors.New("malformed input")
+)
+
+// InputError describes a phrase that was rejected before it ran.
+type InputError struct {
+	Limit string `json:"limit"`         // the option exceeded, or "control_characters" / "utf8"
+	Max   int    `json:"max,omitempty"` // the limit's value
+	Got   int    `json:"got,omitempty"` // what the phrase had
+	Err   error  `json:"-"`             // ErrInputTooLarge or ErrMalformedInput
+}
+
+func (e *InputError) Error() string {
+	if e.Max > 0 {
+		return fmt.Sprintf("%v: %s is %d, got %d", e.Err, e.Limit, e.Max, e.Got)
+	}
+	return fmt.Sprintf("%v: %s", e.Err, e.Limit)
+}
+
+func (e *InputError) Unwrap() error {
+	return e.Err
+}
+
+// CheckInput reports whether input fits the size limits and is free of
+// control characters (tabs and newlines are fine).
+func (e *Engine) CheckInput(input string) error {
+	opts := e.Options()
+	if len(input) > opts.MaxInputBytes {
+		return &InputError{Limit: "max_input_bytes", Max: opts.MaxInputBytes, Got: len(input), Err: ErrInputTooLarge}
+	}
+	if !utf8.ValidString(input) {
+		return &InputError{Limit: "utf8", Err: ErrMalformedInput}
+	}
+	for _, r := range input {
+		if unicode.IsControl(r) && !unicode.IsSpace(r) {
+			return &InputError{Limit: "control_characters", Err: ErrMalformedInput}
+		}
+	}
+	if n := len(strings.Fields(input)); n > opts.MaxInputTokens {
+		return &InputError{Limit: "max_input_tokens", Max: opts.MaxInputTokens, Got: n, Err: ErrInputTooLarge}
+	}
+	return nil
+}
+
+// checkRepeats rejects a number used as a repetition count ("south 5000")
+// above the MaxRepeat option. Numbers an ArgTaker claims as its arguments
+// ("move 1200 800") aren't counts, and neither is anything after a command
+// that consumes the rest of the phrase.
+func (e *Engine) checkRepeats(state *EngineState) error {
+	limit := e.Options().MaxRepeat
+	skip := 0
+	for _, token := range state.Tokens {
+		if skip > 0 {
+			skip--
+			continue
+		}
+		switch t := token.(type) {
+		case *CmdToken:
+			if taker, ok := t.Command().(ArgTaker); ok {
+				skip = taker.ArgCount()
+			} else if CommandConsumesArgs(t.Command()) {
+				return nil
+			}
+		case *NumberToken:
+			if t.Value() > limit {
+				return &InputError{Limit: "max_repeat", Max: limit, Got: t.Value(), Err: ErrInputTooLarge}
+			}
+		}
+	}
+	return nil
+}
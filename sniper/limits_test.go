@@ -0,0 +1,112 @@
+package sniper_test
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/phillip-england/sniper/sniper"
+	"github.com/phillip-england/sniper/sniper/snipertest"
+)
+
+func TestCheckInput(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	opts := e.Options()
+
+	tests := []struct {
+		name  string
+		input string
+		want  error
+		limit string
+	}{
+		{"plain phrase", "left 5 then say hello", nil, ""},
+		{"tabs and newlines", "left\tright\nup", nil, ""},
+		{"too many bytes", strings.Repeat("a", opts.MaxInputBytes+1), sniper.ErrInputTooLarge, "max_input_bytes"},
+		{"too many tokens", strings.Repeat("a ", opts.MaxInputTokens+1), sniper.ErrInputTooLarge, "max_input_tokens"},
+		{"control character", "left\x00right", sniper.ErrMalformedInput, "control_characters"},
+		{"escape sequence", "left \x1b[2J", sniper.ErrMalformedInput, "control_characters"},
+		{"invalid utf8", "left \xff", sniper.ErrMalformedInput, "utf8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := e.CheckInput(tt.input)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("CheckInput = %v, want %v", err, tt.want)
+			}
+			var inputErr *sniper.InputError
+			if tt.want != nil && (!errors.As(err, &inputErr) || inputErr.Limit != tt.limit) {
+				t.Errorf("limit = %+v, want %s", inputErr, tt.limit)
+			}
+		})
+	}
+}
+
+func TestRejectedPhraseDoesNotRun(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+
+	for _, phrase := range []string{"south 5000", "say hi \x07", strings.Repeat("south ", 300)} {
+		_, err := e.Run(phrase)
+		var inputErr *sniper.InputError
+		if !errors.As(err, &inputErr) {
+			t.Errorf("%q: err = %v, want an InputError", phrase, err)
+		}
+		if ops := e.Input.Ops(); len(ops) > 0 {
+			t.Errorf("%q ran %q before it was rejected", phrase, ops)
+		}
+	}
+
+	// A number an ArgTaker claims as its argument isn't a repetition
+	snipertest.ExpectTyped(t, e, "number 1200", "1200")
+}
+
+func TestEmptyPhrase(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	for _, mode := range []string{"phrase", "rapid"} {
+		for _, input := range []string{"", "   ", "\t\n"} {
+			e.Input.Reset()
+			e.Parse(input, mode)
+			if _, err := e.Execute(); err != nil {
+				t.Errorf("%s %q: %v", mode, input, err)
+			}
+			if ops := e.Input.Ops(); len(ops) > 0 {
+				t.Errorf("%s %q sent %q", mode, input, ops)
+			}
+		}
+	}
+}
+
+// FuzzParse runs arbitrary phrases through Parse and Execute with the input
+// limits in place: go test ./sniper -fuzz FuzzParse
+func FuzzParse(f *testing.F) {
+	seeds := []string{
+		"", " ", "south", "south 3", "5 south", "south 5000", "left 5 then say hello",
+		"say Hello, World.", "camel get http client", "number 1200", "help climb",
+		"remember banana then banana", "times 3", "then then", "cancel", "left\x00",
+		"repeat back 2", strings.Repeat("north ", 40),
+	}
+	for _, seed := range seeds {
+		f.Add(seed, false)
+		f.Add(seed, true)
+	}
+
+	e := snipertest.NewTestEngine(f, sniper.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	opts := e.Options()
+	opts.MaxExecutionMs = 50 // "wait" and "sleep" would otherwise stall the fuzzer
+	if err := e.SetOptions(opts); err != nil {
+		f.Fatal(err)
+	}
+
+	f.Fuzz(func(t *testing.T, input string, rapid bool) {
+		mode := "phrase"
+		if rapid {
+			mode = "rapid"
+		}
+		e.Parse(input, mode)
+		e.Execute()
+		if !e.Listening.Load() {
+			e.Listening.Store(true)
+		}
+	})
+}
@@ -0,0 +1,193 @@
+package sniper
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MacroPhrase is a single recorded phrase inside a Macro.
+type MacroPhrase struct {
+	Input string `json:"input"`
+	Mode  string `json:"mode"`
+}
+
+// Macro is a named list of phrases replayed in order by "play <name>".
+type Macro struct {
+	Name    string        `json:"name"`
+	Phrases []MacroPhrase `json:"phrases"`
+}
+
+// MacroMemory manages the persistence of recorded macros.
+type MacroMemory struct {
+	Macros   map[string]Macro `json:"macros"`
+	FilePath string
+	mu       sync.RWMutex
+
+	// Logger receives save errors. Nil means slog.Default().
+	Logger *slog.Logger
+}
+
+func (mm *MacroMemory) log() *slog.Logger {
+	if mm.Logger != nil {
+		return mm.Logger
+	}
+	return slog.Default()
+}
+
+// NewMacroMemory creates the manager and loads existing macros.
+func NewMacroMemory() *MacroMemory {
+	home, _ := os.UserHomeDir()
+	path := filepath.Join(home, ".sniper_macros.json")
+
+	mm := &MacroMemory{
+		Macros:   make(map[string]Macro),
+		FilePath: path,
+	}
+	mm.Load()
+	return mm
+}
+
+// Load reads the JSON file from disk.
+func (mm *MacroMemory) Load() {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	data, err := os.ReadFile(mm.FilePath)
+	if err != nil {
+		// If file doesn't exist, start fresh
+		return
+	}
+
+	json.Unmarshal(data, &mm.Macros)
+}
+
+// Save writes the current map to disk.
+func (mm *MacroMemory) Save() {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+
+	data, err := json.MarshalIndent(mm.Macros, "", "  ")
+	if err != nil {
+		mm.log().Error("failed to save macro memory", "path", mm.FilePath, "error", err)
+		return
+	}
+
+	if err := writeFileAtomic(mm.FilePath, data); err != nil {
+		mm.log().Error("failed to save macro memory", "path", mm.FilePath, "error", err)
+	}
+}
+
+// Set stores a macro under its (lower-cased) name.
+func (mm *MacroMemory) Set(macro Macro) {
+	mm.mu.Lock()
+	macro.Name = strings.ToLower(macro.Name)
+	mm.Macros[macro.Name] = macro
+	mm.mu.Unlock()
+	mm.Save()
+}
+
+// Get retrieves a macro. Returns bool indicating existence.
+func (mm *MacroMemory) Get(name string) (Macro, bool) {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+	val, ok := mm.Macros[strings.ToLower(name)]
+	return val, ok
+}
+
+// Delete removes a macro.
+func (mm *MacroMemory) Delete(name string) {
+	mm.mu.Lock()
+	delete(mm.Macros, strings.ToLower(name))
+	mm.mu.Unlock()
+	mm.Save()
+}
+
+// List returns every macro sorted by name.
+func (mm *MacroMemory) List() []Macro {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+
+	out := make([]Macro, 0, len(mm.Macros))
+	for _, m := range mm.Macros {
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// ----------------------------------------------------------------------------
+// RECORDING & PLAYBACK
+// ----------------------------------------------------------------------------
+
+// StartRecording begins capturing executed phrases into a new macro.
+func (e *Engine) StartRecording() {
+	e.Recording = true
+	e.recorded = make([]MacroPhrase, 0)
+}
+
+// StopRecording finalizes the captured phrases under name and saves them.
+func (e *Engine) StopRecording(name string) (Macro, error) {
+	if !e.Recording {
+		return Macro{}, fmt.Errorf("not recording a macro")
+	}
+	e.Recording = false
+
+	macro := Macro{Name: name, Phrases: e.recorded}
+	e.recorded = nil
+	if len(macro.Phrases) == 0 {
+		return Macro{}, fmt.Errorf("macro %q has no phrases", name)
+	}
+
+	e.Macros.Set(macro)
+	return macro, nil
+}
+
+// recordPhrase captures the phrase that just executed when a recording is active.
+// The phrases that start and stop the recording aren't part of the macro.
+func (e *Engine) recordPhrase() {
+	if !e.Recording || e.State == nil {
+		return
+	}
+	if containsCmd[Record](e.State) || containsCmd[StopRecording](e.State) {
+		return
+	}
+	e.recorded = append(e.recorded, MacroPhrase{
+		Input: e.RawInput,
+		Mode:  strings.ToLower(string(e.State.ExecutionMode)),
+	})
+}
+
+// PlayMacro replays every phrase of the named macro in order.
+// A macro that (directly or indirectly) plays itself is refused.
+func (e *Engine) PlayMacro(name string) error {
+	name = strings.ToLower(name)
+	macro, ok := e.Macros.Get(name)
+	if !ok {
+		return fmt.Errorf("no macro named %q", name)
+	}
+
+	return e.playPhrases(name, macro.Phrases)
+}
+
+// playPhrases replays phrases in order under the given macro name,
+// refusing to start if that macro is already playing further up the stack.
+func (e *Engine) playPhrases(name string, phrases []MacroPhrase) error {
+	if e.playing[name] {
+		return fmt.Errorf("macro %q cannot play itself", name)
+	}
+	e.playing[name] = true
+	defer delete(e.playing, name)
+
+	for _, phrase := range phrases {
+		if err := e.Replay(e.parseState(phrase.Input, phrase.Mode)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
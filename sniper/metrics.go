@@ -0,0 +1,152 @@
+package sniper
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Histogram bucket upper bounds for the phrase latency and tokens-per-phrase metrics.
+var (
+	PhraseLatencyBuckets   = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+	TokensPerPhraseBuckets = []float64{1, 2, 3, 5, 8, 13, 21}
+)
+
+// histogram is a cumulative Prometheus-style histogram.
+type histogram struct {
+	bounds []float64
+	counts []uint64 // counts[i] is observations <= bounds[i]
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, b := range h.bounds {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// Metrics counts command executions and phrase shapes for GET /metrics.
+// It is safe for concurrent use.
+type Metrics struct {
+	executions map[string]uint64 // per command name
+	errors     map[string]uint64 // per command name
+	phrases    uint64
+	latency    *histogram // seconds per phrase
+	tokens     *histogram // tokens per phrase
+	mu         sync.Mutex
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{}
+	m.Reset()
+	return m
+}
+
+// Reset zeroes every counter and histogram.
+func (m *Metrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.executions = make(map[string]uint64)
+	m.errors = make(map[string]uint64)
+	m.phrases = 0
+	m.latency = newHistogram(PhraseLatencyBuckets)
+	m.tokens = newHistogram(TokensPerPhraseBuckets)
+}
+
+// ObservePhrase records one executed phrase from its History entry.
+func (m *Metrics) ObservePhrase(entry HistoryEntry, elapsed time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.phrases++
+	m.latency.observe(elapsed.Seconds())
+	m.tokens.observe(float64(len(entry.Tokens)))
+
+	for _, t := range entry.Tokens {
+		if t.Command == "" {
+			continue
+		}
+		switch t.Outcome {
+		case OutcomeHandled:
+			m.executions[t.Command]++
+		case OutcomeFailed:
+			m.executions[t.Command]++
+			m.errors[t.Command]++
+		}
+	}
+}
+
+// Executions returns how many times the named command has run.
+func (m *Metrics) Executions(name string) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.executions[name]
+}
+
+// Errors returns how many times the named command has failed.
+func (m *Metrics) Errors(name string) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.errors[name]
+}
+
+// WritePrometheus writes every metric in the Prometheus text exposition format.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+
+	writeCounterVec(&b, "sniper_command_executions_total", "Commands executed, by command name.", m.executions)
+	writeCounterVec(&b, "sniper_command_errors_total", "Commands that returned an error, by command name.", m.errors)
+
+	fmt.Fprintf(&b, "# HELP sniper_phrases_total Phrases executed.\n")
+	fmt.Fprintf(&b, "# TYPE sniper_phrases_total counter\n")
+	fmt.Fprintf(&b, "sniper_phrases_total %d\n", m.phrases)
+
+	writeHistogram(&b, "sniper_phrase_duration_seconds", "Time taken to execute a phrase.", m.latency)
+	writeHistogram(&b, "sniper_phrase_tokens", "Tokens per executed phrase.", m.tokens)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeCounterVec(b *strings.Builder, name, help string, values map[string]uint64) {
+	fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(b, "# TYPE %s counter\n", name)
+
+	// Sorted so scrapes are stable
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(b, "%s{command=%s} %d\n", name, strconv.Quote(k), values[k])
+	}
+}
+
+func writeHistogram(b *strings.Builder, name, help string, h *histogram) {
+	fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(b, "# TYPE %s histogram\n", name)
+	for i, bound := range h.bounds {
+		le := strconv.FormatFloat(bound, 'g', -1, 64)
+		fmt.Fprintf(b, "%s_bucket{le=\"%s\"} %d\n", name, le, h.counts[i])
+	}
+	fmt.Fprintf(b, "%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+	fmt.Fprintf(b, "%s_sum %s\n", name, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	fmt.Fprintf(b, "%s_count %d\n", name, h.count)
+}
@@ -0,0 +1,96 @@
+package sniper_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phillip-england/sniper/sniper"
+	"github.com/phillip-england/sniper/sniper/snipertest"
+)
+
+// scrape returns the engine's metrics in the Prometheus text format.
+func scrape(t *testing.T, m *sniper.Metrics) string {
+	t.Helper()
+	var b strings.Builder
+	if err := m.WritePrometheus(&b); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestMetricsCountPhrases(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	if err := e.Register(jamCmd{}); err != nil {
+		t.Fatal(err)
+	}
+	e.MustRun(t, "south east")
+	e.MustRun(t, "south 3")
+	e.Run("east jam")
+
+	if got := e.Metrics.Executions("south"); got != 2 {
+		t.Errorf("south ran %d times, want 2", got)
+	}
+	if got := e.Metrics.Errors("jam"); got != 1 {
+		t.Errorf("jam failed %d times, want 1", got)
+	}
+
+	body := scrape(t, e.Metrics)
+	for _, line := range []string{
+		"# TYPE sniper_command_executions_total counter",
+		`sniper_command_executions_total{command="east"} 2`,
+		`sniper_command_executions_total{command="jam"} 1`,
+		`sniper_command_executions_total{command="south"} 2`,
+		`sniper_command_errors_total{command="jam"} 1`,
+		"sniper_phrases_total 3",
+		"# TYPE sniper_phrase_duration_seconds histogram",
+		`sniper_phrase_duration_seconds_bucket{le="+Inf"} 3`,
+		"sniper_phrase_duration_seconds_count 3",
+		`sniper_phrase_tokens_bucket{le="1"} 0`,
+		`sniper_phrase_tokens_bucket{le="2"} 3`,
+		"sniper_phrase_tokens_sum 6",
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("scrape is missing %q:\n%s", line, body)
+		}
+	}
+	if strings.Contains(body, `sniper_command_errors_total{command="south"}`) {
+		t.Error("south has an error count without failing")
+	}
+}
+
+func TestMetricsReset(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.MustRun(t, "south")
+	e.Metrics.Reset()
+
+	body := scrape(t, e.Metrics)
+	if !strings.Contains(body, "sniper_phrases_total 0\n") || strings.Contains(body, `command="south"`) {
+		t.Errorf("metrics not reset:\n%s", body)
+	}
+}
+
+func TestMetricsConcurrentUse(t *testing.T) {
+	m := sniper.NewMetrics()
+	entry := sniper.HistoryEntry{Tokens: []sniper.HistoryToken{
+		{Literal: "south", Command: "south", Outcome: sniper.OutcomeHandled},
+	}}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				m.ObservePhrase(entry, time.Millisecond)
+				scrape(t, m)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := m.Executions("south"); got != 800 {
+		t.Errorf("south counted %d times, want 800", got)
+	}
+}
@@ -0,0 +1,162 @@
+package sniper
+
+// ----------------------------------------------------------------------------
+// BROWSER MODE
+// ----------------------------------------------------------------------------
+//
+// "mode browser" layers these over the base registry. They live in a mode so
+// words like "top", "bottom" and "history" keep their global meaning elsewhere.
+
+// BrowserMode builds the "browser" mode.
+func BrowserMode() ModeSpec {
+	return ModeSpec{
+		Name:        "browser",
+		Description: "Web navigation shortcuts",
+		Commands: []Cmd{
+			BrowserAddress{}, BrowserSearch{}, BrowserBookmark{}, BrowserDownloads{},
+			BrowserHistory{}, BrowserTop{}, BrowserBottom{}, BrowserBack{}, BrowserForward{},
+		},
+	}
+}
+
+// BrowserAddress performs Ctrl+L.
+type BrowserAddress struct{}
+
+func (BrowserAddress) Name() string           { return "browser_address" }
+func (BrowserAddress) CalledBy() []string     { return []string{"address"} }
+func (BrowserAddress) Description() string    { return "Focuses the address bar (Ctrl+L)" }
+func (BrowserAddress) Category() string       { return "Browser" }
+func (BrowserAddress) Effects() []NamedEffect { return nil }
+func (c BrowserAddress) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.StickyKeyboard.Control()
+		e.StickyKeyboard.L()
+		return nil
+	}, c.Effects()...)
+}
+
+// BrowserSearch performs Ctrl+K.
+type BrowserSearch struct{}
+
+func (BrowserSearch) Name() string           { return "browser_search" }
+func (BrowserSearch) CalledBy() []string     { return []string{"search"} }
+func (BrowserSearch) Description() string    { return "Focuses the search box (Ctrl+K)" }
+func (BrowserSearch) Category() string       { return "Browser" }
+func (BrowserSearch) Effects() []NamedEffect { return nil }
+func (c BrowserSearch) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.StickyKeyboard.Control()
+		e.StickyKeyboard.K()
+		return nil
+	}, c.Effects()...)
+}
+
+// BrowserBookmark performs Ctrl+D.
+type BrowserBookmark struct{}
+
+func (BrowserBookmark) Name() string           { return "browser_bookmark" }
+func (BrowserBookmark) CalledBy() []string     { return []string{"bookmark"} }
+func (BrowserBookmark) Description() string    { return "Bookmarks the page (Ctrl+D)" }
+func (BrowserBookmark) Category() string       { return "Browser" }
+func (BrowserBookmark) Effects() []NamedEffect { return nil }
+func (c BrowserBookmark) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.StickyKeyboard.Control()
+		e.StickyKeyboard.D()
+		return nil
+	}, c.Effects()...)
+}
+
+// BrowserDownloads performs Ctrl+J.
+type BrowserDownloads struct{}
+
+func (BrowserDownloads) Name() string           { return "browser_downloads" }
+func (BrowserDownloads) CalledBy() []string     { return []string{"downloads"} }
+func (BrowserDownloads) Description() string    { return "Opens downloads (Ctrl+J)" }
+func (BrowserDownloads) Category() string       { return "Browser" }
+func (BrowserDownloads) Effects() []NamedEffect { return nil }
+func (c BrowserDownloads) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.StickyKeyboard.Control()
+		e.StickyKeyboard.J()
+		return nil
+	}, c.Effects()...)
+}
+
+// BrowserHistory performs Ctrl+H.
+type BrowserHistory struct{}
+
+func (BrowserHistory) Name() string           { return "browser_history" }
+func (BrowserHistory) CalledBy() []string     { return []string{"history"} }
+func (BrowserHistory) Description() string    { return "Opens history (Ctrl+H)" }
+func (BrowserHistory) Category() string       { return "Browser" }
+func (BrowserHistory) Effects() []NamedEffect { return nil }
+func (c BrowserHistory) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.StickyKeyboard.Control()
+		e.StickyKeyboard.H()
+		return nil
+	}, c.Effects()...)
+}
+
+// BrowserTop performs Home.
+type BrowserTop struct{}
+
+func (BrowserTop) Name() string           { return "browser_top" }
+func (BrowserTop) CalledBy() []string     { return []string{"top"} }
+func (BrowserTop) Description() string    { return "Scrolls to the top of the page (Home)" }
+func (BrowserTop) Category() string       { return "Browser" }
+func (BrowserTop) Effects() []NamedEffect { return nil }
+func (c BrowserTop) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.StickyKeyboard.Home()
+		return nil
+	}, c.Effects()...)
+}
+
+// BrowserBottom performs End.
+type BrowserBottom struct{}
+
+func (BrowserBottom) Name() string           { return "browser_bottom" }
+func (BrowserBottom) CalledBy() []string     { return []string{"bottom"} }
+func (BrowserBottom) Description() string    { return "Scrolls to the bottom of the page (End)" }
+func (BrowserBottom) Category() string       { return "Browser" }
+func (BrowserBottom) Effects() []NamedEffect { return nil }
+func (c BrowserBottom) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.StickyKeyboard.End()
+		return nil
+	}, c.Effects()...)
+}
+
+// BrowserBack performs Alt+Left.
+type BrowserBack struct{}
+
+func (BrowserBack) Name() string           { return "browser_back" }
+func (BrowserBack) CalledBy() []string     { return []string{"back page"} }
+func (BrowserBack) Description() string    { return "Goes back a page (Alt+Left)" }
+func (BrowserBack) Category() string       { return "Browser" }
+func (BrowserBack) Effects() []NamedEffect { return nil }
+func (c BrowserBack) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.StickyKeyboard.Alt()
+		e.StickyKeyboard.Left()
+		return nil
+	}, c.Effects()...)
+}
+
+// BrowserForward performs Alt+Right.
+type BrowserForward struct{}
+
+func (BrowserForward) Name() string           { return "browser_forward" }
+func (BrowserForward) CalledBy() []string     { return []string{"forward page"} }
+func (BrowserForward) Description() string    { return "Goes forward a page (Alt+Right)" }
+func (BrowserForward) Category() string       { return "Browser" }
+func (BrowserForward) Effects() []NamedEffect { return nil }
+func (c BrowserForward) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.StickyKeyboard.Alt()
+		e.StickyKeyboard.Right()
+		return nil
+	}, c.Effects()...)
+}
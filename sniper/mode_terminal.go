@@ -0,0 +1,194 @@
+package sniper
+
+// ----------------------------------------------------------------------------
+// TERMINAL MODE
+// ----------------------------------------------------------------------------
+//
+// "mode terminal" layers these over the base registry. Ctrl+C means interrupt
+// in a shell, so "copy" and "paste" are overridden with their Shift variants.
+
+// TerminalMode builds the "terminal" mode.
+func TerminalMode() ModeSpec {
+	return ModeSpec{
+		Name:        "terminal",
+		Description: "Shell and readline shortcuts",
+		Commands: []Cmd{
+			TerminalClear{}, TerminalInterrupt{}, TerminalEOF{}, TerminalLastCommand{},
+			TerminalSearchHistory{}, TerminalWordBack{}, TerminalWordForward{}, TerminalKillLine{},
+			TerminalCopy{}, TerminalPaste{},
+		},
+	}
+}
+
+// TerminalClear performs Ctrl+L.
+type TerminalClear struct{}
+
+func (TerminalClear) Name() string           { return "terminal_clear" }
+func (TerminalClear) CalledBy() []string     { return []string{"clear screen"} }
+func (TerminalClear) Description() string    { return "Clears the screen (Ctrl+L)" }
+func (TerminalClear) Category() string       { return "Terminal" }
+func (TerminalClear) Effects() []NamedEffect { return nil }
+func (c TerminalClear) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.StickyKeyboard.Control()
+		e.StickyKeyboard.L()
+		return nil
+	}, c.Effects()...)
+}
+
+// TerminalInterrupt performs Ctrl+C.
+type TerminalInterrupt struct{}
+
+func (TerminalInterrupt) Name() string           { return "terminal_interrupt" }
+func (TerminalInterrupt) CalledBy() []string     { return []string{"interrupt"} }
+func (TerminalInterrupt) Description() string    { return "Interrupts the running program (Ctrl+C)" }
+func (TerminalInterrupt) Category() string       { return "Terminal" }
+func (TerminalInterrupt) Effects() []NamedEffect { return nil }
+func (c TerminalInterrupt) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.StickyKeyboard.Control()
+		e.StickyKeyboard.C()
+		return nil
+	}, c.Effects()...)
+}
+
+// TerminalEOF performs Ctrl+D.
+type TerminalEOF struct{}
+
+func (TerminalEOF) Name() string           { return "terminal_eof" }
+func (TerminalEOF) CalledBy() []string     { return []string{"end of file"} }
+func (TerminalEOF) Description() string    { return "Sends end of file (Ctrl+D)" }
+func (TerminalEOF) Category() string       { return "Terminal" }
+func (TerminalEOF) Effects() []NamedEffect { return nil }
+func (c TerminalEOF) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.StickyKeyboard.Control()
+		e.StickyKeyboard.D()
+		return nil
+	}, c.Effects()...)
+}
+
+// TerminalLastCommand recalls the previous shell command (Up).
+// "last command run" also presses Enter.
+type TerminalLastCommand struct{}
+
+func (TerminalLastCommand) Name() string       { return "terminal_last_command" }
+func (TerminalLastCommand) CalledBy() []string { return []string{"last command"} }
+func (TerminalLastCommand) Description() string {
+	return "Recalls the previous command (Up); add \"run\" to press Enter"
+}
+func (TerminalLastCommand) Category() string       { return "Terminal" }
+func (TerminalLastCommand) ConsumesArgs() bool     { return true }
+func (TerminalLastCommand) Effects() []NamedEffect { return nil }
+func (c TerminalLastCommand) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.StickyKeyboard.Up()
+
+		// Optional "run": consume it and execute the recalled command
+		if len(e.State.RemainingTokens) > 0 && e.State.RemainingTokens[0].Literal() == "run" {
+			e.ConsumeNext(1)
+			e.StickyKeyboard.Enter()
+		}
+		return nil
+	}, c.Effects()...)
+}
+
+// TerminalSearchHistory performs Ctrl+R.
+type TerminalSearchHistory struct{}
+
+func (TerminalSearchHistory) Name() string           { return "terminal_search_history" }
+func (TerminalSearchHistory) CalledBy() []string     { return []string{"search history"} }
+func (TerminalSearchHistory) Description() string    { return "Searches shell history (Ctrl+R)" }
+func (TerminalSearchHistory) Category() string       { return "Terminal" }
+func (TerminalSearchHistory) Effects() []NamedEffect { return nil }
+func (c TerminalSearchHistory) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.StickyKeyboard.Control()
+		e.StickyKeyboard.R()
+		return nil
+	}, c.Effects()...)
+}
+
+// TerminalWordBack performs Alt+B.
+type TerminalWordBack struct{}
+
+func (TerminalWordBack) Name() string           { return "terminal_word_back" }
+func (TerminalWordBack) CalledBy() []string     { return []string{"word back"} }
+func (TerminalWordBack) Description() string    { return "Moves back one word (Alt+B)" }
+func (TerminalWordBack) Category() string       { return "Terminal" }
+func (TerminalWordBack) Effects() []NamedEffect { return nil }
+func (c TerminalWordBack) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.StickyKeyboard.Alt()
+		e.StickyKeyboard.B()
+		return nil
+	}, c.Effects()...)
+}
+
+// TerminalWordForward performs Alt+F.
+type TerminalWordForward struct{}
+
+func (TerminalWordForward) Name() string           { return "terminal_word_forward" }
+func (TerminalWordForward) CalledBy() []string     { return []string{"word forward"} }
+func (TerminalWordForward) Description() string    { return "Moves forward one word (Alt+F)" }
+func (TerminalWordForward) Category() string       { return "Terminal" }
+func (TerminalWordForward) Effects() []NamedEffect { return nil }
+func (c TerminalWordForward) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.StickyKeyboard.Alt()
+		e.StickyKeyboard.F()
+		return nil
+	}, c.Effects()...)
+}
+
+// TerminalKillLine performs Ctrl+K.
+type TerminalKillLine struct{}
+
+func (TerminalKillLine) Name() string           { return "terminal_kill_line" }
+func (TerminalKillLine) CalledBy() []string     { return []string{"kill line"} }
+func (TerminalKillLine) Description() string    { return "Deletes to the end of the line (Ctrl+K)" }
+func (TerminalKillLine) Category() string       { return "Terminal" }
+func (TerminalKillLine) Effects() []NamedEffect { return nil }
+func (c TerminalKillLine) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.StickyKeyboard.Control()
+		e.StickyKeyboard.K()
+		return nil
+	}, c.Effects()...)
+}
+
+// TerminalCopy performs Ctrl+Shift+C.
+type TerminalCopy struct{}
+
+func (TerminalCopy) Name() string       { return "terminal_copy" }
+func (TerminalCopy) CalledBy() []string { return []string{"copy"} }
+func (TerminalCopy) Description() string {
+	return "Copies the selection (Ctrl+Shift+C), since Ctrl+C interrupts"
+}
+func (TerminalCopy) Category() string       { return "Terminal" }
+func (TerminalCopy) Effects() []NamedEffect { return nil }
+func (c TerminalCopy) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.StickyKeyboard.Control()
+		e.StickyKeyboard.Shift()
+		e.StickyKeyboard.C()
+		return nil
+	}, c.Effects()...)
+}
+
+// TerminalPaste performs Ctrl+Shift+V.
+type TerminalPaste struct{}
+
+func (TerminalPaste) Name() string           { return "terminal_paste" }
+func (TerminalPaste) CalledBy() []string     { return []string{"paste"} }
+func (TerminalPaste) Description() string    { return "Pastes (Ctrl+Shift+V)" }
+func (TerminalPaste) Category() string       { return "Terminal" }
+func (TerminalPaste) Effects() []NamedEffect { return nil }
+func (c TerminalPaste) Action(e *Engine, p string) error {
+	return EffectChain(e, func() error {
+		e.StickyKeyboard.Control()
+		e.StickyKeyboard.Shift()
+		e.StickyKeyboard.V()
+		return nil
+	}, c.Effects()...)
+}
@@ -0,0 +1,84 @@
+package sniper
+
+// ----------------------------------------------------------------------------
+// VIM MODE
+// ----------------------------------------------------------------------------
+//
+// "mode vim" layers these commands over the base registry. The mode tracks
+// whether vim is in insert mode so that normal-mode commands press Escape
+// first and dictation commands press "i" first, only when needed.
+
+// vimKeys is the vim command set as data. enterInsert marks commands that
+// leave vim in insert mode; every other command leaves it in normal mode.
+var vimKeys = []struct {
+	cmd         KeysCmd
+	enterInsert bool
+}{
+	{cmd: KeysCmd{CmdName: "vim_save", Triggers: []string{"save file"}, Keys: []string{"escape", ":", "w", "enter"}, Desc: "Writes the file (:w)"}},
+	{cmd: KeysCmd{CmdName: "vim_quit", Triggers: []string{"quit"}, Keys: []string{"escape", ":", "q", "enter"}, Desc: "Quits vim (:q)"}},
+	{cmd: KeysCmd{CmdName: "vim_visual", Triggers: []string{"visual"}, Keys: []string{"v"}, Desc: "Starts visual mode"}},
+	{cmd: KeysCmd{CmdName: "vim_yank_line", Triggers: []string{"yank line"}, Keys: []string{"y", "y"}, Desc: "Yanks the current line (yy)"}},
+	{cmd: KeysCmd{CmdName: "vim_delete_line", Triggers: []string{"delete line"}, Keys: []string{"d", "d"}, Desc: "Deletes the current line (dd)"}},
+	{cmd: KeysCmd{CmdName: "vim_top", Triggers: []string{"go top"}, Keys: []string{"g", "g"}, Desc: "Jumps to the first line (gg)"}},
+	{cmd: KeysCmd{CmdName: "vim_bottom", Triggers: []string{"go bottom"}, Keys: []string{"shift+g"}, Desc: "Jumps to the last line (G)"}},
+	{cmd: KeysCmd{CmdName: "vim_insert", Triggers: []string{"insert"}, Keys: []string{"i"}, Desc: "Enters insert mode"}, enterInsert: true},
+	{cmd: KeysCmd{CmdName: "vim_normal", Triggers: []string{"normal"}, Keys: []string{"escape"}, Desc: "Returns to normal mode"}},
+}
+
+// dictationCmds type text and so need vim to be in insert mode.
+var dictationCmds = map[string]bool{
+	"raw_type": true, "camel_case": true, "pascal_case": true,
+	"snake_case": true, "say": true, "word": true,
+}
+
+// vimState is the per-engine insert/normal tracking for VimMode.
+type vimState struct {
+	insert bool
+}
+
+// vimCmd wraps a KeysCmd with the insert/normal bookkeeping.
+type vimCmd struct {
+	KeysCmd
+	state       *vimState
+	enterInsert bool
+}
+
+func (c vimCmd) Category() string { return "Vim" }
+func (c vimCmd) Action(e *Engine, p string) error {
+	// Normal-mode keys would be typed as text while inserting
+	if c.state.insert && c.Keys[0] != "escape" {
+		e.StickyKeyboard.Escape()
+	}
+	if err := c.KeysCmd.Action(e, p); err != nil {
+		return err
+	}
+	c.state.insert = c.enterInsert
+	return nil
+}
+
+// VimMode builds the "vim" mode with fresh insert/normal tracking.
+func VimMode() ModeSpec {
+	state := &vimState{}
+
+	cmds := make([]Cmd, 0, len(vimKeys))
+	for _, k := range vimKeys {
+		cmds = append(cmds, vimCmd{KeysCmd: k.cmd, state: state, enterInsert: k.enterInsert})
+	}
+
+	return ModeSpec{
+		Name:        "vim",
+		Description: "Vim motions and ex commands; tracks insert vs normal mode",
+		Commands:    cmds,
+		BeforeCmd: func(e *Engine, cmd Cmd) {
+			// A plain "escape" from the base registry also leaves insert mode
+			if _, ok := cmd.(Escape); ok {
+				state.insert = false
+				return
+			}
+			if dictationCmds[cmd.Name()] && !state.insert {
+				e.StickyKeyboard.I()
+				state.insert = true
+			}
+		},
+	}
+}
@@ -0,0 +1,184 @@
+package sniper
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// APPLICATION MODES
+// ----------------------------------------------------------------------------
+//
+// A mode is a named set of commands layered over the base registry. While a
+// mode is active its triggers win; everything else falls through to the base.
+// "mode browser" switches, "mode off" goes back to the base registry.
+
+// ModeOff is the argument to ModeCmd that deactivates the current mode.
+const ModeOff = "off"
+
+// ErrUnknownMode is returned when switching to a mode that isn't defined.
+var ErrUnknownMode = errors.New("unknown mode")
+
+// ModeSpec defines a mode in Go.
+type ModeSpec struct {
+	Name        string
+	Description string
+	Commands    []Cmd
+
+	// BeforeCmd, when set, runs before every command while the mode is active
+	// (base commands included), e.g. to get the target app into the right state.
+	BeforeCmd func(e *Engine, cmd Cmd)
+}
+
+// BuiltinModes build the modes registered on every engine that keeps the
+// default commands. They are constructors so each engine gets its own mode state.
+var BuiltinModes = []func() ModeSpec{
+	VimMode,
+	BrowserMode,
+	TerminalMode,
+}
+
+// Mode is a ModeSpec compiled into a trigger lookup.
+type Mode struct {
+	Name        string
+	Description string
+	registry    map[string]Cmd
+	beforeCmd   func(e *Engine, cmd Cmd)
+}
+
+// ModeInfo describes a mode for the /api/modes endpoint.
+type ModeInfo struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Triggers    []string `json:"triggers"`
+	Active      bool     `json:"active"`
+}
+
+func newMode(name, description string) *Mode {
+	return &Mode{Name: name, Description: description, registry: make(map[string]Cmd)}
+}
+
+func (m *Mode) add(cmd Cmd) {
+	for _, trigger := range cmd.CalledBy() {
+		m.registry[strings.ToLower(trigger)] = cmd
+	}
+}
+
+// RegisterMode adds (or replaces) a mode defined in Go. It survives config reloads.
+func (e *Engine) RegisterMode(spec ModeSpec) error {
+	name := strings.ToLower(strings.TrimSpace(spec.Name))
+	if name == "" || name == ModeOff {
+		return fmt.Errorf("invalid mode name '%s'", spec.Name)
+	}
+	spec.Name = name
+
+	e.configMu.Lock()
+	defer e.configMu.Unlock()
+
+	e.registryMu.Lock()
+	replaced := false
+	for i, existing := range e.modeSpecs {
+		if existing.Name == name {
+			e.modeSpecs[i] = spec
+			replaced = true
+		}
+	}
+	if !replaced {
+		e.modeSpecs = append(e.modeSpecs, spec)
+	}
+	cfg := e.config
+	e.registryMu.Unlock()
+
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	e.ApplyConfig(cfg)
+	return nil
+}
+
+// buildModes compiles the Go mode specs plus the config file's modes.
+// Config triggers are layered on top of a Go mode with the same name.
+func (e *Engine) buildModes(cfg *Config) map[string]*Mode {
+	modes := make(map[string]*Mode)
+	for _, spec := range e.modeSpecs {
+		m := newMode(spec.Name, spec.Description)
+		m.beforeCmd = spec.BeforeCmd
+		for _, cmd := range spec.Commands {
+			m.add(cmd)
+		}
+		modes[spec.Name] = m
+	}
+
+	for _, name := range sortedKeys(cfg.Modes) {
+		key := strings.ToLower(strings.TrimSpace(name))
+		m, ok := modes[key]
+		if !ok {
+			m = newMode(key, "Defined in the config file")
+			modes[key] = m
+		}
+		for _, trigger := range sortedKeys(cfg.Modes[name]) {
+			m.add(NewMacroCmd(strings.ToLower(trigger), cfg.Modes[name][trigger]))
+		}
+	}
+	return modes
+}
+
+// SetMode activates a mode by name ("off" or "" deactivates).
+// An unknown name returns ErrUnknownMode and leaves the current mode alone.
+func (e *Engine) SetMode(name string) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	e.registryMu.Lock()
+	defer e.registryMu.Unlock()
+
+	if name == "" || name == ModeOff {
+		e.activeMode, e.manualMode = "", ""
+		e.registryVersion.Add(1)
+		e.publishMode("")
+		return nil
+	}
+	if _, ok := e.modes[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownMode, name)
+	}
+	e.activeMode, e.manualMode = name, name
+	e.registryVersion.Add(1)
+	e.publishMode(name)
+	e.log().Info("mode switched", "mode", name)
+	return nil
+}
+
+// ActiveMode returns the active mode's name, or "" when none is active.
+func (e *Engine) ActiveMode() string {
+	e.registryMu.RLock()
+	defer e.registryMu.RUnlock()
+	return e.activeMode
+}
+
+// Modes lists every defined mode, sorted by name.
+func (e *Engine) Modes() []ModeInfo {
+	e.registryMu.RLock()
+	defer e.registryMu.RUnlock()
+
+	out := make([]ModeInfo, 0, len(e.modes))
+	for _, name := range sortedKeys(e.modes) {
+		m := e.modes[name]
+		out = append(out, ModeInfo{
+			Name:        m.Name,
+			Description: m.Description,
+			Triggers:    sortedKeys(m.registry),
+			Active:      name == e.activeMode,
+		})
+	}
+	return out
+}
+
+// activeBeforeCmd returns the active mode's BeforeCmd hook, if it has one.
+func (e *Engine) activeBeforeCmd() func(e *Engine, cmd Cmd) {
+	e.registryMu.RLock()
+	defer e.registryMu.RUnlock()
+	if mode, ok := e.modes[e.activeMode]; ok {
+		return mode.beforeCmd
+	}
+	return nil
+}
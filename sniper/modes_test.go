@@ -0,0 +1,94 @@
+package sniper_test
+
+import (
+	"testing"
+
+	"github.com/phillip-england/sniper/sniper/snipertest"
+)
+
+func TestVimMode(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.MustRun(t, "mode vim")
+
+	snipertest.ExpectKeys(t, e, "save file", "escape", "shift+;", "w", "enter")
+	snipertest.ExpectKeys(t, e, "quit", "escape", "shift+;", "q", "enter")
+	snipertest.ExpectKeys(t, e, "visual", "v")
+	snipertest.ExpectKeys(t, e, "yank line", "y", "y")
+	snipertest.ExpectKeys(t, e, "delete line", "d", "d")
+	snipertest.ExpectKeys(t, e, "go top", "g", "g")
+	snipertest.ExpectKeys(t, e, "go bottom", "shift+g")
+}
+
+func TestVimModeTracksInsertMode(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.MustRun(t, "mode vim")
+
+	// Dictating from normal mode enters insert mode first, once
+	snipertest.ExpectKeys(t, e, "say hello", "i")
+	snipertest.ExpectKeys(t, e, "say again")
+
+	// Normal-mode commands leave insert mode first
+	snipertest.ExpectKeys(t, e, "yank line", "escape", "y", "y")
+	snipertest.ExpectKeys(t, e, "insert", "i")
+	snipertest.ExpectKeys(t, e, "normal", "escape")
+	snipertest.ExpectKeys(t, e, "visual", "v")
+
+	// A plain escape from the base registry leaves insert mode too
+	snipertest.ExpectKeys(t, e, "insert escape visual", "i", "escape", "v")
+	snipertest.ExpectTyped(t, e, "say done", "Done. ")
+}
+
+func TestBrowserMode(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	mod := primary()
+
+	// Outside the mode the words keep their global meaning
+	snipertest.ExpectKeys(t, e, "back", "backspace")
+
+	e.MustRun(t, "mode browser")
+	snipertest.ExpectKeys(t, e, "address", mod+"+l")
+	snipertest.ExpectKeys(t, e, "search", mod+"+k")
+	snipertest.ExpectKeys(t, e, "bookmark", mod+"+d")
+	snipertest.ExpectKeys(t, e, "downloads", mod+"+j")
+	snipertest.ExpectKeys(t, e, "history", mod+"+h")
+	snipertest.ExpectKeys(t, e, "top", "home")
+	snipertest.ExpectKeys(t, e, "bottom", "end")
+	snipertest.ExpectKeys(t, e, "back page", "alt+left")
+	snipertest.ExpectKeys(t, e, "forward page", "alt+right")
+
+	// Base commands the mode doesn't replace still work
+	snipertest.ExpectKeys(t, e, "back", "backspace")
+
+	e.MustRun(t, "mode off")
+	snipertest.ExpectKeys(t, e, "back page", "backspace")
+}
+
+func TestTerminalMode(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	mod := primary()
+
+	e.MustRun(t, "mode terminal")
+	snipertest.ExpectKeys(t, e, "clear screen", mod+"+l")
+	snipertest.ExpectKeys(t, e, "end of file", mod+"+d")
+	snipertest.ExpectKeys(t, e, "search history", mod+"+r")
+	snipertest.ExpectKeys(t, e, "word back", "alt+b")
+	snipertest.ExpectKeys(t, e, "word forward", "alt+f")
+	snipertest.ExpectKeys(t, e, "kill line", mod+"+k")
+	snipertest.ExpectKeys(t, e, "last command", "up")
+	snipertest.ExpectKeys(t, e, "last command run", "up", "enter")
+}
+
+func TestTerminalModeOverridesCopy(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	mod := primary()
+
+	// Ctrl+C copies in the base registry but interrupts in a terminal
+	snipertest.ExpectKeys(t, e, "copy", mod+"+c")
+	e.MustRun(t, "mode terminal")
+	snipertest.ExpectKeys(t, e, "interrupt", mod+"+c")
+	snipertest.ExpectKeys(t, e, "copy", mod+"+shift+c")
+	snipertest.ExpectKeys(t, e, "paste", mod+"+shift+v")
+
+	e.MustRun(t, "mode off")
+	snipertest.ExpectKeys(t, e, "copy", mod+"+c")
+}
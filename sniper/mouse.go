@@ -1,7 +1,9 @@
 package sniper
 
 import (
+	"log/slog"
 	"math"
+	"sync"
 	"time"
 
 	"github.com/go-vgo/robotgo"
@@ -12,6 +14,31 @@ type Mouse struct {
 	X    int
 	Y    int
 	Jump int // Determines how far the mouse moves on directional commands
+
+	// Delay is waited between the steps of a scroll
+	Delay time.Duration
+
+	// ClickGap is waited between the clicks of a double or triple click
+	ClickGap time.Duration
+
+	// mu guards Delay and ClickGap, which SetDelays changes while commands run
+	mu sync.Mutex
+
+	// Logger receives a debug line per move and click. Nil means slog.Default().
+	Logger *slog.Logger
+
+	// recorder collects the moves, clicks and scrolls of the running phrase
+	recorder *ActionRecorder
+
+	// backend performs the moves, clicks and scrolls (nil means robotgo)
+	backend InputBackend
+}
+
+func (m *Mouse) log() *slog.Logger {
+	if m.Logger != nil {
+		return m.Logger
+	}
+	return slog.Default()
 }
 
 // NewMouse initializes a new Mouse struct with the current screen position
@@ -19,24 +46,87 @@ type Mouse struct {
 func NewMouse() *Mouse {
 	x, y := robotgo.Location()
 	return &Mouse{
-		X:    x,
-		Y:    y,
-		Jump: 1, // Default jump distance in pixels
+		X:        x,
+		Y:        y,
+		Jump:     1, // Default jump distance in pixels
+		Delay:    50 * time.Millisecond,
+		ClickGap: 50 * time.Millisecond,
 	}
 }
 
+// SetDelays changes Delay and ClickGap.
+func (m *Mouse) SetDelays(delay, clickGap time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Delay = delay
+	m.ClickGap = clickGap
+}
+
+// delays returns Delay and ClickGap.
+func (m *Mouse) delays() (delay, clickGap time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.Delay, m.ClickGap
+}
+
+// stepPause waits Delay between two scroll steps.
+func (m *Mouse) stepPause() {
+	delay, _ := m.delays()
+	time.Sleep(delay)
+}
+
+// clickPause waits ClickGap between two clicks.
+func (m *Mouse) clickPause() {
+	_, gap := m.delays()
+	time.Sleep(gap)
+}
+
 // SyncPosition updates the internal X and Y coordinates to match the actual system mouse position.
 func (m *Mouse) SyncPosition() {
-	x, y := robotgo.Location()
+	x, y := m.input().Location()
 	m.X = x
 	m.Y = y
 }
 
+// SetBackend makes the mouse move and click through b. Nil restores robotgo.
+func (m *Mouse) SetBackend(b InputBackend) {
+	m.backend = b
+}
+
+// input returns the backend moves and clicks go through.
+func (m *Mouse) input() InputBackend {
+	if m.backend == nil {
+		return RobotgoBackend{}
+	}
+	return m.backend
+}
+
+// check logs a backend failure.
+func (m *Mouse) check(op string, err error) {
+	if err != nil {
+		m.log().Warn("mouse backend failed", "component", "mouse", "op", op, "backend", m.input().Name(), "error", err)
+	}
+}
+
+// SetRecorder makes the mouse report its actions to r. Nil stops reporting.
+func (m *Mouse) SetRecorder(r *ActionRecorder) {
+	m.recorder = r
+}
+
 // SetJump allows you to update the distance the mouse moves.
 func (m *Mouse) SetJump(pixels int) {
 	m.Jump = pixels
 }
 
+// MoveTo puts the mouse at x, y.
+func (m *Mouse) MoveTo(x, y int) {
+	m.X = x
+	m.Y = y
+	m.check("move", m.input().Move(m.X, m.Y))
+	m.recorder.Record(RecordedAction{Kind: ActionMouseMove, X: m.X, Y: m.Y})
+	m.log().Debug("mouse move", "component", "mouse", "x", m.X, "y", m.Y)
+}
+
 // --- Movement Methods (Using m.Jump with Bounds Checking) ---
 
 // MoveLeft moves the mouse left by the current Jump amount, stopping at the screen edge (0).
@@ -51,7 +141,9 @@ func (m *Mouse) MoveLeft() {
 	}
 
 	m.X = targetX
-	robotgo.Move(m.X, m.Y)
+	m.check("move", m.input().Move(m.X, m.Y))
+	m.recorder.Record(RecordedAction{Kind: ActionMouseMove, X: m.X, Y: m.Y})
+	m.log().Debug("mouse move", "component", "mouse", "x", m.X, "y", m.Y)
 }
 
 // MoveRight moves the mouse right by the current Jump amount, stopping at the screen width.
@@ -59,7 +151,7 @@ func (m *Mouse) MoveRight() {
 	m.SyncPosition()
 
 	// Get screen width for boundary check
-	screenWidth, _ := robotgo.GetScreenSize()
+	screenWidth, _ := m.input().ScreenSize()
 	targetX := m.X + m.Jump
 
 	// Boundary check: Right edge is screenWidth - 1 (0-indexed)
@@ -68,7 +160,9 @@ func (m *Mouse) MoveRight() {
 	}
 
 	m.X = targetX
-	robotgo.Move(m.X, m.Y)
+	m.check("move", m.input().Move(m.X, m.Y))
+	m.recorder.Record(RecordedAction{Kind: ActionMouseMove, X: m.X, Y: m.Y})
+	m.log().Debug("mouse move", "component", "mouse", "x", m.X, "y", m.Y)
 }
 
 // MoveUp moves the mouse up by the current Jump amount, stopping at the top edge (0).
@@ -83,7 +177,9 @@ func (m *Mouse) MoveUp() {
 	}
 
 	m.Y = targetY
-	robotgo.Move(m.X, m.Y)
+	m.check("move", m.input().Move(m.X, m.Y))
+	m.recorder.Record(RecordedAction{Kind: ActionMouseMove, X: m.X, Y: m.Y})
+	m.log().Debug("mouse move", "component", "mouse", "x", m.X, "y", m.Y)
 }
 
 // MoveDown moves the mouse down by the current Jump amount, stopping at the screen height.
@@ -91,7 +187,7 @@ func (m *Mouse) MoveDown() {
 	m.SyncPosition()
 
 	// Get screen height for boundary check
-	_, screenHeight := robotgo.GetScreenSize()
+	_, screenHeight := m.input().ScreenSize()
 	targetY := m.Y + m.Jump
 
 	// Boundary check: Bottom edge is screenHeight - 1 (0-indexed)
@@ -100,30 +196,42 @@ func (m *Mouse) MoveDown() {
 	}
 
 	m.Y = targetY
-	robotgo.Move(m.X, m.Y)
+	m.check("move", m.input().Move(m.X, m.Y))
+	m.recorder.Record(RecordedAction{Kind: ActionMouseMove, X: m.X, Y: m.Y})
+	m.log().Debug("mouse move", "component", "mouse", "x", m.X, "y", m.Y)
 }
 
 // --- Click Methods ---
 
+// ReleaseButtons lets go of the mouse buttons, in case a cancelled phrase left one down.
+func (m *Mouse) ReleaseButtons() {
+	m.input().MouseUp("left")
+	m.input().MouseUp("right")
+}
+
 // Click performs a single left click.
 func (m *Mouse) Click() {
-	robotgo.Click("left")
+	m.check("click", m.input().Click("left"))
+	m.recorder.Record(RecordedAction{Kind: ActionClick, Button: "left", Clicks: 1})
+	m.log().Debug("mouse click", "component", "mouse", "button", "left")
 }
 
 // DoubleClick performs two left clicks with a small delay.
 func (m *Mouse) DoubleClick() {
-	robotgo.Click("left")
-	time.Sleep(time.Millisecond * 50)
-	robotgo.Click("left")
+	m.check("click", m.input().Click("left"))
+	m.clickPause()
+	m.check("click", m.input().Click("left"))
+	m.recorder.Record(RecordedAction{Kind: ActionClick, Button: "left", Clicks: 2})
 }
 
 // TripleClick performs three left clicks.
 func (m *Mouse) TripleClick() {
-	robotgo.Click("left")
-	time.Sleep(time.Millisecond * 50)
-	robotgo.Click("left")
-	time.Sleep(time.Millisecond * 50)
-	robotgo.Click("left")
+	m.check("click", m.input().Click("left"))
+	m.clickPause()
+	m.check("click", m.input().Click("left"))
+	m.clickPause()
+	m.check("click", m.input().Click("left"))
+	m.recorder.Record(RecordedAction{Kind: ActionClick, Button: "left", Clicks: 3})
 }
 
 // --- Scrolling Methods ---
@@ -135,8 +243,9 @@ func (m *Mouse) ScrollDown(amount int) {
 
 	for i := 0; i < steps; i++ {
 		// x=0, y=-1 (Usually down on standard OS configs)
-		robotgo.Scroll(0, -1)
-		time.Sleep(time.Millisecond * 50)
+		m.check("scroll", m.input().Scroll(0, -1))
+		m.recorder.Record(RecordedAction{Kind: ActionScroll, X: 0, Y: -1})
+		m.stepPause()
 	}
 }
 
@@ -147,8 +256,9 @@ func (m *Mouse) ScrollUp(amount int) {
 
 	for i := 0; i < steps; i++ {
 		// x=0, y=1 (Usually up)
-		robotgo.Scroll(0, 1)
-		time.Sleep(time.Millisecond * 50)
+		m.check("scroll", m.input().Scroll(0, 1))
+		m.recorder.Record(RecordedAction{Kind: ActionScroll, X: 0, Y: 1})
+		m.stepPause()
 	}
 }
 
@@ -160,8 +270,9 @@ func (m *Mouse) ScrollLeft(amount int) {
 	for i := 0; i < steps; i++ {
 		// x=1, y=0 (Positive X is usually left in robotgo depending on OS)
 		// If this scrolls right instead, switch to -1
-		robotgo.Scroll(1, 0)
-		time.Sleep(time.Millisecond * 50)
+		m.check("scroll", m.input().Scroll(1, 0))
+		m.recorder.Record(RecordedAction{Kind: ActionScroll, X: 1, Y: 0})
+		m.stepPause()
 	}
 }
 
@@ -173,7 +284,8 @@ func (m *Mouse) ScrollRight(amount int) {
 	for i := 0; i < steps; i++ {
 		// x=-1, y=0 (Negative X is usually right in robotgo depending on OS)
 		// If this scrolls left instead, switch to 1
-		robotgo.Scroll(-1, 0)
-		time.Sleep(time.Millisecond * 50)
+		m.check("scroll", m.input().Scroll(-1, 0))
+		m.recorder.Record(RecordedAction{Kind: ActionScroll, X: -1, Y: 0})
+		m.stepPause()
 	}
 }
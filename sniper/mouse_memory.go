@@ -2,9 +2,10 @@ package sniper
 
 import (
 	"encoding/json"
-	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 )
@@ -20,6 +21,16 @@ type MouseMemory struct {
 	Spots    map[string]MouseSpot `json:"spots"`
 	FilePath string
 	mu       sync.RWMutex
+
+	// Logger receives save errors. Nil means slog.Default().
+	Logger *slog.Logger
+}
+
+func (mm *MouseMemory) log() *slog.Logger {
+	if mm.Logger != nil {
+		return mm.Logger
+	}
+	return slog.Default()
 }
 
 // NewMouseMemory creates the manager and loads existing spots.
@@ -56,11 +67,35 @@ func (mm *MouseMemory) Save() {
 
 	data, err := json.MarshalIndent(mm.Spots, "", "  ")
 	if err != nil {
-		fmt.Printf("Error saving mouse memory: %v\n", err)
+		mm.log().Error("failed to save mouse memory", "path", mm.FilePath, "error", err)
 		return
 	}
 
-	os.WriteFile(mm.FilePath, data, 0644)
+	if err := writeFileAtomic(mm.FilePath, data); err != nil {
+		mm.log().Error("failed to save mouse memory", "path", mm.FilePath, "error", err)
+	}
+}
+
+// writeFileAtomic writes data to a temp file next to path and renames it into place,
+// so a crash mid-write never leaves a truncated file behind.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once the rename succeeds
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
 }
 
 // Set saves a coordinate with a name (normalized to lower case).
@@ -89,3 +124,15 @@ func (mm *MouseMemory) Delete(name string) {
 	mm.mu.Unlock()
 	mm.Save()
 }
+
+// Names returns the saved spot names, sorted.
+func (mm *MouseMemory) Names() []string {
+	mm.mu.RLock()
+	defer mm.mu.RUnlock()
+	names := make([]string, 0, len(mm.Spots))
+	for name := range mm.Spots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
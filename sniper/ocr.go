@@ -0,0 +1,197 @@
+package sniper
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-vgo/robotgo"
+)
+
+// ----------------------------------------------------------------------------
+// ON-SCREEN TEXT
+// ----------------------------------------------------------------------------
+//
+// "point submit" captures the screen, asks an OCRProvider where the words
+// are, and clicks the middle of "submit". No provider is built in, so the
+// OCR dependency (tesseract, a cloud API, ...) stays optional.
+
+// ErrNoOCRProvider is returned by FindText until SetOCRProvider (or WithOCR) is called.
+var ErrNoOCRProvider = errors.New("no OCR provider configured")
+
+// ErrTextNotFound is returned by FindText when nothing on screen matches.
+var ErrTextNotFound = errors.New("text not found on screen")
+
+// OCRCacheTTL is how long the words found on a screen capture are reused, so
+// "point submit" right after "point cancel" doesn't capture and OCR again.
+const OCRCacheTTL = 2 * time.Second
+
+// TextBox is a word (or run of words) an OCRProvider found, with its bounds
+// in screen coordinates.
+type TextBox struct {
+	Text string `json:"text"`
+	X    int    `json:"x"`
+	Y    int    `json:"y"`
+	W    int    `json:"w"`
+	H    int    `json:"h"`
+}
+
+// Center returns the middle of the box.
+func (b TextBox) Center() (int, int) {
+	return b.X + b.W/2, b.Y + b.H/2
+}
+
+// OCRProvider finds the words in a screen capture.
+type OCRProvider interface {
+	Recognize(img image.Image) ([]TextBox, error)
+}
+
+// noOCR is the default provider; it always fails with ErrNoOCRProvider.
+type noOCR struct{}
+
+func (noOCR) Recognize(image.Image) ([]TextBox, error) { return nil, ErrNoOCRProvider }
+
+// ocrCache holds the words of the last screen capture.
+type ocrCache struct {
+	boxes []TextBox
+	at    time.Time
+}
+
+// WithOCR makes the engine find on-screen text with provider.
+func WithOCR(provider OCRProvider) EngineOption {
+	return func(s *engineSetup) {
+		s.ocr = provider
+	}
+}
+
+// SetOCRProvider changes how on-screen text is found. Nil restores the
+// default, which has no OCR.
+func (e *Engine) SetOCRProvider(provider OCRProvider) {
+	e.ocrMu.Lock()
+	defer e.ocrMu.Unlock()
+	if provider == nil {
+		provider = noOCR{}
+	}
+	e.ocr = provider
+	e.ocrCache = ocrCache{}
+}
+
+// screenText returns the words on screen, from the cache while it is fresh.
+func (e *Engine) screenText() ([]TextBox, error) {
+	e.ocrMu.Lock()
+	defer e.ocrMu.Unlock()
+
+	if e.ocrCache.boxes != nil && e.Now().Sub(e.ocrCache.at) < OCRCacheTTL {
+		return e.ocrCache.boxes, nil
+	}
+	if _, ok := e.ocr.(noOCR); ok || e.ocr == nil {
+		return nil, ErrNoOCRProvider
+	}
+	img, err := robotgo.CaptureImg()
+	if err != nil {
+		return nil, fmt.Errorf("capturing the screen: %w", err)
+	}
+	boxes, err := e.ocr.Recognize(img)
+	if err != nil {
+		return nil, err
+	}
+	e.ocrCache = ocrCache{boxes: boxes, at: e.Now()}
+	return boxes, nil
+}
+
+// FindText finds query on screen. Queries of several words match runs of
+// adjacent boxes on one line. Case and surrounding punctuation are ignored.
+// When query appears more than once, the topmost (then leftmost) match wins.
+func (e *Engine) FindText(query string) (TextBox, error) {
+	boxes, err := e.screenText()
+	if err != nil {
+		return TextBox{}, err
+	}
+	matches := matchText(boxes, query)
+	if len(matches) == 0 {
+		return TextBox{}, fmt.Errorf("%w: '%s'", ErrTextNotFound, query)
+	}
+	if len(matches) > 1 {
+		e.log().Debug("text found more than once", "query", query, "matches", len(matches))
+	}
+	return matches[0], nil
+}
+
+// matchText returns every match of query in boxes, topmost-leftmost first.
+func matchText(boxes []TextBox, query string) []TextBox {
+	want := ocrWords(query)
+	if len(want) == 0 {
+		return nil
+	}
+
+	// Reading order, so consecutive boxes are neighbouring words
+	sorted := make([]TextBox, len(boxes))
+	copy(sorted, boxes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Y != sorted[j].Y {
+			return sorted[i].Y < sorted[j].Y
+		}
+		return sorted[i].X < sorted[j].X
+	})
+
+	// Each box may hold several words; flatten to one entry per word
+	type word struct {
+		text string
+		box  int
+	}
+	var words []word
+	for i, b := range sorted {
+		for _, w := range ocrWords(b.Text) {
+			words = append(words, word{w, i})
+		}
+	}
+
+	matches := make([]TextBox, 0)
+	for i := 0; i+len(want) <= len(words); i++ {
+		ok := true
+		for j, w := range want {
+			if words[i+j].text != w {
+				ok = false
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+		first, last := sorted[words[i].box], sorted[words[i+len(want)-1].box]
+		if first.Y+first.H < last.Y {
+			continue // the run wraps onto another line
+		}
+		matches = append(matches, unionBox(sorted[words[i].box:words[i+len(want)-1].box+1]))
+	}
+	return matches
+}
+
+// ocrWords lowercases s and splits it into words, trimming punctuation.
+func ocrWords(s string) []string {
+	var words []string
+	for _, f := range strings.Fields(strings.ToLower(s)) {
+		if w := strings.TrimFunc(f, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }); w != "" {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// unionBox returns the box around boxes.
+func unionBox(boxes []TextBox) TextBox {
+	out := boxes[0]
+	texts := []string{out.Text}
+	for _, b := range boxes[1:] {
+		right, bottom := max(out.X+out.W, b.X+b.W), max(out.Y+out.H, b.Y+b.H)
+		out.X, out.Y = min(out.X, b.X), min(out.Y, b.Y)
+		out.W, out.H = right-out.X, bottom-out.Y
+		texts = append(texts, b.Text)
+	}
+	out.Text = strings.Join(texts, " ")
+	return out
+}
@@ -0,0 +1,349 @@
+package sniper
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// EngineOptions holds the user-tunable settings of an Engine.
+// It is read and written as JSON by the /api/config endpoint.
+type EngineOptions struct {
+	// DateLayout is the Go time layout typed by the "today" command.
+	DateLayout string `json:"date_layout"`
+
+	// TimestampLayout is the Go time layout typed by the "timestamp" command.
+	TimestampLayout string `json:"timestamp_layout"`
+
+	// UUIDUppercase types generated UUIDs in upper case.
+	UUIDUppercase bool `json:"uuid_uppercase"`
+
+	// UUIDBare strips the hyphens from generated UUIDs ("uuid bare" does this for one call).
+	UUIDBare bool `json:"uuid_bare"`
+
+	// FuzzyMatch lets a word that isn't a trigger match the closest one, when
+	// it is within FuzzyDistance edits and no other command is as close.
+	FuzzyMatch bool `json:"fuzzy_match"`
+
+	// FuzzyDistance is the largest edit distance FuzzyMatch accepts.
+	FuzzyDistance int `json:"fuzzy_distance"`
+
+	// MinConfidence is the recognizer confidence (0-1) a command word needs to run.
+	// Words sent as plain text always have confidence 1.0.
+	MinConfidence float64 `json:"min_confidence"`
+
+	// Debounce skips a phrase whose normalized text matches the previous one
+	// when it arrives within DebounceMs, since speech APIs sometimes deliver
+	// the same final result twice.
+	Debounce bool `json:"debounce"`
+
+	// DebounceMs is the debounce window in milliseconds.
+	DebounceMs int `json:"debounce_ms"`
+
+	// MaxExecutionMs aborts a phrase that runs longer than this many
+	// milliseconds, releasing any held keys. 0 means no limit.
+	MaxExecutionMs int `json:"max_execution_ms"`
+
+	// RestoreMouse puts the cursor back after grab, shove and find, which
+	// click to focus.
+	RestoreMouse bool `json:"restore_mouse"`
+
+	// PreviousStates is how many earlier phrases "repeat", "repeat back N" and
+	// a bare number can reach.
+	PreviousStates int `json:"previous_states"`
+
+	// Layout is the keyboard layout characters are typed for ("qwerty", "azerty").
+	Layout string `json:"layout"`
+
+	// TypingDelayMs pauses this many milliseconds after every key tap.
+	// 0 types as fast as possible.
+	TypingDelayMs int `json:"typing_delay_ms"`
+
+	// PostReleaseDelayMs is how long to wait after releasing keys so the OS
+	// registers it.
+	PostReleaseDelayMs int `json:"post_release_delay_ms"`
+
+	// ThenDelayMs is the pause between the segments of a phrase split by "then".
+	ThenDelayMs int `json:"then_delay_ms"`
+
+	// TokenDelayUs is Engine.Delay in microseconds: the pause between the
+	// tokens of a phrase and between repetitions ("left 5").
+	TokenDelayUs int `json:"token_delay_us"`
+
+	// MouseDelayMs is Mouse.Delay in milliseconds: the pause between the
+	// steps of a scroll, and after the click of ClickBefore or ClickAfter.
+	MouseDelayMs int `json:"mouse_delay_ms"`
+
+	// ClickGapMs is the pause between the clicks of a double or triple click.
+	ClickGapMs int `json:"click_gap_ms"`
+
+	// ClipboardDelayMs gives the clipboard time to settle: it is waited after
+	// a copy before the copy is saved to the clipboard history, and after
+	// "paste second" pastes before the original clipboard is put back.
+	ClipboardDelayMs int `json:"clipboard_delay_ms"`
+
+	// StuckModifierMs releases a modifier that has been queued or held this
+	// many milliseconds without a key tap using it. 0 disables the watchdog.
+	StuckModifierMs int `json:"stuck_modifier_ms"`
+
+	// FormalDictation makes "say" continue the sentence it left off: phrases
+	// are only capitalized after a full stop, question or exclamation mark, and
+	// aren't ended with one. Off (the default), every "say" is a capitalized
+	// sentence.
+	FormalDictation bool `json:"formal_dictation"`
+
+	// VerbatimCase makes camel, pascal and snake case keep the casing the
+	// recognizer sent ("get HTTP client" -> "getHTTPClient") instead of
+	// lowercasing the words first.
+	VerbatimCase bool `json:"verbatim_case"`
+
+	// AggressiveNumbers applies the number homophones ("to" -> 2, see
+	// DefaultNumberHomophones), so "left to" moves twice. Words after a
+	// dictation command are never converted.
+	AggressiveNumbers bool `json:"aggressive_numbers"`
+
+	// SilentHelp keeps "help" from typing its answer; it is only returned in
+	// the execution result.
+	SilentHelp bool `json:"silent_help"`
+
+	// DefaultMode is the mode of messages sent without one: "auto" guesses
+	// it with DetectMode, "rapid" or "phrase" always uses that mode.
+	DefaultMode string `json:"default_mode"`
+
+	// RapidMaxTokens is the longest message "auto" sends to rapid mode.
+	RapidMaxTokens int `json:"rapid_max_tokens"`
+
+	// RapidRawSuffix is typed after a word rapid mode dictates because it
+	// isn't a command or number. Empty runs the words together.
+	RapidRawSuffix string `json:"rapid_raw_suffix"`
+
+	// StrictAlphabet switches off single-character triggers and the ambiguous
+	// words in DefaultAmbiguousWords (or the config's "ambiguous_words"), so
+	// recognizer noise can't fire them. NATO letters keep working.
+	StrictAlphabet bool `json:"strict_alphabet"`
+
+	// TypedLog keeps a log of the keys and text the engine typed, for
+	// GET /api/typed. Turn it off when dictating passwords.
+	TypedLog bool `json:"typed_log"`
+
+	// Backend sends keys and mouse input: "robotgo", or "ydotool" (Wayland)
+	// or "xdotool" (X11) when robotgo's synthetic input is unreliable.
+	Backend string `json:"backend"`
+
+	// Screenshots allows GET /api/screenshot. Turn it off to keep the screen
+	// from ever leaving the machine.
+	Screenshots bool `json:"screenshots"`
+
+	// CompatTyping types dictation one key tap at a time instead of in batches,
+	// for apps that drop fast synthetic input.
+	CompatTyping bool `json:"compat_typing"`
+
+	// IconTolerance is how far, from 0 to 1, each colour channel of the
+	// screen may differ from an icon template and still match ("icon save").
+	IconTolerance float64 `json:"icon_tolerance"`
+
+	// MaxInputBytes and MaxInputTokens reject a phrase longer than this many
+	// bytes or words before it runs.
+	MaxInputBytes  int `json:"max_input_bytes"`
+	MaxInputTokens int `json:"max_input_tokens"`
+
+	// MaxRepeat rejects a phrase repeating a command more times than this
+	// ("south 5000").
+	MaxRepeat int `json:"max_repeat"`
+
+	// RemoteSession marks the engine as driving a remote desktop (VNC, RDP),
+	// for effects wrapped in When(IsRemoteSession, ...).
+	RemoteSession bool `json:"remote_session"`
+}
+
+// MaxDelayMs caps the millisecond delays; a longer pause per tap or click
+// is a typo, and would make the engine look hung.
+const MaxDelayMs = 5000
+
+// MaxTokenDelayUs caps TokenDelayUs, which is waited between every token.
+const MaxTokenDelayUs = 1_000_000
+
+// MaxFuzzyDistance caps FuzzyDistance; beyond it nearly every short word matches something.
+const MaxFuzzyDistance = 3
+
+// DefaultEngineOptions returns the settings a fresh Engine starts with.
+func DefaultEngineOptions() EngineOptions {
+	return EngineOptions{
+		DateLayout:      "2006-01-02",
+		TimestampLayout: time.RFC3339,
+		FuzzyDistance:   1,
+		MinConfidence:   0.5,
+		Debounce:        true,
+		DebounceMs:      150,
+		MaxExecutionMs:  15000,
+		PreviousStates:  10,
+		Layout:          DefaultLayout,
+		Backend:         BackendRobotgo,
+		RapidRawSuffix:  " ",
+		DefaultMode:     "auto",
+		RapidMaxTokens:  DefaultRapidMaxTokens,
+
+		PostReleaseDelayMs: 5,
+		TokenDelayUs:       800,
+		ThenDelayMs:        300,
+		MouseDelayMs:       50,
+		ClickGapMs:         50,
+		ClipboardDelayMs:   100,
+		IconTolerance:      0.05,
+		MaxInputBytes:      4096,
+		MaxInputTokens:     256,
+		MaxRepeat:          500,
+		StuckModifierMs:    30000,
+		TypedLog:           true,
+		Screenshots:        true,
+	}
+}
+
+// Validate reports the first setting that can't be used as-is.
+func (o EngineOptions) Validate() error {
+	if o.DateLayout == "" {
+		return errors.New("date_layout cannot be empty")
+	}
+	if o.TimestampLayout == "" {
+		return errors.New("timestamp_layout cannot be empty")
+	}
+	if o.FuzzyDistance < 1 || o.FuzzyDistance > MaxFuzzyDistance {
+		return fmt.Errorf("fuzzy_distance must be between 1 and %d", MaxFuzzyDistance)
+	}
+	if o.MinConfidence < 0 || o.MinConfidence > 1 {
+		return errors.New("min_confidence must be between 0 and 1")
+	}
+	if o.DebounceMs < 0 {
+		return errors.New("debounce_ms cannot be negative")
+	}
+	if o.MaxExecutionMs < 0 {
+		return errors.New("max_execution_ms cannot be negative")
+	}
+	for _, d := range []struct {
+		name string
+		ms   int
+	}{
+		{"typing_delay_ms", o.TypingDelayMs},
+		{"post_release_delay_ms", o.PostReleaseDelayMs},
+		{"then_delay_ms", o.ThenDelayMs},
+		{"mouse_delay_ms", o.MouseDelayMs},
+		{"click_gap_ms", o.ClickGapMs},
+		{"clipboard_delay_ms", o.ClipboardDelayMs},
+	} {
+		if d.ms < 0 || d.ms > MaxDelayMs {
+			return fmt.Errorf("%s must be between 0 and %d", d.name, MaxDelayMs)
+		}
+	}
+	if o.TokenDelayUs < 0 || o.TokenDelayUs > MaxTokenDelayUs {
+		return fmt.Errorf("token_delay_us must be between 0 and %d", MaxTokenDelayUs)
+	}
+	if o.IconTolerance < 0 || o.IconTolerance > 1 {
+		return errors.New("icon_tolerance must be between 0 and 1")
+	}
+	if o.StuckModifierMs < 0 {
+		return errors.New("stuck_modifier_ms cannot be negative")
+	}
+	if _, ok := LookupLayout(o.Layout); !ok {
+		return fmt.Errorf("layout must be one of %s", strings.Join(LayoutNames(), ", "))
+	}
+	if !slices.Contains(Backends, o.Backend) {
+		return fmt.Errorf("backend must be one of %s", strings.Join(Backends, ", "))
+	}
+	switch o.DefaultMode {
+	case "auto", "rapid", "phrase":
+	default:
+		return errors.New("default_mode must be auto, rapid or phrase")
+	}
+	if o.RapidMaxTokens < 1 {
+		return errors.New("rapid_max_tokens must be at least 1")
+	}
+	if o.MaxInputBytes < 1 || o.MaxInputTokens < 1 || o.MaxRepeat < 1 {
+		return errors.New("max_input_bytes, max_input_tokens and max_repeat must be at least 1")
+	}
+	if o.PreviousStates < 1 {
+		return errors.New("previous_states must be at least 1")
+	}
+	return nil
+}
+
+// Options returns a copy of the Engine's current settings.
+func (e *Engine) Options() EngineOptions {
+	e.optsMu.RLock()
+	defer e.optsMu.RUnlock()
+	return e.opts
+}
+
+// SetOptions validates and applies new settings.
+// On error the current settings are left untouched.
+func (e *Engine) SetOptions(opts EngineOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	e.optsMu.Lock()
+	strictChanged := e.opts.StrictAlphabet != opts.StrictAlphabet
+	backendChanged := e.opts.Backend != opts.Backend
+	e.opts = opts
+	e.Delay = time.Duration(opts.TokenDelayUs) * time.Microsecond
+	e.optsMu.Unlock()
+
+	// Triggers StrictAlphabet suppresses are left out of the registry itself
+	if strictChanged {
+		e.rebuildRegistry()
+	}
+	if backendChanged {
+		e.useBackend(opts.Backend)
+	}
+
+	layout, _ := LookupLayout(opts.Layout)
+	e.StickyKeyboard.SetLayout(layout)
+	e.StickyKeyboard.SetCompat(opts.CompatTyping)
+	e.StickyKeyboard.SetVerbatimCase(opts.VerbatimCase)
+	e.StickyKeyboard.SetOutputLog(opts.TypedLog)
+	e.StickyKeyboard.SetDelays(
+		time.Duration(opts.TypingDelayMs)*time.Millisecond,
+		time.Duration(opts.PostReleaseDelayMs)*time.Millisecond,
+	)
+	e.Mouse.SetDelays(
+		time.Duration(opts.MouseDelayMs)*time.Millisecond,
+		time.Duration(opts.ClickGapMs)*time.Millisecond,
+	)
+	return nil
+}
+
+// PacePresets are the token delays (in microseconds) "pace <preset>" switches to.
+var PacePresets = map[string]int{
+	"fast":   0,
+	"normal": 800,
+	"slow":   150000,
+}
+
+// SetPacePreset switches the token delay to one of PacePresets.
+func (e *Engine) SetPacePreset(name string) error {
+	delay, ok := PacePresets[name]
+	if !ok {
+		return fmt.Errorf("unknown pace preset '%s'", name)
+	}
+	opts := e.Options()
+	opts.TokenDelayUs = delay
+	return e.SetOptions(opts)
+}
+
+// TypingPresets are the typing delays (in milliseconds) "typing <preset>" switches to.
+var TypingPresets = map[string]int{
+	"fast":   0,
+	"normal": 10,
+	"slow":   40,
+}
+
+// SetTypingPreset switches the typing delay to one of TypingPresets.
+func (e *Engine) SetTypingPreset(name string) error {
+	delay, ok := TypingPresets[name]
+	if !ok {
+		return fmt.Errorf("unknown typing preset '%s'", name)
+	}
+	opts := e.Options()
+	opts.TypingDelayMs = delay
+	return e.SetOptions(opts)
+}
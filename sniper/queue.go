@@ -0,0 +1,283 @@
+package sniper
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// DefaultQueueSize is how many phrases may wait for the job worker before
+// Enqueue starts refusing them.
+const DefaultQueueSize = 32
+
+// maxFinishedJobs caps how many jobs are kept around for GET /api/jobs/{id}.
+const maxFinishedJobs = 200
+
+var (
+	// ErrQueueFull is returned when the job queue has no room left.
+	ErrQueueFull = errors.New("execution queue is full")
+
+	// ErrEngineClosed is returned for jobs that were still queued when the engine closed.
+	ErrEngineClosed = errors.New("engine is closed")
+)
+
+// JobStatus is where a Job is in its lifecycle.
+type JobStatus string
+
+const (
+	JobQueued  JobStatus = "queued"
+	JobRunning JobStatus = "running"
+	JobDone    JobStatus = "done"
+	JobFailed  JobStatus = "failed"
+)
+
+// Job is one phrase waiting for, or finished by, the job worker.
+type Job struct {
+	ID    uint64
+	Input string
+	Words []SpokenWord // set instead of Input for recognizers with confidences
+	Mode  string
+
+	ctx    context.Context // cancelling it cancels the phrase
+	status JobStatus
+	result ExecutionResult
+	err    error
+	done   chan struct{}
+	mu     sync.Mutex
+
+	// workerDone is closed when the engine's worker exits, so a job that
+	// slipped into the queue during shutdown doesn't wait forever
+	workerDone <-chan struct{}
+}
+
+// Status returns the job's current status.
+func (j *Job) Status() JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status
+}
+
+// Finished reports whether the job is done or failed.
+func (j *Job) Finished() bool {
+	status := j.Status()
+	return status == JobDone || status == JobFailed
+}
+
+// Result returns the execution result and error. Both are empty until the job has finished.
+func (j *Job) Result() (ExecutionResult, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.result, j.err
+}
+
+// Wait blocks until the job finishes and returns its result.
+func (j *Job) Wait() (ExecutionResult, error) {
+	select {
+	case <-j.done:
+	case <-j.workerDone:
+		select {
+		case <-j.done:
+		default:
+			return ExecutionResult{}, ErrEngineClosed
+		}
+	}
+	return j.Result()
+}
+
+func (j *Job) setStatus(status JobStatus) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.status = status
+}
+
+func (j *Job) finish(result ExecutionResult, err error) {
+	j.mu.Lock()
+	j.result = result
+	j.err = err
+	j.status = JobDone
+	if err != nil {
+		j.status = JobFailed
+	}
+	j.mu.Unlock()
+	close(j.done)
+}
+
+// ----------------------------------------------------------------------------
+// ENGINE INTEGRATION
+// ----------------------------------------------------------------------------
+
+// Submit parses and executes a phrase on the job worker, waiting for the result.
+// Phrases from every transport (HTTP, UDP, ...) run one at a time, in arrival order.
+func (e *Engine) Submit(input string, mode string) (ExecutionResult, error) {
+	job, err := e.Enqueue(input, mode)
+	if err != nil {
+		return ExecutionResult{}, err
+	}
+	return job.Wait()
+}
+
+// SubmitWords is Submit for words that carry a recognizer confidence.
+func (e *Engine) SubmitWords(words []SpokenWord, mode string) (ExecutionResult, error) {
+	job, err := e.EnqueueWords(words, mode)
+	if err != nil {
+		return ExecutionResult{}, err
+	}
+	return job.Wait()
+}
+
+// Enqueue queues a phrase without waiting for it. It returns ErrQueueFull
+// instead of blocking when the queue has no room.
+func (e *Engine) Enqueue(input string, mode string) (*Job, error) {
+	return e.EnqueueContext(context.Background(), input, mode)
+}
+
+// EnqueueWords is Enqueue for words that carry a recognizer confidence.
+func (e *Engine) EnqueueWords(words []SpokenWord, mode string) (*Job, error) {
+	return e.EnqueueWordsContext(context.Background(), words, mode)
+}
+
+// EnqueueContext is Enqueue with a context; cancelling ctx cancels the phrase
+// (see ExecuteContext).
+func (e *Engine) EnqueueContext(ctx context.Context, input string, mode string) (*Job, error) {
+	return e.enqueue(&Job{Input: input, Mode: mode, ctx: ctx})
+}
+
+// EnqueueWordsContext is EnqueueWords with a context.
+func (e *Engine) EnqueueWordsContext(ctx context.Context, words []SpokenWord, mode string) (*Job, error) {
+	return e.enqueue(&Job{Words: words, Mode: mode, ctx: ctx})
+}
+
+func (e *Engine) enqueue(job *Job) (*Job, error) {
+	select {
+	case <-e.done:
+		return nil, ErrEngineClosed
+	default:
+	}
+
+	if err := e.CheckInput(jobText(job)); err != nil {
+		return nil, err
+	}
+
+	// A lone stop word brakes the running phrase instead of queuing behind it
+	if isStopPhrase(job) {
+		e.Cancel()
+	}
+
+	if job.ctx == nil {
+		job.ctx = context.Background()
+	}
+	job.status = JobQueued
+	job.done = make(chan struct{})
+	job.workerDone = e.workerDone
+
+	// The job is findable by ID before the worker can see it, and IDs go to
+	// the queue in order, so jobOrder stays sorted. A full queue takes the
+	// job back out and leaves its ID for the next one.
+	e.jobsMu.Lock()
+	defer e.jobsMu.Unlock()
+	e.nextJobID++
+	job.ID = e.nextJobID
+	e.jobTable[job.ID] = job
+	e.jobOrder = append(e.jobOrder, job.ID)
+
+	select {
+	case e.jobs <- job:
+	default:
+		delete(e.jobTable, job.ID)
+		e.jobOrder = e.jobOrder[:len(e.jobOrder)-1]
+		e.nextJobID--
+		return nil, ErrQueueFull
+	}
+	e.evictJobs()
+	return job, nil
+}
+
+// jobText is the phrase a job carries, as one string.
+func jobText(job *Job) string {
+	if job.Words == nil {
+		return job.Input
+	}
+	words := make([]string, len(job.Words))
+	for i, w := range job.Words {
+		words[i] = w.W
+	}
+	return strings.Join(words, " ")
+}
+
+// isStopPhrase reports whether the job is a single trigger of Stop.
+func isStopPhrase(job *Job) bool {
+	words := strings.Fields(job.Input)
+	if job.Words != nil {
+		words = words[:0]
+		for _, w := range job.Words {
+			words = append(words, strings.Fields(w.W)...)
+		}
+	}
+	return len(words) == 1 && slices.Contains(Stop{}.CalledBy(), strings.ToLower(trimWordPunct(words[0])))
+}
+
+// evictJobs forgets the oldest finished jobs beyond maxFinishedJobs.
+// jobsMu must be held.
+func (e *Engine) evictJobs() {
+	for len(e.jobOrder) > maxFinishedJobs {
+		oldest := e.jobTable[e.jobOrder[0]]
+		if oldest != nil && !oldest.Finished() {
+			return
+		}
+		delete(e.jobTable, e.jobOrder[0])
+		e.jobOrder = e.jobOrder[1:]
+	}
+}
+
+// Job returns a queued, running or recently finished job by ID.
+func (e *Engine) Job(id uint64) (*Job, bool) {
+	e.jobsMu.Lock()
+	defer e.jobsMu.Unlock()
+	job, ok := e.jobTable[id]
+	return job, ok
+}
+
+// runJobs is the single worker that executes queued phrases. Once the engine
+// is closed it fails whatever is still queued and exits.
+func (e *Engine) runJobs() {
+	defer close(e.workerDone)
+	for {
+		select {
+		case job := <-e.jobs:
+			e.runJob(job)
+		case <-e.done:
+			for {
+				select {
+				case job := <-e.jobs:
+					job.finish(ExecutionResult{}, ErrEngineClosed)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (e *Engine) runJob(job *Job) {
+	// Panics outside token handling (parsing, history) fail the job instead of
+	// taking the worker down
+	defer func() {
+		if r := recover(); r != nil {
+			e.EmergencyRelease()
+			e.log().Error("phrase panicked", "input", job.Input, "panic", r)
+			job.finish(ExecutionResult{}, &PanicError{Value: r, Stack: string(debug.Stack())})
+		}
+	}()
+
+	job.setStatus(JobRunning)
+	if job.Words != nil {
+		e.ParseWords(job.Words, job.Mode)
+	} else {
+		e.Parse(job.Input, job.Mode)
+	}
+	result, err := e.ExecuteContext(job.ctx)
+	job.finish(result, err)
+}
@@ -0,0 +1,127 @@
+package sniper_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/phillip-england/sniper/sniper"
+	"github.com/phillip-england/sniper/sniper/snipertest"
+)
+
+// stallCmd keeps the job worker busy until released.
+type stallCmd struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func newStallCmd() stallCmd {
+	return stallCmd{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (stallCmd) Name() string                  { return "stall" }
+func (stallCmd) CalledBy() []string            { return []string{"stall"} }
+func (stallCmd) Effects() []sniper.NamedEffect { return nil }
+func (c stallCmd) Action(e *sniper.Engine, p string) error {
+	c.started <- struct{}{}
+	<-c.release
+	return nil
+}
+
+// busyEngine returns an engine whose worker is stuck on a "stall" phrase, and
+// the command that releases it.
+func busyEngine(t *testing.T) (*snipertest.Engine, stallCmd) {
+	t.Helper()
+	stall := newStallCmd()
+	e := snipertest.NewTestEngine(t, sniper.WithCommands(stall))
+	if _, err := e.Enqueue("stall", "phrase"); err != nil {
+		t.Fatal(err)
+	}
+	<-stall.started
+	return e, stall
+}
+
+func TestFullQueueRefusesPhrases(t *testing.T) {
+	e, stall := busyEngine(t)
+
+	var jobs []*sniper.Job
+	for range sniper.DefaultQueueSize {
+		job, err := e.Enqueue("east", "phrase")
+		if err != nil {
+			t.Fatalf("job %d: %v", len(jobs), err)
+		}
+		if job.Status() != sniper.JobQueued {
+			t.Errorf("job %d is %s, want queued", job.ID, job.Status())
+		}
+		jobs = append(jobs, job)
+	}
+	last := jobs[len(jobs)-1].ID
+	if _, err := e.Enqueue("west", "phrase"); !errors.Is(err, sniper.ErrQueueFull) {
+		t.Fatalf("err = %v, want ErrQueueFull", err)
+	}
+	if _, ok := e.Job(last + 1); ok {
+		t.Error("the refused phrase can be looked up")
+	}
+
+	// The refused phrase didn't use up an ID
+	close(stall.release)
+	if _, err := jobs[0].Wait(); err != nil {
+		t.Fatal(err)
+	}
+	job, err := e.Enqueue("west", "phrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.ID != last+1 {
+		t.Errorf("next job has ID %d, want %d", job.ID, last+1)
+	}
+	if _, err := job.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	for _, j := range jobs {
+		if j.Status() != sniper.JobDone {
+			t.Errorf("job %d is %s, want done", j.ID, j.Status())
+		}
+	}
+}
+
+func TestQueuedJobsCanBeLookedUp(t *testing.T) {
+	e, stall := busyEngine(t)
+
+	job, err := e.Enqueue("east", "phrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := e.Job(job.ID); !ok || got != job {
+		t.Fatalf("Job(%d) = %v, %v before the worker took it", job.ID, got, ok)
+	}
+	close(stall.release)
+	result, err := job.Wait()
+	if err != nil || !job.Finished() || result.Input != "east" {
+		t.Errorf("job finished as %s with %q, %v", job.Status(), result.Input, err)
+	}
+}
+
+func TestFinishedJobsAreEvicted(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+
+	var ids []uint64
+	for range sniper.MaxFinishedJobs + 50 {
+		job, err := e.Enqueue("east", "phrase")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := job.Wait(); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, job.ID)
+	}
+
+	kept := ids[len(ids)-sniper.MaxFinishedJobs:]
+	for _, id := range ids {
+		_, ok := e.Job(id)
+		if want := slices.Contains(kept, id); ok != want {
+			t.Errorf("Job(%d) found = %v, want %v", id, ok, want)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package sniper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ----------------------------------------------------------------------------
+// CUSTOM COMMANDS
+// ----------------------------------------------------------------------------
+//
+// Register, MustRegister and Commands let programs embedding sniper add their
+// own commands without touching the package-level Registry.
+//
+// Thread-safety: all three take the engine's registry lock, so they may be
+// called while the server is running. A command registered mid-phrase only
+// takes effect for phrases parsed after Register returns.
+
+// Register adds cmd to the engine. It fails if cmd has no name or triggers, or
+// if any trigger is already taken by another command or an alias.
+// Registered commands survive config reloads.
+func (e *Engine) Register(cmd Cmd) error {
+	if cmd == nil || strings.TrimSpace(cmd.Name()) == "" {
+		return fmt.Errorf("command must have a name")
+	}
+	if len(cmd.CalledBy()) == 0 {
+		return fmt.Errorf("command '%s' has no triggers", cmd.Name())
+	}
+
+	e.registryMu.Lock()
+	defer e.registryMu.Unlock()
+
+	keys := make([]string, 0, len(cmd.CalledBy()))
+	for _, trigger := range cmd.CalledBy() {
+		key := strings.ToLower(strings.TrimSpace(trigger))
+		if key == "" {
+			return fmt.Errorf("command '%s' has an empty trigger", cmd.Name())
+		}
+		if existing, taken := e.registry[key]; taken {
+			return fmt.Errorf("trigger '%s' of command '%s' is already used by '%s'", key, cmd.Name(), existing.Name())
+		}
+		if _, taken := e.aliases[key]; taken {
+			return fmt.Errorf("trigger '%s' of command '%s' is already used by an alias", key, cmd.Name())
+		}
+		keys = append(keys, key)
+	}
+
+	e.commands = append(e.commands, cmd)
+	for _, key := range keys {
+		e.registry[key] = cmd
+		e.builtinTriggers[key] = cmd
+	}
+	e.registryVersion.Add(1)
+	return nil
+}
+
+// MustRegister is like Register but panics on error. Handy in init code.
+func (e *Engine) MustRegister(cmd Cmd) {
+	if err := e.Register(cmd); err != nil {
+		panic(err)
+	}
+}
+
+// Commands returns the commands the engine knows about (built-ins and
+// registered ones), in registration order. Config macros are not included.
+func (e *Engine) Commands() []Cmd {
+	e.registryMu.RLock()
+	defer e.registryMu.RUnlock()
+
+	out := make([]Cmd, len(e.commands))
+	copy(out, e.commands)
+	return out
+}
+
+// ----------------------------------------------------------------------------
+// DISABLING COMMANDS
+// ----------------------------------------------------------------------------
+
+// ErrCommandNotFound is returned when no command has the given Name().
+var ErrCommandNotFound = errors.New("command not found")
+
+// Disable removes every trigger of the named command from the live registry.
+// It lasts until Enable or a restart; use PersistDisabled to keep it.
+func (e *Engine) Disable(name string) error {
+	return e.setDisabled(name, true)
+}
+
+// Enable restores a disabled command. If the config file lists it as
+// disabled, it is removed from the file too.
+func (e *Engine) Enable(name string) error {
+	if err := e.setDisabled(name, false); err != nil {
+		return err
+	}
+
+	e.registryMu.RLock()
+	persisted := e.config != nil && slices.Contains(e.config.Disabled, name)
+	e.registryMu.RUnlock()
+	if !persisted {
+		return nil
+	}
+
+	return e.updateConfig(func(cfg *Config) {
+		cfg.Disabled = slices.DeleteFunc(cfg.Disabled, func(n string) bool { return n == name })
+	})
+}
+
+// PersistDisabled writes the currently disabled commands to the config file.
+func (e *Engine) PersistDisabled() error {
+	return e.updateConfig(func(cfg *Config) {
+		cfg.Disabled = e.DisabledCommands()
+	})
+}
+
+// IsDisabled reports whether the named command is switched off.
+func (e *Engine) IsDisabled(name string) bool {
+	e.registryMu.RLock()
+	defer e.registryMu.RUnlock()
+	return e.isDisabled(name)
+}
+
+// DisabledCommands returns the names of all disabled commands, sorted.
+func (e *Engine) DisabledCommands() []string {
+	e.registryMu.RLock()
+	defer e.registryMu.RUnlock()
+
+	names := make([]string, 0)
+	for _, cmd := range e.commands {
+		if e.isDisabled(cmd.Name()) {
+			names = append(names, cmd.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isDisabled checks both runtime and config-file disables. Callers hold registryMu.
+func (e *Engine) isDisabled(name string) bool {
+	if e.disabled[name] {
+		return true
+	}
+	return e.config != nil && slices.Contains(e.config.Disabled, name)
+}
+
+func (e *Engine) setDisabled(name string, disabled bool) error {
+	e.registryMu.Lock()
+	found := false
+	for _, cmd := range e.commands {
+		if cmd.Name() == name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		e.registryMu.Unlock()
+		return fmt.Errorf("%w: %s", ErrCommandNotFound, name)
+	}
+
+	if disabled {
+		e.disabled[name] = true
+	} else {
+		delete(e.disabled, name)
+	}
+	e.registryMu.Unlock()
+
+	e.rebuildRegistry()
+	return nil
+}
+
+// rebuildRegistry re-applies the current config, so aliases and macros are
+// layered correctly over the commands that are now active.
+func (e *Engine) rebuildRegistry() {
+	e.configMu.Lock()
+	defer e.configMu.Unlock()
+
+	e.registryMu.RLock()
+	cfg := e.config
+	e.registryMu.RUnlock()
+
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	e.ApplyConfig(cfg)
+}
+
+// ----------------------------------------------------------------------------
+// STRICT ALPHABET
+// ----------------------------------------------------------------------------
+
+// DefaultAmbiguousWords are the triggers the StrictAlphabet option switches
+// off (along with single characters) unless the config file lists its own:
+// words recognizers hear in ordinary speech.
+var DefaultAmbiguousWords = []string{
+	"a", "add", "and", "at", "back", "close", "down", "end", "home", "in",
+	"left", "mod", "next", "not", "open", "right", "times", "to", "up", "write",
+}
+
+// suppressed reports whether the StrictAlphabet option keeps trigger out of
+// the registry. Callers hold registryMu.
+func (e *Engine) suppressed(trigger string) bool {
+	if !e.Options().StrictAlphabet {
+		return false
+	}
+	if utf8.RuneCountInString(trigger) == 1 {
+		return true
+	}
+	if e.config != nil && e.config.AmbiguousWords != nil {
+		return slices.Contains(e.config.AmbiguousWords, trigger)
+	}
+	return slices.Contains(DefaultAmbiguousWords, trigger)
+}
+
+// SuppressedTriggers lists the triggers StrictAlphabet currently switches off.
+func (e *Engine) SuppressedTriggers() []string {
+	e.registryMu.RLock()
+	defer e.registryMu.RUnlock()
+
+	out := make([]string, 0)
+	for _, cmd := range e.commands {
+		for _, trigger := range cmd.CalledBy() {
+			if key := strings.ToLower(trigger); e.suppressed(key) {
+				out = append(out, key)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// CommandsJSON describes this engine's commands, flagging the disabled ones.
+func (e *Engine) CommandsJSON() []CmdJSON {
+	cmds := e.Commands()
+	export := make([]CmdJSON, 0, len(cmds))
+	for _, cmd := range cmds {
+		item := cmdToJSON(cmd)
+		item.Disabled = e.IsDisabled(cmd.Name())
+		export = append(export, item)
+	}
+	return export
+}
+
+// RegistryToJSON is like the package-level RegistryToJSON but lists this
+// engine's commands and flags the disabled ones.
+func (e *Engine) RegistryToJSON() (minimal string, full string, err error) {
+	export := e.CommandsJSON()
+
+	minBytes, err := json.Marshal(export)
+	if err != nil {
+		return "", "", err
+	}
+
+	fullBytes, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return "", "", err
+	}
+
+	return string(minBytes), string(fullBytes), nil
+}
+
+// ----------------------------------------------------------------------------
+// REGISTRY SNAPSHOT
+// ----------------------------------------------------------------------------
+//
+// The registry JSON only changes when a command is registered, disabled or
+// enabled, the config is applied or the mode switches; each of those bumps
+// registryVersion. RegistrySnapshot encodes (and gzips) the JSON once per
+// version, so the web UI can fetch it on every page load for free.
+
+// RegistrySnapshot is the encoded registry, as served by /api/commands/min
+// and /api/commands/full.
+type RegistrySnapshot struct {
+	Minimal     []byte
+	Full        []byte
+	MinimalGzip []byte
+	FullGzip    []byte
+
+	// MinimalETag and FullETag are strong ETags (quoted hashes) of the
+	// uncompressed bodies.
+	MinimalETag string
+	FullETag    string
+
+	version uint64
+}
+
+// RegistrySnapshot returns the encoded registry, building it when the
+// registry changed since the last call.
+func (e *Engine) RegistrySnapshot() (*RegistrySnapshot, error) {
+	version := e.registryVersion.Load()
+
+	e.snapshotMu.Lock()
+	defer e.snapshotMu.Unlock()
+	if e.snapshot != nil && e.snapshot.version == version {
+		return e.snapshot, nil
+	}
+
+	minimal, full, err := e.RegistryToJSON()
+	if err != nil {
+		return nil, err
+	}
+	snap := &RegistrySnapshot{
+		Minimal:     []byte(minimal),
+		Full:        []byte(full),
+		MinimalETag: etag([]byte(minimal)),
+		FullETag:    etag([]byte(full)),
+		version:     version,
+	}
+	if snap.MinimalGzip, err = gzipBytes(snap.Minimal); err != nil {
+		return nil, err
+	}
+	if snap.FullGzip, err = gzipBytes(snap.Full); err != nil {
+		return nil, err
+	}
+	e.snapshot = snap
+	return snap, nil
+}
+
+// etag returns a strong ETag for body.
+func etag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+func gzipBytes(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zw.Write(body); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
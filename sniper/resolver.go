@@ -0,0 +1,224 @@
+package sniper
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// TRIGGER RESOLUTION
+// ----------------------------------------------------------------------------
+//
+// Several sources can claim the same spoken word: the active mode, the user's
+// macros and aliases, saved mouse spots and the built-in commands. The source
+// with the highest priority wins.
+
+// TriggerSource is where a trigger came from.
+type TriggerSource string
+
+const (
+	SourceMode    TriggerSource = "mode"
+	SourceUser    TriggerSource = "user" // config macros and aliases
+	SourceSpot    TriggerSource = "spot"
+	SourceBuiltin TriggerSource = "builtin"
+)
+
+// TriggerSources lists every source, highest default priority first.
+var TriggerSources = []TriggerSource{SourceMode, SourceUser, SourceSpot, SourceBuiltin}
+
+// DefaultSourcePriorities is the resolution order unless the config file's
+// "priorities" changes it. Higher wins.
+var DefaultSourcePriorities = map[TriggerSource]int{
+	SourceMode:    40,
+	SourceUser:    30,
+	SourceSpot:    20,
+	SourceBuiltin: 10,
+}
+
+// Claim is one source's command for a word.
+type Claim struct {
+	Source  TriggerSource `json:"source"`
+	Command string        `json:"command"`
+
+	cmd Cmd
+}
+
+// Resolver picks the command a word resolves to among the sources claiming it.
+type Resolver struct {
+	layers   map[TriggerSource]map[string]Cmd
+	memory   *MouseMemory // spots are looked up live
+	priority map[TriggerSource]int
+}
+
+// NewResolver builds a resolver over the given trigger layers. memory may be
+// nil, and priorities nil means DefaultSourcePriorities.
+func NewResolver(layers map[TriggerSource]map[string]Cmd, memory *MouseMemory, priorities map[TriggerSource]int) *Resolver {
+	if priorities == nil {
+		priorities = DefaultSourcePriorities
+	}
+	return &Resolver{layers: layers, memory: memory, priority: priorities}
+}
+
+// Claims returns every source claiming word, the winner first.
+func (r *Resolver) Claims(word string) []Claim {
+	claims := make([]Claim, 0)
+	for _, source := range TriggerSources {
+		if source == SourceSpot {
+			if r.memory == nil {
+				continue
+			}
+			if spot, ok := r.memory.Get(word); ok {
+				claims = append(claims, Claim{Source: SourceSpot, Command: "spot:" + word, cmd: NewSpotCmd(word, spot.X, spot.Y)})
+			}
+			continue
+		}
+		if cmd, ok := r.layers[source][word]; ok {
+			claims = append(claims, Claim{Source: source, Command: cmd.Name(), cmd: cmd})
+		}
+	}
+	// Stable, so equal priorities keep the order of TriggerSources
+	sort.SliceStable(claims, func(i, j int) bool {
+		return r.priority[claims[i].Source] > r.priority[claims[j].Source]
+	})
+	return claims
+}
+
+// Resolve returns the winning command for word and where it came from.
+func (r *Resolver) Resolve(word string) (Cmd, TriggerSource, bool) {
+	claims := r.Claims(word)
+	if len(claims) == 0 {
+		return nil, "", false
+	}
+	return claims[0].cmd, claims[0].Source, true
+}
+
+// Triggers returns the winning command of every trigger in the command layers
+// (spots aren't included), for multi-word and fuzzy matching.
+func (r *Resolver) Triggers() map[string]Cmd {
+	merged := make(map[string]Cmd)
+	best := make(map[string]int)
+	for _, source := range TriggerSources {
+		for trigger, cmd := range r.layers[source] {
+			if p, seen := best[trigger]; seen && p >= r.priority[source] {
+				continue
+			}
+			merged[trigger] = cmd
+			best[trigger] = r.priority[source]
+		}
+	}
+	return merged
+}
+
+// ----------------------------------------------------------------------------
+// ENGINE INTEGRATION
+// ----------------------------------------------------------------------------
+
+// TriggerConflict is a word claimed by more than one source.
+type TriggerConflict struct {
+	Trigger string  `json:"trigger"`
+	Claims  []Claim `json:"claims"` // winner first
+	Winner  string  `json:"winner"` // source of the winning claim
+}
+
+// sourcePriorities returns the configured priorities. With override_builtins
+// off, user entries rank just below built-ins whatever their priority.
+// Callers hold registryMu.
+func (e *Engine) sourcePriorities() map[TriggerSource]int {
+	priorities := make(map[TriggerSource]int, len(DefaultSourcePriorities))
+	for source, p := range DefaultSourcePriorities {
+		priorities[source] = p
+	}
+	if e.config == nil {
+		return priorities
+	}
+	for name, p := range e.config.Priorities {
+		priorities[TriggerSource(strings.ToLower(name))] = p
+	}
+	if !e.config.overrideBuiltins() && priorities[SourceUser] >= priorities[SourceBuiltin] {
+		priorities[SourceUser] = priorities[SourceBuiltin] - 1
+	}
+	return priorities
+}
+
+// resolver builds the Resolver Parse uses: the active mode, user macros,
+// spots and built-ins. Callers hold registryMu.
+func (e *Engine) resolver() *Resolver {
+	layers := map[TriggerSource]map[string]Cmd{
+		SourceBuiltin: e.builtinTriggers,
+		SourceUser:    e.userTriggers,
+	}
+	if mode, ok := e.modes[e.activeMode]; ok {
+		layers[SourceMode] = mode.registry
+	}
+	return NewResolver(layers, e.Memory, e.sourcePriorities())
+}
+
+// TriggerConflicts lists every word more than one source claims, with the
+// winner first. Aliases count as user claims.
+func (e *Engine) TriggerConflicts() []TriggerConflict {
+	e.registryMu.RLock()
+	defer e.registryMu.RUnlock()
+
+	user := make(map[string]Cmd, len(e.userTriggers))
+	for k, v := range e.userTriggers {
+		user[k] = v
+	}
+	if e.config != nil {
+		for word, expansion := range e.config.Aliases {
+			user[strings.ToLower(strings.TrimSpace(word))] = aliasCmd{word: word, expansion: expansion}
+		}
+	}
+
+	// Built-ins are listed before filtering, so a trigger an alias took over still shows
+	builtin := make(map[string]Cmd)
+	for _, cmd := range e.commands {
+		for _, trigger := range cmd.CalledBy() {
+			builtin[strings.ToLower(trigger)] = cmd
+		}
+	}
+
+	r := e.resolver()
+	r.layers[SourceUser] = user
+	r.layers[SourceBuiltin] = builtin
+
+	words := make(map[string]bool)
+	for _, layer := range r.layers {
+		for word := range layer {
+			words[word] = true
+		}
+	}
+	for _, spot := range e.Memory.Names() {
+		words[strings.ToLower(spot)] = true
+	}
+
+	conflicts := make([]TriggerConflict, 0)
+	for _, word := range sortedKeys(words) {
+		if claims := r.Claims(word); len(claims) > 1 {
+			conflicts = append(conflicts, TriggerConflict{Trigger: word, Claims: claims, Winner: string(claims[0].Source)})
+		}
+	}
+	return conflicts
+}
+
+// validPriorities checks the source names in a config's "priorities".
+func validPriorities(priorities map[string]int) error {
+	for name := range priorities {
+		if !slices.Contains(TriggerSources, TriggerSource(strings.ToLower(name))) {
+			return fmt.Errorf("unknown trigger source '%s' in priorities", name)
+		}
+	}
+	return nil
+}
+
+// aliasCmd stands in for an alias in TriggerConflicts; it is never executed.
+type aliasCmd struct {
+	word      string
+	expansion string
+}
+
+func (a aliasCmd) Name() string                     { return "alias:" + a.word }
+func (a aliasCmd) CalledBy() []string               { return []string{a.word} }
+func (a aliasCmd) Effects() []NamedEffect           { return nil }
+func (a aliasCmd) Action(e *Engine, p string) error { return nil }
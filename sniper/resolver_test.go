@@ -0,0 +1,88 @@
+package sniper_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/phillip-england/sniper/sniper"
+	"github.com/phillip-england/sniper/sniper/snipertest"
+)
+
+func TestResolverPriority(t *testing.T) {
+	layers := map[sniper.TriggerSource]map[string]sniper.Cmd{
+		sniper.SourceMode:    {"jump": searchCmd{"mode_jump", nil, ""}},
+		sniper.SourceUser:    {"jump": searchCmd{"user_jump", nil, ""}, "hop": searchCmd{"user_hop", nil, ""}},
+		sniper.SourceBuiltin: {"jump": searchCmd{"builtin_jump", nil, ""}, "hop": searchCmd{"builtin_hop", nil, ""}},
+	}
+	tests := []struct {
+		priorities map[sniper.TriggerSource]int
+		word       string
+		want       []string // claiming commands, winner first
+	}{
+		{nil, "jump", []string{"mode_jump", "user_jump", "builtin_jump"}},
+		{nil, "hop", []string{"user_hop", "builtin_hop"}},
+		{nil, "skip", []string{}},
+		{map[sniper.TriggerSource]int{sniper.SourceBuiltin: 50, sniper.SourceMode: 40, sniper.SourceUser: 30}, "jump", []string{"builtin_jump", "mode_jump", "user_jump"}},
+		// Equal priorities keep the default order
+		{map[sniper.TriggerSource]int{}, "jump", []string{"mode_jump", "user_jump", "builtin_jump"}},
+	}
+	for _, tt := range tests {
+		r := sniper.NewResolver(layers, nil, tt.priorities)
+		got := make([]string, 0)
+		for _, c := range r.Claims(tt.word) {
+			got = append(got, c.Command)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Claims(%q) with %v = %v, want %v", tt.word, tt.priorities, got, tt.want)
+		}
+		cmd, source, ok := r.Resolve(tt.word)
+		if ok != (len(tt.want) > 0) || ok && (cmd.Name() != tt.want[0] || r.Claims(tt.word)[0].Source != source) {
+			t.Errorf("Resolve(%q) with %v = %v, %q, %v", tt.word, tt.priorities, cmd, source, ok)
+		}
+	}
+
+	// Multi-word and fuzzy matching see only the winners
+	merged := sniper.NewResolver(layers, nil, nil).Triggers()
+	if len(merged) != 2 || merged["jump"].Name() != "mode_jump" || merged["hop"].Name() != "user_hop" {
+		t.Errorf("Triggers() = %v", merged)
+	}
+}
+
+func TestSpotsAndBuiltinsShareAWord(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.Memory.Set("east", 40, 50)
+
+	// A saved spot beats a built-in unless the config says otherwise
+	result := e.MustRun(t, "east")
+	if got := result.Tokens[0].Source; got != "spot" {
+		t.Errorf("east resolved to %s, want spot", got)
+	}
+	if ops := e.Input.Ops(); !slices.Contains(ops, "move 40 50") {
+		t.Errorf("east ran %v, want a move to the spot", ops)
+	}
+	conflicts := e.TriggerConflicts()
+	i := slices.IndexFunc(conflicts, func(c sniper.TriggerConflict) bool { return c.Trigger == "east" })
+	if i < 0 || conflicts[i].Winner != "spot" || len(conflicts[i].Claims) != 2 {
+		t.Fatalf("conflicts = %+v, want east won by a spot over the built-in", conflicts)
+	}
+
+	e.ApplyConfig(&sniper.Config{Priorities: map[string]int{"builtin": 50}})
+	result = e.MustRun(t, "east")
+	if got := result.Tokens[0].Source; got != "builtin" {
+		t.Errorf("east with built-ins first resolved to %s, want builtin", got)
+	}
+	if keys := e.Input.Keys(); !slices.Equal(keys, []string{"right"}) {
+		t.Errorf("east with built-ins first tapped %v, want right", keys)
+	}
+	conflicts = e.TriggerConflicts()
+	if i := slices.IndexFunc(conflicts, func(c sniper.TriggerConflict) bool { return c.Trigger == "east" }); i < 0 || conflicts[i].Winner != "builtin" {
+		t.Errorf("conflicts = %+v, want east won by the built-in", conflicts)
+	}
+}
+
+func TestUnknownPrioritySource(t *testing.T) {
+	cfg := &sniper.Config{Priorities: map[string]int{"plugin": 5}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("a priority for an unknown source validated")
+	}
+}
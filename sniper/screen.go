@@ -0,0 +1,193 @@
+package sniper
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+	"time"
+
+	"github.com/go-vgo/robotgo"
+)
+
+// ----------------------------------------------------------------------------
+// SCREEN
+// ----------------------------------------------------------------------------
+//
+// Screen is the small abstraction the Engine uses to read the display, so
+// commands like "color" can run against a fake in tests and headless setups.
+
+// Screen reads pixels off the displays.
+type Screen interface {
+	// Displays returns the bounds of every display in global coordinates.
+	Displays() []image.Rectangle
+	// PixelColor returns the colour at x, y in global coordinates as "rrggbb".
+	PixelColor(x, y int) string
+	// Capture returns an image of the area r, in global coordinates.
+	Capture(r image.Rectangle) (image.Image, error)
+}
+
+// SystemScreen reads the real displays through robotgo.
+type SystemScreen struct{}
+
+func NewSystemScreen() *SystemScreen {
+	return &SystemScreen{}
+}
+
+// Displays returns the bounds of every connected display.
+func (s *SystemScreen) Displays() []image.Rectangle {
+	n := max(robotgo.DisplaysNum(), 1)
+	displays := make([]image.Rectangle, 0, n)
+	for i := 0; i < n; i++ {
+		x, y, w, h := robotgo.GetDisplayBounds(i)
+		if w > 0 && h > 0 {
+			displays = append(displays, image.Rect(x, y, x+w, y+h))
+		}
+	}
+	if len(displays) == 0 {
+		w, h := robotgo.GetScreenSize()
+		displays = append(displays, image.Rect(0, 0, w, h))
+	}
+	return displays
+}
+
+// PixelColor returns the colour of the pixel at x, y.
+func (s *SystemScreen) PixelColor(x, y int) string {
+	return robotgo.GetPixelColor(x, y)
+}
+
+// Capture grabs the area r of the screen.
+func (s *SystemScreen) Capture(r image.Rectangle) (image.Image, error) {
+	return robotgo.CaptureImg(r.Min.X, r.Min.Y, r.Dx(), r.Dy())
+}
+
+// WithScreen makes the engine read the displays through screen.
+func WithScreen(screen Screen) EngineOption {
+	return func(s *engineSetup) {
+		s.screen = screen
+	}
+}
+
+// Pixel is the colour of one pixel.
+type Pixel struct {
+	X   int    `json:"x"`
+	Y   int    `json:"y"`
+	Hex string `json:"hex"` // "#rrggbb"
+}
+
+// PixelColor returns the colour at x, y. A point off every display is moved
+// onto the nearest one, so the returned X and Y are the pixel actually read.
+func (e *Engine) PixelColor(x, y int) Pixel {
+	p := clampToDisplays(image.Pt(x, y), e.Screen.Displays())
+	hex := strings.ToLower(strings.TrimPrefix(e.Screen.PixelColor(p.X, p.Y), "#"))
+	return Pixel{X: p.X, Y: p.Y, Hex: "#" + hex}
+}
+
+// clampToDisplays returns p when a display contains it, and otherwise the
+// closest point on the nearest display.
+func clampToDisplays(p image.Point, displays []image.Rectangle) image.Point {
+	best, bestDist := p, -1
+	for _, d := range displays {
+		if d.Empty() {
+			continue
+		}
+		if p.In(d) {
+			return p
+		}
+		q := image.Pt(min(max(p.X, d.Min.X), d.Max.X-1), min(max(p.Y, d.Min.Y), d.Max.Y-1))
+		dx, dy := q.X-p.X, q.Y-p.Y
+		if dist := dx*dx + dy*dy; bestDist < 0 || dist < bestDist {
+			best, bestDist = q, dist
+		}
+	}
+	return best
+}
+
+// ----------------------------------------------------------------------------
+// SCREENSHOTS
+// ----------------------------------------------------------------------------
+
+// ErrScreenshotsDisabled is returned by Screenshot while the screenshots option is off.
+var ErrScreenshotsDisabled = errors.New("screenshots are disabled")
+
+// ErrNoSuchDisplay is returned for a display index with no display behind it.
+var ErrNoSuchDisplay = errors.New("no such display")
+
+// ScreenshotCacheTTL is how long a capture is reused, so a page polling for
+// screenshots can't make the engine capture more than twice a second.
+const ScreenshotCacheTTL = 500 * time.Millisecond
+
+// screenshotCache holds the last capture of each display.
+type screenshotCache struct {
+	img image.Image
+	at  time.Time
+}
+
+// Screenshot returns a PNG of display (an index into Screen.Displays, or -1
+// for every display at once), scaled down to width pixels wide when width is
+// positive and smaller than the capture.
+func (e *Engine) Screenshot(display, width int) ([]byte, error) {
+	if !e.Options().Screenshots {
+		return nil, ErrScreenshotsDisabled
+	}
+	img, err := e.captureDisplay(display)
+	if err != nil {
+		return nil, err
+	}
+	if width > 0 && width < img.Bounds().Dx() {
+		img = scaleToWidth(img, width)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// captureDisplay captures display, from the cache while it is fresh.
+func (e *Engine) captureDisplay(display int) (image.Image, error) {
+	e.shotMu.Lock()
+	defer e.shotMu.Unlock()
+
+	if cached, ok := e.shotCache[display]; ok && e.Now().Sub(cached.at) < ScreenshotCacheTTL {
+		return cached.img, nil
+	}
+
+	displays := e.Screen.Displays()
+	var area image.Rectangle
+	switch {
+	case display == -1:
+		for _, d := range displays {
+			area = area.Union(d)
+		}
+	case display >= 0 && display < len(displays):
+		area = displays[display]
+	default:
+		return nil, fmt.Errorf("%w: %d (have %d)", ErrNoSuchDisplay, display, len(displays))
+	}
+
+	img, err := e.Screen.Capture(area)
+	if err != nil {
+		return nil, fmt.Errorf("capturing the screen: %w", err)
+	}
+	e.shotCache[display] = screenshotCache{img: img, at: e.Now()}
+	return img, nil
+}
+
+// scaleToWidth shrinks img to width pixels wide, keeping its aspect ratio,
+// by sampling the nearest pixel.
+func scaleToWidth(img image.Image, width int) image.Image {
+	b := img.Bounds()
+	height := max(b.Dy()*width/b.Dx(), 1)
+	out := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		sy := b.Min.Y + y*b.Dy()/height
+		for x := 0; x < width; x++ {
+			out.Set(x, y, img.At(b.Min.X+x*b.Dx()/width, sy))
+		}
+	}
+	return out
+}
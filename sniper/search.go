@@ -0,0 +1,136 @@
+package sniper
+
+import (
+	"sort"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// COMMAND SEARCH
+// ----------------------------------------------------------------------------
+
+// Match scores, highest first. Exact trigger hits always outrank description hits.
+const (
+	scoreExactTrigger = 100
+	scoreExactName    = 90
+	scorePrefix       = 80
+	scoreTrigger      = 70
+	scoreName         = 60
+	scoreDescription  = 40
+	scoreFuzzy        = 20
+)
+
+// MaxSearchDistance is the largest edit distance accepted as a fuzzy search hit.
+const MaxSearchDistance = 2
+
+// Match is one search result.
+type Match struct {
+	Command CmdJSON `json:"command"`
+	Score   int     `json:"score"`
+	Field   string  `json:"field"` // "trigger", "name", "description" or "fuzzy"
+	Text    string  `json:"text"`  // The trigger/name/description that matched
+}
+
+// SearchCommands searches the built-in Registry. See SearchCommandList.
+func SearchCommands(q string) []Match {
+	return SearchCommandList(Registry, q)
+}
+
+// SearchCommands searches this engine's commands, including registered ones.
+func (e *Engine) SearchCommands(q string) []Match {
+	return SearchCommandList(e.Commands(), q)
+}
+
+// SearchCommandList does a case-insensitive substring and fuzzy search over
+// names, triggers and descriptions, returning matches best first.
+// Each command appears at most once, scored by its best field.
+func SearchCommandList(cmds []Cmd, q string) []Match {
+	q = strings.ToLower(strings.TrimSpace(q))
+	matches := make([]Match, 0)
+	if q == "" {
+		return matches
+	}
+
+	// Short queries are within a couple of edits of almost everything
+	maxDistance := MaxSearchDistance
+	switch n := len([]rune(q)); {
+	case n < 3:
+		maxDistance = 0
+	case n < 5:
+		maxDistance = 1
+	}
+
+	for _, cmd := range cmds {
+		best := Match{}
+		consider := func(score int, field, text string) {
+			if score > best.Score {
+				best = Match{Score: score, Field: field, Text: text}
+			}
+		}
+
+		name := strings.ToLower(cmd.Name())
+		switch {
+		case name == q:
+			consider(scoreExactName, "name", cmd.Name())
+		case strings.Contains(name, q):
+			consider(scoreName, "name", cmd.Name())
+		}
+
+		for _, trigger := range cmd.CalledBy() {
+			t := strings.ToLower(trigger)
+			switch {
+			case t == "":
+				continue
+			case t == q:
+				consider(scoreExactTrigger, "trigger", trigger)
+			case strings.HasPrefix(t, q):
+				consider(scorePrefix, "trigger", trigger)
+			case strings.Contains(t, q):
+				consider(scoreTrigger, "trigger", trigger)
+			case maxDistance > 0 && editDistance(t, q) <= maxDistance:
+				consider(scoreFuzzy, "fuzzy", trigger)
+			}
+		}
+
+		description := CommandDescription(cmd)
+		if strings.Contains(strings.ToLower(description), q) {
+			consider(scoreDescription, "description", description)
+		}
+
+		if best.Score > 0 {
+			best.Command = cmdToJSON(cmd)
+			matches = append(matches, best)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Command.Name < matches[j].Command.Name
+	})
+	return matches
+}
+
+// editDistance is the Levenshtein distance between a and b, counted in runes.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,94 @@
+package sniper_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/phillip-england/sniper/sniper"
+	"github.com/phillip-england/sniper/sniper/snipertest"
+)
+
+// searchCmd is a command that only exists to be searched for.
+type searchCmd struct {
+	name        string
+	triggers    []string
+	description string
+}
+
+func (c searchCmd) Name() string                          { return c.name }
+func (c searchCmd) CalledBy() []string                    { return c.triggers }
+func (c searchCmd) Description() string                   { return c.description }
+func (searchCmd) Effects() []sniper.NamedEffect           { return nil }
+func (searchCmd) Action(e *sniper.Engine, p string) error { return nil }
+
+func TestSearchRanking(t *testing.T) {
+	cmds := []sniper.Cmd{
+		searchCmd{"fuzzy", []string{"pasta"}, "Cooks dinner."},
+		searchCmd{"described", []string{"glue"}, "Puts the clipboard down, like paste."},
+		searchCmd{"contains", []string{"copy paste"}, "Copies, then puts it back."},
+		searchCmd{"prefix", []string{"pastebin"}, "Uploads the selection."},
+		searchCmd{"paste", []string{"stick"}, "Sticks things."},
+		searchCmd{"exact", []string{"paste"}, "Pastes."},
+		searchCmd{"unrelated", []string{"north"}, "Moves up."},
+	}
+
+	tests := []struct {
+		query  string
+		names  []string
+		fields []string
+	}{
+		{
+			"paste",
+			[]string{"exact", "paste", "prefix", "contains", "described", "fuzzy"},
+			[]string{"trigger", "name", "trigger", "trigger", "description", "fuzzy"},
+		},
+		{
+			// Case and surrounding space don't matter
+			"  PASTE ",
+			[]string{"exact", "paste", "prefix", "contains", "described", "fuzzy"},
+			[]string{"trigger", "name", "trigger", "trigger", "description", "fuzzy"},
+		},
+		{
+			// Equal scores go by name
+			"pa",
+			[]string{"exact", "fuzzy", "prefix", "contains", "paste", "described"},
+			[]string{"trigger", "trigger", "trigger", "trigger", "name", "description"},
+		},
+		{"north", []string{"unrelated"}, []string{"trigger"}},
+		// Short queries get no fuzzy matches, though "gu" is two edits from "glue"
+		{"gu", nil, nil},
+		{"", nil, nil},
+	}
+	for _, tt := range tests {
+		matches := sniper.SearchCommandList(cmds, tt.query)
+		var names, fields []string
+		for _, m := range matches {
+			names = append(names, m.Command.Name)
+			fields = append(fields, m.Field)
+		}
+		if !slices.Equal(names, tt.names) || !slices.Equal(fields, tt.fields) {
+			t.Errorf("search %q:\n got %q %q\nwant %q %q", tt.query, names, fields, tt.names, tt.fields)
+		}
+		for i := 1; i < len(matches); i++ {
+			if matches[i].Score > matches[i-1].Score {
+				t.Errorf("search %q: %s scored above the match before it", tt.query, matches[i].Command.Name)
+			}
+		}
+	}
+}
+
+func TestSearchFindsRegisteredCommands(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	if err := e.Register(searchCmd{"teleport", []string{"beam"}, "Moves the cursor far."}); err != nil {
+		t.Fatal(err)
+	}
+	matches := e.SearchCommands("beam")
+	if len(matches) == 0 || matches[0].Command.Name != "teleport" {
+		t.Errorf("search beam = %+v, want teleport first", matches)
+	}
+	for _, m := range sniper.SearchCommands("beam") {
+		if m.Command.Name == "teleport" {
+			t.Error("a command registered on one engine shows up in the built-in search")
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// Package sniperrpc serves the Sniper gRPC service (proto/sniper.proto) over
+// an Engine. It is a thin adapter: phrases go through the engine's job queue
+// like every other transport, the registry comes from CommandsJSON, and
+// events from Subscribe. The generated client is in proto/sniperpb.
+package sniperrpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	"github.com/phillip-england/sniper/proto/sniperpb"
+	"github.com/phillip-england/sniper/sniper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements sniperpb.SniperServer.
+type Server struct {
+	sniperpb.UnimplementedSniperServer
+	engine *sniper.Engine
+}
+
+// NewServer returns the service for engine, to register on a grpc.Server.
+func NewServer(engine *sniper.Engine) *Server {
+	return &Server{engine: engine}
+}
+
+// NewGRPCServer returns a grpc.Server with the service registered. When the
+// engine has an API token, every call but ListCommands needs it, the way the
+// HTTP API keeps the command list public.
+func NewGRPCServer(engine *sniper.Engine, opts ...grpc.ServerOption) *grpc.Server {
+	if token := engine.APIToken(); token != "" {
+		opts = append(opts,
+			grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+				if err := checkToken(ctx, info.FullMethod, token); err != nil {
+					return nil, err
+				}
+				return handler(ctx, req)
+			}),
+			grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				if err := checkToken(ss.Context(), info.FullMethod, token); err != nil {
+					return err
+				}
+				return handler(srv, ss)
+			}),
+		)
+	}
+	s := grpc.NewServer(opts...)
+	sniperpb.RegisterSniperServer(s, NewServer(engine))
+	return s
+}
+
+// checkToken requires "authorization: Bearer <token>" metadata on every
+// method but ListCommands.
+func checkToken(ctx context.Context, method, token string) error {
+	if method == sniperpb.Sniper_ListCommands_FullMethodName {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		given, ok := strings.CutPrefix(value, "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or invalid bearer token")
+}
+
+// ExecuteCommand queues the phrase and waits for it, or only parses it for a
+// dry run. Cancelling the call cancels the phrase.
+func (s *Server) ExecuteCommand(ctx context.Context, req *sniperpb.ExecuteCommandRequest) (*sniperpb.ExecutionResult, error) {
+	if req.GetDryRun() {
+		result, err := s.engine.DryRun(req.GetPhrase(), req.GetMode())
+		if err != nil {
+			return nil, requestError(err)
+		}
+		return toProtoResult(result), nil
+	}
+
+	job, err := s.engine.EnqueueContext(ctx, req.GetPhrase(), req.GetMode())
+	if err != nil {
+		return nil, requestError(err)
+	}
+	result, err := job.Wait()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, status.FromContextError(ctxErr).Err()
+	}
+	if err != nil {
+		var inputErr *sniper.InputError
+		if errors.As(err, &inputErr) || errors.Is(err, sniper.ErrEngineClosed) {
+			return nil, requestError(err)
+		}
+		// The phrase ran and failed: report how far it got
+		if result.Error == "" {
+			result.Error = err.Error()
+		}
+	}
+	return toProtoResult(result), nil
+}
+
+// requestError turns an error that kept a phrase from running into a status.
+func requestError(err error) error {
+	var inputErr *sniper.InputError
+	switch {
+	case errors.As(err, &inputErr):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, sniper.ErrQueueFull):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, sniper.ErrEngineClosed):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// ListCommands returns every registered command.
+func (s *Server) ListCommands(ctx context.Context, req *sniperpb.ListCommandsRequest) (*sniperpb.ListCommandsResponse, error) {
+	cmds := s.engine.CommandsJSON()
+	resp := &sniperpb.ListCommandsResponse{Commands: make([]*sniperpb.Command, len(cmds))}
+	for i, cmd := range cmds {
+		resp.Commands[i] = &sniperpb.Command{
+			Name:         cmd.Name,
+			CalledBy:     cmd.CalledBy,
+			Description:  cmd.Description,
+			Category:     cmd.Category,
+			Effects:      cmd.Effects,
+			ConsumesArgs: cmd.ConsumesArgs,
+			Disabled:     cmd.Disabled,
+		}
+	}
+	return resp, nil
+}
+
+// Events streams the engine's events until the client goes away.
+func (s *Server) Events(req *sniperpb.EventsRequest, stream grpc.ServerStreamingServer[sniperpb.Event]) error {
+	events, unsubscribe := s.engine.Subscribe()
+	defer unsubscribe()
+
+	// Tell the client it is subscribed
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event := <-events:
+			if err := stream.Send(&sniperpb.Event{Type: event.Type, Data: string(event.Data)}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toProtoResult(result sniper.ExecutionResult) *sniperpb.ExecutionResult {
+	out := &sniperpb.ExecutionResult{
+		HistoryId:    result.HistoryID,
+		Input:        result.Input,
+		Normalized:   result.Normalized,
+		Mode:         string(result.Mode),
+		Tokens:       make([]*sniperpb.HistoryToken, len(result.Tokens)),
+		DurationMs:   result.DurationMs,
+		Error:        result.Error,
+		Deduplicated: result.Deduplicated,
+		ModeDetected: result.ModeDetected,
+	}
+	for i, t := range result.Tokens {
+		out.Tokens[i] = &sniperpb.HistoryToken{
+			Literal:    t.Literal,
+			Type:       t.Type,
+			Outcome:    string(t.Outcome),
+			Confidence: t.Confidence,
+			Command:    t.Command,
+			DurationMs: t.DurationMs,
+			FuzzyMatch: t.FuzzyMatch,
+			Effects:    t.Effects,
+			Source:     t.Source,
+			Word:       int32(t.Word),
+			Original:   t.Original,
+			Segment:    int32(t.Segment),
+		}
+	}
+	return out
+}
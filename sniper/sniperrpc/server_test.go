@@ -0,0 +1,255 @@
+package sniperrpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phillip-england/sniper/proto/sniperpb"
+	"github.com/phillip-england/sniper/sniper"
+	"github.com/phillip-england/sniper/sniper/sniperrpc"
+	"github.com/phillip-england/sniper/sniper/snipertest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dial serves e over an in-memory connection and returns a client for it.
+func dial(t *testing.T, e *snipertest.Engine) sniperpb.SniperClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	server := sniperrpc.NewGRPCServer(e.Engine)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return sniperpb.NewSniperClient(conn)
+}
+
+var errJammed = errors.New("keyboard jammed")
+
+// jamCmd fails every time it runs.
+type jamCmd struct{}
+
+func (jamCmd) Name() string                            { return "jam" }
+func (jamCmd) CalledBy() []string                      { return []string{"jam"} }
+func (jamCmd) Effects() []sniper.NamedEffect           { return nil }
+func (jamCmd) Action(e *sniper.Engine, p string) error { return errJammed }
+
+func TestExecuteCommand(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	client := dial(t, e)
+
+	result, err := client.ExecuteCommand(context.Background(), &sniperpb.ExecuteCommandRequest{Phrase: "south east", Mode: "phrase"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := e.Input.Keys(); !slices.Equal(got, []string{"down", "right"}) {
+		t.Errorf("tapped %q, want down and right", got)
+	}
+	if result.GetMode() != string(sniper.ModePhrase) || result.GetHistoryId() == 0 || result.GetError() != "" {
+		t.Errorf("result = %v", result)
+	}
+	var commands []string
+	for _, tok := range result.GetTokens() {
+		if tok.GetOutcome() != string(sniper.OutcomeHandled) {
+			t.Errorf("token %q: outcome %s", tok.GetLiteral(), tok.GetOutcome())
+		}
+		commands = append(commands, tok.GetCommand())
+	}
+	if !slices.Equal(commands, []string{"south", "east"}) {
+		t.Errorf("commands = %q", commands)
+	}
+}
+
+func TestExecuteCommandDryRun(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	client := dial(t, e)
+
+	result, err := client.ExecuteCommand(context.Background(), &sniperpb.ExecuteCommandRequest{Phrase: "south 3 then say hi", DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ops := e.Input.Ops(); len(ops) > 0 {
+		t.Errorf("a dry run sent %q", ops)
+	}
+	if n := len(e.History.Recent(1)); n != 0 {
+		t.Error("a dry run was recorded in the history")
+	}
+	var literals []string
+	for _, tok := range result.GetTokens() {
+		literals = append(literals, tok.GetLiteral())
+		if tok.GetOutcome() != string(sniper.OutcomeNotRun) {
+			t.Errorf("token %q: outcome %s in a dry run", tok.GetLiteral(), tok.GetOutcome())
+		}
+	}
+	if !slices.Equal(literals, []string{"south", "3", "then", "say", "hi"}) {
+		t.Errorf("tokens = %q", literals)
+	}
+	if got := result.GetTokens()[3].GetSegment(); got != 1 {
+		t.Errorf("say is in segment %d, want 1", got)
+	}
+
+	// A dry run doesn't become something a number can repeat
+	e.MustRun(t, "east")
+	if _, err := client.ExecuteCommand(context.Background(), &sniperpb.ExecuteCommandRequest{Phrase: "west", DryRun: true}); err != nil {
+		t.Fatal(err)
+	}
+	snipertest.ExpectKeys(t, e, "2", "right", "right")
+
+	_, err = client.ExecuteCommand(context.Background(), &sniperpb.ExecuteCommandRequest{Phrase: "south 5000", DryRun: true})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("dry run of south 5000: err = %v, want InvalidArgument", err)
+	}
+}
+
+func TestExecuteCommandErrors(t *testing.T) {
+	e := snipertest.NewTestEngine(t, sniper.WithCommands(jamCmd{}))
+	client := dial(t, e)
+
+	// A phrase that fails while running reports how far it got
+	result, err := client.ExecuteCommand(context.Background(), &sniperpb.ExecuteCommandRequest{Phrase: "south jam east"})
+	if err != nil {
+		t.Fatalf("a failed phrase was a call error: %v", err)
+	}
+	if !strings.Contains(result.GetError(), errJammed.Error()) {
+		t.Errorf("error = %q, want the command's error", result.GetError())
+	}
+	if got := e.Input.Keys(); !slices.Equal(got, []string{"down"}) {
+		t.Errorf("tapped %q, want only the token before the failure", got)
+	}
+
+	// A phrase that breaks an input limit never runs
+	e.Input.Reset()
+	for _, phrase := range []string{"south 5000", strings.Repeat("south ", 300)} {
+		_, err := client.ExecuteCommand(context.Background(), &sniperpb.ExecuteCommandRequest{Phrase: phrase})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%.20q: err = %v, want InvalidArgument", phrase, err)
+		}
+	}
+	if ops := e.Input.Ops(); len(ops) > 0 {
+		t.Errorf("rejected phrases sent %q", ops)
+	}
+}
+
+func TestListCommands(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	if err := e.Disable("page_up"); err != nil {
+		t.Fatal(err)
+	}
+	client := dial(t, e)
+
+	resp, err := client.ListCommands(context.Background(), &sniperpb.ListCommandsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(resp.GetCommands()), len(e.Commands()); got != want {
+		t.Errorf("listed %d commands, want %d", got, want)
+	}
+	for _, cmd := range resp.GetCommands() {
+		if cmd.GetDisabled() != (cmd.GetName() == "page_up") {
+			t.Errorf("%s: disabled = %v", cmd.GetName(), cmd.GetDisabled())
+		}
+		if cmd.GetName() == "south" && !slices.Contains(cmd.GetCalledBy(), "south") {
+			t.Errorf("south is called by %q", cmd.GetCalledBy())
+		}
+	}
+}
+
+func TestEvents(t *testing.T) {
+	e := snipertest.NewTestEngine(t, sniper.WithCommands(jamCmd{}))
+	client := dial(t, e)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.Events(ctx, &sniperpb.EventsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The headers arrive once the server has subscribed
+	if _, err := stream.Header(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, phrase := range []string{"east", "mode vim", "jam"} {
+		if _, err := client.ExecuteCommand(ctx, &sniperpb.ExecuteCommandRequest{Phrase: phrase}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []struct {
+		eventType string
+		field     string
+		value     string
+	}{
+		{sniper.EventPhrase, "input", "east"},
+		{sniper.EventMode, "mode", "vim"},
+		{sniper.EventPhrase, "input", "mode vim"},
+		{sniper.EventPhrase, "error", "token 0 'jam' (jam): keyboard jammed"},
+	}
+	for _, w := range want {
+		event, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var data map[string]any
+		if err := json.Unmarshal([]byte(event.GetData()), &data); err != nil {
+			t.Fatalf("%s event data %q: %v", event.GetType(), event.GetData(), err)
+		}
+		if event.GetType() != w.eventType || data[w.field] != w.value {
+			t.Errorf("event = %s %s=%v, want %s %s=%q", event.GetType(), w.field, data[w.field], w.eventType, w.field, w.value)
+		}
+	}
+}
+
+func TestToken(t *testing.T) {
+	t.Setenv(sniper.APITokenEnv, "s3cret")
+	e := snipertest.NewTestEngine(t)
+	client := dial(t, e)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	authed := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer s3cret")
+	wrong := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer nope")
+
+	for name, ctx := range map[string]context.Context{"no token": ctx, "wrong token": wrong} {
+		_, err := client.ExecuteCommand(ctx, &sniperpb.ExecuteCommandRequest{Phrase: "east"})
+		if status.Code(err) != codes.Unauthenticated {
+			t.Errorf("%s: ExecuteCommand err = %v, want Unauthenticated", name, err)
+		}
+		stream, err := client.Events(ctx, &sniperpb.EventsRequest{})
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		if status.Code(err) != codes.Unauthenticated {
+			t.Errorf("%s: Events err = %v, want Unauthenticated", name, err)
+		}
+	}
+	if ops := e.Input.Ops(); len(ops) > 0 {
+		t.Errorf("unauthenticated calls sent %q", ops)
+	}
+
+	if _, err := client.ExecuteCommand(authed, &sniperpb.ExecuteCommandRequest{Phrase: "east"}); err != nil {
+		t.Errorf("ExecuteCommand with the token: %v", err)
+	}
+	// The command list is public, as over HTTP
+	if _, err := client.ListCommands(ctx, &sniperpb.ListCommandsRequest{}); err != nil {
+		t.Errorf("ListCommands without the token: %v", err)
+	}
+}
@@ -0,0 +1,289 @@
+package snipertest
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/phillip-england/sniper/sniper"
+)
+
+// ----------------------------------------------------------------------------
+// RECORDING DRIVERS
+// ----------------------------------------------------------------------------
+//
+// Fakes for everything the engine would otherwise ask the machine for. None
+// of them touch the real keyboard, mouse, screen or window manager.
+
+// Input is a sniper.InputBackend that records every call as one line
+// ("tap ctrl+c", "type hello", "move 10 20") and keeps a pretend cursor.
+type Input struct {
+	mu     sync.Mutex
+	ops    []string
+	x, y   int
+	width  int
+	height int
+}
+
+// NewInput returns an Input with the cursor at the origin of a 1920x1080 screen.
+func NewInput() *Input {
+	return &Input{width: 1920, height: 1080}
+}
+
+func (in *Input) record(format string, args ...any) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.ops = append(in.ops, fmt.Sprintf(format, args...))
+}
+
+// Ops returns every recorded call, oldest first.
+func (in *Input) Ops() []string {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return append([]string(nil), in.ops...)
+}
+
+// Reset forgets the recorded calls. The cursor stays where it is.
+func (in *Input) Reset() {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.ops = nil
+}
+
+// Keys returns the recorded key taps as chords, modifiers first ("ctrl+c").
+func (in *Input) Keys() []string {
+	return in.filter("tap ")
+}
+
+// Typed returns the text sent with TypeStr, joined.
+func (in *Input) Typed() string {
+	return strings.Join(in.filter("type "), "")
+}
+
+// Mouse returns the recorded moves, clicks and scrolls.
+func (in *Input) Mouse() []string {
+	var out []string
+	for _, op := range in.Ops() {
+		switch strings.SplitN(op, " ", 2)[0] {
+		case "move", "click", "mouseup", "scroll":
+			out = append(out, op)
+		}
+	}
+	return out
+}
+
+// filter returns the ops starting with prefix, with the prefix cut off.
+func (in *Input) filter(prefix string) []string {
+	var out []string
+	for _, op := range in.Ops() {
+		if rest, ok := strings.CutPrefix(op, prefix); ok {
+			out = append(out, rest)
+		}
+	}
+	return out
+}
+
+// SetCursor moves the pretend cursor without recording a move.
+func (in *Input) SetCursor(x, y int) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.x, in.y = x, y
+}
+
+// SetScreenSize changes the size ScreenSize reports.
+func (in *Input) SetScreenSize(width, height int) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.width, in.height = width, height
+}
+
+func (in *Input) Name() string { return "snipertest" }
+
+func (in *Input) KeyTap(key string, modifiers ...string) error {
+	in.record("tap %s", strings.Join(append(append([]string(nil), modifiers...), key), "+"))
+	return nil
+}
+
+func (in *Input) KeyDown(key string) error {
+	in.record("down %s", key)
+	return nil
+}
+
+func (in *Input) KeyUp(key string) error {
+	in.record("up %s", key)
+	return nil
+}
+
+func (in *Input) TypeStr(text string) error {
+	in.record("type %s", text)
+	return nil
+}
+
+func (in *Input) Move(x, y int) error {
+	in.mu.Lock()
+	in.x, in.y = x, y
+	in.mu.Unlock()
+	in.record("move %d %d", x, y)
+	return nil
+}
+
+func (in *Input) Location() (int, int) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.x, in.y
+}
+
+func (in *Input) ScreenSize() (int, int) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.width, in.height
+}
+
+func (in *Input) Click(button string) error {
+	in.record("click %s", button)
+	return nil
+}
+
+func (in *Input) MouseUp(button string) error {
+	in.record("mouseup %s", button)
+	return nil
+}
+
+func (in *Input) Scroll(x, y int) error {
+	in.record("scroll %d %d", x, y)
+	return nil
+}
+
+// Screen is a sniper.Screen showing Image, one display covering its bounds.
+type Screen struct {
+	Image *image.RGBA
+}
+
+// NewScreen returns a width by height screen filled with c.
+func NewScreen(width, height int, c color.Color) *Screen {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return &Screen{Image: img}
+}
+
+func (s *Screen) Displays() []image.Rectangle {
+	return []image.Rectangle{s.Image.Bounds()}
+}
+
+func (s *Screen) PixelColor(x, y int) string {
+	c := s.Image.RGBAAt(x, y)
+	return fmt.Sprintf("%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func (s *Screen) Capture(r image.Rectangle) (image.Image, error) {
+	if !r.In(s.Image.Bounds()) {
+		return nil, errors.New("capture outside the screen")
+	}
+	return s.Image.SubImage(r), nil
+}
+
+// Window is a sniper.WindowProvider reporting the window last given to
+// Focus. Until then no window can be read.
+type Window struct {
+	mu   sync.Mutex
+	info sniper.WindowInfo
+	err  error
+}
+
+// Focus makes info the focused window.
+func (w *Window) Focus(info sniper.WindowInfo) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.info, w.err = info, nil
+}
+
+// Fail makes the focused window unreadable, as under Wayland.
+func (w *Window) Fail() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.info, w.err = sniper.WindowInfo{}, sniper.ErrNoActiveWindow
+}
+
+func (w *Window) ActiveWindow() (sniper.WindowInfo, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return sniper.WindowInfo{}, w.err
+	}
+	if w.info.Title == "" && w.info.Process == "" {
+		return sniper.WindowInfo{}, sniper.ErrNoActiveWindow
+	}
+	return w.info, nil
+}
+
+// Feedback is a sniper.FeedbackProvider that records every outcome as one
+// line: "ok <input>" after a phrase runs, "error <message>" after one fails.
+// Feedback arrives asynchronously, so read it with Wait.
+type Feedback struct {
+	mu     sync.Mutex
+	events []string
+	notify chan struct{}
+}
+
+// NewFeedback returns an empty Feedback.
+func NewFeedback() *Feedback {
+	return &Feedback{notify: make(chan struct{}, 1)}
+}
+
+func (f *Feedback) record(event string) {
+	f.mu.Lock()
+	f.events = append(f.events, event)
+	f.mu.Unlock()
+	select {
+	case f.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Events returns the outcomes recorded so far, oldest first.
+func (f *Feedback) Events() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.events...)
+}
+
+// Wait returns the recorded outcomes once there are at least n, or whatever
+// there is after timeout.
+func (f *Feedback) Wait(n int, timeout time.Duration) []string {
+	deadline := time.After(timeout)
+	for {
+		if events := f.Events(); len(events) >= n {
+			return events
+		}
+		select {
+		case <-f.notify:
+		case <-deadline:
+			return f.Events()
+		}
+	}
+}
+
+// Reset forgets the recorded outcomes.
+func (f *Feedback) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = nil
+}
+
+func (f *Feedback) Name() string { return "snipertest" }
+
+func (f *Feedback) OnSuccess(result sniper.ExecutionResult) {
+	f.record("ok " + result.Input)
+}
+
+func (f *Feedback) OnError(err error) {
+	f.record("error " + err.Error())
+}
@@ -0,0 +1,178 @@
+// Package snipertest runs phrases through a real sniper.Engine wired to
+// recording drivers, so custom commands can be tested without a display.
+//
+//	func TestCopy(t *testing.T) {
+//		e := snipertest.NewTestEngine(t, sniper.WithCommands(MyCmd{}))
+//		snipertest.ExpectKeys(t, e, "copy", "ctrl+c")
+//	}
+//
+// Key names are robotgo's and follow the platform, so "copy" is "cmd+c" on
+// macOS.
+package snipertest
+
+import (
+	"flag"
+	"image/color"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/phillip-england/sniper/sniper"
+)
+
+// update rewrites golden files instead of comparing against them:
+// go test ./... -snipertest.update
+var update = flag.Bool("snipertest.update", false, "rewrite snipertest golden files")
+
+// Engine is a sniper.Engine plus the fakes it drives.
+type Engine struct {
+	*sniper.Engine
+
+	Input     *Input
+	Clipboard *sniper.MemoryClipboard
+	Screen    *Screen
+	Window    *Window
+	Feedback  *Feedback
+}
+
+// NewTestEngine builds an engine that reads and writes nothing outside
+// t.TempDir(): recording keyboard and mouse input and feedback, an in-memory
+// clipboard, a plain white screen, no focused window, empty spot, macro and
+// icon memories and an empty config file. Pacing delays and the debounce are off
+// so the same phrase can run back to back. options are applied after these,
+// and the engine is closed when the test ends.
+func NewTestEngine(t testing.TB, options ...sniper.EngineOption) *Engine {
+	t.Helper()
+	dir := t.TempDir()
+
+	te := &Engine{
+		Input:     NewInput(),
+		Clipboard: sniper.NewMemoryClipboard(),
+		Screen:    NewScreen(64, 64, color.White),
+		Window:    &Window{},
+		Feedback:  NewFeedback(),
+	}
+
+	opts := sniper.DefaultEngineOptions()
+	opts.Debounce = false
+	opts.TokenDelayUs = 0
+	opts.ThenDelayMs = 0
+	opts.PostReleaseDelayMs = 0
+	opts.MouseDelayMs = 0
+	opts.ClickGapMs = 0
+	opts.ClipboardDelayMs = 0
+
+	memory := &sniper.MouseMemory{
+		Spots:    make(map[string]sniper.MouseSpot),
+		FilePath: filepath.Join(dir, "spots.json"),
+	}
+	base := []sniper.EngineOption{
+		sniper.WithConfigPath(filepath.Join(dir, "sniper.json")),
+		sniper.WithOptions(opts),
+		sniper.WithKeyboard(sniper.NewStickyKeyboard()),
+		sniper.WithMouse(&sniper.Mouse{Jump: 1}),
+		sniper.WithMemory(memory),
+		sniper.WithInputBackend(te.Input),
+		sniper.WithScreen(te.Screen),
+		sniper.WithWindowProvider(te.Window),
+		sniper.WithFeedback(te.Feedback),
+	}
+
+	e, err := sniper.New(append(base, options...)...)
+	if err != nil {
+		t.Fatalf("snipertest: building engine: %v", err)
+	}
+	t.Cleanup(e.Close)
+
+	e.Clipboard = te.Clipboard
+	e.Macros.FilePath = filepath.Join(dir, "macros.json")
+	e.Macros.Macros = make(map[string]sniper.Macro)
+	e.Icons.FilePath = filepath.Join(dir, "icons.json")
+	e.Icons.Dir = filepath.Join(dir, "icons")
+	e.Icons.Icons = make(map[string]string)
+
+	te.Engine = e
+	return te
+}
+
+// Run parses phrase in phrase mode and executes it, after forgetting the
+// input recorded so far.
+func (e *Engine) Run(phrase string) (sniper.ExecutionResult, error) {
+	e.Input.Reset()
+	e.Parse(phrase, "phrase")
+	return e.Execute()
+}
+
+// MustRun is Run that fails the test when the phrase returns an error.
+func (e *Engine) MustRun(t testing.TB, phrase string) sniper.ExecutionResult {
+	t.Helper()
+	result, err := e.Run(phrase)
+	if err != nil {
+		t.Fatalf("%q: %v", phrase, err)
+	}
+	return result
+}
+
+// ExpectKeys runs phrase and checks it tapped exactly the chords want, in
+// order ("ctrl+c", "enter").
+func ExpectKeys(t testing.TB, e *Engine, phrase string, want ...string) {
+	t.Helper()
+	e.MustRun(t, phrase)
+	if got := e.Input.Keys(); !slices.Equal(got, want) {
+		t.Errorf("%q tapped %q, want %q", phrase, got, want)
+	}
+}
+
+// ExpectTyped runs phrase and checks the text it typed with TypeStr.
+func ExpectTyped(t testing.TB, e *Engine, phrase, want string) {
+	t.Helper()
+	e.MustRun(t, phrase)
+	if got := e.Input.Typed(); got != want {
+		t.Errorf("%q typed %q, want %q", phrase, got, want)
+	}
+}
+
+// ExpectMouse runs phrase and checks its moves, clicks and scrolls, written
+// as Input records them ("move 10 20", "click left", "scroll 0 -1").
+func ExpectMouse(t testing.TB, e *Engine, phrase string, want ...string) {
+	t.Helper()
+	e.MustRun(t, phrase)
+	if got := e.Input.Mouse(); !slices.Equal(got, want) {
+		t.Errorf("%q did %q to the mouse, want %q", phrase, got, want)
+	}
+}
+
+// ExpectGolden runs phrase and compares everything it sent to the input
+// driver, one call per line, with testdata/<name>.golden. Run the tests with
+// -snipertest.update to write the file.
+func ExpectGolden(t testing.TB, e *Engine, phrase, name string) {
+	t.Helper()
+	e.MustRun(t, phrase)
+	ExpectGoldenText(t, name, strings.Join(e.Input.Ops(), "\n")+"\n")
+}
+
+// ExpectGoldenText compares got with testdata/<name>.golden, writing the
+// file instead when the tests run with -snipertest.update.
+func ExpectGoldenText(t testing.TB, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -snipertest.update to create it): %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs from %s\ngot:\n%swant:\n%s", name, path, got, want)
+	}
+}
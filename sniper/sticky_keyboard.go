@@ -1,15 +1,15 @@
 package sniper
 
 import (
-	"fmt"
+	"log/slog"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 	"unicode"
-
-	"github.com/go-vgo/robotgo"
+	"unicode/utf8"
 )
 
 // StickyKeyboard represents a keyboard that remembers modifier keys
@@ -18,14 +18,74 @@ type StickyKeyboard struct {
 	// pendingModifiers holds keys like "shift", "command" waiting for the next keystroke
 	pendingModifiers []string
 
-	// mu protects the pendingModifiers slice for thread safety
+	// held holds modifiers pressed down by HoldModifier, which stay down across
+	// taps (and phrases) until ReleaseModifier or ReleaseAll
+	held []string
+
+	// layout remaps characters for non-US keyboards (nil means QWERTY)
+	layout *Layout
+
+	// recorder collects the taps and text of the running phrase
+	recorder *ActionRecorder
+
+	// backend performs the taps and text (nil means robotgo)
+	backend InputBackend
+
+	// compat forces one tap per character, for apps that drop fast synthetic input
+	compat bool
+
+	// verbatimCase keeps the casing words were heard in when formatting
+	// camel, pascal and snake case
+	verbatimCase bool
+
+	// output logs what was typed, oldest first, for GET /api/typed. phraseID
+	// tags new entries; outputOff disables the log.
+	output    []TypedOutput
+	phraseID  uint64
+	outputOff bool
+
+	// acronyms are written in capitals by CamelCase and PascalCase
+	// (nil means DefaultAcronyms)
+	acronyms map[string]bool
+
+	// lastChar is the last non-space character typed, 0 before anything was
+	lastChar rune
+
+	// modifiersSince is when a modifier was last queued, held or consumed by a
+	// tap, for ReleaseStale
+	modifiersSince time.Time
+
+	// mu protects the pendingModifiers and held slices, and layout, for thread safety
 	mu sync.Mutex
 
 	// PostReleaseDelay is the time to sleep after keys are released
 	// to ensure the OS registers the state change.
 	PostReleaseDelay time.Duration
+
+	// TypingDelay is an extra pause after every tap, for apps and remote
+	// sessions that drop keys arriving too fast. Text isn't batched while it's set.
+	TypingDelay time.Duration
+
+	// typedCount is how many printable characters the current phrase has emitted.
+	// journal stacks the counts of earlier phrases (newest last) for "scratch that".
+	typedCount int
+	journal    []int
+
+	// Logger receives a debug line per key tap. Nil means slog.Default().
+	Logger *slog.Logger
+
+	// cancelled reports whether the running phrase was cancelled, so long
+	// strings stop typing part way through. Set by the Engine.
+	cancelled func() bool
 }
 
+// typeChunk is how many characters TypeStr hands the OS at once, so a
+// cancelled phrase stops long dictation part way through.
+const typeChunk = 32
+
+// MaxJournalDepth bounds how many phrases "scratch" can walk back through.
+const MaxJournalDepth = 20
+
 // NewStickyKeyboard initializes the keyboard structure.
 func NewStickyKeyboard() *StickyKeyboard {
 	return &StickyKeyboard{
@@ -34,6 +94,28 @@ func NewStickyKeyboard() *StickyKeyboard {
 	}
 }
 
+// SetBackend makes the keyboard type through b. Nil restores robotgo.
+func (k *StickyKeyboard) SetBackend(b InputBackend) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.backend = b
+}
+
+// input returns the backend taps and text go through.
+func (k *StickyKeyboard) input() InputBackend {
+	if k.backend == nil {
+		return RobotgoBackend{}
+	}
+	return k.backend
+}
+
+// check logs a backend failure; a dropped key shouldn't stop the phrase.
+func (k *StickyKeyboard) check(op string, err error) {
+	if err != nil {
+		k.log().Warn("keyboard backend failed", "component", "keyboard", "op", op, "backend", k.input().Name(), "error", err)
+	}
+}
+
 // ----------------------------------------------------------------------------
 // INTERNAL LOGIC
 // ----------------------------------------------------------------------------
@@ -44,23 +126,7 @@ func (k *StickyKeyboard) queueModifier(key string) {
 	k.mu.Lock()
 	defer k.mu.Unlock()
 
-	// Normalize modifiers based on OS
-	normalizedKey := key
-	if runtime.GOOS == "darwin" {
-		switch key {
-		case "command":
-			normalizedKey = "cmd"
-		case "option":
-			normalizedKey = "lalt" // left alt usually maps to option
-		case "control":
-			normalizedKey = "lctrl"
-		}
-	} else {
-		// Windows/Linux mapping
-		if key == "command" {
-			normalizedKey = "control" // standard mapping for windows users using mac terms
-		}
-	}
+	normalizedKey := normalizeModifier(runtime.GOOS, key)
 
 	// Prevent duplicates
 	for _, m := range k.pendingModifiers {
@@ -70,39 +136,395 @@ func (k *StickyKeyboard) queueModifier(key string) {
 	}
 
 	k.pendingModifiers = append(k.pendingModifiers, normalizedKey)
-	fmt.Printf("[Keyboard] Modifier Queued: %s\n", normalizedKey)
+	k.modifiersSince = time.Now()
+	k.log().Debug("modifier queued", "component", "keyboard", "modifier", normalizedKey)
 }
 
-// executeTap performs the actual robotgo action.
+// modifierKeys maps every spoken or written modifier name to the key robotgo
+// expects, per GOOS. "default" covers Windows and Linux.
+var modifierKeys = map[string]map[string]string{
+	"darwin": {
+		"shift":   "shift",
+		"ctrl":    "lctrl",
+		"control": "lctrl",
+		"alt":     "lalt", // left alt usually maps to option
+		"option":  "lalt",
+		"cmd":     "cmd",
+		"command": "cmd",
+		"super":   "cmd",
+		"win":     "cmd",
+		"windows": "cmd",
+		"meta":    "cmd",
+	},
+	"default": {
+		"shift":   "shift",
+		"ctrl":    "ctrl",
+		"control": "ctrl",
+		"alt":     "alt",
+		"option":  "alt",
+		"cmd":     "ctrl", // standard mapping for windows users using mac terms
+		"command": "ctrl",
+		"super":   "cmd", // robotgo's "cmd" is the Windows/Super key off macOS
+		"win":     "cmd",
+		"windows": "cmd",
+		"meta":    "cmd",
+	},
+}
+
+// normalizeModifier maps a modifier name to the key robotgo expects on goos.
+// Names it doesn't know are passed through lower-cased.
+func normalizeModifier(goos, name string) string {
+	name = strings.ToLower(name)
+	table, ok := modifierKeys[goos]
+	if !ok {
+		table = modifierKeys["default"]
+	}
+	if key, ok := table[name]; ok {
+		return key
+	}
+	return name
+}
+
+// executeTap performs the actual robotgo action. A backend failure is logged
+// rather than returned, like every other single key.
 func (k *StickyKeyboard) executeTap(key string) {
+	k.check("tap", k.tapKey(key))
+}
+
+// tapKey taps key with the queued modifiers and reports a backend failure.
+func (k *StickyKeyboard) tapKey(key string) error {
 	k.mu.Lock()
 	defer k.mu.Unlock()
 
-	// Convert string slice to interface slice for robotgo
-	args := make([]interface{}, len(k.pendingModifiers))
-	for i, v := range k.pendingModifiers {
-		args[i] = v
+	// Only plain characters count as typed output; shortcuts and navigation don't
+	typed := len(k.pendingModifiers) == 0 && isPrintableKey(key)
+	if typed && key != "space" {
+		k.noteTyped(key)
 	}
+	k.logOutput(TypedOutput{Key: key, Modifiers: slices.Clone(k.pendingModifiers)})
 
-	if len(args) > 0 {
-		fmt.Printf("[Keyboard] Tapping '%s' with modifiers: %v\n", key, args)
-	} else {
-		fmt.Printf("[Keyboard] Tapping '%s'\n", key)
+	// Characters go through the layout; shortcuts keep their physical key
+	modifiers := k.pendingModifiers
+	if len(modifiers) == 0 {
+		if stroke, ok := k.layout.Stroke(key); ok {
+			key, modifiers = stroke.Key, stroke.Modifiers
+		}
 	}
 
-	// RobotGo KeyTap holds the modifiers (args) and taps the key.
-	robotgo.KeyTap(key, args...)
+	// KeyTap holds the modifiers and taps the key.
+	err := k.input().KeyTap(key, modifiers...)
+	k.recorder.Record(RecordedAction{Kind: ActionKeyTap, Key: key, Modifiers: slices.Clone(modifiers)})
 
-	// EXPLICIT SAFETY RELEASE
-	for _, mod := range k.pendingModifiers {
-		robotgo.KeyUp(mod)
+	// EXPLICIT SAFETY RELEASE (held modifiers stay down). Plain taps skip it
+	for _, mod := range modifiers {
+		if !slices.Contains(k.held, mod) {
+			k.input().KeyUp(mod)
+		}
 	}
 
+	if typed {
+		k.typedCount++
+	}
+	k.modifiersSince = time.Now()
+
 	// Clear memory immediately after execution
 	k.pendingModifiers = []string{}
 
-	// Ensure OS registers the release
+	// Ensure OS registers the release. A plain tap released nothing, so only
+	// compat typing (for apps that drop fast input) still waits for it
+	delay := k.TypingDelay
+	if len(modifiers) > 0 || k.compat {
+		delay += k.PostReleaseDelay
+	}
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+
+	k.log().Debug("key tap", "component", "keyboard", "key", key, "modifiers", modifiers)
+	return err
+}
+
+// allModifierKeys is every modifier key name robotgo knows.
+var allModifierKeys = []string{
+	"shift", "lshift", "rshift",
+	"ctrl", "lctrl", "rctrl", "control",
+	"alt", "lalt", "ralt",
+	"cmd", "lcmd", "rcmd", "command",
+}
+
+// ReleaseAll lets go of every modifier, queued or physically held, so a
+// cancelled phrase can't leave one stuck down.
+func (k *StickyKeyboard) ReleaseAll() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.releaseAll()
+}
+
+func (k *StickyKeyboard) releaseAll() {
+	for _, mod := range k.pendingModifiers {
+		k.input().KeyUp(mod)
+	}
+	for _, mod := range k.held {
+		k.input().KeyUp(mod)
+	}
+	for _, mod := range allModifierKeys {
+		k.input().KeyUp(mod)
+	}
+	k.pendingModifiers = []string{}
+	k.held = nil
+	k.recorder.Record(RecordedAction{Kind: ActionReleaseAll})
+}
+
+// ReleaseStale releases every modifier when one has been queued or held for
+// longer than maxAge without a tap using it, and reports whether it did.
+func (k *StickyKeyboard) ReleaseStale(maxAge time.Duration) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if len(k.pendingModifiers) == 0 && len(k.held) == 0 {
+		return false
+	}
+	if time.Since(k.modifiersSince) < maxAge {
+		return false
+	}
+	k.log().Warn("releasing stuck modifiers", "component", "keyboard", "queued", k.pendingModifiers, "held", k.held)
+	k.releaseAll()
+	return true
+}
+
+// HoldModifier presses a modifier ("shift", "control", "alt", "option",
+// "command") and keeps it down across taps until ReleaseModifier or
+// ReleaseAll, e.g. to extend a selection with several arrow presses.
+func (k *StickyKeyboard) HoldModifier(name string) {
+	k.HoldKey(normalizeModifier(runtime.GOOS, name))
+}
+
+// ReleaseModifier lets go of a modifier pressed by HoldModifier.
+func (k *StickyKeyboard) ReleaseModifier(name string) {
+	k.ReleaseKey(normalizeModifier(runtime.GOOS, name))
+}
+
+// HoldKey presses any key by its robotgo name ("left", "w", "space") and
+// keeps it down until ReleaseKey or ReleaseAll.
+func (k *StickyKeyboard) HoldKey(key string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if slices.Contains(k.held, key) {
+		return
+	}
+	k.check("key down", k.input().KeyDown(key))
+	k.recorder.Record(RecordedAction{Kind: ActionKeyDown, Key: key})
+	k.held = append(k.held, key)
+	k.modifiersSince = time.Now()
+	k.log().Debug("key held", "component", "keyboard", "key", key)
+}
+
+// ReleaseKey lets go of a key pressed by HoldKey or HoldModifier.
+func (k *StickyKeyboard) ReleaseKey(key string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	i := slices.Index(k.held, key)
+	if i < 0 {
+		return
+	}
+	k.input().KeyUp(key)
+	k.recorder.Record(RecordedAction{Kind: ActionKeyUp, Key: key})
+	k.held = slices.Delete(k.held, i, i+1)
 	time.Sleep(k.PostReleaseDelay)
+	k.log().Debug("key released", "component", "keyboard", "key", key)
+}
+
+// ReleaseHeld lets go of every key pressed by HoldModifier or HoldKey.
+func (k *StickyKeyboard) ReleaseHeld() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for _, mod := range k.held {
+		k.input().KeyUp(mod)
+		k.recorder.Record(RecordedAction{Kind: ActionKeyUp, Key: mod})
+	}
+	k.held = nil
+	time.Sleep(k.PostReleaseDelay)
+}
+
+// Held returns the keys currently held down by HoldModifier or HoldKey.
+func (k *StickyKeyboard) Held() []string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return slices.Clone(k.held)
+}
+
+// SetRecorder makes the keyboard report its actions to r. Nil stops reporting.
+func (k *StickyKeyboard) SetRecorder(r *ActionRecorder) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.recorder = r
+}
+
+// SetCompat turns compat typing on or off. With it on, TypeStr taps every
+// character instead of typing plain text in one go.
+func (k *StickyKeyboard) SetCompat(compat bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.compat = compat
+}
+
+// SetVerbatimCase makes CamelCase, PascalCase and SnakeCase keep the casing
+// of the words they are given instead of lowercasing them first.
+func (k *StickyKeyboard) SetVerbatimCase(verbatim bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.verbatimCase = verbatim
+}
+
+// foldCase lowercases phrase unless verbatim case is on.
+func (k *StickyKeyboard) foldCase(phrase string) string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.verbatimCase {
+		return phrase
+	}
+	return strings.ToLower(phrase)
+}
+
+// SetDelays changes TypingDelay and PostReleaseDelay.
+func (k *StickyKeyboard) SetDelays(typing, postRelease time.Duration) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.TypingDelay = typing
+	k.PostReleaseDelay = postRelease
+}
+
+// SetLayout switches the keyboard layout characters are typed for.
+func (k *StickyKeyboard) SetLayout(layout *Layout) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.layout = layout
+}
+
+// isCancelled reports whether the running phrase was cancelled.
+func (k *StickyKeyboard) isCancelled() bool {
+	return k.cancelled != nil && k.cancelled()
+}
+
+func (k *StickyKeyboard) log() *slog.Logger {
+	if k.Logger != nil {
+		return k.Logger
+	}
+	return slog.Default()
+}
+
+// isPrintableKey reports whether tapping key emits a visible character.
+func isPrintableKey(key string) bool {
+	if key == "space" {
+		return true
+	}
+	runes := []rune(key)
+	return len(runes) == 1 && unicode.IsPrint(runes[0])
+}
+
+// ----------------------------------------------------------------------------
+// TYPED OUTPUT JOURNAL
+// ----------------------------------------------------------------------------
+
+// BeginPhrase closes out the previous phrase's typed-character count, pushing it
+// onto the journal (when it typed anything) and starting a fresh count. Output
+// from here on is logged under phraseID.
+func (k *StickyKeyboard) BeginPhrase(phraseID uint64) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.phraseID = phraseID
+
+	if k.typedCount > 0 {
+		k.journal = append(k.journal, k.typedCount)
+		if len(k.journal) > MaxJournalDepth {
+			k.journal = k.journal[1:]
+		}
+	}
+	k.typedCount = 0
+}
+
+// PopJournal removes and returns the character count of the most recent phrase
+// that typed something. It returns 0 when the journal is empty.
+func (k *StickyKeyboard) PopJournal() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if len(k.journal) == 0 {
+		return 0
+	}
+	n := k.journal[len(k.journal)-1]
+	k.journal = k.journal[:len(k.journal)-1]
+	return n
+}
+
+// noteTyped remembers the last non-space character of text. mu must be held.
+func (k *StickyKeyboard) noteTyped(text string) {
+	if i := strings.LastIndexFunc(text, func(r rune) bool { return !unicode.IsSpace(r) }); i >= 0 {
+		k.lastChar, _ = utf8.DecodeRuneInString(text[i:])
+	}
+}
+
+// AtSentenceStart reports whether the next dictated word starts a sentence:
+// nothing has been typed yet, or the last character typed ended one.
+func (k *StickyKeyboard) AtSentenceStart() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.lastChar == 0 || strings.ContainsRune(".!?", k.lastChar)
+}
+
+// MaxTypedOutput bounds how many taps and text batches the output log keeps.
+const MaxTypedOutput = 500
+
+// TypedOutput is one key tap or batch of text the keyboard emitted.
+type TypedOutput struct {
+	Key       string    `json:"key,omitempty"`       // a tap, e.g. "a" or "enter"
+	Modifiers []string  `json:"modifiers,omitempty"` // queued modifiers the tap used
+	Text      string    `json:"text,omitempty"`      // text typed in one go
+	PhraseID  uint64    `json:"phrase_id"`           // the History entry that typed it
+	At        time.Time `json:"at"`
+}
+
+// logOutput appends to the output log. mu must be held.
+func (k *StickyKeyboard) logOutput(out TypedOutput) {
+	if k.outputOff {
+		return
+	}
+	out.PhraseID = k.phraseID
+	out.At = time.Now()
+	k.output = append(k.output, out)
+	if len(k.output) > MaxTypedOutput {
+		k.output = slices.Delete(k.output, 0, len(k.output)-MaxTypedOutput)
+	}
+}
+
+// Output returns up to the n most recent output log entries, oldest first.
+func (k *StickyKeyboard) Output(n int) []TypedOutput {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	n = min(max(n, 0), len(k.output))
+	return slices.Clone(k.output[len(k.output)-n:])
+}
+
+// ClearOutput empties the output log.
+func (k *StickyKeyboard) ClearOutput() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.output = nil
+}
+
+// SetOutputLog turns the output log on or off. Turning it off clears it.
+func (k *StickyKeyboard) SetOutputLog(on bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.outputOff = !on
+	if !on {
+		k.output = nil
+	}
 }
 
 // ----------------------------------------------------------------------------
@@ -114,6 +536,7 @@ func (k *StickyKeyboard) Command() { k.queueModifier("command") }
 func (k *StickyKeyboard) Control() { k.queueModifier("ctrl") }
 func (k *StickyKeyboard) Alt()     { k.queueModifier("alt") }
 func (k *StickyKeyboard) Option()  { k.queueModifier("option") }
+func (k *StickyKeyboard) Super()   { k.queueModifier("super") }
 
 // ----------------------------------------------------------------------------
 // STANDARD KEY METHODS
@@ -159,61 +582,216 @@ func (k *StickyKeyboard) Num7() { k.executeTap("7") }
 func (k *StickyKeyboard) Num8() { k.executeTap("8") }
 func (k *StickyKeyboard) Num9() { k.executeTap("9") }
 
+// --- Chords ---
+
+// Press taps a key by name, with optional "+"-separated modifiers in front,
+// e.g. "escape", "g", "shift+g", "ctrl+shift+t".
+func (k *StickyKeyboard) Press(chord string) {
+	parts := strings.Split(strings.ToLower(chord), "+")
+	for _, mod := range parts[:len(parts)-1] {
+		k.queueModifier(mod)
+	}
+	k.executeTap(parts[len(parts)-1])
+}
+
+// TapWith taps key with modifiers held. Unlike Press, the names are robotgo
+// keys already (see normalizeModifier) and are used as they are.
+func (k *StickyKeyboard) TapWith(key string, modifiers ...string) {
+	k.mu.Lock()
+	for _, mod := range modifiers {
+		if !slices.Contains(k.pendingModifiers, mod) {
+			k.pendingModifiers = append(k.pendingModifiers, mod)
+		}
+	}
+	k.mu.Unlock()
+	k.executeTap(key)
+}
+
 // --- Special Text Helpers ---
 
 func (k *StickyKeyboard) TypeInt(n int) {
 	str := strconv.Itoa(n)
 	for _, char := range str {
+		if k.isCancelled() {
+			return
+		}
 		k.executeTap(string(char))
 	}
 }
 
-func (k *StickyKeyboard) TypeStr(s string) {
-	for _, char := range s {
-		k.executeTap(string(char))
+// TypeStr types s. Plain text goes through the OS text input in chunks, which
+// is much faster than a tap per character. With modifiers queued or held, or
+// in compat mode, it taps one character at a time so the modifiers and layout
+// apply; runs of non-ASCII characters ("café", emoji) have no key to tap and
+// still go through the text input. It stops at the first backend failure and
+// returns it; a cancelled phrase stops it quietly.
+func (k *StickyKeyboard) TypeStr(s string) error {
+	if k.canBatch() {
+		for chunk := range slices.Chunk([]rune(s), typeChunk) {
+			if k.isCancelled() {
+				return nil
+			}
+			if err := k.typeText(string(chunk)); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
-}
 
-func (k *StickyKeyboard) CamelCase(phrase string) {
-	words := strings.Fields(phrase)
-	for i, w := range words {
-		runes := []rune(strings.ToLower(w))
-		if len(runes) == 0 {
+	for len(s) > 0 {
+		if k.isCancelled() {
+			return nil
+		}
+		if s[0] < utf8.RuneSelf {
+			if err := k.tapKey(s[:1]); err != nil {
+				return err
+			}
+			s = s[1:]
 			continue
 		}
-		if i > 0 {
-			runes[0] = unicode.ToUpper(runes[0])
+		end := strings.IndexFunc(s, func(r rune) bool { return r < utf8.RuneSelf })
+		if end < 0 {
+			end = len(s)
 		}
-		words[i] = string(runes)
+		if err := k.typeText(s[:end]); err != nil {
+			return err
+		}
+		s = s[end:]
 	}
-	k.TypeStr(strings.Join(words, ""))
+	return nil
+}
+
+// canBatch reports whether TypeStr may skip per-character taps.
+func (k *StickyKeyboard) canBatch() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return !k.compat && k.TypingDelay == 0 && len(k.pendingModifiers) == 0 && len(k.held) == 0
 }
 
-func (k *StickyKeyboard) PascalCase(phrase string) {
-	words := strings.Fields(phrase)
+// typeText types text through the OS text input.
+// Queued modifiers can't apply to it, so they are dropped.
+func (k *StickyKeyboard) typeText(text string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if len(k.pendingModifiers) > 0 {
+		k.log().Debug("dropping modifiers before unicode text", "component", "keyboard", "modifiers", k.pendingModifiers)
+		k.pendingModifiers = []string{}
+	}
+	return k.typed(text)
+}
+
+// typed sends text to the backend and, once it went through, records it as
+// typed output. The caller holds k.mu.
+func (k *StickyKeyboard) typed(text string) error {
+	if err := k.input().TypeStr(text); err != nil {
+		return err
+	}
+	k.recorder.Record(RecordedAction{Kind: ActionType, Text: text})
+	k.typedCount += utf8.RuneCountInString(text)
+	k.noteTyped(text)
+	k.logOutput(TypedOutput{Text: text})
+	return nil
+}
+
+// DefaultAcronyms are the words CamelCase and PascalCase write in capitals
+// ("parse url" -> "parseURL") unless the config file lists its own.
+var DefaultAcronyms = []string{
+	"api", "css", "html", "http", "https", "id", "io", "ip", "json",
+	"sql", "ui", "uri", "url", "uuid", "xml",
+}
+
+// SetAcronyms replaces the words CamelCase and PascalCase write in capitals.
+func (k *StickyKeyboard) SetAcronyms(words []string) {
+	acronyms := make(map[string]bool, len(words))
+	for _, w := range words {
+		acronyms[strings.ToLower(w)] = true
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.acronyms = acronyms
+}
+
+// isAcronym reports whether word is written in capitals when cased.
+func (k *StickyKeyboard) isAcronym(word string) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.acronyms == nil {
+		return slices.Contains(DefaultAcronyms, word)
+	}
+	return k.acronyms[word]
+}
+
+// formatWords splits phrase into the words a formatter joins, with spelled-out
+// numbers as digits ("user id two" -> "user", "id", "2").
+func (k *StickyKeyboard) formatWords(phrase string) []string {
+	prep := NewNumberPreprocessor()
+	words := strings.Fields(k.foldCase(phrase))
 	for i, w := range words {
-		runes := []rune(strings.ToLower(w))
-		if len(runes) > 0 {
-			runes[0] = unicode.ToUpper(runes[0])
+		if digits := prep.Process(strings.ToLower(w)); isDigits(digits) {
+			words[i] = digits
 		}
-		words[i] = string(runes)
 	}
-	k.TypeStr(strings.Join(words, ""))
+	return words
 }
 
-func (k *StickyKeyboard) SnakeCase(phrase string) {
-	words := strings.Fields(phrase)
+// caseWords joins words with each one capitalized (acronyms in full), except
+// that the first word stays lower case when lowerFirst is set. Digits need no
+// capital, so "user id two" becomes "userID2".
+func (k *StickyKeyboard) caseWords(phrase string, lowerFirst bool) string {
+	words := k.formatWords(phrase)
 	for i, w := range words {
-		words[i] = strings.ToLower(w)
+		if i == 0 && lowerFirst {
+			continue
+		}
+		if k.isAcronym(strings.ToLower(w)) {
+			words[i] = strings.ToUpper(w)
+			continue
+		}
+		runes := []rune(w)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, "")
+}
+
+func (k *StickyKeyboard) CamelCase(phrase string) error {
+	return k.TypeStr(k.caseWords(phrase, true))
+}
+
+func (k *StickyKeyboard) PascalCase(phrase string) error {
+	return k.TypeStr(k.caseWords(phrase, false))
+}
+
+func (k *StickyKeyboard) SnakeCase(phrase string) error {
+	return k.TypeStr(strings.Join(k.formatWords(phrase), "_"))
+}
+
+// Dictate types phrase followed by a space, capitalizing its first letter
+// only when it starts a new sentence (see AtSentenceStart), so a sentence
+// can be dictated across several phrases.
+func (k *StickyKeyboard) Dictate(phrase string) error {
+	if len(phrase) == 0 {
+		return nil
 	}
-	k.TypeStr(strings.Join(words, "_"))
+	runes := []rune(phrase + " ")
+	if k.AtSentenceStart() {
+		runes[0] = unicode.ToUpper(runes[0])
+	}
+	return k.Type(string(runes))
 }
 
+// Sentence types phrase as a whole sentence: capitalized, with a full stop
+// unless the recognizer already ended it with one ("okay." or "really?").
 func (k *StickyKeyboard) Sentence(phrase string) error {
 	if len(phrase) == 0 {
 		return nil
 	}
-	phrase += ". "
+	if strings.ContainsAny(phrase[len(phrase)-1:], ".!?") {
+		phrase += " "
+	} else {
+		phrase += ". "
+	}
 	runes := []rune(phrase)
 	if len(runes) > 0 {
 		runes[0] = unicode.ToUpper(runes[0])
@@ -221,9 +799,11 @@ func (k *StickyKeyboard) Sentence(phrase string) error {
 	return k.Type(string(runes))
 }
 
+// Type sends text to the backend in one piece, returning its error.
 func (k *StickyKeyboard) Type(text string) error {
-	robotgo.TypeStr(text)
-	return nil
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.typed(text)
 }
 
 // --- Function Keys ---
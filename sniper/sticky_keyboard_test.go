@@ -0,0 +1,348 @@
+package sniper_test
+
+import (
+	"errors"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/phillip-england/sniper/sniper"
+	"github.com/phillip-england/sniper/sniper/snipertest"
+)
+
+func TestFormalDictationChainsPhrases(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.MustRun(t, "formal on")
+
+	phrases := []struct{ phrase, want string }{
+		{"say the quick brown fox", "The quick brown fox "},
+		{"say jumps over the lazy dog.", "jumps over the lazy dog. "},
+		{"say after that it slept", "After that it slept "},
+	}
+	for _, p := range phrases {
+		snipertest.ExpectTyped(t, e, p.phrase, p.want)
+	}
+}
+
+func TestFormalOffCapitalizesEveryPhrase(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.MustRun(t, "formal on")
+	e.MustRun(t, "formal off")
+
+	phrases := []struct{ phrase, want string }{
+		{"say the quick brown fox", "The quick brown fox. "},
+		{"say jumps over the lazy dog", "Jumps over the lazy dog. "},
+		{"say after that it slept", "After that it slept. "},
+	}
+	for _, p := range phrases {
+		snipertest.ExpectTyped(t, e, p.phrase, p.want)
+	}
+}
+
+func TestDictationKeepsOriginalCasing(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+
+	snipertest.ExpectTyped(t, e, "say Hello World", "Hello World. ")
+	snipertest.ExpectTyped(t, e, "say Hello from Berlin", "Hello from Berlin. ")
+	snipertest.ExpectTyped(t, e, "type getHTTPClient", "getHTTPClient")
+
+	// Commands still match whatever the casing
+	snipertest.ExpectKeys(t, e, "SOUTH", "down")
+}
+
+func TestVerbatimCaseFormatters(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	snipertest.ExpectTyped(t, e, "camel get MyWidget", "getMywidget")
+
+	opts := e.Options()
+	opts.VerbatimCase = true
+	if err := e.SetOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	snipertest.ExpectTyped(t, e, "camel get MyWidget", "getMyWidget")
+}
+
+// backspaces returns n backspace taps, as ExpectKeys lists them.
+func backspaces(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = "backspace"
+	}
+	return keys
+}
+
+func TestScratchErasesThePreviousPhrase(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+
+	// "Hello big world. " is 17 characters
+	e.MustRun(t, "say hello big world")
+	snipertest.ExpectKeys(t, e, "scratch that", backspaces(17)...)
+
+	// Arrow keys and shortcuts aren't output: only "Hi. " is erased
+	e.MustRun(t, "say hi then south then copy")
+	snipertest.ExpectKeys(t, e, "scratch", backspaces(4)...)
+}
+
+func TestScratchWalksBackThroughPhrases(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.MustRun(t, "say one two")
+	e.MustRun(t, "south")
+	e.MustRun(t, "type abc")
+
+	snipertest.ExpectKeys(t, e, "scratch", backspaces(3)...)
+	snipertest.ExpectKeys(t, e, "scratch", backspaces(9)...) // "One two. "
+	snipertest.ExpectKeys(t, e, "scratch")
+}
+
+func TestScratchJournalIsBounded(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	for range sniper.MaxJournalDepth + 5 {
+		e.MustRun(t, "type a")
+	}
+
+	erased := 0
+	for range sniper.MaxJournalDepth + 5 {
+		e.MustRun(t, "scratch")
+		erased += len(e.Input.Keys())
+	}
+	if erased != sniper.MaxJournalDepth {
+		t.Errorf("scratch erased %d characters, want %d", erased, sniper.MaxJournalDepth)
+	}
+}
+
+func TestHoldShiftAcrossTaps(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+
+	e.MustRun(t, "hold shift east east east release")
+	want := []string{"down shift", "tap right", "tap right", "tap right", "up shift"}
+	if ops := e.Input.Ops(); !slices.Equal(ops, want) {
+		t.Errorf("ops = %q, want %q", ops, want)
+	}
+
+	// A held modifier outlives the phrase until "release"
+	steps := []struct {
+		phrase string
+		want   []string
+	}{
+		{"hold control", []string{"down ctrl"}},
+		{"east", []string{"tap right"}},
+		{"release", []string{"up ctrl"}},
+	}
+	for _, step := range steps {
+		e.MustRun(t, step.phrase)
+		if ops := e.Input.Ops(); !slices.Equal(ops, step.want) {
+			t.Errorf("%q: ops = %q, want %q", step.phrase, ops, step.want)
+		}
+	}
+}
+
+func TestHeldModifiersAreForceReleased(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.Register(jamCmd{})
+	e.Register(panicCmd{})
+
+	for _, phrase := range []string{"hold shift then jam", "hold shift then boom"} {
+		e.Input.Reset()
+		e.Run(phrase)
+		if ops := e.Input.Ops(); !slices.Contains(ops, "up shift") {
+			t.Errorf("%q left shift down: %q", phrase, ops)
+		}
+	}
+
+	e.MustRun(t, "hold alt")
+	e.Close()
+	if ops := e.Input.Ops(); !slices.Contains(ops, "up alt") {
+		t.Errorf("Close left alt down: %q", ops)
+	}
+}
+
+func TestNormalizeModifier(t *testing.T) {
+	names := []string{"shift", "ctrl", "control", "alt", "option", "cmd", "command", "super", "win", "Control", "f5"}
+	tests := map[string][]string{
+		"darwin":  {"shift", "lctrl", "lctrl", "lalt", "lalt", "cmd", "cmd", "cmd", "cmd", "lctrl", "f5"},
+		"linux":   {"shift", "ctrl", "ctrl", "alt", "alt", "ctrl", "ctrl", "cmd", "cmd", "ctrl", "f5"},
+		"windows": {"shift", "ctrl", "ctrl", "alt", "alt", "ctrl", "ctrl", "cmd", "cmd", "ctrl", "f5"},
+	}
+	for goos, want := range tests {
+		for i, name := range names {
+			if got := sniper.NormalizeModifier(goos, name); got != want[i] {
+				t.Errorf("%s: NormalizeModifier(%q) = %q, want %q", goos, name, got, want[i])
+			}
+		}
+	}
+}
+
+func TestUnicodeTypingSplitsTapsFromText(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	opts := e.Options()
+	opts.CompatTyping = true
+	if err := e.SetOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+
+	// ASCII is tapped key by key; non-ASCII runs go through the text input whole
+	e.MustRun(t, "type café über 🙂!")
+	want := []string{
+		"tap c", "tap a", "tap f", "type éü", "tap b", "tap e", "tap r",
+		"type 🙂", "tap shift+1", "up shift",
+	}
+	if ops := e.Input.Ops(); !slices.Equal(ops, want) {
+		t.Errorf("ops = %q, want %q", ops, want)
+	}
+	if got := e.Input.Typed(); got != "éü🙂" {
+		t.Errorf("typed = %q, want %q", got, "éü🙂")
+	}
+
+	// A queued modifier can't apply to unicode text and is dropped, not
+	// carried over to the next key
+	e.MustRun(t, "shift then type é then east")
+	want = []string{"type é", "tap right"}
+	if ops := e.Input.Ops(); !slices.Equal(ops, want) {
+		t.Errorf("ops = %q, want %q", ops, want)
+	}
+}
+
+// longText is a long phrase for the batch typing tests. "type" runs its
+// words together, 66 characters in all.
+const longText = "type the quick brown fox jumps over the lazy dog while running until the sun goes down"
+
+func TestBatchTypingMatchesCompatTyping(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.MustRun(t, longText)
+	batched := e.Input.Typed()
+	if keys := e.Input.Keys(); len(keys) > 0 {
+		t.Errorf("batch typing tapped %q", keys)
+	}
+	if chunks := len(e.Input.Ops()); chunks != 3 {
+		t.Errorf("%d chunks for %d characters, want 3", chunks, len(batched))
+	}
+
+	opts := e.Options()
+	opts.CompatTyping = true
+	if err := e.SetOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	e.MustRun(t, longText)
+	if typed := e.Input.Typed(); typed != "" {
+		t.Errorf("compat typing sent %q as text", typed)
+	}
+	if tapped := strings.Join(e.Input.Keys(), ""); tapped != batched {
+		t.Errorf("compat typing = %q, batch typing = %q", tapped, batched)
+	}
+}
+
+// BenchmarkLongText compares batch typing with compat typing at the default
+// post-release delay, which snipertest otherwise turns off.
+func BenchmarkLongText(b *testing.B) {
+	for _, compat := range []bool{false, true} {
+		name := "batch"
+		if compat {
+			name = "compat"
+		}
+		b.Run(name, func(b *testing.B) {
+			e := snipertest.NewTestEngine(b)
+			opts := e.Options()
+			opts.CompatTyping = compat
+			opts.PostReleaseDelayMs = sniper.DefaultEngineOptions().PostReleaseDelayMs
+			if err := e.SetOptions(opts); err != nil {
+				b.Fatal(err)
+			}
+			for b.Loop() {
+				e.MustRun(b, longText)
+			}
+		})
+	}
+}
+
+func TestResolveSystemChord(t *testing.T) {
+	tests := []struct {
+		goos, action string
+		want         string // modifiers and key joined with "+"; "" for no chord
+	}{
+		{"darwin", sniper.SystemMissionControl, "lctrl+up"},
+		{"darwin", sniper.SystemSpaceEast, "lctrl+right"},
+		{"darwin", sniper.SystemSpaceWest, "lctrl+left"},
+		{"darwin", sniper.SystemShowDesktop, "f11"},
+		{"darwin", sniper.SystemSpotlight, "cmd+space"},
+		{"darwin", sniper.SystemLockScreen, "lctrl+cmd+q"},
+		{"linux", sniper.SystemStartMenu, "cmd"},
+		{"windows", sniper.SystemLockScreen, "cmd+l"},
+		{"linux", sniper.SystemMissionControl, ""},
+		{"windows", sniper.SystemSpaceEast, ""},
+		{"linux", sniper.SystemSpotlight, ""},
+		{"darwin", sniper.SystemSettings, ""},
+	}
+	for _, tt := range tests {
+		modifiers, key, err := sniper.ResolveSystemChord(tt.goos, tt.action)
+		if tt.want == "" {
+			if !errors.Is(err, sniper.ErrNoSystemChord) {
+				t.Errorf("%s %s: err = %v, want ErrNoSystemChord", tt.goos, tt.action, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %s: %v", tt.goos, tt.action, err)
+			continue
+		}
+		if got := strings.Join(append(modifiers, key), "+"); got != tt.want {
+			t.Errorf("%s %s = %q, want %q", tt.goos, tt.action, got, tt.want)
+		}
+	}
+}
+
+func TestSystemChordCommands(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	phrases := map[string]string{
+		"mission control": sniper.SystemMissionControl,
+		"space east":      sniper.SystemSpaceEast,
+		"space west":      sniper.SystemSpaceWest,
+		"show desktop":    sniper.SystemShowDesktop,
+		"spotlight":       sniper.SystemSpotlight,
+	}
+	for phrase, action := range phrases {
+		modifiers, key, chordErr := sniper.ResolveSystemChord(runtime.GOOS, action)
+		_, err := e.Run(phrase)
+		if chordErr != nil {
+			if !errors.Is(err, sniper.ErrNoSystemChord) {
+				t.Errorf("%q on %s: err = %v, want ErrNoSystemChord", phrase, runtime.GOOS, err)
+			}
+			if keys := e.Input.Keys(); len(keys) > 0 {
+				t.Errorf("%q on %s tapped %q", phrase, runtime.GOOS, keys)
+			}
+			continue
+		}
+		want := strings.Join(append(modifiers, key), "+")
+		if keys := e.Input.Keys(); !slices.Equal(keys, []string{want}) {
+			t.Errorf("%q tapped %q, want %q", phrase, keys, want)
+		}
+	}
+}
+
+var errNoText = errors.New("text input unavailable")
+
+// textlessInput taps keys but can't type text.
+type textlessInput struct{ *snipertest.Input }
+
+func (textlessInput) TypeStr(text string) error { return errNoText }
+
+func TestTypingFailuresFailThePhrase(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	e.StickyKeyboard.SetBackend(textlessInput{e.Input})
+
+	for _, phrase := range []string{"say hello", "type hello", "camel get user", "phrase get user"} {
+		if _, err := e.Run(phrase); !errors.Is(err, errNoText) {
+			t.Errorf("%s: err = %v, want the backend's error", phrase, err)
+		}
+	}
+	e.Parse("hello", "rapid")
+	if _, err := e.Execute(); !errors.Is(err, errNoText) {
+		t.Errorf("rapid raw word: err = %v, want the backend's error", err)
+	}
+	if got := e.StickyKeyboard.Output(10); len(got) != 0 {
+		t.Errorf("text that never went through was logged as output: %v", got)
+	}
+
+	// Taps don't need the text input
+	snipertest.ExpectKeys(t, e, "south", "down")
+}
@@ -0,0 +1,97 @@
+package sniper
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// SYSTEM CHORDS
+// ----------------------------------------------------------------------------
+//
+// OS-level shortcuts ("start menu", "lock screen") differ per platform, so they
+// are data: a chord per GOOS, written with spoken modifier names and resolved
+// through normalizeModifier like every other modifier. Platforms without a
+// close equivalent have no entry and fail with ErrNoSystemChord.
+
+// ErrNoSystemChord is returned for a system action the platform has no chord for.
+var ErrNoSystemChord = errors.New("no shortcut for this action on this platform")
+
+// System actions with a chord in SystemChords.
+const (
+	SystemStartMenu  = "start_menu"
+	SystemLockScreen = "lock_screen"
+	SystemExplorer   = "explorer"
+	SystemSettings   = "settings"
+
+	// macOS only
+	SystemMissionControl = "mission_control"
+	SystemSpaceEast      = "space_east"
+	SystemSpaceWest      = "space_west"
+	SystemShowDesktop    = "show_desktop"
+	SystemSpotlight      = "spotlight"
+)
+
+// SystemChords maps a system action to its "+"-separated chord per GOOS.
+// "super" is the Windows key off macOS and Command on it.
+var SystemChords = map[string]map[string]string{
+	SystemStartMenu: {
+		"windows": "super",
+		"linux":   "super",       // the activities overview or app launcher
+		"darwin":  "super+space", // Spotlight
+	},
+	SystemLockScreen: {
+		"windows": "super+l",
+		"linux":   "super+l",
+		"darwin":  "ctrl+super+q",
+	},
+	SystemExplorer: {
+		"windows": "super+e",
+		"linux":   "super+e",
+		"darwin":  "super+alt+space", // a Finder search window
+	},
+	SystemSettings: {
+		"windows": "super+i",
+	},
+	SystemMissionControl: {
+		"darwin": "ctrl+up",
+	},
+	SystemSpaceEast: {
+		"darwin": "ctrl+right",
+	},
+	SystemSpaceWest: {
+		"darwin": "ctrl+left",
+	},
+	SystemShowDesktop: {
+		"darwin": "f11",
+	},
+	SystemSpotlight: {
+		"darwin": "super+space",
+	},
+}
+
+// resolveSystemChord returns the robotgo modifiers and key that perform action
+// on goos. A lone modifier ("super") is tapped as a key of its own.
+func resolveSystemChord(goos, action string) (modifiers []string, key string, err error) {
+	chord, ok := SystemChords[action][goos]
+	if !ok {
+		return nil, "", fmt.Errorf("%w: '%s' on %s", ErrNoSystemChord, action, goos)
+	}
+	parts := strings.Split(chord, "+")
+	for _, mod := range parts[:len(parts)-1] {
+		modifiers = append(modifiers, normalizeModifier(goos, mod))
+	}
+	return modifiers, normalizeModifier(goos, parts[len(parts)-1]), nil
+}
+
+// PressSystemChord performs a system action (see SystemChords) on this platform.
+func (k *StickyKeyboard) PressSystemChord(action string) error {
+	modifiers, key, err := resolveSystemChord(runtime.GOOS, action)
+	if err != nil {
+		return err
+	}
+	k.TapWith(key, modifiers...)
+	return nil
+}
@@ -0,0 +1,60 @@
+package sniper
+
+// ----------------------------------------------------------------------------
+// TIMINGS
+// ----------------------------------------------------------------------------
+//
+// Target apps disagree about how fast synthetic input may arrive, so every
+// delay the engine waits is gathered here, read and written together by
+// GET/PUT /api/config/timings and saved to the config file.
+
+// Timings are the engine's delays. See EngineOptions for what each one does.
+type Timings struct {
+	TypingDelayMs      int `json:"typing_delay_ms"`
+	PostReleaseDelayMs int `json:"post_release_delay_ms"`
+	TokenDelayUs       int `json:"token_delay_us"`
+	ThenDelayMs        int `json:"then_delay_ms"`
+	MouseDelayMs       int `json:"mouse_delay_ms"`
+	ClickGapMs         int `json:"click_gap_ms"`
+	ClipboardDelayMs   int `json:"clipboard_delay_ms"`
+}
+
+// Timings returns the delays in effect.
+func (e *Engine) Timings() Timings {
+	opts := e.Options()
+	return Timings{
+		TypingDelayMs:      opts.TypingDelayMs,
+		PostReleaseDelayMs: opts.PostReleaseDelayMs,
+		TokenDelayUs:       opts.TokenDelayUs,
+		ThenDelayMs:        opts.ThenDelayMs,
+		MouseDelayMs:       opts.MouseDelayMs,
+		ClickGapMs:         opts.ClickGapMs,
+		ClipboardDelayMs:   opts.ClipboardDelayMs,
+	}
+}
+
+// SetTimings validates t, applies it to the running engine and saves it to
+// the config file. On error nothing changes.
+func (e *Engine) SetTimings(t Timings) error {
+	opts := e.Options()
+	opts.TypingDelayMs = t.TypingDelayMs
+	opts.PostReleaseDelayMs = t.PostReleaseDelayMs
+	opts.TokenDelayUs = t.TokenDelayUs
+	opts.ThenDelayMs = t.ThenDelayMs
+	opts.MouseDelayMs = t.MouseDelayMs
+	opts.ClickGapMs = t.ClickGapMs
+	opts.ClipboardDelayMs = t.ClipboardDelayMs
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	return e.updateConfig(func(cfg *Config) {
+		cfg.TypingDelayMs = &t.TypingDelayMs
+		cfg.PostReleaseDelayMs = &t.PostReleaseDelayMs
+		cfg.TokenDelayUs = &t.TokenDelayUs
+		cfg.ThenDelayMs = &t.ThenDelayMs
+		cfg.MouseDelayMs = &t.MouseDelayMs
+		cfg.ClickGapMs = &t.ClickGapMs
+		cfg.ClipboardDelayMs = &t.ClipboardDelayMs
+	})
+}
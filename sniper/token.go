@@ -2,6 +2,8 @@ package sniper
 
 import (
 	"strconv"
+	"strings"
+	"unicode"
 )
 
 // TokenType identifies the category of a token.
@@ -13,78 +15,185 @@ const (
 	TokenTypeNumber
 )
 
+func (t TokenType) String() string {
+	switch t {
+	case TokenTypeCmd:
+		return "cmd"
+	case TokenTypeNumber:
+		return "number"
+	default:
+		return "raw"
+	}
+}
+
 // Token is the interface that all token types must implement.
 type Token interface {
 	Type() TokenType
 	Literal() string
+	// Index is the position of the token's first word in the spoken phrase.
+	Index() int
+	// Original is what was heard, before the number preprocessor rewrote it.
+	Original() string
 	// Handle executes the logic specific to this token type.
 	// It returns a bool indicating if execution should stop (true), and an error.
 	Handle(e *Engine, index int) (bool, error)
 }
 
+// position is the place in the phrase every token carries. Parse fills it in.
+type position struct {
+	index    int
+	original string
+}
+
+func (p *position) Index() int       { return p.index }
+func (p *position) Original() string { return p.original }
+
+func (p *position) place(index int, original string) {
+	p.index, p.original = index, original
+}
+
+// CasedLiteral returns the token's literal in the casing, and with the
+// punctuation, it was heard in. Words the number preprocessor rewrote keep
+// their rewritten form.
+func CasedLiteral(t Token) string {
+	if strings.EqualFold(t.Original(), t.Literal()) || strings.EqualFold(trimWordPunct(t.Original()), t.Literal()) {
+		return t.Original()
+	}
+	return t.Literal()
+}
+
+// trimWordPunct strips the punctuation recognizers attach to a word ("Click.",
+// "copy,", "\"quoted\""), keeping anything inside it ("don't"). A word that is
+// nothing but punctuation (".") is returned as it is.
+func trimWordPunct(word string) string {
+	if trimmed := strings.TrimFunc(word, unicode.IsPunct); trimmed != "" {
+		return trimmed
+	}
+	return word
+}
+
+// placer is implemented by tokens that embed position.
+type placer interface {
+	place(index int, original string)
+}
+
 // TokenFactory takes a raw string word, processes it, and returns the appropriate Token.
-// UPDATED: Now accepts MouseMemory to check for dynamic spots.
-func TokenFactory(word string, registry map[string]Cmd, memory *MouseMemory) Token {
+// The resolver picks among the commands and saved spots claiming the word.
+// fuzzyDistance > 0 enables the fuzzy fallback for words that match nothing else.
+func TokenFactory(word string, resolver *Resolver, fuzzyDistance int) Token {
 	// 1. Run the number preprocessor
 	numberPrep := NewNumberPreprocessor()
 	processed := numberPrep.Process(word)
 
-	// 2. Check Registry (Static Commands)
-	if cmd, ok := registry[processed]; ok {
+	// 2. Check the commands and saved spots, highest priority source first
+	if cmd, source, ok := resolver.Resolve(processed); ok {
 		return &CmdToken{
-			cmd:     cmd,
-			literal: processed,
+			position: position{original: word},
+			cmd:      cmd,
+			literal:  processed,
+			source:   source,
 		}
 	}
 
-	// 3. Check Mouse Memory (Dynamic Spots)
-	// If the word matches a saved spot, we create a dynamic command to move there.
-	if spot, ok := memory.Get(processed); ok {
-		return &CmdToken{
-			cmd:     NewSpotCmd(processed, spot.X, spot.Y),
-			literal: processed,
+	// 3. Check Number
+	if val, err := strconv.Atoi(processed); err == nil {
+		return &NumberToken{
+			position: position{original: word},
+			value:    val,
+			literal:  processed,
 		}
 	}
 
-	// 4. Check Number
-	if val, err := strconv.Atoi(processed); err == nil {
-		return &NumberToken{
-			value:   val,
-			literal: processed,
+	// 4. Fuzzy fallback: the closest trigger, if it's close enough and unambiguous.
+	// The literal stays as heard so dictated text after "say" isn't rewritten.
+	if fuzzyDistance > 0 {
+		if trigger, cmd, ok := fuzzyLookup(processed, resolver.Triggers(), fuzzyDistance); ok {
+			_, source, _ := resolver.Resolve(trigger)
+			return &CmdToken{
+				position:     position{original: word},
+				cmd:          cmd,
+				literal:      processed,
+				fuzzyTrigger: trigger,
+				source:       source,
+			}
 		}
 	}
 
 	// 5. Default to Raw token
 	return &RawToken{
-		literal: processed,
+		position: position{original: word},
+		literal:  processed,
 	}
 }
 
+// MultiWordTokenFactory matches the longest multi-word trigger at the start of words.
+// triggers is keyed by the space-joined trigger; maxSpan is its longest length in words.
+// It returns nil when no multi-word trigger matches.
+func MultiWordTokenFactory(words []string, triggers map[string]Cmd, maxSpan int) (Token, int) {
+	for n := min(maxSpan, len(words)); n >= 2; n-- {
+		phrase := strings.Join(words[:n], " ")
+		if cmd, ok := triggers[phrase]; ok {
+			return &CmdToken{
+				position: position{original: phrase},
+				cmd:      cmd,
+				literal:  phrase,
+				span:     n,
+			}, n
+		}
+	}
+	return nil, 0
+}
+
 // --- Token Implementations ---
 
 // CmdToken represents a valid command found in the registry.
 type CmdToken struct {
-	cmd     Cmd
-	literal string
+	position
+	cmd          Cmd
+	literal      string
+	fuzzyTrigger string // set when the word only matched approximately
+	span         int    // raw words covered; more than 1 for triggers like "select all"
+	source       TriggerSource
 }
 
 func (t *CmdToken) Type() TokenType { return TokenTypeCmd }
 func (t *CmdToken) Literal() string { return t.literal }
 func (t *CmdToken) Command() Cmd    { return t.cmd }
 
+// FuzzyTrigger returns the trigger a misheard word was matched to, or "" for exact matches.
+func (t *CmdToken) FuzzyTrigger() string { return t.fuzzyTrigger }
+
+// Source returns where the token's command came from (mode, user, spot or builtin).
+func (t *CmdToken) Source() TriggerSource { return t.source }
+
+// Span returns how many spoken words the token covers.
+func (t *CmdToken) Span() int { return max(t.span, 1) }
+
 func (t *CmdToken) Handle(e *Engine, index int) (bool, error) {
-	// Execute the standard command once
-	if err := t.cmd.Action(e, ""); err != nil {
+	// Execute the standard command once, handing an ArgTaker its arguments
+	var args []Token
+	if taker, ok := t.cmd.(ArgTaker); ok {
+		args = e.ConsumeNext(taker.ArgCount())
+	}
+	if err := e.InvokeWithArgs(t.cmd, args); err != nil {
 		return false, err
 	}
 
-	// Store this as the previous command for potential repetition
+	// Store this as the previous command for potential repetition. Repeats
+	// reuse its arguments rather than claiming the tokens after the number.
 	e.State.LastCmd = t.cmd
+	e.State.LastArgs = args
+
+	// "cancel" and "halt" ask us to drop everything after them
+	if e.State.Cancelled || e.State.Halted {
+		return true, nil
+	}
 	return false, nil
 }
 
 // NumberToken represents a numeric value.
 type NumberToken struct {
+	position
 	value   int
 	literal string
 }
@@ -99,8 +208,15 @@ func (t *NumberToken) Handle(e *Engine, index int) (bool, error) {
 	if e.State.LastCmd != nil {
 		// The command already ran once. Run it (value - 1) more times.
 		if t.value > 1 {
+			tight := e.tightRepeat(e.State.LastCmd)
 			for k := 0; k < t.value-1; k++ {
-				if err := e.State.LastCmd.Action(e, ""); err != nil {
+				if !tight {
+					e.pace()
+				}
+				if err := e.checkCancelled(); err != nil {
+					return false, err
+				}
+				if err := e.InvokeWithArgs(e.State.LastCmd, e.State.LastArgs); err != nil {
 					return false, err
 				}
 			}
@@ -110,36 +226,20 @@ func (t *NumberToken) Handle(e *Engine, index int) (bool, error) {
 		return false, nil
 	}
 
-	// CASE 2: Inter-phrase Repetition (e.g., User said "Left Down", then says "5")
-	// There is no command in the current sequence, and Parse has preserved LastState.
-	if e.LastState != nil && len(e.LastState.Tokens) > 0 {
-		// We repeat the entire sequence 't.value' times.
-		for k := 0; k < t.value; k++ {
-			for _, prevToken := range e.LastState.Tokens {
-
-				// SAFETY CHECK: Prevent infinite recursion.
-				// If the previous sequence contained a Number, we only allow it
-				// if it has a valid LastCmd to act on. If it's a "naked" number, skip it.
-				// This prevents "2" -> "2" from exploding if State was wiped.
-				if prevToken.Type() == TokenTypeNumber && e.State.LastCmd == nil {
-					continue
-				}
-
-				// Execute the token.
-				// We pass -1 as index because strict indexing doesn't matter for replay.
-				_, err := prevToken.Handle(e, -1)
-				if err != nil {
-					return false, err
-				}
-			}
-		}
+	// A number opening a later segment ("left then 5") has nothing to repeat
+	if e.State.Segment > 0 {
+		return false, nil
 	}
 
-	return false, nil
+	// CASE 2: Inter-phrase Repetition (e.g., User said "Left Down", then says "5")
+	// There is no command in the current sequence, and Parse left the previous phrase alone.
+	// This is exactly "repeat 5": the whole sequence is replayed 't.value' times.
+	return false, e.replayTimes(e.PreviousState(0), t.value)
 }
 
 // RawToken represents input that is neither a command nor a number.
 type RawToken struct {
+	position
 	literal string
 }
 
@@ -151,3 +251,43 @@ func (t *RawToken) Handle(e *Engine, index int) (bool, error) {
 	// to preserve original functionality, but this handler exists for future expansion.
 	return false, nil
 }
+
+// MinFuzzyWordLen is the shortest word the fuzzy fallback will consider.
+// Shorter words are within one edit of too many triggers.
+const MinFuzzyWordLen = 3
+
+// fuzzyLookup finds the single command whose trigger is closest to word, within maxDistance.
+// It gives up when two different commands are equally close.
+func fuzzyLookup(word string, registry map[string]Cmd, maxDistance int) (string, Cmd, bool) {
+	if len([]rune(word)) < MinFuzzyWordLen {
+		return "", nil, false
+	}
+
+	bestDistance := maxDistance + 1
+	var bestTrigger string
+	var bestCmd Cmd
+	ambiguous := false
+
+	for _, trigger := range sortedKeys(registry) {
+		// Multi-word triggers can't match a single word
+		if trigger == "" || strings.Contains(trigger, " ") {
+			continue
+		}
+		d := editDistance(word, trigger)
+		if d > maxDistance {
+			continue
+		}
+		switch {
+		case d < bestDistance:
+			bestDistance, bestTrigger, bestCmd = d, trigger, registry[trigger]
+			ambiguous = false
+		case d == bestDistance && registry[trigger].Name() != bestCmd.Name():
+			ambiguous = true
+		}
+	}
+
+	if bestCmd == nil || ambiguous {
+		return "", nil, false
+	}
+	return bestTrigger, bestCmd, true
+}
@@ -0,0 +1,161 @@
+package sniper_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/phillip-england/sniper/sniper"
+	"github.com/phillip-england/sniper/sniper/snipertest"
+)
+
+func TestFuzzyFallback(t *testing.T) {
+	resolver := sniper.NewResolver(map[sniper.TriggerSource]map[string]sniper.Cmd{
+		sniper.SourceBuiltin: {
+			"click":   sniper.Click{},
+			"east":    sniper.East{},
+			"west":    sniper.West{},
+			"foxtrot": sniper.F{},
+		},
+	}, nil, nil)
+
+	tests := []struct {
+		word     string
+		distance int
+		want     string // command name, "" for a raw token
+		fuzzy    string // trigger the word was matched to
+	}{
+		{"click", 1, "click", ""},
+		{"clck", 1, "click", "click"},
+		{"clique", 1, "", ""},
+		{"clique", 3, "click", "click"},
+		{"clck", 0, "", ""}, // the fallback is off
+		{"wast", 1, "", ""}, // as close to east as to west
+		{"east", 1, "east", ""},
+		{"foxtrt", 1, "f", "foxtrot"},
+		{"fou", 1, "", ""},
+		{"ck", 3, "", ""}, // too short to guess at
+	}
+	for _, tt := range tests {
+		token := sniper.TokenFactory(tt.word, resolver, tt.distance)
+		ct, ok := token.(*sniper.CmdToken)
+		if tt.want == "" {
+			if ok {
+				t.Errorf("%q (distance %d) matched %s, want a raw token", tt.word, tt.distance, ct.Command().Name())
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("%q (distance %d) = %T, want %s", tt.word, tt.distance, token, tt.want)
+			continue
+		}
+		if ct.Command().Name() != tt.want || ct.FuzzyTrigger() != tt.fuzzy {
+			t.Errorf("%q (distance %d) = %s via %q, want %s via %q",
+				tt.word, tt.distance, ct.Command().Name(), ct.FuzzyTrigger(), tt.want, tt.fuzzy)
+		}
+	}
+}
+
+func TestFuzzyMatchIsOptIn(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	snipertest.ExpectMouse(t, e, "clck")
+
+	opts := e.Options()
+	opts.FuzzyMatch = true
+	if err := e.SetOptions(opts); err != nil {
+		t.Fatal(err)
+	}
+	result, err := e.Run("clck")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := e.Input.Mouse(); len(got) != 1 || got[0] != "click left" {
+		t.Errorf("clck did %q to the mouse, want a left click", got)
+	}
+	// The trace keeps what was heard and notes what it was taken for
+	if tok := result.Tokens[0]; tok.Literal != "clck" || tok.FuzzyMatch != "click" {
+		t.Errorf("trace token = %+v, want literal clck matched to click", tok)
+	}
+
+	// Exact matches never go through the fallback
+	result = e.MustRun(t, "east")
+	if tok := result.Tokens[0]; tok.FuzzyMatch != "" {
+		t.Errorf("east was fuzzy matched to %q", tok.FuzzyMatch)
+	}
+}
+
+func TestMultiWordTriggers(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	selectAll := primary() + "+a"
+
+	tests := []struct {
+		phrase string
+		tokens []string
+		keys   []string
+	}{
+		// "select all" wins over its prefix "select"
+		{"select all", []string{"select all"}, []string{selectAll}},
+		{"select", []string{"select"}, []string{selectAll}},
+		{"select east", []string{"select", "east"}, []string{selectAll, "right"}},
+		{"east select all", []string{"east", "select all"}, []string{"right", selectAll}},
+		{"select all 2", []string{"select all", "2"}, []string{selectAll, selectAll}},
+		{"select all east 2", []string{"select all", "east", "2"}, []string{selectAll, "right", "right"}},
+	}
+	for _, tt := range tests {
+		result := e.MustRun(t, tt.phrase)
+		var tokens []string
+		for _, tok := range result.Tokens {
+			tokens = append(tokens, tok.Literal)
+		}
+		if !slices.Equal(tokens, tt.tokens) {
+			t.Errorf("%q parsed as %q, want %q", tt.phrase, tokens, tt.tokens)
+		}
+		if got := e.Input.Keys(); !slices.Equal(got, tt.keys) {
+			t.Errorf("%q tapped %q, want %q", tt.phrase, got, tt.keys)
+		}
+	}
+
+	// The words after a multi-word trigger are still read as one
+	snipertest.ExpectTyped(t, e, "select all say all done", "All done. ")
+}
+
+func TestRecognizerPunctuation(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+
+	keys := []struct {
+		phrase string
+		want   []string
+	}{
+		{"East.", []string{"right"}},
+		{"copy,", []string{primary() + "+c"}},
+		{`"east"`, []string{"right"}},
+		{"east, west.", []string{"right", "left"}},
+		{"east 3.", []string{"right", "right", "right"}},
+		{"period.", []string{"."}}, // Dot still types a literal dot
+		{"dot", []string{"."}},
+		{".", nil}, // bare punctuation isn't trimmed away to nothing
+	}
+	for _, tt := range keys {
+		snipertest.ExpectKeys(t, e, tt.phrase, tt.want...)
+	}
+	snipertest.ExpectMouse(t, e, "Click.", "click left")
+
+	// Dictation types the words as heard, punctuation and all
+	typed := []struct {
+		phrase string
+		want   string
+	}{
+		{"word Hello.", "Hello."},
+		{"say don't stop, okay.", "Don't stop, okay. "},
+		{`say "quoted" words`, `"quoted" words. `},
+		{"say really?", "Really? "},
+	}
+	for _, tt := range typed {
+		snipertest.ExpectTyped(t, e, tt.phrase, tt.want)
+	}
+
+	// The trace names the command without the punctuation
+	result := e.MustRun(t, "Click.")
+	if tok := result.Tokens[0]; tok.Literal != "click" || tok.Original != "Click." {
+		t.Errorf("trace token = %+v, want click heard as Click.", tok)
+	}
+}
@@ -0,0 +1,58 @@
+package sniper
+
+import (
+	"slices"
+	"time"
+)
+
+// EffectTiming is one measurement recorded by a Timed effect.
+type EffectTiming struct {
+	Name       string  `json:"name"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// tokenTrace is what the effects around a token's command(s) left in the trace.
+type tokenTrace struct {
+	effects []string
+	timings []EffectTiming
+}
+
+// trace returns the trace of the token being handled, or nil outside of one.
+func (s *EngineState) trace() *tokenTrace {
+	if s == nil || s.current < 0 || s.current >= len(s.Tokens) {
+		return nil
+	}
+	if len(s.traces) < len(s.Tokens) {
+		s.traces = append(s.traces, make([]tokenTrace, len(s.Tokens)-len(s.traces))...)
+	}
+	return &s.traces[s.current]
+}
+
+// traceEffects notes which effects wrapped a command of the current token.
+// Repeats ("left 5") are listed once.
+func (s *EngineState) traceEffects(names []string) {
+	t := s.trace()
+	if t == nil {
+		return
+	}
+	for _, name := range names {
+		if !slices.Contains(t.effects, name) {
+			t.effects = append(t.effects, name)
+		}
+	}
+}
+
+// traceTiming adds a Timed measurement to the current token.
+func (s *EngineState) traceTiming(name string, d time.Duration) {
+	if t := s.trace(); t != nil {
+		t.timings = append(t.timings, EffectTiming{Name: name, DurationMs: durationMs(d)})
+	}
+}
+
+// tokenTrace returns the trace recorded for token i.
+func (s *EngineState) tokenTrace(i int) tokenTrace {
+	if i >= 0 && i < len(s.traces) {
+		return s.traces[i]
+	}
+	return tokenTrace{}
+}
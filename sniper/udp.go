@@ -0,0 +1,72 @@
+package sniper
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+)
+
+// MaxUDPPacket is the largest datagram the UDP listener accepts; anything
+// bigger is dropped.
+const MaxUDPPacket = 1024
+
+// UDPPhrase is the datagram the UDP listener expects: {"c":"left","m":"rapid"}.
+type UDPPhrase struct {
+	Command string `json:"c"`
+	Mode    string `json:"m"`
+}
+
+// UDPServer feeds phrases from UDP datagrams into Engine.Submit.
+// It never replies; failures are recorded in History and the log.
+type UDPServer struct {
+	engine *Engine
+	conn   net.PacketConn
+}
+
+// ListenUDP opens a UDP listener on addr (e.g. "127.0.0.1:9091").
+// Call Serve to start handling packets.
+func (e *Engine) ListenUDP(addr string) (*UDPServer, error) {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &UDPServer{engine: e, conn: conn}, nil
+}
+
+// Addr returns the address the server is listening on.
+func (s *UDPServer) Addr() net.Addr {
+	return s.conn.LocalAddr()
+}
+
+// Serve reads datagrams until Close is called. Malformed and oversized packets
+// are dropped silently.
+func (s *UDPServer) Serve() error {
+	// One extra byte tells an oversized packet apart from one that fits exactly
+	buf := make([]byte, MaxUDPPacket+1)
+	for {
+		n, _, err := s.conn.ReadFrom(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if n > MaxUDPPacket {
+			continue
+		}
+
+		var phrase UDPPhrase
+		if err := json.Unmarshal(buf[:n], &phrase); err != nil || phrase.Command == "" {
+			continue
+		}
+
+		if _, err := s.engine.Submit(phrase.Command, phrase.Mode); err != nil {
+			s.engine.log().Warn("udp phrase failed", "phrase", phrase.Command, "error", err)
+		}
+	}
+}
+
+// Close stops Serve.
+func (s *UDPServer) Close() error {
+	return s.conn.Close()
+}
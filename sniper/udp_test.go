@@ -0,0 +1,104 @@
+package sniper_test
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phillip-england/sniper/sniper"
+	"github.com/phillip-england/sniper/sniper/snipertest"
+)
+
+// udpClient starts a UDP listener for e on a free loopback port and returns
+// a socket connected to it.
+func udpClient(t *testing.T, e *snipertest.Engine) net.Conn {
+	t.Helper()
+	server, err := e.ListenUDP("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve()
+	t.Cleanup(func() { server.Close() })
+
+	conn, err := net.Dial("udp", server.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// waitTyped waits until the last text typed is want, and returns everything
+// typed so far.
+func waitTyped(t *testing.T, e *snipertest.Engine, want string) []string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var typed []string
+		for _, op := range e.Input.Ops() {
+			if text, ok := strings.CutPrefix(op, "type "); ok {
+				typed = append(typed, text)
+			}
+		}
+		if len(typed) > 0 && typed[len(typed)-1] == want {
+			return typed
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %q; typed %q", want, typed)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestUDPRunsPacketsInOrder(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	conn := udpClient(t, e)
+
+	var want []string
+	for i := range 100 {
+		word := fmt.Sprintf("w%d", i)
+		want = append(want, word)
+		if _, err := fmt.Fprintf(conn, `{"c":"type %s"}`, word); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := waitTyped(t, e, "w99"); !slices.Equal(got, want) {
+		t.Errorf("typed %q\nwant %q", got, want)
+	}
+	if n := len(e.History.Recent(200)); n != 100 {
+		t.Errorf("%d phrases in the history, want 100", n)
+	}
+}
+
+func TestUDPDropsOversizedPackets(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	conn := udpClient(t, e)
+
+	// pad fills a phrase out to size bytes with trailing spaces, which JSON
+	// allows, so only its size can get it dropped
+	pad := func(phrase string, size int) []byte {
+		packet := fmt.Sprintf(`{"c":"type %s"}`, phrase)
+		return []byte(packet + strings.Repeat(" ", size-len(packet)))
+	}
+	packets := [][]byte{
+		pad("exact", sniper.MaxUDPPacket),
+		// Its first MaxUDPPacket bytes are a valid phrase on their own: a
+		// listener that read a truncated packet would run it
+		pad("oversized", sniper.MaxUDPPacket+1),
+		pad("huge", 4*sniper.MaxUDPPacket),
+		[]byte(`{"c":"type done"}`),
+	}
+	for _, p := range packets {
+		if _, err := conn.Write(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := waitTyped(t, e, "done"); !slices.Equal(got, []string{"exact", "done"}) {
+		t.Errorf("typed %q, want only the packets that fit", got)
+	}
+}
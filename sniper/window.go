@@ -0,0 +1,166 @@
+package sniper
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-vgo/robotgo"
+)
+
+// ----------------------------------------------------------------------------
+// ACTIVE WINDOW
+// ----------------------------------------------------------------------------
+//
+// Before a phrase's words are resolved, the engine asks which window has focus
+// and activates the mode of the first config "window_modes" rule matching it,
+// so "- Visual Studio Code" can switch on a vscode mode by itself. When no rule
+// matches, or the window can't be read (Wayland, missing permissions), the mode
+// chosen with SetMode (or "mode <name>") is used.
+
+// ErrNoActiveWindow is returned when the focused window can't be determined.
+var ErrNoActiveWindow = errors.New("active window unavailable")
+
+// WindowInfo describes the focused window.
+type WindowInfo struct {
+	Title   string `json:"title"`
+	Process string `json:"process,omitempty"`
+	PID     int    `json:"pid,omitempty"`
+}
+
+// WindowProvider reports the focused window.
+type WindowProvider interface {
+	ActiveWindow() (WindowInfo, error)
+}
+
+// SystemWindows reads the focused window through robotgo.
+type SystemWindows struct{}
+
+func NewSystemWindows() *SystemWindows {
+	return &SystemWindows{}
+}
+
+// ActiveWindow returns the title and process of the focused window.
+func (w *SystemWindows) ActiveWindow() (WindowInfo, error) {
+	info := WindowInfo{Title: robotgo.GetTitle(), PID: robotgo.GetPid()}
+	if info.PID > 0 {
+		info.Process, _ = robotgo.FindName(info.PID)
+	}
+	if info.Title == "" && info.Process == "" {
+		return WindowInfo{}, ErrNoActiveWindow
+	}
+	return info, nil
+}
+
+// WithWindowProvider makes the engine read the focused window through provider.
+func WithWindowProvider(provider WindowProvider) EngineOption {
+	return func(s *engineSetup) {
+		s.windows = provider
+	}
+}
+
+// WindowRule activates Mode while the focused window's title (and process,
+// when set) match the regular expressions.
+type WindowRule struct {
+	Title   string `json:"title,omitempty"`
+	Process string `json:"process,omitempty"`
+	Mode    string `json:"mode"`
+}
+
+// windowRule is a WindowRule with its expressions compiled.
+type windowRule struct {
+	title   *regexp.Regexp
+	process *regexp.Regexp
+	mode    string
+}
+
+func (r windowRule) matches(w WindowInfo) bool {
+	if r.title != nil && !r.title.MatchString(w.Title) {
+		return false
+	}
+	if r.process != nil && !r.process.MatchString(w.Process) {
+		return false
+	}
+	return true
+}
+
+// compileWindowRules compiles the config's "window_modes", checking every
+// expression and that each rule names a mode and matches on something.
+func compileWindowRules(rules []WindowRule) ([]windowRule, error) {
+	compiled := make([]windowRule, 0, len(rules))
+	for i, rule := range rules {
+		mode := strings.ToLower(strings.TrimSpace(rule.Mode))
+		if mode == "" {
+			return nil, fmt.Errorf("window_modes[%d] needs a mode", i)
+		}
+		if rule.Title == "" && rule.Process == "" {
+			return nil, fmt.Errorf("window_modes[%d] needs a title or process", i)
+		}
+		r := windowRule{mode: mode}
+		var err error
+		if rule.Title != "" {
+			if r.title, err = regexp.Compile(rule.Title); err != nil {
+				return nil, fmt.Errorf("window_modes[%d] title: %w", i, err)
+			}
+		}
+		if rule.Process != "" {
+			if r.process, err = regexp.Compile(rule.Process); err != nil {
+				return nil, fmt.Errorf("window_modes[%d] process: %w", i, err)
+			}
+		}
+		compiled = append(compiled, r)
+	}
+	return compiled, nil
+}
+
+// ActiveWindow returns the focused window.
+func (e *Engine) ActiveWindow() (WindowInfo, error) {
+	if e.Windows == nil {
+		return WindowInfo{}, ErrNoActiveWindow
+	}
+	return e.Windows.ActiveWindow()
+}
+
+// followWindow reads the focused window and, when window rules are
+// configured, activates the mode they pick for it. It returns the window, or
+// nil when it couldn't be read.
+func (e *Engine) followWindow() *WindowInfo {
+	window, err := e.ActiveWindow()
+	if err != nil {
+		e.log().Debug("active window unavailable", "error", err)
+	}
+
+	e.registryMu.Lock()
+	defer e.registryMu.Unlock()
+	if len(e.windowRules) == 0 {
+		if err != nil {
+			return nil
+		}
+		return &window
+	}
+
+	mode := e.manualMode
+	if err == nil {
+		for _, rule := range e.windowRules {
+			if rule.matches(window) {
+				mode = rule.mode
+				break
+			}
+		}
+	}
+	if _, ok := e.modes[mode]; !ok && mode != "" {
+		e.log().Warn("window rule names an unknown mode", "mode", mode, "title", window.Title)
+		mode = e.manualMode
+	}
+	if mode != e.activeMode {
+		e.activeMode = mode
+		e.publishMode(mode)
+		e.log().Info("mode switched for window", "mode", mode, "title", window.Title, "process", window.Process)
+	}
+
+	if err != nil {
+		return nil
+	}
+	return &window
+}
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/phillip-england/sniper/sniper"
+	"github.com/phillip-england/sniper/sniper/snipertest"
+)
+
+func TestRequireToken(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	guarded := requireToken("s3cret")(ok)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		auth   string
+		want   int
+	}{
+		{"phrase without token", "POST", "/api/data", "", http.StatusUnauthorized},
+		{"phrase with wrong token", "POST", "/api/data", "Bearer nope", http.StatusUnauthorized},
+		{"phrase with basic auth", "POST", "/api/data", "Basic czNjcmV0", http.StatusUnauthorized},
+		{"token without scheme", "POST", "/api/data", "s3cret", http.StatusUnauthorized},
+		{"delete without token", "DELETE", "/api/history", "", http.StatusUnauthorized},
+		{"put without token", "PUT", "/api/config", "", http.StatusUnauthorized},
+		{"phrase with token", "POST", "/api/data", "Bearer s3cret", http.StatusOK},
+
+		{"typed text without token", "GET", "/api/typed", "", http.StatusUnauthorized},
+		{"screenshot without token", "GET", "/api/screenshot", "", http.StatusUnauthorized},
+		{"clipboard without token", "GET", "/api/clipboard", "", http.StatusUnauthorized},
+		{"clipboard history without token", "GET", "/api/clipboard/history", "", http.StatusUnauthorized},
+		{"history without token", "GET", "/api/history", "", http.StatusUnauthorized},
+		{"pixel without token", "GET", "/api/pixel", "", http.StatusUnauthorized},
+		{"job without token", "GET", "/api/jobs/3", "", http.StatusUnauthorized},
+		{"events without token", "GET", "/api/events", "", http.StatusUnauthorized},
+		{"typed text with token", "GET", "/api/typed", "Bearer s3cret", http.StatusOK},
+
+		{"commands are public", "GET", "/api/commands/min", "", http.StatusOK},
+		{"health is public", "GET", "/api/health", "", http.StatusOK},
+		{"ui is public", "GET", "/", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.auth != "" {
+				r.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+			guarded.ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Fatalf("%s %s = %d, want %d", tt.method, tt.path, w.Code, tt.want)
+			}
+			if tt.want == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without a WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestRequireTokenDisabled(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	w := httptest.NewRecorder()
+	requireToken("")(ok).ServeHTTP(w, httptest.NewRequest("POST", "/api/data", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST without a configured token = %d, want 200", w.Code)
+	}
+}
+
+func TestGuardMutations(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	guarded := guardMutations()(ok)
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		origin      string
+		contentType string
+		body        string
+		want        int
+	}{
+		{"json phrase", "POST", "/api/data", "", "application/json", `{"command":"east"}`, http.StatusOK},
+		{"json with charset", "POST", "/api/data", "", "application/json; charset=utf-8", `{}`, http.StatusOK},
+		{"same origin", "POST", "/api/data", "http://localhost:9090", "application/json", `{}`, http.StatusOK},
+		{"no body", "POST", "/api/cancel", "", "", "", http.StatusOK},
+		{"png icon", "PUT", "/api/icons/save", "", "image/png", "\x89PNG", http.StatusOK},
+
+		{"form post", "POST", "/api/data", "", "application/x-www-form-urlencoded", "command=east", http.StatusUnsupportedMediaType},
+		{"text post", "POST", "/api/data", "", "text/plain", `{"command":"east"}`, http.StatusUnsupportedMediaType},
+		{"no content type", "PUT", "/api/config", "", "", `{}`, http.StatusUnsupportedMediaType},
+		{"json icon", "PUT", "/api/icons/save", "", "application/json", `{}`, http.StatusUnsupportedMediaType},
+		{"foreign origin", "POST", "/api/data", "http://evil.example", "application/json", `{}`, http.StatusForbidden},
+		{"foreign origin without body", "POST", "/api/release", "http://evil.example", "", "", http.StatusForbidden},
+		{"other port", "DELETE", "/api/history", "http://localhost:3000", "", "", http.StatusForbidden},
+		{"null origin", "POST", "/api/data", "null", "application/json", `{}`, http.StatusForbidden},
+
+		{"reads are left alone", "GET", "/api/history", "http://evil.example", "text/plain", "x", http.StatusOK},
+		{"pages are left alone", "POST", "/signs", "", "text/plain", "x", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "http://localhost:9090"+tt.path, strings.NewReader(tt.body))
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			guarded.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("%s %s = %d, want %d", tt.method, tt.path, w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageToken(t *testing.T) {
+	tests := []struct {
+		host   string
+		remote string
+		want   string
+	}{
+		{"localhost:9090", "127.0.0.1:5000", "s3cret"},
+		{"127.0.0.1:9090", "127.0.0.1:5000", "s3cret"},
+		{"[::1]:9090", "[::1]:5000", "s3cret"},
+		{"LOCALHOST", "127.0.0.1:5000", "s3cret"},
+
+		// A rebound name reaches the server from this machine too
+		{"evil.example:9090", "127.0.0.1:5000", ""},
+		{"localhost.evil.example", "127.0.0.1:5000", ""},
+		// Another machine gets nothing whatever it calls the server
+		{"localhost:9090", "192.168.1.20:5000", ""},
+		{"192.168.1.10:9090", "192.168.1.20:5000", ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Host, r.RemoteAddr = tt.host, tt.remote
+		if got := pageToken(r, "s3cret"); got != tt.want {
+			t.Errorf("Host %s from %s: token %q, want %q", tt.host, tt.remote, got, tt.want)
+		}
+	}
+}
+
+func TestListenAddr(t *testing.T) {
+	tests := []struct {
+		token    string
+		insecure bool
+		want     string
+	}{
+		{"", false, "127.0.0.1:" + ServerPort},
+		{"", true, ":" + ServerPort},
+		{"s3cret", false, ":" + ServerPort},
+	}
+	for _, tt := range tests {
+		if got := listenAddr(tt.token, tt.insecure); got != tt.want {
+			t.Errorf("listenAddr(%q, %v) = %q, want %q", tt.token, tt.insecure, got, tt.want)
+		}
+	}
+}
+
+// beepCmd is a command registered by the tests after the engine is built.
+type beepCmd struct{}
+
+func (beepCmd) Name() string                            { return "beep" }
+func (beepCmd) CalledBy() []string                      { return []string{"beep"} }
+func (beepCmd) Effects() []sniper.NamedEffect           { return nil }
+func (beepCmd) Action(e *sniper.Engine, p string) error { return nil }
+
+func TestRegistryHandlerCaching(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	handler := registryHandler(e.Engine, true)
+	get := func(header, value string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/api/commands/full", nil)
+		if header != "" {
+			r.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	first := get("", "")
+	tag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || tag == "" {
+		t.Fatalf("first fetch = %d with ETag %q", first.Code, tag)
+	}
+
+	// The client's copy is current
+	if w := get("If-None-Match", tag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("If-None-Match with the current ETag = %d with %d bytes, want an empty 304", w.Code, w.Body.Len())
+	}
+	if w := get("If-None-Match", `"stale", `+tag); w.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match listing the current ETag = %d, want 304", w.Code)
+	}
+
+	// The gzipped body is the same JSON
+	zipped := get("Accept-Encoding", "gzip, deflate")
+	if zipped.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatal("a client accepting gzip got an uncompressed body")
+	}
+	zr, err := gzip.NewReader(zipped.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(body, first.Body.Bytes()) {
+		t.Error("the gzipped body differs from the plain one")
+	}
+	if w := get("Accept-Encoding", "gzip;q=0"); w.Header().Get("Content-Encoding") != "" {
+		t.Error("gzip;q=0 still got a gzipped body")
+	}
+
+	// Registering a command changes the registry, and so the ETag
+	if err := e.Register(beepCmd{}); err != nil {
+		t.Fatal(err)
+	}
+	changed := get("If-None-Match", tag)
+	if changed.Code != http.StatusOK || changed.Header().Get("ETag") == tag {
+		t.Fatalf("after Register, the old ETag got %d with ETag %q", changed.Code, changed.Header().Get("ETag"))
+	}
+	if !bytes.Contains(changed.Body.Bytes(), []byte(`"beep"`)) {
+		t.Error("the registry served after Register doesn't list the new command")
+	}
+}
+
+func TestEventsHandler(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	server := httptest.NewServer(eventsHandler(e.Engine))
+	defer server.Close()
+
+	// Get returns once the headers are flushed, after the handler subscribed
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	e.MustRun(t, "east")
+	e.MustRun(t, "mode vim")
+
+	lines := bufio.NewScanner(resp.Body)
+	next := func() (string, map[string]any) {
+		t.Helper()
+		var eventType string
+		for lines.Scan() {
+			line := lines.Text()
+			if v, ok := strings.CutPrefix(line, "event: "); ok {
+				eventType = v
+			}
+			if v, ok := strings.CutPrefix(line, "data: "); ok {
+				var data map[string]any
+				if err := json.Unmarshal([]byte(v), &data); err != nil {
+					t.Fatalf("%s data %q: %v", eventType, v, err)
+				}
+				return eventType, data
+			}
+		}
+		t.Fatalf("stream ended: %v", lines.Err())
+		return "", nil
+	}
+
+	if eventType, data := next(); eventType != sniper.EventPhrase || data["input"] != "east" {
+		t.Errorf("first event = %s %v, want the east phrase", eventType, data)
+	}
+	if eventType, data := next(); eventType != sniper.EventMode || data["mode"] != "vim" {
+		t.Errorf("second event = %s %v, want the switch to vim", eventType, data)
+	}
+}
+
+// stallCmd keeps the job worker busy until released.
+type stallCmd struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (stallCmd) Name() string                  { return "stall" }
+func (stallCmd) CalledBy() []string            { return []string{"stall"} }
+func (stallCmd) Effects() []sniper.NamedEffect { return nil }
+func (c stallCmd) Action(e *sniper.Engine, p string) error {
+	c.started <- struct{}{}
+	<-c.release
+	return nil
+}
+
+func TestDataHandlerQueue(t *testing.T) {
+	stall := stallCmd{started: make(chan struct{}), release: make(chan struct{})}
+	e := snipertest.NewTestEngine(t, sniper.WithCommands(stall))
+	mux := http.NewServeMux()
+	mux.Handle("POST /api/data", dataHandler(e.Engine))
+	mux.Handle("GET /api/jobs/{id}", jobHandler(e.Engine))
+
+	do := func(method, path, body string) (*httptest.ResponseRecorder, map[string]any) {
+		t.Helper()
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		var reply map[string]any
+		json.Unmarshal(w.Body.Bytes(), &reply)
+		return w, reply
+	}
+
+	// Keep the worker busy so async phrases pile up
+	if _, err := e.Enqueue("stall", "phrase"); err != nil {
+		t.Fatal(err)
+	}
+	<-stall.started
+
+	var ids []string
+	for range sniper.DefaultQueueSize {
+		w, reply := do("POST", "/api/data", `{"command": "east", "async": true}`)
+		if w.Code != http.StatusAccepted || reply["status"] != "queued" {
+			t.Fatalf("async phrase got %d %s", w.Code, w.Body)
+		}
+		ids = append(ids, strconv.FormatFloat(reply["job_id"].(float64), 'f', -1, 64))
+	}
+	if w, _ := do("POST", "/api/data", `{"command": "east", "async": true}`); w.Code != http.StatusTooManyRequests {
+		t.Errorf("a phrase for a full queue got %d, want 429", w.Code)
+	}
+	if _, reply := do("GET", "/api/jobs/"+ids[0], ""); reply["status"] != string(sniper.JobQueued) {
+		t.Errorf("waiting job: %v", reply)
+	}
+	for path, want := range map[string]int{"/api/jobs/999": http.StatusNotFound, "/api/jobs/first": http.StatusBadRequest} {
+		if w, _ := do("GET", path, ""); w.Code != want {
+			t.Errorf("GET %s = %d, want %d", path, w.Code, want)
+		}
+	}
+
+	close(stall.release)
+	last, err := strconv.ParseUint(ids[len(ids)-1], 10, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, _ := e.Job(last)
+	job.Wait()
+	w, reply := do("GET", "/api/jobs/"+ids[len(ids)-1], "")
+	result, _ := reply["result"].(map[string]any)
+	if w.Code != http.StatusOK || reply["status"] != string(sniper.JobDone) || result["input"] != "east" {
+		t.Errorf("finished job: %d %s", w.Code, w.Body)
+	}
+}
+
+func TestDataHandlerWords(t *testing.T) {
+	e := snipertest.NewTestEngine(t)
+	handler := dataHandler(e.Engine)
+
+	r := httptest.NewRequest("POST", "/api/data", strings.NewReader(`{"words": [{"w": "south", "conf": 0.2}, {"w": "east"}], "mode": "phrase"}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("words got %d %s", w.Code, w.Body)
+	}
+
+	var reply struct {
+		Result sniper.ExecutionResult `json:"result"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &reply); err != nil {
+		t.Fatal(err)
+	}
+	tokens := reply.Result.Tokens
+	if len(tokens) != 2 || reply.Result.Input != "south east" {
+		t.Fatalf("result = %+v", reply.Result)
+	}
+	if tokens[0].Outcome != sniper.OutcomeLowConfidence || tokens[0].Confidence != 0.2 {
+		t.Errorf("south traced as %s at %v", tokens[0].Outcome, tokens[0].Confidence)
+	}
+	// A word without a score is trusted fully
+	if tokens[1].Outcome != sniper.OutcomeHandled || tokens[1].Confidence != sniper.DefaultConfidence {
+		t.Errorf("east traced as %s at %v", tokens[1].Outcome, tokens[1].Confidence)
+	}
+	if keys := e.Input.Keys(); !slices.Equal(keys, []string{"right"}) {
+		t.Errorf("tapped %v, want only right", keys)
+	}
+}
@@ -175,6 +175,7 @@ class UIManager {
 // client/SniperService.ts
 class SniperService {
   baseUrl = "http://localhost:9090";
+  token = document.querySelector('meta[name="sniper-token"]')?.content || localStorage.getItem("sniper-token") || "";
   async sendCommand(command, mode) {
     try {
       console.log(`[SniperService] Sending: ${command}`);
@@ -182,11 +183,15 @@ class SniperService {
         command,
         mode: mode.name()
       });
+      const headers = {
+        "Content-Type": "application/json"
+      };
+      if (this.token) {
+        headers["Authorization"] = `Bearer ${this.token}`;
+      }
       const response = await fetch(`${this.baseUrl}/api/data`, {
         method: "POST",
-        headers: {
-          "Content-Type": "application/json"
-        },
+        headers,
         body: reqBody
       });
       if (!response.ok) {